@@ -0,0 +1,304 @@
+// Package cache 进程内 LRU 加 Redis 的两级缓存。
+//
+// 读取时依次查询进程内缓存和 Redis，都没有时由调用方加载后写入两级缓存。
+// 多个网关实例共享 Redis 中的缓存，进程内缓存的 TTL 较短，减少实例之间不一致的时间。
+// Redis 不可用时只使用进程内缓存，不影响接口调用。
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+const (
+	// redis key 前缀
+	keyPrefix = "data-application-gateway-cache:"
+	// 单次 redis 操作的超时时间，避免 redis 异常时拖慢接口调用
+	redisTimeout = 200 * time.Millisecond
+	// redis 异常后，在这段时间内只使用进程内缓存
+	redisRetryInterval = 10 * time.Second
+	// 按前缀删除时每次 SCAN 的数量
+	scanCount = 1000
+
+	defaultLocalSize = 1 << 10
+	defaultLocalTTL  = 10 * time.Second
+)
+
+// Options 缓存配置
+type Options struct {
+	// 进程内缓存的最大条数
+	LocalSize int
+	// 进程内缓存的 TTL，超过 TTL 时使用 TTL
+	LocalTTL time.Duration
+	// 缓存的 TTL
+	TTL time.Duration
+}
+
+// NewOptions 根据配置生成缓存配置，ttl 为缓存的 TTL，单位秒，为 0 时使用 defaultTTL
+func NewOptions(conf settings.Cache, ttl int, defaultTTL time.Duration) Options {
+	opts := Options{
+		LocalSize: conf.LocalSize,
+		LocalTTL:  time.Duration(conf.LocalTTL) * time.Second,
+		TTL:       time.Duration(ttl) * time.Second,
+	}
+	if opts.LocalSize <= 0 {
+		opts.LocalSize = defaultLocalSize
+	}
+	if opts.LocalTTL <= 0 {
+		opts.LocalTTL = defaultLocalTTL
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = defaultTTL
+	}
+	return opts
+}
+
+// Cache 两级缓存，值在 Redis 中以 JSON 保存
+type Cache[V any] struct {
+	name  string
+	redis *repository.Redis
+	ttl   time.Duration
+	local *expirable.LRU[string, V]
+
+	// redis 恢复前不再尝试访问 redis 的截止时间，unix 纳秒
+	redisDownUntil atomic.Int64
+
+	localHit, redisHit, miss atomic.Int64
+}
+
+// New 创建名为 name 的缓存，redis 为 nil 时只使用进程内缓存
+func New[V any](name string, redis *repository.Redis, opts Options) *Cache[V] {
+	localTTL := opts.LocalTTL
+	if localTTL <= 0 || localTTL > opts.TTL {
+		localTTL = opts.TTL
+	}
+	c := &Cache[V]{
+		name:  name,
+		redis: redis,
+		ttl:   opts.TTL,
+		local: expirable.NewLRU[string, V](opts.LocalSize, nil, localTTL),
+	}
+	register(c)
+	return c
+}
+
+// Get 读取缓存，依次查询进程内缓存和 Redis
+func (c *Cache[V]) Get(ctx context.Context, key string) (v V, ok bool) {
+	if v, ok = c.local.Get(key); ok {
+		c.localHit.Add(1)
+		return v, true
+	}
+
+	if c.redisAvailable() {
+		v, ok = c.getRedis(ctx, key)
+		if ok {
+			c.redisHit.Add(1)
+			c.local.Add(key, v)
+			return v, true
+		}
+	}
+
+	c.miss.Add(1)
+	return v, false
+}
+
+// Set 写入两级缓存
+func (c *Cache[V]) Set(ctx context.Context, key string, v V) {
+	c.local.Add(key, v)
+	if !c.redisAvailable() {
+		return
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.WithContext(ctx).Warn("cache Set marshal failed", zap.String("cache", c.name), zap.Error(err))
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	if err := c.redis.Client.Set(ctx, c.redisKey(key), b, c.ttl).Err(); err != nil {
+		c.redisFailed(ctx, err)
+	}
+}
+
+// GetOrLoad 读取缓存，不存在时调用 load 加载并写入缓存，load 返回错误时不写入
+func (c *Cache[V]) GetOrLoad(ctx context.Context, key string, load func(ctx context.Context) (V, error)) (V, error) {
+	if v, ok := c.Get(ctx, key); ok {
+		return v, nil
+	}
+	v, err := load(ctx)
+	if err != nil {
+		return v, err
+	}
+	c.Set(ctx, key, v)
+	return v, nil
+}
+
+// Delete 从两级缓存中删除。只删除当前实例的进程内缓存，其他实例由各自的失效消息删除
+func (c *Cache[V]) Delete(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	redisKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		c.local.Remove(key)
+		redisKeys = append(redisKeys, c.redisKey(key))
+	}
+	if c.redis == nil || c.redis.Client == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	if err := c.redis.Client.Del(ctx, redisKeys...).Err(); err != nil {
+		c.redisFailed(ctx, err)
+	}
+}
+
+// DeletePrefix 删除 key 以 prefix 开头的缓存，Redis 中通过 SCAN 查找，只应在失效等低频场景使用
+func (c *Cache[V]) DeletePrefix(ctx context.Context, prefix string) {
+	for _, key := range c.local.Keys() {
+		if strings.HasPrefix(key, prefix) {
+			c.local.Remove(key)
+		}
+	}
+	if c.redis == nil || c.redis.Client == nil {
+		return
+	}
+
+	match := escapePattern(c.redisKey(prefix)) + "*"
+	scan := func(ctx context.Context, client redis.UniversalClient) error {
+		iter := client.Scan(ctx, 0, match, scanCount).Iterator()
+		var keys []string
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+		// 集群模式下 key 可能属于不同的 slot，逐个删除
+		for _, key := range keys {
+			if err := client.Del(ctx, key).Err(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var err error
+	if cluster, ok := c.redis.Client.(*redis.ClusterClient); ok {
+		err = cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return scan(ctx, client)
+		})
+	} else {
+		err = scan(ctx, c.redis.Client)
+	}
+	if err != nil {
+		log.WithContext(ctx).Warn("cache DeletePrefix failed", zap.String("cache", c.name), zap.String("prefix", prefix), zap.Error(err))
+	}
+}
+
+// Stats 返回缓存的命中统计
+func (c *Cache[V]) Stats() Stats {
+	return Stats{
+		Name:     c.name,
+		Size:     c.local.Len(),
+		LocalHit: c.localHit.Load(),
+		RedisHit: c.redisHit.Load(),
+		Miss:     c.miss.Load(),
+	}
+}
+
+func (c *Cache[V]) getRedis(ctx context.Context, key string) (v V, ok bool) {
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	b, err := c.redis.Client.Get(ctx, c.redisKey(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return v, false
+	} else if err != nil {
+		c.redisFailed(ctx, err)
+		return v, false
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		log.WithContext(ctx).Warn("cache Get unmarshal failed", zap.String("cache", c.name), zap.Error(err))
+		return v, false
+	}
+	return v, true
+}
+
+func (c *Cache[V]) redisKey(key string) string {
+	return keyPrefix + c.name + ":" + key
+}
+
+func (c *Cache[V]) redisAvailable() bool {
+	if c.redis == nil || c.redis.Client == nil {
+		return false
+	}
+	return time.Now().UnixNano() >= c.redisDownUntil.Load()
+}
+
+func (c *Cache[V]) redisFailed(ctx context.Context, err error) {
+	log.WithContext(ctx).Warn("cache redis unavailable, use local cache only", zap.String("cache", c.name), zap.Error(err))
+	c.redisDownUntil.Store(time.Now().Add(redisRetryInterval).UnixNano())
+}
+
+// escapePattern 转义 redis glob 模式中的特殊字符
+func escapePattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Stats 缓存的命中统计，计数从进程启动开始累计
+type Stats struct {
+	Name     string `json:"name"`      // 缓存名称
+	Size     int    `json:"size"`      // 进程内缓存的条数
+	LocalHit int64  `json:"local_hit"` // 进程内缓存命中次数
+	RedisHit int64  `json:"redis_hit"` // Redis 命中次数
+	Miss     int64  `json:"miss"`      // 未命中次数
+}
+
+type statser interface {
+	Stats() Stats
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []statser
+)
+
+func register(c statser) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, c)
+}
+
+// AllStats 返回所有缓存的命中统计，按名称排序
+func AllStats() []Stats {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	stats := make([]Stats, 0, len(registry))
+	for _, c := range registry {
+		stats = append(stats, c.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+func TestMain(m *testing.M) {
+	// 初始化日志，否则调用 log.Warn 等方法会 panic
+	log.InitLogger(nil, &telemetry.Config{})
+	m.Run()
+}
+
+func TestNewOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		conf settings.Cache
+		ttl  int
+		want Options
+	}{
+		{
+			name: "默认值",
+			want: Options{LocalSize: defaultLocalSize, LocalTTL: defaultLocalTTL, TTL: time.Minute},
+		},
+		{
+			name: "配置",
+			conf: settings.Cache{LocalSize: 10, LocalTTL: 5},
+			ttl:  30,
+			want: Options{LocalSize: 10, LocalTTL: 5 * time.Second, TTL: 30 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewOptions(tt.conf, tt.ttl, time.Minute))
+		})
+	}
+}
+
+func TestCache_GetOrLoad(t *testing.T) {
+	c := New[string]("test-get-or-load", nil, Options{LocalSize: 10, TTL: time.Minute})
+	ctx := context.Background()
+
+	loads := 0
+	load := func(context.Context) (string, error) {
+		loads++
+		return "v", nil
+	}
+	for i := 0; i < 3; i++ {
+		v, err := c.GetOrLoad(ctx, "k", load)
+		assert.NoError(t, err)
+		assert.Equal(t, "v", v)
+	}
+	assert.Equal(t, 1, loads)
+
+	// 加载失败不写入缓存
+	_, err := c.GetOrLoad(ctx, "e", func(context.Context) (string, error) { return "", errors.New("load failed") })
+	assert.Error(t, err)
+	_, ok := c.Get(ctx, "e")
+	assert.False(t, ok)
+
+	assert.Equal(t, Stats{Name: "test-get-or-load", Size: 1, LocalHit: 2, Miss: 3}, c.Stats())
+}
+
+func TestCache_Delete(t *testing.T) {
+	c := New[bool]("test-delete", nil, Options{LocalSize: 10, TTL: time.Minute})
+	ctx := context.Background()
+	for _, k := range []string{"a:1", "a:2", "ab:1", "b:1"} {
+		c.Set(ctx, k, true)
+	}
+
+	c.Delete(ctx, "b:1")
+	c.DeletePrefix(ctx, "a:")
+
+	for k, want := range map[string]bool{"a:1": false, "a:2": false, "ab:1": true, "b:1": false} {
+		_, ok := c.Get(ctx, k)
+		assert.Equal(t, want, ok, k)
+	}
+}
+
+func TestCache_redisUnavailable(t *testing.T) {
+	r := &repository.Redis{Client: redis.NewUniversalClient(&redis.UniversalOptions{Addrs: []string{"127.0.0.1:1"}, DialTimeout: 100 * time.Millisecond})}
+	c := New[int]("test-redis-unavailable", r, Options{LocalSize: 10, TTL: time.Minute})
+	ctx := context.Background()
+
+	// redis 不可用时仍然使用进程内缓存
+	c.Set(ctx, "k", 1)
+	assert.False(t, c.redisAvailable())
+	v, ok := c.Get(ctx, "k")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	// 退避期间不再访问 redis
+	start := time.Now()
+	_, ok = c.Get(ctx, "missing")
+	assert.False(t, ok)
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestEscapePattern(t *testing.T) {
+	assert.Equal(t, `a\*b\?c\[d\]e\\f`, escapePattern(`a*b?c[d]e\f`))
+}
+
+func TestAllStats(t *testing.T) {
+	New[int]("test-stats-b", nil, Options{LocalSize: 1, TTL: time.Minute})
+	New[int]("test-stats-a", nil, Options{LocalSize: 1, TTL: time.Minute})
+
+	var names []string
+	for _, s := range AllStats() {
+		names = append(names, s.Name)
+	}
+	assert.Subset(t, names, []string{"test-stats-a", "test-stats-b"})
+	assert.IsNonDecreasing(t, names)
+}
@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/metrics"
+)
+
+func init() {
+	metrics.Registry.MustRegister(newStatsCollector())
+}
+
+// statsCollector 在采集时将 AllStats 导出为 Prometheus 指标
+type statsCollector struct {
+	size *prometheus.Desc
+	hits *prometheus.Desc
+	miss *prometheus.Desc
+}
+
+func newStatsCollector() *statsCollector {
+	return &statsCollector{
+		size: prometheus.NewDesc("data_application_gateway_cache_size", "进程内缓存的条数", []string{"cache"}, nil),
+		hits: prometheus.NewDesc("data_application_gateway_cache_hits_total", "缓存命中次数，tier 为 local 或 redis", []string{"cache", "tier"}, nil),
+		miss: prometheus.NewDesc("data_application_gateway_cache_misses_total", "缓存未命中次数", []string{"cache"}, nil),
+	}
+}
+
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.size
+	ch <- c.hits
+	ch <- c.miss
+}
+
+// Collect 同名的缓存合并统计，避免重复的指标
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	merged := make(map[string]*Stats)
+	var names []string
+	for _, s := range AllStats() {
+		m, ok := merged[s.Name]
+		if !ok {
+			m = &Stats{Name: s.Name}
+			merged[s.Name] = m
+			names = append(names, s.Name)
+		}
+		m.Size += s.Size
+		m.LocalHit += s.LocalHit
+		m.RedisHit += s.RedisHit
+		m.Miss += s.Miss
+	}
+	for _, name := range names {
+		s := merged[name]
+		ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(s.Size), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(s.LocalHit), s.Name, "local")
+		ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(s.RedisHit), s.Name, "redis")
+		ch <- prometheus.MustNewConstMetric(c.miss, prometheus.CounterValue, float64(s.Miss), s.Name)
+	}
+}
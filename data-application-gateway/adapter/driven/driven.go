@@ -10,6 +10,7 @@ import (
 	hydra "github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/hydra/v6"
 	mdl_uniquery "github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/mdl-uniquery"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/microservice"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/rate_limiter"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/reverse_proxy"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/virtual_engine"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/util"
@@ -58,4 +59,5 @@ var Set = wire.NewSet(
 	NewUserManagementService,
 	NewAuthServiceInternalV1Interface,
 	mdl_uniquery.NewMDLUniQuery,
+	rate_limiter.NewRateLimiterRepo,
 )
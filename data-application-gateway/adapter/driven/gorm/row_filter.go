@@ -0,0 +1,273 @@
+package gorm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blastrain/vitess-sqlparser/sqlparser"
+	"github.com/samber/lo"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/enum"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+)
+
+// 子接口的行过滤条件根据子接口的行列规则在语法树上构造，规则中的值只作为字面量出现在 SQL 中
+
+// currentTimestamp 虚拟化引擎中的当前时间(北京时间)，只由网关生成
+const currentTimestamp = "current_timestamp at time zone 'UTC' at time zone 'Asia/Shanghai'"
+
+// beforeUnits 行过滤规则 before 支持的时间单位
+var beforeUnits = []string{"second", "minute", "hour", "day", "week", "month", "quarter", "year"}
+
+// currentFormats 行过滤规则 current 支持的时间格式
+var currentFormats = []string{"%Y", "%Y-%m", "%Y-%m-%d", "%Y-%m-%d %H", "%Y-%m-%d %H:%i", "%x-%v"}
+
+// rowFilterExpr 已授权子接口的行过滤条件，多个子接口的条件做 or。
+// 任一子接口没有行过滤条件时不限制行，返回 nil
+func (r *serviceRepo) rowFilterExpr(subServices []model.SubService) (sqlparser.Expr, error) {
+	var exprs []sqlparser.Expr
+	for _, subService := range subServices {
+		if subService.Detail == "" {
+			return nil, nil
+		}
+		detail := &dto.SubServiceDetail{}
+		if err := json.Unmarshal([]byte(subService.Detail), detail); err != nil {
+			return nil, errorcode.Detail(errorcode.PublicInternalError, err.Error())
+		}
+		expr, err := r.subServiceRowFilterExpr(detail)
+		if err != nil {
+			return nil, errorcode.Detail(errorcode.PublicInternalError, fmt.Sprintf("子接口%s的行过滤规则无效：%v", subService.Name, err))
+		}
+		if expr == nil {
+			return nil, nil
+		}
+		exprs = append(exprs, paren(expr))
+	}
+	return joinExprs(exprs, "or"), nil
+}
+
+// subServiceRowFilterExpr 子接口的行过滤条件，固定的行过滤规则与行过滤规则做 and
+func (r *serviceRepo) subServiceRowFilterExpr(detail *dto.SubServiceDetail) (sqlparser.Expr, error) {
+	var exprs []sqlparser.Expr
+	if detail.FixedRowFilters != nil {
+		expr, err := r.rowFiltersExpr(detail.FixedRowFilters)
+		if err != nil {
+			return nil, err
+		}
+		if expr != nil {
+			exprs = append(exprs, paren(expr))
+		}
+	}
+	expr, err := r.rowFiltersExpr(&detail.RowFilters)
+	if err != nil {
+		return nil, err
+	}
+	if expr != nil {
+		exprs = append(exprs, paren(expr))
+	}
+	return joinExprs(exprs, "and"), nil
+}
+
+// rowFiltersExpr 条件组内按限定关系、条件组间按条件组间关系组合，关系为空时为 and
+func (r *serviceRepo) rowFiltersExpr(f *dto.RowFilters) (sqlparser.Expr, error) {
+	groups := make([]sqlparser.Expr, 0, len(f.Where))
+	for _, w := range f.Where {
+		members := make([]sqlparser.Expr, 0, len(w.Member))
+		for _, m := range w.Member {
+			expr, err := r.memberExpr(m)
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, expr)
+		}
+		if len(members) == 0 {
+			continue
+		}
+		relation, err := rowFilterRelation(w.Relation)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, paren(joinExprs(members, relation)))
+	}
+	relation, err := rowFilterRelation(f.WhereRelation)
+	if err != nil {
+		return nil, err
+	}
+	return joinExprs(groups, relation), nil
+}
+
+// rowFilterRelation 条件间的关系只能是 and、or
+func rowFilterRelation(relation string) (string, error) {
+	relation = strings.ToLower(strings.TrimSpace(relation))
+	switch relation {
+	case "":
+		return "and", nil
+	case "and", "or":
+		return relation, nil
+	}
+	return "", fmt.Errorf("unsupported relation %q", relation)
+}
+
+// paren and、or 条件加括号，作为其他条件的一部分时保持原来的优先级
+func paren(expr sqlparser.Expr) sqlparser.Expr {
+	switch expr.(type) {
+	case *sqlparser.AndExpr, *sqlparser.OrExpr:
+		return &sqlparser.ParenExpr{Expr: expr}
+	}
+	return expr
+}
+
+// joinExprs 按 relation 组合条件，没有条件时返回 nil
+func joinExprs(exprs []sqlparser.Expr, relation string) sqlparser.Expr {
+	if len(exprs) == 0 {
+		return nil
+	}
+	expr := exprs[0]
+	for _, right := range exprs[1:] {
+		if relation == "or" {
+			expr = &sqlparser.OrExpr{Left: expr, Right: right}
+		} else {
+			expr = &sqlparser.AndExpr{Left: expr, Right: right}
+		}
+	}
+	return expr
+}
+
+// memberExpr 单个字段的限定条件
+func (r *serviceRepo) memberExpr(m dto.Member) (sqlparser.Expr, error) {
+	if m.NameEn == "" {
+		return nil, errors.New("field name is empty")
+	}
+	column := &sqlparser.ColName{Name: sqlparser.NewColIdent(m.NameEn)}
+	isNumber := lo.Contains([]string{enum.SimpleInt, enum.SimpleFloat, enum.SimpleDecimal}, m.DataType)
+	isChar := m.DataType == enum.SimpleChar
+
+	switch m.Operator {
+	case "<", "<=", ">", ">=":
+		val, err := r.numberLiteral(m.Value, m.DataType)
+		if err != nil {
+			return nil, err
+		}
+		return &sqlparser.ComparisonExpr{Operator: m.Operator, Left: column, Right: val}, nil
+	case "=", "<>":
+		operator := sqlparser.EqualStr
+		if m.Operator == "<>" {
+			operator = sqlparser.NotEqualStr
+		}
+		switch {
+		case isNumber:
+			val, err := r.numberLiteral(m.Value, m.DataType)
+			if err != nil {
+				return nil, err
+			}
+			return &sqlparser.ComparisonExpr{Operator: operator, Left: column, Right: val}, nil
+		case isChar:
+			return &sqlparser.ComparisonExpr{Operator: operator, Left: column, Right: sqlparser.NewStrVal([]byte(m.Value))}, nil
+		}
+	case "null":
+		return &sqlparser.IsExpr{Operator: sqlparser.IsNullStr, Expr: column}, nil
+	case "not null":
+		return &sqlparser.IsExpr{Operator: sqlparser.IsNotNullStr, Expr: column}, nil
+	case "include", "not include", "prefix", "not prefix":
+		if !isChar {
+			break
+		}
+		pattern := likeEscaper.Replace(m.Value) + "%"
+		if !strings.HasSuffix(m.Operator, "prefix") {
+			pattern = "%" + pattern
+		}
+		operator := sqlparser.LikeStr
+		if strings.HasPrefix(m.Operator, "not ") {
+			operator = sqlparser.NotLikeStr
+		}
+		return &sqlparser.ComparisonExpr{
+			Operator: operator,
+			Left:     column,
+			Right:    sqlparser.NewStrVal([]byte(pattern)),
+			Escape:   sqlparser.NewStrVal([]byte(likeEscape)),
+		}, nil
+	case "in list", "belong":
+		values := strings.Split(m.Value, ",")
+		tuple := make(sqlparser.ValTuple, 0, len(values))
+		for _, value := range values {
+			switch {
+			case isNumber:
+				val, err := r.numberLiteral(value, m.DataType)
+				if err != nil {
+					return nil, err
+				}
+				tuple = append(tuple, val)
+			case isChar:
+				tuple = append(tuple, sqlparser.NewStrVal([]byte(value)))
+			default:
+				return nil, fmt.Errorf("operator %q not allowed for %s", m.Operator, m.DataType)
+			}
+		}
+		return &sqlparser.ComparisonExpr{Operator: sqlparser.InStr, Left: column, Right: tuple}, nil
+	case "true", "false":
+		return &sqlparser.ComparisonExpr{Operator: sqlparser.EqualStr, Left: column, Right: sqlparser.BoolVal(m.Operator == "true")}, nil
+	case "before":
+		// 值为 数量 单位，如 7 day，表示最近一段时间
+		amount, unit, ok := strings.Cut(m.Value, " ")
+		n, err := strconv.ParseUint(amount, 10, 32)
+		if !ok || err != nil || !lo.Contains(beforeUnits, unit) {
+			return nil, fmt.Errorf("invalid value %q for operator %q", m.Value, m.Operator)
+		}
+		now := &sqlparser.SQLVal{Type: rawVal, Val: []byte(currentTimestamp)}
+		start := funcExpr("date_add", sqlparser.NewStrVal([]byte(unit)), sqlparser.NewIntVal([]byte("-"+strconv.FormatUint(n, 10))), now)
+		return &sqlparser.AndExpr{
+			Left:  &sqlparser.ComparisonExpr{Operator: sqlparser.GreaterEqualStr, Left: column, Right: start},
+			Right: &sqlparser.ComparisonExpr{Operator: sqlparser.LessEqualStr, Left: column, Right: now},
+		}, nil
+	case "current":
+		if !lo.Contains(currentFormats, m.Value) {
+			break
+		}
+		format := sqlparser.NewStrVal([]byte(m.Value))
+		now := &sqlparser.SQLVal{Type: rawVal, Val: []byte(currentTimestamp)}
+		return &sqlparser.ComparisonExpr{
+			Operator: sqlparser.EqualStr,
+			Left:     funcExpr("date_format", column, format),
+			Right:    funcExpr("date_format", now, format),
+		}, nil
+	case "between":
+		values := strings.Split(m.Value, ",")
+		if len(values) != 2 {
+			return nil, fmt.Errorf("invalid value %q for operator %q", m.Value, m.Operator)
+		}
+		bound := func(value string) sqlparser.Expr {
+			timestamp := &sqlparser.ConvertExpr{Expr: sqlparser.NewStrVal([]byte(value)), Type: &sqlparser.ConvertType{Type: "timestamp"}}
+			return funcExpr("date_trunc", sqlparser.NewStrVal([]byte("minute")), timestamp)
+		}
+		return &sqlparser.RangeCond{Operator: sqlparser.BetweenStr, Left: column, From: bound(values[0]), To: bound(values[1])}, nil
+	}
+	return nil, fmt.Errorf("operator %q not allowed for %s", m.Operator, m.DataType)
+}
+
+// numberLiteral 数值型字段的比较值，整数字段的值不是整数时按浮点数比较
+func (r *serviceRepo) numberLiteral(value, dataType string) (*sqlparser.SQLVal, error) {
+	value = strings.TrimSpace(value)
+	if dataType == enum.SimpleInt {
+		if val, err := r.paramLiteral(value, dto.ParamDataTypeLong, ""); err == nil {
+			return val, nil
+		}
+	}
+	val, err := r.paramLiteral(value, dto.ParamDataTypeDouble, "")
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %q", value)
+	}
+	return val, nil
+}
+
+func funcExpr(name string, args ...sqlparser.Expr) *sqlparser.FuncExpr {
+	exprs := make(sqlparser.SelectExprs, 0, len(args))
+	for _, arg := range args {
+		exprs = append(exprs, &sqlparser.AliasedExpr{Expr: arg})
+	}
+	return &sqlparser.FuncExpr{Name: sqlparser.NewColIdent(name), Exprs: exprs}
+}
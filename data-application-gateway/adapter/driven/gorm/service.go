@@ -3,23 +3,20 @@ package gorm
 import (
 	"context"
 	"errors"
-	"fmt"
-	"io"
-	"strings"
-	"sync/atomic"
+	"sort"
 	"time"
 
 	"github.com/blastrain/vitess-sqlparser/sqlparser"
-	"github.com/hashicorp/golang-lru/v2/expirable"
 	"github.com/spf13/cast"
-	"github.com/valyala/fasttemplate"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/cache"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/dto"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/errorcode"
-	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/form_validator"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/util"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
 	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
@@ -27,45 +24,55 @@ import (
 
 type ServiceRepo interface {
 	ServiceGet(ctx context.Context, servicePath string) (res *model.ServiceAssociations, err error)
+	// ServiceMatch 按请求路径查找接口，没有路径相同的接口时匹配带路径变量的接口路径，如 /orders/{id}，返回路径变量的值
+	ServiceMatch(ctx context.Context, requestPath string) (res *model.ServiceAssociations, pathParams map[string]string, err error)
 	GetSubServices(ctx context.Context, serviceID string) (subServices []*model.SubService, err error)
 	ServiceGetFields(ctx context.Context, httpMethod string, servicePath string, fields []string) (service *model.Service, err error)
 	IsServicePathExist(ctx context.Context, servicePath, serviceID string) (exist bool, err error)
-	WizardModelScript(ctx context.Context, params map[string]*dto.Param, catalogName, schemaName, tableName, subServiceRule string, serviceParams []model.ServiceParam, isCount bool) (s string, err error)
-	ScriptModelScript(ctx context.Context, params map[string]*dto.Param, catalogName, schemaName, script, subServiceRule string, serviceParams []model.ServiceParam, isCount bool) (s string, err error)
+	// InvalidateCache 删除接口定义的缓存，接口状态变化时调用
+	InvalidateCache(ctx context.Context, serviceID string)
+	// WizardModelScript 和 ScriptModelScript 将能下推的过滤规则转换为 SQL 条件，remaining 为需要在查询结果上过滤的规则
+	WizardModelScript(ctx context.Context, params map[string]*dto.Param, catalogName, schemaName, tableName string, subServices []model.SubService, serviceParams []model.ServiceParam, serviceResponseFilters []model.ServiceResponseFilter, isCount bool) (s string, remaining []model.ServiceResponseFilter, err error)
+	ScriptModelScript(ctx context.Context, params map[string]*dto.Param, catalogName, schemaName, script string, subServices []model.SubService, serviceParams []model.ServiceParam, serviceResponseFilters []model.ServiceResponseFilter, isCount bool) (s string, remaining []model.ServiceResponseFilter, err error)
 }
 
-var (
-	cacheSize = 1 << 10
-	cacheTTL  = 10 * time.Second
+// 缓存名称，与 Redis key 的前缀相关，修改后已有的缓存会失效
+const (
+	serviceCacheName         = "service"
+	servicePathCacheName     = "service-path"
+	serviceTemplateCacheName = "service-template"
+
+	// serviceTemplateCacheKey 所有带路径变量的接口路径缓存为一个 key
+	serviceTemplateCacheKey = "all"
+
+	defaultServiceCacheTTL = 5 * time.Minute
 )
 
 type serviceRepo struct {
 	data *db.Data
-	// model.ServiceAssociations 的缓存
-	cache *expirable.LRU[string, *model.ServiceAssociations]
-
-	hit, miss atomic.Int64
+	// model.ServiceAssociations 的缓存，key 为接口路径
+	cache *cache.Cache[*model.ServiceAssociations]
+	// 接口 ID 到接口路径的索引，用于按接口 ID 失效缓存
+	pathCache *cache.Cache[string]
+	// 带路径变量的接口路径
+	templateCache *cache.Cache[[]string]
 }
 
-func NewServiceRepo(data *db.Data) ServiceRepo {
-	repo := &serviceRepo{
-		data:  data,
-		cache: expirable.NewLRU[string, *model.ServiceAssociations](cacheSize, nil, cacheTTL),
+func NewServiceRepo(data *db.Data, redis *repository.Redis, s *settings.Settings) ServiceRepo {
+	opts := cache.NewOptions(s.Cache, s.Cache.ServiceTTL, defaultServiceCacheTTL)
+	return &serviceRepo{
+		data:      data,
+		cache:     cache.New[*model.ServiceAssociations](serviceCacheName, redis, opts),
+		pathCache: cache.New[string](servicePathCacheName, redis, opts),
+
+		templateCache: cache.New[[]string](serviceTemplateCacheName, redis, opts),
 	}
-	go func() {
-		for range time.Tick(10 * time.Second) {
-			log.Debug("cache state", zap.Int64("hit", repo.hit.Load()), zap.Int64("miss", repo.miss.Load()))
-		}
-	}()
-	return repo
 }
 
 func (r *serviceRepo) ServiceGet(ctx context.Context, servicePath string) (res *model.ServiceAssociations, err error) {
-	if res, ok := r.cache.Get(servicePath); ok {
-		r.hit.Add(1)
+	if res, ok := r.cache.Get(ctx, servicePath); ok {
 		return res, nil
 	}
-	r.miss.Add(1)
 
 	tx := r.data.DB.WithContext(ctx).Model(&model.Service{}).Scopes(Undeleted()).
 		Preload("ServiceDataSource", "delete_time = 0").
@@ -81,14 +88,90 @@ func (r *serviceRepo) ServiceGet(ctx context.Context, servicePath string) (res *
 		return nil, errorcode.Desc(errorcode.ServicePathNotExist)
 	} else if tx.Error != nil {
 		log.WithContext(ctx).Error("ServiceGet", zap.Error(tx.Error))
-		return nil, err
+		return nil, tx.Error
 	}
 
-	r.cache.Add(servicePath, res)
+	// 不存在的接口不缓存，避免接口上线后仍然返回不存在
+	if res != nil && res.ServiceID != "" {
+		r.pathCache.Set(ctx, res.ServiceID, servicePath)
+		r.cache.Set(ctx, servicePath, res)
+	}
 
 	return
 }
 
+func (r *serviceRepo) ServiceMatch(ctx context.Context, requestPath string) (res *model.ServiceAssociations, pathParams map[string]string, err error) {
+	res, err = r.ServiceGet(ctx, requestPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 请求路径与带路径变量的接口路径相同时，同样按模板匹配
+	if res != nil && res.ServiceID != "" && !util.IsPathTemplate(res.ServicePath) {
+		return res, nil, nil
+	}
+
+	templates, err := r.servicePathTemplates(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, t := range templates {
+		values, ok := t.Match(requestPath)
+		if !ok {
+			continue
+		}
+		if res, err = r.ServiceGet(ctx, t.String()); err != nil {
+			return nil, nil, err
+		}
+		return res, values, nil
+	}
+
+	return res, nil, nil
+}
+
+// servicePathTemplates 返回带路径变量的接口路径，固定段多的在前
+func (r *serviceRepo) servicePathTemplates(ctx context.Context) ([]*util.PathTemplate, error) {
+	paths, err := r.templateCache.GetOrLoad(ctx, serviceTemplateCacheKey, func(ctx context.Context) ([]string, error) {
+		var paths []string
+		err := r.data.DB.WithContext(ctx).Model(&model.Service{}).Scopes(Undeleted()).
+			Where("service_path LIKE ?", "%{%").
+			Pluck("service_path", &paths).Error
+		return paths, err
+	})
+	if err != nil {
+		log.WithContext(ctx).Error("servicePathTemplates", zap.Error(err))
+		return nil, err
+	}
+
+	templates := make([]*util.PathTemplate, 0, len(paths))
+	for _, p := range paths {
+		t, err := util.ParsePathTemplate(p)
+		if err != nil {
+			log.WithContext(ctx).Warn("servicePathTemplates invalid service path", zap.String("service_path", p), zap.Error(err))
+			continue
+		}
+		templates = append(templates, t)
+	}
+	sort.SliceStable(templates, func(i, j int) bool {
+		if templates[i].Literals() != templates[j].Literals() {
+			return templates[i].Literals() > templates[j].Literals()
+		}
+		return templates[i].String() < templates[j].String()
+	})
+	return templates, nil
+}
+
+func (r *serviceRepo) InvalidateCache(ctx context.Context, serviceID string) {
+	// 接口上线、下线或修改路径都可能改变带路径变量的接口路径
+	r.templateCache.Delete(ctx, serviceTemplateCacheKey)
+
+	servicePath, ok := r.pathCache.Get(ctx, serviceID)
+	if !ok {
+		return
+	}
+	r.cache.Delete(ctx, servicePath)
+	r.pathCache.Delete(ctx, serviceID)
+}
+
 func (r *serviceRepo) GetSubServices(ctx context.Context, serviceID string) (subServices []*model.SubService, err error) {
 	if err = r.data.DB.WithContext(ctx).Where("service_id=? and deleted_at=0 ", serviceID).Find(&subServices).Error; err != nil {
 		return nil, err
@@ -131,71 +214,64 @@ func (r *serviceRepo) IsServicePathExist(ctx context.Context, servicePath, servi
 	return false, nil
 }
 
-func (r *serviceRepo) WizardModelScript(ctx context.Context, requestParams map[string]*dto.Param, catalogName, schemaName, tableName, subServiceRule string, serviceParams []model.ServiceParam, isCount bool) (script string, err error) {
-	tx := r.data.DB.WithContext(ctx).Session(&gorm.Session{DryRun: true}).Table(tableName)
-	if isCount {
-		tx = tx.Select("count(*)")
-	} else {
-		var selects []string
-		for _, p := range serviceParams {
-			//sql字段名增加反引号
-			quote := util.Quote(tx, p.EnName)
-			switch p.ParamType {
-			case "request":
-				requestParam, ok := requestParams[p.EnName]
-				if !ok {
-					continue
-				}
-				if cast.ToString(requestParam.Value) == "" {
-					continue
-				}
+func (r *serviceRepo) WizardModelScript(ctx context.Context, requestParams map[string]*dto.Param, catalogName, schemaName, tableName string, subServices []model.SubService, serviceParams []model.ServiceParam, serviceResponseFilters []model.ServiceResponseFilter, isCount bool) (script string, remaining []model.ServiceResponseFilter, err error) {
+	sel := &sqlparser.Select{
+		From: sqlparser.TableExprs{&sqlparser.AliasedTableExpr{
+			Expr: sqlparser.TableName{Name: sqlparser.NewTableIdent(tableName)},
+		}},
+	}
+	for _, p := range serviceParams {
+		column := &sqlparser.ColName{Name: sqlparser.NewColIdent(p.EnName)}
+		switch p.ParamType {
+		case "request":
+			requestParam, ok := requestParams[p.EnName]
+			if !ok {
+				continue
+			}
+			if cast.ToString(requestParam.Value) == "" {
+				continue
+			}
 
-				switch p.Operator {
-				case "=":
-					tx = tx.Where(fmt.Sprintf("%s = ${%s}", quote, p.EnName))
-				case "!=":
-					tx = tx.Where(fmt.Sprintf("%s != ${%s}", quote, p.EnName))
-				case ">":
-					tx = tx.Where(fmt.Sprintf("%s > ${%s}", quote, p.EnName))
-				case ">=":
-					tx = tx.Where(fmt.Sprintf("%s >= ${%s}", quote, p.EnName))
-				case "<":
-					tx = tx.Where(fmt.Sprintf("%s < ${%s}", quote, p.EnName))
-				case "<=":
-					tx = tx.Where(fmt.Sprintf("%s <= ${%s}", quote, p.EnName))
-				case "like":
-					tx = tx.Where(fmt.Sprintf("%s like ${%s}", quote, p.EnName))
-				case "in":
-					tx = tx.Where(fmt.Sprintf("%s in (${%s})", quote, p.EnName))
-				case "not in":
-					tx = tx.Where(fmt.Sprintf("%s not in (${%s})", quote, p.EnName))
+			switch p.Operator {
+			case "=", "!=", ">", ">=", "<", "<=", "like":
+				vals, err := r.paramLiterals(p.EnName, requestParam, p, false)
+				if err != nil {
+					return "", nil, err
 				}
-
-			case "response":
-				if p.DataProtectionQuery {
-					quote = fmt.Sprintf(`'*' AS %s`, quote)
+				andWhere(sel, &sqlparser.ComparisonExpr{Operator: p.Operator, Left: column, Right: vals[0]})
+			case "in", "not in":
+				vals, err := r.paramLiterals(p.EnName, requestParam, p, true)
+				if err != nil {
+					return "", nil, err
 				}
-				selects = append(selects, quote)
-				switch p.Sort {
-				case "asc":
-					tx = tx.Order(quote + " asc")
-				case "desc":
-					tx = tx.Order(quote + " desc")
+				tuple := make(sqlparser.ValTuple, 0, len(vals))
+				for _, v := range vals {
+					tuple = append(tuple, v)
 				}
+				andWhere(sel, &sqlparser.ComparisonExpr{Operator: p.Operator, Left: column, Right: tuple})
+			}
+
+		case "response":
+			var expr sqlparser.SelectExpr = &sqlparser.AliasedExpr{Expr: column}
+			if p.DataProtectionQuery {
+				// 开启了查询保护的字段只返回 '*'
+				expr = &sqlparser.AliasedExpr{Expr: sqlparser.NewStrVal([]byte("*")), As: column.Name}
+			}
+			sel.SelectExprs = append(sel.SelectExprs, expr)
+			switch p.Sort {
+			case "asc":
+				sel.OrderBy = append(sel.OrderBy, &sqlparser.Order{Expr: column, Direction: sqlparser.AscScr})
+			case "desc":
+				sel.OrderBy = append(sel.OrderBy, &sqlparser.Order{Expr: column, Direction: sqlparser.DescScr})
 			}
 		}
-		tx = tx.Select(strings.Join(selects, ","))
 	}
-
-	tx.Find(nil)
-
-	script = tx.Dialector.Explain(tx.Statement.SQL.String())
-	script, err = r.ScriptModelScript(ctx, requestParams, catalogName, schemaName, script, subServiceRule, serviceParams, isCount)
-	if err != nil {
-		return "", err
+	if len(sel.SelectExprs) == 0 {
+		log.WithContext(ctx).Error("WizardModelScript no response param", zap.String("table", tableName))
+		return "", nil, errorcode.Desc(errorcode.ServiceSQLSyntaxError)
 	}
 
-	return
+	return r.buildScript(sel, requestParams, catalogName, schemaName, subServices, serviceParams, serviceResponseFilters, isCount)
 }
 
 // 检查是否时间字符串
@@ -213,185 +289,45 @@ func (r *serviceRepo) isTimeParam(value interface{}) bool {
 	return false
 }
 
-func (r *serviceRepo) ScriptModelScript(ctx context.Context, params map[string]*dto.Param, catalogName, schemaName, script, subServiceRule string, serviceParams []model.ServiceParam, isCount bool) (s string, err error) {
-	_, err = r.checkScript(ctx, script)
-	if err != nil {
-		return "", err
-	}
-
-	script, err = r.replaceParams(script, params, serviceParams)
-	if err != nil {
-		return "", err
-	}
-
-	script, err = r.addSchemaName(ctx, catalogName, schemaName, script)
+func (r *serviceRepo) ScriptModelScript(ctx context.Context, params map[string]*dto.Param, catalogName, schemaName, script string, subServices []model.SubService, serviceParams []model.ServiceParam, serviceResponseFilters []model.ServiceResponseFilter, isCount bool) (s string, remaining []model.ServiceResponseFilter, err error) {
+	sel, bindVars, err := parseScript(script)
 	if err != nil {
 		log.WithContext(ctx).Error("ScriptModelScript", zap.Error(err))
-		return "", err
+		return "", nil, err
 	}
 
-	//时间型的字符串 拼接为 timestamp 'value'
-	//select cjsj, fgjldshyj from "maria_daf11ee4b25245ec948fb611db87b421"."test"."xzcfjasp_jg" where cjsj = '@TIMESTAMP@2023-08-20'
-	//select cjsj, fgjldshyj from "maria_daf11ee4b25245ec948fb611db87b421"."test"."xzcfjasp_jg" where cjsj = timestamp '2023-08-20'
-	script = strings.ReplaceAll(script, "'@TIMESTAMP@", "timestamp '")
-	if !isCount {
-		script = r.addPaginate(ctx, script, params[dto.Offset].Value, params[dto.Limit].Value)
+	if err = r.bindParams(sel, bindVars, params, serviceParams); err != nil {
+		return "", nil, err
 	}
 
-	//将用户自定义的SQL拼接上
-	if subServiceRule != "" {
-		whereIndex := strings.Index(strings.ToLower(script), " where ")
-		if whereIndex > 0 {
-			script = fmt.Sprintf("%s where %s and %s", script[:whereIndex], subServiceRule, script[whereIndex+len(" where "):])
-		} else {
-			orderByIndex := strings.Index(strings.ToLower(script), " order by ")
-			if orderByIndex > 0 {
-				script = fmt.Sprintf("%s where %s %s", script[:orderByIndex], subServiceRule, script[orderByIndex:])
-			} else {
-				script = fmt.Sprintf("%s where %s ", script, subServiceRule)
-			}
-		}
-	}
-
-	return script, nil
+	return r.buildScript(sel, params, catalogName, schemaName, subServices, serviceParams, serviceResponseFilters, isCount)
 }
 
-func (r *serviceRepo) checkScript(ctx context.Context, script string) (stmt sqlparser.Statement, err error) {
-	if script == "" {
-		return nil, nil
-	}
-
-	// ${xxx} 转换为 ? 否则语法检查会不通过
-	t := fasttemplate.New(script, "${", "}")
-	script = t.ExecuteFuncString(func(w io.Writer, tag string) (int, error) {
-		return w.Write([]byte("?"))
-	})
-
-	// 语法解析检查
-	stmt, err = sqlparser.Parse(script)
+// buildScript 拼接子接口的行过滤条件、返回参数的过滤规则和分页，输出虚拟化引擎执行的 SQL
+func (r *serviceRepo) buildScript(sel *sqlparser.Select, params map[string]*dto.Param, catalogName, schemaName string, subServices []model.SubService, serviceParams []model.ServiceParam, serviceResponseFilters []model.ServiceResponseFilter, isCount bool) (string, []model.ServiceResponseFilter, error) {
+	rowFilter, err := r.rowFilterExpr(subServices)
 	if err != nil {
-		return nil, errorcode.Desc(errorcode.ServiceSQLSyntaxError)
-	}
-
-	sql := strings.ToLower(script)
-	//排除 select *
-	if strings.Contains(sql, "select*") || strings.Contains(sql, "select *") {
-		return nil, errorcode.Desc(errorcode.ServiceSQLSyntaxError)
-	}
-
-	//排除注释
-	if strings.HasPrefix(sql, "#") || strings.HasPrefix(sql, "/*") {
-		return nil, errorcode.Desc(errorcode.ServiceSQLSyntaxError)
+		return "", nil, err
 	}
-
-	//排除 insert、update、delete
-	_, ok := stmt.(*sqlparser.Insert)
-	if ok {
-		return nil, errorcode.Desc(errorcode.ServiceSQLSyntaxError)
-	}
-	_, ok = stmt.(*sqlparser.Update)
-	if ok {
-		return nil, errorcode.Desc(errorcode.ServiceSQLSyntaxError)
-	}
-	_, ok = stmt.(*sqlparser.Delete)
-	if ok {
-		return nil, errorcode.Desc(errorcode.ServiceSQLSyntaxError)
-	}
-
-	_, ok = stmt.(*sqlparser.Select)
-	if !ok {
-		return nil, errorcode.Desc(errorcode.ServiceSQLSyntaxError)
+	if rowFilter != nil {
+		andWhere(sel, rowFilter)
 	}
+	// 在统计总数替换查询字段之前下推，过滤规则按查询结果的列名匹配
+	remaining := r.pushResponseFilters(sel, serviceParams, serviceResponseFilters)
 
-	return stmt, nil
-}
-
-func (r *serviceRepo) replaceParams(script string, params map[string]*dto.Param, serviceParams []model.ServiceParam) (string, error) {
-	//用户配置的参数
-	serviceParamsMap := make(map[string]model.ServiceParam)
-	for _, param := range serviceParams {
-		if param.ParamType == "request" {
-			serviceParamsMap[param.EnName] = param
-		}
-	}
-
-	t := fasttemplate.New(script, "${", "}")
-	script, err := t.ExecuteFuncStringWithErr(func(w io.Writer, tag string) (int, error) {
-		param, ok := params[tag]
-		if !ok || cast.ToString(param.Value) == "" {
-			var validErrors form_validator.ValidErrors
-			validErrors = append(validErrors, &form_validator.ValidError{
-				Key:     tag,
-				Message: "请求参数 " + tag + " 为必填字段",
-			})
-			return 0, validErrors
-		}
-
-		if param.DataType == dto.ParamDataTypeString {
-			//时间型的字符串 拼接为 timestamp 'value'
-			isTime := r.isTimeParam(param.Value)
-			if isTime == true {
-				param.Value = fmt.Sprintf("'@TIMESTAMP@%s'", cast.ToString(param.Value))
-			} else {
-				p := serviceParamsMap[tag]
-				// like 字符串 拼接为 '%value%'
-				if p.Operator == "like" {
-					param.Value = fmt.Sprintf("'%%%s%%'", cast.ToString(param.Value))
-				} else {
-					//普通的字符串 拼接为 'value'
-					param.Value = fmt.Sprintf("'%s'", strings.Trim(cast.ToString(param.Value), "'"))
-				}
-			}
+	if isCount {
+		sel = countSelect(sel)
+	} else {
+		var offset, limit interface{}
+		if p, ok := params[dto.Offset]; ok {
+			offset = p.Value
 		}
-
-		v := cast.ToString(param.Value)
-		return w.Write([]byte(v))
-	})
-
-	return script, err
-}
-
-func (r *serviceRepo) addSchemaName(ctx context.Context, catalogName, schemaName, script string) (ss string, err error) {
-	stmt, err := sqlparser.Parse(script)
-	if err != nil {
-		return "", err
-	}
-
-	//提取表名
-	s := stmt.(*sqlparser.Select)
-	for _, tableExpr := range s.From {
-		switch tableExpr.(type) {
-		case *sqlparser.AliasedTableExpr:
-			aliasedTableExpr := tableExpr.(*sqlparser.AliasedTableExpr)
-			table := aliasedTableExpr.Expr.(sqlparser.TableName).Name.String()
-			aliasedTableExpr.Expr = r.newTableName(catalogName, schemaName, table)
-
-		case *sqlparser.JoinTableExpr:
-			joinTableExpr := tableExpr.(*sqlparser.JoinTableExpr)
-			rightTable := joinTableExpr.RightExpr.(*sqlparser.AliasedTableExpr).Expr.(sqlparser.TableName).Name.String()
-			leftTable := joinTableExpr.LeftExpr.(*sqlparser.AliasedTableExpr).Expr.(sqlparser.TableName).Name.String()
-
-			joinTableExpr.RightExpr.(*sqlparser.AliasedTableExpr).Expr = r.newTableName(catalogName, schemaName, rightTable)
-			joinTableExpr.LeftExpr.(*sqlparser.AliasedTableExpr).Expr = r.newTableName(catalogName, schemaName, leftTable)
+		if p, ok := params[dto.Limit]; ok {
+			limit = p.Value
 		}
+		sel = paginateSelect(sel, offset, limit)
 	}
 
-	ss = sqlparser.String(stmt)
-	ss = strings.ReplaceAll(ss, "`@@@", "\"")
-	ss = strings.ReplaceAll(ss, "@@@`", "\"")
-	ss = strings.ReplaceAll(ss, "@@@", "\"")
-	return ss, nil
-}
-
-func (r *serviceRepo) addPaginate(ctx context.Context, script string, offset, limit interface{}) (s string) {
-	o, l := PaginateCalculate(cast.ToInt(offset), cast.ToInt(limit))
-	script = script + fmt.Sprintf(" offset %d limit %d", o, l)
-	return script
-}
-
-func (r *serviceRepo) newTableName(catalogName, dataSchemaName, table string) sqlparser.TableName {
-	return sqlparser.TableName{
-		Name:      sqlparser.NewTableIdent(fmt.Sprintf("@@@%s@@@.@@@%s@@@.@@@%s@@@", catalogName, dataSchemaName, table)),
-		Qualifier: sqlparser.NewTableIdent(""),
-	}
+	f := &sqlFormatter{catalogName: catalogName, schemaName: schemaName}
+	return f.String(sel), remaining, nil
 }
@@ -9,6 +9,8 @@ import (
 
 type ServiceCallRecordRepo interface {
 	Create(ctx context.Context, record *model.ServiceCallRecord) error
+	// BatchCreate 批量写入调用记录
+	BatchCreate(ctx context.Context, records []*model.ServiceCallRecord) error
 }
 
 type serviceCallRecordRepo struct {
@@ -30,3 +32,11 @@ func (r *serviceCallRecordRepo) Create(ctx context.Context, record *model.Servic
 
 	return r.data.DB.WithContext(ctx).Create(record).Error
 }
+
+func (r *serviceCallRecordRepo) BatchCreate(ctx context.Context, records []*model.ServiceCallRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	return r.data.DB.WithContext(ctx).CreateInBatches(records, len(records)).Error
+}
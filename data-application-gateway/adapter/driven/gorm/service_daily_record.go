@@ -15,6 +15,8 @@ type DataApplicationServiceRepo interface {
 	IncrementSuccessCount(ctx context.Context, serviceID string) error
 	IncrementFailCount(ctx context.Context, serviceID string) error
 	EnsureTodayRecordExists(ctx context.Context, serviceID string) error
+	// AddCounts 增加指定日期的成功和失败次数，记录不存在时先创建
+	AddCounts(ctx context.Context, serviceID string, recordDate time.Time, successCount, failCount int64) error
 	// 辅助方法
 	Exists(ctx context.Context, serviceID string, recordDate time.Time) (bool, error)
 	GetServiceInfo(ctx context.Context, serviceID string) (*model.Service, error)
@@ -74,13 +76,44 @@ func (r *dataApplicationServiceRepo) IncrementFailCount(ctx context.Context, ser
 	return nil
 }
 
+func (r *dataApplicationServiceRepo) AddCounts(ctx context.Context, serviceID string, recordDate time.Time, successCount, failCount int64) error {
+	if successCount == 0 && failCount == 0 {
+		return nil
+	}
+
+	// 确保当日记录存在
+	if err := r.ensureRecordExists(ctx, serviceID, recordDate); err != nil {
+		log.WithContext(ctx).Error("dataApplicationServiceRepo AddCounts ensureRecordExists", zap.Error(err))
+		return err
+	}
+
+	result := r.data.DB.WithContext(ctx).
+		Model(&model.ServiceDailyRecord{}).
+		Where("service_id = ? AND record_date = ?", serviceID, recordDate.Format("2006-01-02")).
+		UpdateColumns(map[string]interface{}{
+			"success_count": gorm.Expr("success_count + ?", successCount),
+			"fail_count":    gorm.Expr("fail_count + ?", failCount),
+		})
+
+	if result.Error != nil {
+		log.WithContext(ctx).Error("dataApplicationServiceRepo AddCounts", zap.Error(result.Error))
+		return result.Error
+	}
+
+	return nil
+}
+
 // EnsureTodayRecordExists 确保今日记录存在，不存在则插入
 func (r *dataApplicationServiceRepo) EnsureTodayRecordExists(ctx context.Context, serviceID string) error {
-	today := time.Now()
-	todayDate := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+	return r.ensureRecordExists(ctx, serviceID, time.Now())
+}
+
+// ensureRecordExists 确保指定日期的记录存在，不存在则插入
+func (r *dataApplicationServiceRepo) ensureRecordExists(ctx context.Context, serviceID string, day time.Time) error {
+	recordDate := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
 
 	// 检查记录是否存在
-	exists, err := r.Exists(ctx, serviceID, todayDate)
+	exists, err := r.Exists(ctx, serviceID, recordDate)
 	if err != nil {
 		return err
 	}
@@ -88,7 +121,7 @@ func (r *dataApplicationServiceRepo) EnsureTodayRecordExists(ctx context.Context
 	// 获取 service 信息
 	service, err := r.GetServiceInfo(ctx, serviceID)
 	if err != nil {
-		log.WithContext(ctx).Error("dataApplicationServiceRepo EnsureTodayRecordExists GetServiceInfo", zap.Error(err))
+		log.WithContext(ctx).Error("dataApplicationServiceRepo ensureRecordExists GetServiceInfo", zap.Error(err))
 		return err
 	}
 
@@ -104,7 +137,7 @@ func (r *dataApplicationServiceRepo) EnsureTodayRecordExists(ctx context.Context
 		ServiceDepartmentID:   service.DepartmentID,
 		ServiceDepartmentName: service.DepartmentName,
 		ServiceType:           service.ServiceType,
-		RecordDate:            todayDate,
+		RecordDate:            recordDate,
 		SuccessCount:          0,
 		FailCount:             0,
 		OnlineCount:           0, // 初始为0，由调用方决定具体值
@@ -113,11 +146,11 @@ func (r *dataApplicationServiceRepo) EnsureTodayRecordExists(ctx context.Context
 
 	err = r.data.DB.WithContext(ctx).Create(record).Error
 	if err != nil {
-		log.WithContext(ctx).Error("dataApplicationServiceRepo EnsureTodayRecordExists Create", zap.Error(err))
+		log.WithContext(ctx).Error("dataApplicationServiceRepo ensureRecordExists Create", zap.Error(err))
 		return err
 	}
 
-	log.WithContext(ctx).Info("dataApplicationServiceRepo EnsureTodayRecordExists created new record", zap.String("serviceID", serviceID))
+	log.WithContext(ctx).Info("dataApplicationServiceRepo ensureRecordExists created new record", zap.String("serviceID", serviceID), zap.Time("recordDate", recordDate))
 	return nil
 }
 
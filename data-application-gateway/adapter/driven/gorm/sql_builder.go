@@ -0,0 +1,593 @@
+package gorm
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blastrain/vitess-sqlparser/sqlparser"
+	"github.com/samber/lo"
+	"github.com/spf13/cast"
+	"github.com/valyala/fasttemplate"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/enum"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/form_validator"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+)
+
+// 接口生成的 SQL 全部在 sqlparser 的语法树上构造：参数绑定为字面量、子接口的行过滤条件
+// 按行列规则构造后与 where 条件做 and、分页为 Limit 节点，最后由 sqlFormatter 按虚拟化引擎的语法输出。
+// 请求参数的值只会以字面量的形式出现在 SQL 中，不会改变 SQL 的结构
+
+// 在 sqlparser.SQLVal 上扩展的值类型，只由 sqlFormatter 输出
+const (
+	// timestampVal 时间字面量，输出为 timestamp '2023-08-20'
+	timestampVal sqlparser.ValType = iota + 100
+	// rawVal 原样输出的 SQL 片段，只用于网关生成的内容：布尔字面量、当前时间
+	rawVal
+)
+
+// bindVarPrefix 脚本中 ${xxx} 参数转换后的绑定变量前缀
+const bindVarPrefix = ":p"
+
+// countAlias 统计总数时子查询的别名
+const countAlias = "t"
+
+// likeEscape like 条件的转义字符
+const likeEscape = `\`
+
+// likeEscaper 转义 like 条件中的通配符
+var likeEscaper = strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_")
+
+// plainIdentifier 不需要加引号的标识符
+var plainIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseScript 解析脚本模式的 SQL，${xxx} 参数转换为绑定变量，bindVars 为绑定变量与参数名的对应关系
+func parseScript(script string) (sel *sqlparser.Select, bindVars map[string]string, err error) {
+	sql := strings.ToLower(strings.TrimSpace(script))
+	//排除注释
+	if strings.HasPrefix(sql, "#") || strings.HasPrefix(sql, "/*") {
+		return nil, nil, errorcode.Desc(errorcode.ServiceSQLSyntaxError)
+	}
+
+	bindVars = make(map[string]string)
+	t := fasttemplate.New(script, "${", "}")
+	script = t.ExecuteFuncString(func(w io.Writer, tag string) (int, error) {
+		bindVar := bindVarPrefix + strconv.Itoa(len(bindVars)+1)
+		bindVars[bindVar] = tag
+		return w.Write([]byte(bindVar))
+	})
+
+	// 语法解析检查
+	stmt, err := sqlparser.Parse(script)
+	if err != nil {
+		return nil, nil, errorcode.Desc(errorcode.ServiceSQLSyntaxError)
+	}
+
+	// 写在字符串字面量、标识符中的 ${xxx} 不会被绑定，不允许
+	unbound := make(map[string]struct{}, len(bindVars))
+	for bindVar := range bindVars {
+		unbound[bindVar] = struct{}{}
+	}
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if val, ok := node.(*sqlparser.SQLVal); ok && val.Type == sqlparser.ValArg {
+			delete(unbound, string(val.Val))
+		}
+		return true, nil
+	}, stmt)
+	if len(unbound) > 0 {
+		return nil, nil, errorcode.Desc(errorcode.ServiceSQLSyntaxError)
+	}
+
+	//只允许 select，排除 insert、update、delete、union 等
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok {
+		return nil, nil, errorcode.Desc(errorcode.ServiceSQLSyntaxError)
+	}
+
+	//排除 select *
+	err = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		s, ok := node.(*sqlparser.Select)
+		if !ok {
+			return true, nil
+		}
+		for _, expr := range s.SelectExprs {
+			if _, ok := expr.(*sqlparser.StarExpr); ok {
+				return false, errorcode.Desc(errorcode.ServiceSQLSyntaxError)
+			}
+		}
+		return true, nil
+	}, sel)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sel, bindVars, nil
+}
+
+// bindParams 将语法树中的绑定变量替换为请求参数的字面量，in、not in 的参数可以是多个值
+func (r *serviceRepo) bindParams(sel *sqlparser.Select, bindVars map[string]string, params map[string]*dto.Param, serviceParams []model.ServiceParam) error {
+	//用户配置的参数
+	serviceParamsMap := make(map[string]model.ServiceParam)
+	for _, param := range serviceParams {
+		if param.ParamType == "request" {
+			serviceParamsMap[param.EnName] = param
+		}
+	}
+
+	literals := func(bindVar string, multiple bool) ([]*sqlparser.SQLVal, error) {
+		tag, ok := bindVars[bindVar]
+		if !ok {
+			// 脚本中直接写了 ? 或 :xxx
+			return nil, errorcode.Desc(errorcode.ServiceSQLSyntaxError)
+		}
+		return r.paramLiterals(tag, params[tag], serviceParamsMap[tag], multiple)
+	}
+
+	return sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.ComparisonExpr:
+			tuple, ok := node.Right.(sqlparser.ValTuple)
+			if !ok || (node.Operator != sqlparser.InStr && node.Operator != sqlparser.NotInStr) {
+				return true, nil
+			}
+			values := make(sqlparser.ValTuple, 0, len(tuple))
+			for _, expr := range tuple {
+				val, ok := expr.(*sqlparser.SQLVal)
+				if !ok || val.Type != sqlparser.ValArg {
+					values = append(values, expr)
+					continue
+				}
+				vals, err := literals(string(val.Val), true)
+				if err != nil {
+					return false, err
+				}
+				for _, v := range vals {
+					values = append(values, v)
+				}
+			}
+			node.Right = values
+		case *sqlparser.SQLVal:
+			if node.Type != sqlparser.ValArg {
+				return false, nil
+			}
+			vals, err := literals(string(node.Val), false)
+			if err != nil {
+				return false, err
+			}
+			*node = *vals[0]
+		}
+		return true, nil
+	}, sel)
+}
+
+// paramLiterals 按参数的数据类型生成字面量，multiple 为 true 时逗号分隔的字符串和数组会生成多个字面量
+func (r *serviceRepo) paramLiterals(name string, param *dto.Param, serviceParam model.ServiceParam, multiple bool) (vals []*sqlparser.SQLVal, err error) {
+	required := form_validator.ValidErrors{{Key: name, Message: "请求参数 " + name + " 为必填字段"}}
+	if param == nil {
+		return nil, required
+	}
+
+	values := []interface{}{param.Value}
+	if multiple {
+		switch v := param.Value.(type) {
+		case []interface{}:
+			values = v
+		case string:
+			values = values[:0]
+			for _, s := range strings.Split(v, ",") {
+				values = append(values, strings.TrimSpace(s))
+			}
+		}
+	}
+	if len(values) == 0 {
+		return nil, required
+	}
+	for _, value := range values {
+		if cast.ToString(value) == "" {
+			return nil, required
+		}
+	}
+
+	// 优先使用接口配置的数据类型，query 参数在未配置类型时都是字符串
+	dataType := dto.ParamDataType(serviceParam.DataType)
+	if dataType == "" {
+		dataType = param.DataType
+	}
+
+	for _, value := range values {
+		val, err := r.paramLiteral(value, dataType, serviceParam.Operator)
+		if err != nil {
+			return nil, form_validator.ValidErrors{{Key: name, Message: "请求参数 " + name + " 应为 " + string(dataType) + " 类型"}}
+		}
+		vals = append(vals, val)
+	}
+	return vals, nil
+}
+
+func (r *serviceRepo) paramLiteral(value interface{}, dataType dto.ParamDataType, operator string) (*sqlparser.SQLVal, error) {
+	switch dataType {
+	case dto.ParamDataTypeInt, dto.ParamDataTypeLong:
+		i, err := cast.ToInt64E(value)
+		if err != nil {
+			return nil, err
+		}
+		return sqlparser.NewIntVal([]byte(strconv.FormatInt(i, 10))), nil
+	case dto.ParamDataTypeFloat, dto.ParamDataTypeDouble:
+		f, err := cast.ToFloat64E(value)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("invalid number %v", f)
+		}
+		return sqlparser.NewFloatVal([]byte(strconv.FormatFloat(f, 'f', -1, 64))), nil
+	case dto.ParamDataTypeBoolean:
+		b, err := cast.ToBoolE(value)
+		if err != nil {
+			return nil, err
+		}
+		return &sqlparser.SQLVal{Type: rawVal, Val: []byte(strconv.FormatBool(b))}, nil
+	}
+
+	s, err := cast.ToStringE(value)
+	if err != nil {
+		return nil, err
+	}
+	//时间型的字符串 拼接为 timestamp 'value'
+	if r.isTimeParam(s) {
+		return &sqlparser.SQLVal{Type: timestampVal, Val: []byte(s)}, nil
+	}
+	// like 字符串 拼接为 '%value%'
+	if operator == "like" {
+		s = "%" + s + "%"
+	}
+	return sqlparser.NewStrVal([]byte(s)), nil
+}
+
+// andWhere 将条件与 where 条件做 and，or 条件加括号避免优先级问题
+func andWhere(sel *sqlparser.Select, expr sqlparser.Expr) {
+	if _, ok := expr.(*sqlparser.OrExpr); ok {
+		expr = &sqlparser.ParenExpr{Expr: expr}
+	}
+	if sel.Where == nil || sel.Where.Expr == nil {
+		sel.Where = &sqlparser.Where{Type: sqlparser.WhereStr, Expr: expr}
+		return
+	}
+	left := sel.Where.Expr
+	if _, ok := left.(*sqlparser.OrExpr); ok {
+		left = &sqlparser.ParenExpr{Expr: left}
+	}
+	sel.Where.Expr = &sqlparser.AndExpr{Left: left, Right: expr}
+}
+
+// windowFuncs 只能作为窗口函数使用的函数
+var windowFuncs = []string{"row_number", "rank", "dense_rank", "percent_rank", "cume_dist", "ntile", "lag", "lead", "first_value", "last_value", "nth_value"}
+
+// pushResponseFilters 将返回参数的过滤规则转换为 where 条件，使分页和总数在过滤后的结果上计算。
+// 只下推直接输出逻辑视图字段的列，按字段的实际类型生成与在结果上过滤一致的条件。
+// 无法转换的规则原样返回，由调用方在查询结果上过滤
+func (r *serviceRepo) pushResponseFilters(sel *sqlparser.Select, serviceParams []model.ServiceParam, filters []model.ServiceResponseFilter) (remaining []model.ServiceResponseFilter) {
+	if !filterableSelect(sel) {
+		return filters
+	}
+
+	dataTypes := make(map[string]dto.ParamDataType)
+	for _, p := range serviceParams {
+		// 开启了查询保护的字段只返回 '*'，在结果上过滤
+		if p.ParamType != "response" || p.DataProtectionQuery {
+			continue
+		}
+		if dataType, ok := columnDataType(p.ColumnType); ok {
+			dataTypes[strings.ToLower(p.EnName)] = dataType
+		}
+	}
+
+	for _, filter := range filters {
+		dataType, ok := dataTypes[strings.ToLower(filter.Param)]
+		if !ok {
+			remaining = append(remaining, filter)
+			continue
+		}
+		column, ok := outputColumn(sel, filter.Param)
+		if !ok {
+			remaining = append(remaining, filter)
+			continue
+		}
+		cond, ok := r.responseFilterExpr(column, dataType, filter)
+		if !ok {
+			remaining = append(remaining, filter)
+			continue
+		}
+		andWhere(sel, cond)
+	}
+	return remaining
+}
+
+// filterableSelect 查询包含分组、聚合、窗口函数或限定了条数时，where 条件在这些计算之前生效，
+// 与在结果上过滤不一致，返回 false
+func filterableSelect(sel *sqlparser.Select) bool {
+	if len(sel.GroupBy) > 0 || sel.Having != nil || sel.Limit != nil {
+		return false
+	}
+	filterable := true
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.Subquery:
+			// 子查询中的聚合不影响外层
+			return false, nil
+		case *sqlparser.FuncExpr:
+			if node.IsAggregate() || lo.Contains(windowFuncs, node.Name.Lowered()) {
+				filterable = false
+				return false, nil
+			}
+		}
+		return true, nil
+	}, sel.SelectExprs)
+	return filterable
+}
+
+// columnDataType 返回逻辑视图字段的数据类型在结果上过滤时的比较类型，日期时间、二进制等类型不下推
+func columnDataType(columnType string) (dto.ParamDataType, bool) {
+	switch columnType {
+	case enum.SimpleChar:
+		return dto.ParamDataTypeString, true
+	case enum.SimpleInt:
+		return dto.ParamDataTypeLong, true
+	case enum.SimpleFloat, enum.SimpleDecimal:
+		return dto.ParamDataTypeDouble, true
+	case enum.SimpleBool:
+		return dto.ParamDataTypeBoolean, true
+	}
+	return "", false
+}
+
+// outputColumn 查找查询结果中名为 name 的列，只有直接输出同名字段的列才返回，
+// 表达式或别名为其他字段的列与逻辑视图字段的类型无关
+func outputColumn(sel *sqlparser.Select, name string) (*sqlparser.ColName, bool) {
+	for _, selectExpr := range sel.SelectExprs {
+		aliased, isAliased := selectExpr.(*sqlparser.AliasedExpr)
+		if !isAliased {
+			continue
+		}
+		if !aliased.As.IsEmpty() && !aliased.As.EqualString(name) {
+			continue
+		}
+		column, isColumn := aliased.Expr.(*sqlparser.ColName)
+		if !isColumn || !column.Name.EqualString(name) {
+			if aliased.As.IsEmpty() {
+				continue
+			}
+			return nil, false
+		}
+		return column, true
+	}
+	return nil, false
+}
+
+// responseFilterExpr 按字段的类型生成过滤条件，与在结果上过滤的语义一致：结果上的 NULL 按类型的零值比较，
+// 零值满足过滤规则时增加 is null 条件。不支持的类型、运算符或无法转换的值返回 false
+func (r *serviceRepo) responseFilterExpr(expr sqlparser.Expr, dataType dto.ParamDataType, filter model.ServiceResponseFilter) (sqlparser.Expr, bool) {
+	var operators []string
+	switch dataType {
+	case dto.ParamDataTypeString:
+		operators = []string{"=", "!=", "like", "in", "not in"}
+	case dto.ParamDataTypeInt, dto.ParamDataTypeLong, dto.ParamDataTypeFloat, dto.ParamDataTypeDouble:
+		operators = []string{"=", "!=", ">", ">=", "<", "<=", "in", "not in"}
+	case dto.ParamDataTypeBoolean:
+		operators = []string{"=", "!=", "in", "not in"}
+	}
+	if !lo.Contains(operators, filter.Operator) {
+		return nil, false
+	}
+
+	var cond sqlparser.Expr
+	switch filter.Operator {
+	case "like":
+		// 结果上的过滤为包含子串，转义通配符
+		pattern := "%" + likeEscaper.Replace(filter.Value) + "%"
+		cond = &sqlparser.ComparisonExpr{
+			Operator: sqlparser.LikeStr,
+			Left:     expr,
+			Right:    sqlparser.NewStrVal([]byte(pattern)),
+			Escape:   sqlparser.NewStrVal([]byte(likeEscape)),
+		}
+	case "in", "not in":
+		values := strings.Split(filter.Value, ",")
+		tuple := make(sqlparser.ValTuple, 0, len(values))
+		for _, value := range values {
+			val, ok := r.filterLiteral(value, dataType)
+			if !ok {
+				return nil, false
+			}
+			tuple = append(tuple, val)
+		}
+		cond = &sqlparser.ComparisonExpr{Operator: filter.Operator, Left: expr, Right: tuple}
+	default:
+		val, ok := r.filterLiteral(filter.Value, dataType)
+		if !ok {
+			return nil, false
+		}
+		cond = &sqlparser.ComparisonExpr{Operator: filter.Operator, Left: expr, Right: val}
+	}
+
+	if zeroValueMatches(dataType, filter) {
+		cond = &sqlparser.OrExpr{Left: cond, Right: &sqlparser.IsExpr{Operator: sqlparser.IsNullStr, Expr: expr}}
+	}
+	return cond, true
+}
+
+// zeroValueMatches 返回类型的零值是否满足过滤规则，与在结果上把 NULL 转换为零值后的比较一致
+func zeroValueMatches(dataType dto.ParamDataType, filter model.ServiceResponseFilter) bool {
+	// compare 零值与过滤值比较，小于、等于、大于分别为 -1、0、1
+	compare := func(value string) int {
+		switch dataType {
+		case dto.ParamDataTypeInt, dto.ParamDataTypeLong:
+			return cmp.Compare(0, cast.ToInt64(value))
+		case dto.ParamDataTypeFloat, dto.ParamDataTypeDouble:
+			return cmp.Compare(0, cast.ToFloat64(value))
+		case dto.ParamDataTypeBoolean:
+			if cast.ToBool(value) {
+				return -1
+			}
+			return 0
+		}
+		return strings.Compare("", value)
+	}
+
+	switch filter.Operator {
+	case "=":
+		return compare(filter.Value) == 0
+	case "!=":
+		return compare(filter.Value) != 0
+	case ">":
+		return compare(filter.Value) > 0
+	case ">=":
+		return compare(filter.Value) >= 0
+	case "<":
+		return compare(filter.Value) < 0
+	case "<=":
+		return compare(filter.Value) <= 0
+	case "like":
+		return filter.Value == ""
+	case "in", "not in":
+		in := lo.ContainsBy(strings.Split(filter.Value, ","), func(value string) bool { return compare(value) == 0 })
+		return in == (filter.Operator == "in")
+	}
+	return false
+}
+
+// filterLiteral 生成过滤规则的字面量。时间型的字符串在结果上按列的实际类型比较，不下推
+func (r *serviceRepo) filterLiteral(value string, dataType dto.ParamDataType) (*sqlparser.SQLVal, bool) {
+	if dataType == dto.ParamDataTypeString {
+		if r.isTimeParam(value) {
+			return nil, false
+		}
+		return sqlparser.NewStrVal([]byte(value)), true
+	}
+	val, err := r.paramLiteral(value, dataType, "")
+	if err != nil {
+		return nil, false
+	}
+	return val, true
+}
+
+func countExpr() sqlparser.SelectExprs {
+	return sqlparser.SelectExprs{
+		&sqlparser.AliasedExpr{Expr: &sqlparser.FuncExpr{
+			Name:  sqlparser.NewColIdent("count"),
+			Exprs: sqlparser.SelectExprs{&sqlparser.StarExpr{}},
+		}},
+	}
+}
+
+// wrapSelect 在子查询的结果上查询
+func wrapSelect(selectExprs sqlparser.SelectExprs, sel *sqlparser.Select) *sqlparser.Select {
+	return &sqlparser.Select{
+		SelectExprs: selectExprs,
+		From: sqlparser.TableExprs{&sqlparser.AliasedTableExpr{
+			Expr: &sqlparser.Subquery{Select: sel},
+			As:   sqlparser.NewTableIdent(countAlias),
+		}},
+	}
+}
+
+// countSelect 转换为统计总数的查询
+func countSelect(sel *sqlparser.Select) *sqlparser.Select {
+	sel.OrderBy = nil
+	// 去重、分组或限定了条数时，在子查询的结果上统计
+	if sel.Distinct != "" || len(sel.GroupBy) > 0 || sel.Having != nil || sel.Limit != nil {
+		return wrapSelect(countExpr(), sel)
+	}
+	sel.SelectExprs = countExpr()
+	return sel
+}
+
+// paginateSelect 增加分页，脚本中已限定了条数时在子查询的结果上分页
+func paginateSelect(sel *sqlparser.Select, offset, limit interface{}) *sqlparser.Select {
+	if sel.Limit != nil {
+		sel = wrapSelect(sqlparser.SelectExprs{&sqlparser.StarExpr{}}, sel)
+	}
+	o, l := PaginateCalculate(cast.ToInt(offset), cast.ToInt(limit))
+	sel.Limit = &sqlparser.Limit{
+		Offset:   sqlparser.NewIntVal([]byte(strconv.Itoa(o))),
+		Rowcount: sqlparser.NewIntVal([]byte(strconv.Itoa(l))),
+	}
+	return sel
+}
+
+// sqlFormatter 按虚拟化引擎的语法输出语法树，from 中的表名补全为 "catalog"."schema"."table"
+type sqlFormatter struct {
+	catalogName string
+	schemaName  string
+}
+
+func (f *sqlFormatter) String(node sqlparser.SQLNode) string {
+	buf := sqlparser.NewTrackedBuffer(f.format)
+	buf.Myprintf("%v", node)
+	return buf.String()
+}
+
+func (f *sqlFormatter) format(buf *sqlparser.TrackedBuffer, node sqlparser.SQLNode) {
+	switch node := node.(type) {
+	case *sqlparser.SQLVal:
+		switch node.Type {
+		case sqlparser.StrVal:
+			buf.WriteString(quoteString(string(node.Val)))
+		case timestampVal:
+			buf.WriteString("timestamp " + quoteString(string(node.Val)))
+		case rawVal:
+			buf.Write(node.Val)
+		default:
+			node.Format(buf)
+		}
+	case sqlparser.ColIdent:
+		buf.WriteString(quoteIdentifier(node.String(), false))
+	case sqlparser.TableIdent:
+		buf.WriteString(quoteIdentifier(node.String(), false))
+	case *sqlparser.AliasedTableExpr:
+		table, ok := node.Expr.(sqlparser.TableName)
+		if !ok {
+			node.Format(buf)
+			return
+		}
+		// 忽略脚本中的库名，只能查询接口配置的 catalog 和 schema 下的表
+		buf.WriteString(quoteIdentifier(f.catalogName, true) + "." +
+			quoteIdentifier(f.schemaName, true) + "." +
+			quoteIdentifier(table.Name.String(), true))
+		if !node.As.IsEmpty() {
+			buf.Myprintf(" as %v", node.As)
+		}
+	case *sqlparser.Limit:
+		if node == nil {
+			return
+		}
+		if node.Offset != nil {
+			buf.Myprintf(" offset %v", node.Offset)
+		}
+		buf.Myprintf(" limit %v", node.Rowcount)
+	case *sqlparser.ConvertExpr:
+		buf.Myprintf("cast(%v as %v)", node.Expr, node.Type)
+	default:
+		node.Format(buf)
+	}
+}
+
+// quoteString 字符串字面量，单引号转义为两个单引号
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// quoteIdentifier 虚拟化引擎要求标识符使用英文双引号转义，always 为 false 时只转义关键字和包含特殊字符的标识符
+func quoteIdentifier(s string, always bool) string {
+	if !always && plainIdentifier.MatchString(s) && sqlparser.String(sqlparser.NewColIdent(s)) == s {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
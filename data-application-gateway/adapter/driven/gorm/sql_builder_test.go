@@ -0,0 +1,666 @@
+package gorm
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/form_validator"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+// go test ./adapter/driven/gorm -run Golden -update 更新 testdata 中的 golden 文件
+var update = flag.Bool("update", false, "update golden files")
+
+func TestMain(m *testing.M) {
+	// 初始化日志，否则调用 log.Error 等方法会 panic
+	log.InitLogger(nil, &telemetry.Config{})
+	m.Run()
+}
+
+const (
+	testCatalog = "maria_daf11ee4b25245ec948fb611db87b421"
+	testSchema  = "test"
+)
+
+func pageParams(params map[string]*dto.Param) map[string]*dto.Param {
+	if params == nil {
+		params = make(map[string]*dto.Param)
+	}
+	params[dto.Offset] = dto.NewParam(2, "", dto.ParamDataTypeInt)
+	params[dto.Limit] = dto.NewParam(10, "", dto.ParamDataTypeInt)
+	return params
+}
+
+func requestParam(name, dataType, operator string) model.ServiceParam {
+	return model.ServiceParam{ParamType: "request", EnName: name, DataType: dataType, Operator: operator}
+}
+
+func responseParam(name, sort string) model.ServiceParam {
+	return model.ServiceParam{ParamType: "response", EnName: name, Sort: sort}
+}
+
+// typedResponseParam 返回参数，columnType 为逻辑视图中同名字段的数据类型
+func typedResponseParam(name, columnType string) model.ServiceParam {
+	return model.ServiceParam{ParamType: "response", EnName: name, DataType: "string", ColumnType: columnType}
+}
+
+func filter(param, operator, value string) model.ServiceResponseFilter {
+	return model.ServiceResponseFilter{Param: param, Operator: operator, Value: value}
+}
+
+// member 行过滤规则中字段的限定条件
+func member(nameEn, dataType, operator, value string) dto.Member {
+	return dto.Member{Field: dto.Field{NameEn: nameEn, DataType: dataType}, Operator: operator, Value: value}
+}
+
+// subService 行过滤规则只有一个条件组的子接口，组内条件做 and
+func subService(members ...dto.Member) model.SubService {
+	return subServiceWithDetail(&dto.SubServiceDetail{RowFilters: dto.RowFilters{Where: []dto.Where{{Member: members, Relation: "and"}}}})
+}
+
+func subServiceWithDetail(detail *dto.SubServiceDetail) model.SubService {
+	b, err := json.Marshal(detail)
+	if err != nil {
+		panic(err)
+	}
+	return model.SubService{Name: "sub", Detail: string(b)}
+}
+
+func TestScriptModelScript_Golden(t *testing.T) {
+	tests := []struct {
+		name          string
+		script        string
+		params        map[string]*dto.Param
+		serviceParams []model.ServiceParam
+		subServices   []model.SubService
+		filters       []model.ServiceResponseFilter
+		remaining     []model.ServiceResponseFilter
+	}{
+		{
+			name:   "plain",
+			script: "select a, b from t",
+		},
+		{
+			name:   "typed_params",
+			script: "select a from t where s = ${s} and i = ${i} and f > ${f} and b = ${b} and d >= ${d} and n like ${n}",
+			params: map[string]*dto.Param{
+				"s": dto.NewParam("it's", dto.ParamPositionBody, dto.ParamDataTypeString),
+				"i": dto.NewParam(int64(42), dto.ParamPositionBody, dto.ParamDataTypeInt),
+				"f": dto.NewParam(3.5, dto.ParamPositionBody, dto.ParamDataTypeDouble),
+				"b": dto.NewParam(true, dto.ParamPositionBody, dto.ParamDataTypeBoolean),
+				"d": dto.NewParam("2023-08-20", dto.ParamPositionQuery, dto.ParamDataTypeString),
+				"n": dto.NewParam("王", dto.ParamPositionQuery, dto.ParamDataTypeString),
+			},
+			serviceParams: []model.ServiceParam{
+				requestParam("s", "string", "="),
+				requestParam("i", "int", "="),
+				requestParam("f", "double", ">"),
+				requestParam("b", "boolean", "="),
+				requestParam("d", "string", ">="),
+				requestParam("n", "string", "like"),
+			},
+		},
+		{
+			name:   "in_list",
+			script: "select a from t where id in (${ids}) and code not in (${codes})",
+			params: map[string]*dto.Param{
+				"ids":   dto.NewParam("1, 2,3", dto.ParamPositionQuery, dto.ParamDataTypeString),
+				"codes": dto.NewParam([]interface{}{"x", "y'z"}, dto.ParamPositionBody, dto.ParamDataTypeString),
+			},
+			serviceParams: []model.ServiceParam{
+				requestParam("ids", "long", "in"),
+				requestParam("codes", "string", "not in"),
+			},
+		},
+		{
+			name:        "row_filter_with_or",
+			script:      "select a from t where a = 1 or b = 2 order by a desc",
+			subServices: []model.SubService{subService(member("c", "char", "=", "x")), subService(member("d", "int", ">", "1"))},
+		},
+		{
+			name:        "row_filter_without_where",
+			script:      "select a from t order by a",
+			subServices: []model.SubService{subService(member("c", "char", "=", "x"))},
+		},
+		{
+			name:   "row_filter_operators",
+			script: "select a from t",
+			subServices: []model.SubService{
+				subServiceWithDetail(&dto.SubServiceDetail{
+					RowFilters: dto.RowFilters{
+						WhereRelation: "or",
+						Where: []dto.Where{
+							{Relation: "and", Member: []dto.Member{
+								member("s", "char", "=", "x' or '1'='1"),
+								member("s", "char", "<>", `\'`),
+								member("i", "int", ">=", "10"),
+								member("f", "float", "<", "1.5"),
+								member("n", "char", "null", ""),
+								member("n", "char", "not null", ""),
+							}},
+							{Relation: "or", Member: []dto.Member{
+								member("s", "char", "include", "50%_off'"),
+								member("s", "char", "not include", "x"),
+								member("s", "char", "prefix", "ab"),
+								member("s", "char", "not prefix", "ab"),
+								member("s", "char", "in list", "x,y'z"),
+								member("i", "int", "belong", "1, 2"),
+								member("b", "bool", "true", ""),
+								member("b", "bool", "false", ""),
+							}},
+							{Member: []dto.Member{
+								member("d", "datetime", "before", "7 day"),
+								member("d", "datetime", "current", "%Y-%m"),
+								member("d", "datetime", "between", "2023-08-20 10:00,2023-08-21') or 1=1 --"),
+							}},
+						},
+					},
+					FixedRowFilters: &dto.RowFilters{Where: []dto.Where{{Member: []dto.Member{member("org", "char", "=", "a")}}}},
+				}),
+				// 没有固定行过滤规则的子接口
+				subService(member("org", "char", "=", "b")),
+			},
+		},
+		{
+			name:        "row_filter_unrestricted_sub_service",
+			script:      "select a from t",
+			subServices: []model.SubService{subService(member("c", "char", "=", "x")), subServiceWithDetail(&dto.SubServiceDetail{})},
+		},
+		{
+			name:   "where_in_literal_and_subquery",
+			script: "select s.a from (select a from t where w = 'where') as s where s.a > ${a} order by s.a",
+			params: map[string]*dto.Param{
+				"a": dto.NewParam(json.Number("7"), dto.ParamPositionBody, dto.ParamDataTypeInt),
+			},
+			serviceParams: []model.ServiceParam{requestParam("a", "int", ">")},
+			subServices:   []model.SubService{subService(member("c", "char", "=", "x"))},
+		},
+		{
+			name:   "join",
+			script: "select t1.a, t2.b from other.t1 join t2 on t1.id = t2.id",
+		},
+		{
+			name:   "group_by",
+			script: "select a, count(b) as total from t group by a having count(b) > 1",
+		},
+		{
+			name:   "user_limit",
+			script: "select a from t order by a limit 5",
+		},
+		{
+			name:   "identifiers",
+			script: "select `名字`, `select`, cast(a as char) from `order`",
+		},
+		{
+			name:   "response_filters",
+			script: "select t.a, upper(b) as b, c, d as e from t where c > 0 or c < -10 order by a",
+			serviceParams: []model.ServiceParam{
+				typedResponseParam("a", "int"),
+				typedResponseParam("b", "char"),
+				typedResponseParam("c", "decimal"),
+				typedResponseParam("e", "char"),
+			},
+			filters: []model.ServiceResponseFilter{
+				filter("a", ">=", "2"),
+				filter("b", "like", "50%_off"),
+				filter("c", "not in", "1.5,2"),
+				filter("e", "=", "x"),
+				filter("missing", "=", "x"),
+			},
+			// 表达式、别名为其他字段的列与逻辑视图字段的类型无关，在结果上过滤
+			remaining: []model.ServiceResponseFilter{filter("b", "like", "50%_off"), filter("e", "=", "x"), filter("missing", "=", "x")},
+		},
+		{
+			name:   "response_filters_column_type",
+			script: "select a, b, c, d from t",
+			serviceParams: []model.ServiceParam{
+				// 按字段的实际类型比较，不按返回参数配置的类型
+				{ParamType: "response", EnName: "a", DataType: "string", ColumnType: "int"},
+				{ParamType: "response", EnName: "b", DataType: "long", ColumnType: "char"},
+				typedResponseParam("c", "datetime"),
+				typedResponseParam("d", "int"),
+			},
+			filters: []model.ServiceResponseFilter{
+				filter("a", "=", "007"),
+				filter("b", "in", "1,2"),
+				filter("c", "=", "2023-08-20"),
+				filter("d", "=", "x"),
+			},
+			remaining: []model.ServiceResponseFilter{filter("c", "=", "2023-08-20"), filter("d", "=", "x")},
+		},
+		{
+			name:   "response_filters_null",
+			script: "select a, b, c, d, e, f from t",
+			serviceParams: []model.ServiceParam{
+				typedResponseParam("a", "char"),
+				typedResponseParam("b", "char"),
+				typedResponseParam("c", "int"),
+				typedResponseParam("d", "int"),
+				typedResponseParam("e", "bool"),
+				typedResponseParam("f", "float"),
+			},
+			// 结果上的 NULL 按零值比较，零值满足过滤规则时 NULL 也满足
+			filters: []model.ServiceResponseFilter{
+				filter("a", "!=", "x"),
+				filter("b", "not in", "x,y"),
+				filter("c", "<", "5"),
+				filter("d", ">", "5"),
+				filter("e", "=", "false"),
+				filter("f", "in", "0,1.5"),
+			},
+		},
+		{
+			name:   "response_filters_aggregate",
+			script: "select a, count(b) as total from t group by a",
+			serviceParams: []model.ServiceParam{
+				typedResponseParam("a", "char"),
+				typedResponseParam("total", "int"),
+			},
+			filters: []model.ServiceResponseFilter{
+				filter("a", "in", "x,y'z"),
+				filter("total", ">", "1"),
+			},
+			remaining: []model.ServiceResponseFilter{filter("a", "in", "x,y'z"), filter("total", ">", "1")},
+		},
+		{
+			name:          "response_filters_aggregate_without_group_by",
+			script:        "select a, max(b) as m from t",
+			serviceParams: []model.ServiceParam{typedResponseParam("a", "char")},
+			filters:       []model.ServiceResponseFilter{filter("a", "=", "x")},
+			remaining:     []model.ServiceResponseFilter{filter("a", "=", "x")},
+		},
+		{
+			name:          "response_filters_window",
+			script:        "select a, row_number() as n from t",
+			serviceParams: []model.ServiceParam{typedResponseParam("a", "char")},
+			filters:       []model.ServiceResponseFilter{filter("a", "=", "x")},
+			remaining:     []model.ServiceResponseFilter{filter("a", "=", "x")},
+		},
+		{
+			name:          "response_filters_user_limit",
+			script:        "select a from t order by a limit 5",
+			serviceParams: []model.ServiceParam{typedResponseParam("a", "char")},
+			filters:       []model.ServiceResponseFilter{filter("a", "=", "x")},
+			remaining:     []model.ServiceResponseFilter{filter("a", "=", "x")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &serviceRepo{}
+			ctx := context.Background()
+			script, remaining, err := r.ScriptModelScript(ctx, pageParams(tt.params), testCatalog, testSchema, tt.script, tt.subServices, tt.serviceParams, tt.filters, false)
+			require.NoError(t, err)
+			assert.Equal(t, tt.remaining, remaining)
+			count, _, err := r.ScriptModelScript(ctx, pageParams(tt.params), testCatalog, testSchema, tt.script, tt.subServices, tt.serviceParams, tt.filters, true)
+			require.NoError(t, err)
+			assertGolden(t, "script_"+tt.name, script+"\n"+count+"\n")
+		})
+	}
+}
+
+func TestWizardModelScript_Golden(t *testing.T) {
+	tests := []struct {
+		name          string
+		params        map[string]*dto.Param
+		serviceParams []model.ServiceParam
+		subServices   []model.SubService
+		filters       []model.ServiceResponseFilter
+		remaining     []model.ServiceResponseFilter
+	}{
+		{
+			name: "select_and_sort",
+			serviceParams: []model.ServiceParam{
+				responseParam("a", "asc"),
+				responseParam("名字", "desc"),
+				responseParam("b", ""),
+			},
+		},
+		{
+			name: "request_params",
+			params: map[string]*dto.Param{
+				"a":   dto.NewParam("x' or '1'='1", dto.ParamPositionQuery, dto.ParamDataTypeString),
+				"b":   dto.NewParam(int64(3), dto.ParamPositionQuery, dto.ParamDataTypeLong),
+				"c":   dto.NewParam("a,b", dto.ParamPositionQuery, dto.ParamDataTypeString),
+				"d":   dto.NewParam("", dto.ParamPositionQuery, dto.ParamDataTypeString),
+				"day": dto.NewParam("2023-08-20 10:00:00", dto.ParamPositionQuery, dto.ParamDataTypeString),
+			},
+			serviceParams: []model.ServiceParam{
+				requestParam("a", "string", "like"),
+				requestParam("b", "long", "<="),
+				requestParam("c", "string", "in"),
+				requestParam("d", "string", "="),
+				requestParam("day", "string", ">"),
+				requestParam("missing", "string", "="),
+				responseParam("a", ""),
+			},
+			subServices: []model.SubService{subService(member("c", "char", "=", "x")), subService(member("d", "int", ">", "1"))},
+		},
+		{
+			name: "data_protection_query",
+			serviceParams: []model.ServiceParam{
+				responseParam("a", ""),
+				{ParamType: "response", EnName: "phone", Sort: "asc", DataProtectionQuery: true},
+			},
+		},
+		{
+			name: "response_filters",
+			params: map[string]*dto.Param{
+				"a": dto.NewParam("x", dto.ParamPositionQuery, dto.ParamDataTypeString),
+			},
+			serviceParams: []model.ServiceParam{
+				requestParam("a", "string", "="),
+				typedResponseParam("a", "char"),
+				typedResponseParam("b", "int"),
+				typedResponseParam("flag", "bool"),
+				{ParamType: "response", EnName: "phone", DataType: "string", ColumnType: "char", DataProtectionQuery: true},
+			},
+			subServices: []model.SubService{subService(member("c", "char", "=", "x")), subService(member("d", "int", ">", "1"))},
+			filters: []model.ServiceResponseFilter{
+				filter("a", "!=", "it's"),
+				filter("b", "in", "1,2,3"),
+				filter("flag", "=", "true"),
+				filter("phone", "=", "138"),
+			},
+			remaining: []model.ServiceResponseFilter{filter("phone", "=", "138")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &serviceRepo{}
+			ctx := context.Background()
+			script, remaining, err := r.WizardModelScript(ctx, pageParams(tt.params), testCatalog, testSchema, "tbl", tt.subServices, tt.serviceParams, tt.filters, false)
+			require.NoError(t, err)
+			assert.Equal(t, tt.remaining, remaining)
+			count, _, err := r.WizardModelScript(ctx, pageParams(tt.params), testCatalog, testSchema, "tbl", tt.subServices, tt.serviceParams, tt.filters, true)
+			require.NoError(t, err)
+			assertGolden(t, "wizard_"+tt.name, script+"\n"+count+"\n")
+		})
+	}
+}
+
+func TestPushResponseFilters_remaining(t *testing.T) {
+	serviceParams := []model.ServiceParam{
+		typedResponseParam("s", "char"),
+		typedResponseParam("i", "int"),
+		typedResponseParam("f", "float"),
+		typedResponseParam("b", "bool"),
+		typedResponseParam("d", "date"),
+		responseParam("u", ""),
+	}
+	tests := []struct {
+		name   string
+		filter model.ServiceResponseFilter
+		pushed bool
+	}{
+		{name: "string equal", filter: filter("s", "=", "x"), pushed: true},
+		{name: "string like", filter: filter("S", "like", "x"), pushed: true},
+		{name: "string greater", filter: filter("s", ">", "x")},
+		{name: "string time value", filter: filter("s", "=", "2023-08-20")},
+		{name: "int range", filter: filter("i", "<=", "10"), pushed: true},
+		{name: "int invalid value", filter: filter("i", "=", "1 or 1=1")},
+		{name: "int invalid in item", filter: filter("i", "in", "1, 2")},
+		{name: "float", filter: filter("f", ">", "1.5"), pushed: true},
+		{name: "float invalid value", filter: filter("f", ">", "NaN")},
+		{name: "boolean", filter: filter("b", "!=", "false"), pushed: true},
+		{name: "boolean greater", filter: filter("b", ">", "false")},
+		{name: "date column", filter: filter("d", "=", "2023-08-20")},
+		{name: "unknown column type", filter: filter("u", "=", "x")},
+		{name: "unknown operator", filter: filter("s", "between", "x")},
+		{name: "unknown column", filter: filter("x", "=", "x")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, remaining, err := (&serviceRepo{}).ScriptModelScript(context.Background(), pageParams(nil), testCatalog, testSchema,
+				"select s, i, f, b, d, u from t", nil, serviceParams, []model.ServiceResponseFilter{tt.filter}, false)
+			require.NoError(t, err)
+			if tt.pushed {
+				assert.Empty(t, remaining)
+				assert.Contains(t, script, " where ")
+			} else {
+				assert.Equal(t, []model.ServiceResponseFilter{tt.filter}, remaining)
+				assert.NotContains(t, script, " where ")
+			}
+		})
+	}
+}
+
+func Test_zeroValueMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		dataType dto.ParamDataType
+		filter   model.ServiceResponseFilter
+		want     bool
+	}{
+		{name: "string equal", dataType: dto.ParamDataTypeString, filter: filter("a", "=", "x")},
+		{name: "string equal empty", dataType: dto.ParamDataTypeString, filter: filter("a", "=", ""), want: true},
+		{name: "string not equal", dataType: dto.ParamDataTypeString, filter: filter("a", "!=", "x"), want: true},
+		{name: "string like", dataType: dto.ParamDataTypeString, filter: filter("a", "like", "x")},
+		{name: "string like empty", dataType: dto.ParamDataTypeString, filter: filter("a", "like", ""), want: true},
+		{name: "string in", dataType: dto.ParamDataTypeString, filter: filter("a", "in", "x,y")},
+		{name: "string in empty item", dataType: dto.ParamDataTypeString, filter: filter("a", "in", "x,"), want: true},
+		{name: "string not in", dataType: dto.ParamDataTypeString, filter: filter("a", "not in", "x,y"), want: true},
+		{name: "long equal zero", dataType: dto.ParamDataTypeLong, filter: filter("a", "=", "0"), want: true},
+		{name: "long less", dataType: dto.ParamDataTypeLong, filter: filter("a", "<", "5"), want: true},
+		{name: "long less or equal negative", dataType: dto.ParamDataTypeLong, filter: filter("a", "<=", "-1")},
+		{name: "long greater", dataType: dto.ParamDataTypeLong, filter: filter("a", ">", "5")},
+		{name: "long greater or equal zero", dataType: dto.ParamDataTypeLong, filter: filter("a", ">=", "0"), want: true},
+		{name: "long not in", dataType: dto.ParamDataTypeLong, filter: filter("a", "not in", "0,1")},
+		{name: "double in", dataType: dto.ParamDataTypeDouble, filter: filter("a", "in", "0,1.5"), want: true},
+		{name: "double greater negative", dataType: dto.ParamDataTypeDouble, filter: filter("a", ">", "-0.5"), want: true},
+		{name: "boolean equal false", dataType: dto.ParamDataTypeBoolean, filter: filter("a", "=", "false"), want: true},
+		{name: "boolean not equal false", dataType: dto.ParamDataTypeBoolean, filter: filter("a", "!=", "false")},
+		{name: "boolean not in true", dataType: dto.ParamDataTypeBoolean, filter: filter("a", "not in", "true"), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, zeroValueMatches(tt.dataType, tt.filter))
+		})
+	}
+}
+
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "sql_builder", name+".golden")
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), got)
+}
+
+func TestScriptModelScript_invalid(t *testing.T) {
+	tests := []struct {
+		name          string
+		script        string
+		params        map[string]*dto.Param
+		serviceParams []model.ServiceParam
+		validError    bool
+	}{
+		{name: "syntax error", script: "select from"},
+		{name: "select star", script: "select * from t"},
+		{name: "select star in subquery", script: "select a from (select * from t) as s"},
+		{name: "comment", script: "/* x */ select a from t"},
+		{name: "update", script: "update t set a = 1"},
+		{name: "delete", script: "delete from t"},
+		{name: "union", script: "select a from t union select b from u"},
+		{name: "raw bind var", script: "select a from t where a = ?"},
+		{name: "named bind var", script: "select a from t where a = :a"},
+		{name: "bind var in string literal", script: "select a from t where a = '${a}'"},
+		{name: "bind var in identifier", script: "select a from t where `${a}` = 1"},
+		{name: "missing param", script: "select a from t where a = ${a}", validError: true},
+		{
+			name:          "invalid int",
+			script:        "select a from t where a = ${a}",
+			params:        map[string]*dto.Param{"a": dto.NewParam("1 or 1=1", dto.ParamPositionQuery, dto.ParamDataTypeString)},
+			serviceParams: []model.ServiceParam{requestParam("a", "int", "=")},
+			validError:    true,
+		},
+		{
+			name:          "invalid boolean",
+			script:        "select a from t where a = ${a}",
+			params:        map[string]*dto.Param{"a": dto.NewParam("true or 1=1", dto.ParamPositionQuery, dto.ParamDataTypeString)},
+			serviceParams: []model.ServiceParam{requestParam("a", "boolean", "=")},
+			validError:    true,
+		},
+		{
+			name:          "empty in item",
+			script:        "select a from t where a in (${a})",
+			params:        map[string]*dto.Param{"a": dto.NewParam("1,,2", dto.ParamPositionQuery, dto.ParamDataTypeString)},
+			serviceParams: []model.ServiceParam{requestParam("a", "int", "in")},
+			validError:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &serviceRepo{}
+			_, _, err := r.ScriptModelScript(context.Background(), pageParams(tt.params), testCatalog, testSchema, tt.script, nil, tt.serviceParams, nil, false)
+			require.Error(t, err)
+			var validErrors form_validator.ValidErrors
+			assert.Equal(t, tt.validError, errorsAs(err, &validErrors))
+		})
+	}
+}
+
+func TestRowFilterExpr_invalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		subService model.SubService
+	}{
+		{name: "invalid detail", subService: model.SubService{Detail: "{"}},
+		{name: "unknown operator", subService: subService(member("a", "char", "regexp", "x"))},
+		{name: "invalid number", subService: subService(member("a", "int", "=", "1 or 1=1"))},
+		{name: "invalid number in list", subService: subService(member("a", "int", "in list", "1,2) or (1=1"))},
+		{name: "string operator on number", subService: subService(member("a", "int", "include", "1"))},
+		{name: "invalid before unit", subService: subService(member("a", "datetime", "before", "7 day) or (1=1"))},
+		{name: "invalid current format", subService: subService(member("a", "datetime", "current", "%Y') or ('1'='1"))},
+		{name: "invalid between", subService: subService(member("a", "datetime", "between", "2023-08-20"))},
+		{name: "empty field name", subService: subService(member("", "char", "=", "x"))},
+		{
+			name: "invalid relation",
+			subService: subServiceWithDetail(&dto.SubServiceDetail{RowFilters: dto.RowFilters{
+				WhereRelation: "or 1=1",
+				Where:         []dto.Where{{Member: []dto.Member{member("a", "char", "=", "x")}}},
+			}}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := (&serviceRepo{}).ScriptModelScript(context.Background(), pageParams(nil), testCatalog, testSchema, "select a from t",
+				[]model.SubService{tt.subService}, nil, nil, false)
+			require.Error(t, err)
+		})
+	}
+}
+
+func errorsAs(err error, validErrors *form_validator.ValidErrors) bool {
+	v, ok := err.(form_validator.ValidErrors)
+	*validErrors = v
+	return ok
+}
+
+// scanStringLiteral 按虚拟化引擎的规则从 s[i] 的单引号开始读取字符串字面量，返回字面量的值和结束位置
+func scanStringLiteral(s string, i int) (value string, end int, ok bool) {
+	if i >= len(s) || s[i] != '\'' {
+		return "", 0, false
+	}
+	var b strings.Builder
+	for j := i + 1; j < len(s); j++ {
+		if s[j] != '\'' {
+			b.WriteByte(s[j])
+			continue
+		}
+		if j+1 < len(s) && s[j+1] == '\'' {
+			b.WriteByte('\'')
+			j++
+			continue
+		}
+		return b.String(), j + 1, true
+	}
+	return "", 0, false
+}
+
+// FuzzScriptModelScript_stringParam 任意字符串参数都只能作为一个完整的字符串字面量出现在 SQL 中
+func FuzzScriptModelScript_stringParam(f *testing.F) {
+	for _, seed := range []string{
+		"abc",
+		"x' or '1'='1",
+		"'; drop table t; --",
+		`\' or 1=1 --`,
+		"a''b",
+		"' union select password from users --",
+		"%' and '",
+		"王小明",
+		"/* */",
+		"${b}",
+	} {
+		f.Add(seed)
+	}
+
+	const script = "select a from t where b = ${b} and c = 1"
+	serviceParams := []model.ServiceParam{requestParam("b", "string", "=")}
+	build := func(value string) (string, error) {
+		params := pageParams(map[string]*dto.Param{"b": dto.NewParam(value, dto.ParamPositionQuery, dto.ParamDataTypeString)})
+		got, _, err := (&serviceRepo{}).ScriptModelScript(context.Background(), params, testCatalog, testSchema, script, nil, serviceParams, nil, false)
+		return got, err
+	}
+	benign, err := build("x")
+	require.NoError(f, err)
+	start := strings.Index(benign, "'x'")
+	require.Greater(f, start, 0)
+	prefix, suffix := benign[:start], benign[start+len("'x'"):]
+
+	f.Fuzz(func(t *testing.T, value string) {
+		got, err := build(value)
+		if value == "" {
+			// 空字符串视为未传参
+			require.Error(t, err)
+			return
+		}
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(got, prefix), got)
+
+		literal := got[len(prefix):]
+		if (&serviceRepo{}).isTimeParam(value) {
+			literal = strings.TrimPrefix(literal, "timestamp ")
+		}
+		decoded, end, ok := scanStringLiteral(literal, 0)
+		require.True(t, ok, got)
+		assert.Equal(t, value, decoded)
+		assert.Equal(t, suffix, literal[end:], got)
+	})
+}
+
+// FuzzScriptModelScript_numberParam 数值参数要么校验失败，要么输出为数值字面量
+func FuzzScriptModelScript_numberParam(f *testing.F) {
+	for _, seed := range []string{"1", "-1", "1.5", "1e10", "1 or 1=1", "0x10", "NaN", "Inf", "1; drop table t"} {
+		f.Add(seed)
+	}
+
+	const script = "select a from t where b = ${b}"
+	f.Fuzz(func(t *testing.T, value string) {
+		for _, dataType := range []string{"int", "double"} {
+			params := pageParams(map[string]*dto.Param{"b": dto.NewParam(value, dto.ParamPositionQuery, dto.ParamDataTypeString)})
+			got, _, err := (&serviceRepo{}).ScriptModelScript(context.Background(), params, testCatalog, testSchema, script, nil,
+				[]model.ServiceParam{requestParam("b", dataType, "=")}, nil, false)
+			if err != nil {
+				continue
+			}
+			literal := strings.TrimPrefix(got, `select a from "`+testCatalog+`"."`+testSchema+`"."t" where b = `)
+			literal = strings.TrimSuffix(literal, " offset 10 limit 10")
+			assert.Regexp(t, `^-?[0-9]+(\.[0-9]+)?$`, literal, got)
+		}
+	})
+}
@@ -0,0 +1,151 @@
+// Package mq 消息队列，目前只用于订阅其他服务发布的变更消息
+package mq
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+	"go.uber.org/zap"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+const (
+	Plain       = "PLAIN"
+	ScramSHA256 = "SCRAM-SHA-256"
+	ScramSHA512 = "SCRAM-SHA-512"
+
+	// 消费组前缀，每个网关实例使用独立的消费组，保证每个实例都收到全部消息
+	groupIDPrefix = "data-application-gateway-"
+	// 读取消息失败后重试的间隔
+	retryInterval = time.Second
+)
+
+// MessageHandler 消息处理函数
+type MessageHandler func(ctx context.Context, msg []byte) error
+
+// KafkaConsumer 订阅 kafka 消息，实现 transport.Server，随应用启动和停止
+type KafkaConsumer struct {
+	conf     settings.Kafka
+	groupID  string
+	handlers map[string]MessageHandler
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewKafkaConsumer 创建 kafka 消费者，name 用于区分同一实例中的不同消费组
+func NewKafkaConsumer(s *settings.Settings, name string) *KafkaConsumer {
+	hostname, _ := os.Hostname()
+	return &KafkaConsumer{
+		conf:     s.MQ.Kafka,
+		groupID:  groupIDPrefix + name + "-" + hostname,
+		handlers: make(map[string]MessageHandler),
+	}
+}
+
+// Handle 注册 topic 的消息处理函数，需要在 Start 之前调用
+func (c *KafkaConsumer) Handle(topic string, handler MessageHandler) {
+	c.handlers[topic] = handler
+}
+
+// Start 开始消费，阻塞到 Stop 被调用。未配置 kafka 时直接返回
+func (c *KafkaConsumer) Start(ctx context.Context) error {
+	if c.conf.Host == "" {
+		log.Warn("kafka host is empty, consumer disabled", zap.String("group", c.groupID))
+		return nil
+	}
+	mechanism, err := saslMechanism(c.conf)
+	if err != nil {
+		return err
+	}
+	dialer := &kafka.Dialer{
+		SASLMechanism: mechanism,
+		Timeout:       10 * time.Second,
+	}
+
+	ctx, c.cancel = context.WithCancel(ctx)
+	for topic, handler := range c.handlers {
+		r := kafka.NewReader(kafka.ReaderConfig{
+			Brokers: brokers(c.conf),
+			GroupID: c.groupID,
+			Topic:   topic,
+			// 新的消费组只处理启动之后的消息，启动前的变更不影响刚创建的缓存
+			StartOffset: kafka.LastOffset,
+			Dialer:      dialer,
+		})
+		c.wg.Add(1)
+		go c.consume(ctx, r, handler)
+	}
+	<-ctx.Done()
+	return nil
+}
+
+// Stop 停止消费，等待正在处理的消息完成
+func (c *KafkaConsumer) Stop(ctx context.Context) error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.wg.Wait()
+	return nil
+}
+
+func (c *KafkaConsumer) consume(ctx context.Context, r *kafka.Reader, handler MessageHandler) {
+	defer c.wg.Done()
+	defer r.Close()
+	for {
+		m, err := r.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Error("kafka read message failed", zap.String("topic", r.Config().Topic), zap.Error(err))
+			time.Sleep(retryInterval)
+			continue
+		}
+		if err := c.handle(ctx, handler, m.Value); err != nil {
+			log.Error("kafka handle message failed", zap.String("topic", m.Topic), zap.Int64("offset", m.Offset), zap.Error(err))
+		}
+	}
+}
+
+func (c *KafkaConsumer) handle(ctx context.Context, handler MessageHandler, msg []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panic: %v", r)
+		}
+	}()
+	return handler(ctx, msg)
+}
+
+func brokers(conf settings.Kafka) []string {
+	var addrs []string
+	for _, host := range strings.Split(conf.Host, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			addrs = append(addrs, host+":"+conf.Port)
+		}
+	}
+	return addrs
+}
+
+func saslMechanism(conf settings.Kafka) (sasl.Mechanism, error) {
+	switch conf.Mechanism {
+	case ScramSHA256:
+		return scram.Mechanism(scram.SHA256, conf.Username, conf.Password)
+	case ScramSHA512:
+		return scram.Mechanism(scram.SHA512, conf.Username, conf.Password)
+	case Plain:
+		return plain.Mechanism{Username: conf.Username, Password: conf.Password}, nil
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,128 @@
+package rate_limiter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+const (
+	// redis key 前缀
+	keyPrefix = "data-application-gateway-rate:"
+	// 单次 redis 操作的超时时间，避免 redis 异常时拖慢接口调用
+	redisTimeout = 200 * time.Millisecond
+	// redis 异常后，在这段时间内直接使用进程内限流器
+	redisRetryInterval = 10 * time.Second
+
+	localCacheSize = 1 << 12
+	localCacheTTL  = 10 * time.Minute
+)
+
+// Result 限流结果
+type Result struct {
+	// 是否允许本次调用
+	Allowed bool
+	// 不允许调用时，距离下次可调用的时间
+	RetryAfter time.Duration
+}
+
+type RateLimiterRepo interface {
+	// Allow 判断 key 是否允许再调用一次，limit 为每秒允许的调用次数
+	Allow(ctx context.Context, key string, limit int) (res *Result, err error)
+}
+
+func NewRateLimiterRepo(redis *repository.Redis) RateLimiterRepo {
+	return &rateLimiterRepo{
+		redis: redis,
+		local: expirable.NewLRU[string, *rate.Limiter](localCacheSize, nil, localCacheTTL),
+	}
+}
+
+type rateLimiterRepo struct {
+	redis *repository.Redis
+
+	// redis 不可用时使用的进程内限流器，key 与 redis 相同
+	local   *expirable.LRU[string, *rate.Limiter]
+	localMu sync.Mutex
+
+	// redis 恢复前不再尝试访问 redis 的截止时间，unix 纳秒
+	redisDownUntil atomic.Int64
+}
+
+func (r *rateLimiterRepo) Allow(ctx context.Context, key string, limit int) (res *Result, err error) {
+	if limit <= 0 {
+		return &Result{Allowed: true}, nil
+	}
+
+	now := time.Now()
+	if r.redisAvailable(now) {
+		res, err = r.allowRedis(ctx, key, limit, now)
+		if err == nil {
+			return res, nil
+		}
+		log.WithContext(ctx).Warn("rateLimiterRepo Allow redis unavailable, fallback to local limiter", zap.Error(err))
+		r.redisDownUntil.Store(now.Add(redisRetryInterval).UnixNano())
+	}
+
+	return r.allowLocal(key, limit, now), nil
+}
+
+func (r *rateLimiterRepo) redisAvailable(now time.Time) bool {
+	if r.redis == nil || r.redis.Client == nil {
+		return false
+	}
+	return now.UnixNano() >= r.redisDownUntil.Load()
+}
+
+// allowRedis 固定窗口计数，每秒一个窗口，多个网关实例共享计数
+func (r *rateLimiterRepo) allowRedis(ctx context.Context, key string, limit int, now time.Time) (*Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+
+	window := now.Unix()
+	windowKey := fmt.Sprintf("%s%s:%d", keyPrefix, key, window)
+
+	pipe := r.redis.Client.TxPipeline()
+	incr := pipe.Incr(ctx, windowKey)
+	pipe.Expire(ctx, windowKey, 2*time.Second)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	if incr.Val() <= int64(limit) {
+		return &Result{Allowed: true}, nil
+	}
+	return &Result{RetryAfter: time.Unix(window+1, 0).Sub(now)}, nil
+}
+
+// allowLocal 令牌桶限流，仅在当前网关实例内生效
+func (r *rateLimiterRepo) allowLocal(key string, limit int, now time.Time) *Result {
+	r.localMu.Lock()
+	limiter, ok := r.local.Get(key)
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(limit), limit)
+		r.local.Add(key, limiter)
+	} else if limiter.Limit() != rate.Limit(limit) {
+		// 接口的调用频次配置发生了变化
+		limiter.SetLimitAt(now, rate.Limit(limit))
+		limiter.SetBurstAt(now, limit)
+	}
+	r.localMu.Unlock()
+
+	reservation := limiter.ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	if delay == 0 {
+		return &Result{Allowed: true}
+	}
+	reservation.CancelAt(now)
+	return &Result{RetryAfter: delay}
+}
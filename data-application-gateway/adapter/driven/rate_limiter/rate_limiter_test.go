@@ -0,0 +1,86 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+func TestMain(m *testing.M) {
+	// 初始化日志，否则调用 log.Warn 等方法会 panic
+	log.InitLogger(nil, &telemetry.Config{})
+	m.Run()
+}
+
+func Test_rateLimiterRepo_allowLocal(t *testing.T) {
+	r := NewRateLimiterRepo(nil).(*rateLimiterRepo)
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, r.allowLocal("app:service", 3, now).Allowed, "call %d", i)
+	}
+
+	res := r.allowLocal("app:service", 3, now)
+	assert.False(t, res.Allowed)
+	assert.Greater(t, res.RetryAfter, time.Duration(0))
+	assert.LessOrEqual(t, res.RetryAfter, time.Second)
+
+	// 不同的调用方互不影响
+	assert.True(t, r.allowLocal("other-app:service", 3, now).Allowed)
+
+	// 令牌恢复后允许调用
+	assert.True(t, r.allowLocal("app:service", 3, now.Add(time.Second)).Allowed)
+}
+
+func Test_rateLimiterRepo_allowLocal_limitChanged(t *testing.T) {
+	r := NewRateLimiterRepo(nil).(*rateLimiterRepo)
+	now := time.Now()
+
+	assert.True(t, r.allowLocal("app:service", 1, now).Allowed)
+	assert.False(t, r.allowLocal("app:service", 1, now).Allowed)
+
+	// 调大调用频次后立即按新的频次恢复令牌
+	later := now.Add(100 * time.Millisecond)
+	res := r.allowLocal("app:service", 10, later)
+	assert.False(t, res.Allowed)
+	assert.LessOrEqual(t, res.RetryAfter, 90*time.Millisecond)
+	assert.True(t, r.allowLocal("app:service", 10, later.Add(res.RetryAfter)).Allowed)
+}
+
+func Test_rateLimiterRepo_Allow_redisUnavailable(t *testing.T) {
+	client := redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:       []string{"127.0.0.1:1"},
+		DialTimeout: 50 * time.Millisecond,
+		MaxRetries:  -1,
+	})
+	defer client.Close()
+
+	r := NewRateLimiterRepo(&repository.Redis{Client: client}).(*rateLimiterRepo)
+	ctx := context.Background()
+
+	res, err := r.Allow(ctx, "app:service", 1)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.False(t, r.redisAvailable(time.Now()))
+
+	res, err = r.Allow(ctx, "app:service", 1)
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Greater(t, res.RetryAfter, time.Duration(0))
+}
+
+func Test_rateLimiterRepo_Allow_unlimited(t *testing.T) {
+	r := NewRateLimiterRepo(nil)
+	for i := 0; i < 100; i++ {
+		res, err := r.Allow(context.Background(), "app:service", 0)
+		assert.NoError(t, err)
+		assert.True(t, res.Allowed)
+	}
+}
@@ -0,0 +1,248 @@
+package reverse_proxy
+
+import (
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+// 熔断器状态
+const (
+	BreakerStateClosed   = "closed"    // 正常访问后台服务
+	BreakerStateOpen     = "open"      // 熔断，不访问后台服务
+	BreakerStateHalfOpen = "half-open" // 允许少量请求探测后台服务是否恢复
+)
+
+const (
+	defaultFailureThreshold    = 5
+	defaultOpenTimeout         = 30 * time.Second
+	defaultHalfOpenMaxRequests = 1
+)
+
+// outcome 请求的结果
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota
+	outcomeFailure
+	// 调用方取消等与后台服务无关的结果，不影响熔断器状态
+	outcomeIgnored
+)
+
+// breaker 一个后台服务的熔断器
+type breaker struct {
+	backend     string
+	threshold   int
+	openTimeout time.Duration
+	halfOpenMax int
+	now         func() time.Time
+
+	mu       sync.Mutex
+	state    string
+	failures int // 连续失败次数
+	openedAt time.Time
+	inFlight int   // 半开状态正在进行的探测请求数
+	trips    int64 // 熔断的次数
+	// 状态变化时加一，用于忽略状态变化前开始的请求的结果
+	generation int64
+}
+
+func newBreaker(conf settings.Breaker, backend string) *breaker {
+	b := &breaker{
+		backend:     backend,
+		threshold:   conf.FailureThreshold,
+		openTimeout: time.Duration(conf.OpenTimeout) * time.Second,
+		halfOpenMax: conf.HalfOpenMaxRequests,
+		now:         time.Now,
+		state:       BreakerStateClosed,
+	}
+	if b.threshold <= 0 {
+		b.threshold = defaultFailureThreshold
+	}
+	if b.openTimeout <= 0 {
+		b.openTimeout = defaultOpenTimeout
+	}
+	if b.halfOpenMax <= 0 {
+		b.halfOpenMax = defaultHalfOpenMaxRequests
+	}
+	return b
+}
+
+// allow 判断是否允许请求，允许时返回的 done 需要在请求结束后调用
+func (b *breaker) allow() (done func(outcome), ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerStateOpen && b.now().Sub(b.openedAt) >= b.openTimeout {
+		b.setState(BreakerStateHalfOpen)
+	}
+	switch b.state {
+	case BreakerStateOpen:
+		return nil, false
+	case BreakerStateHalfOpen:
+		if b.inFlight >= b.halfOpenMax {
+			return nil, false
+		}
+		b.inFlight++
+	}
+
+	generation := b.generation
+	var once sync.Once
+	return func(o outcome) {
+		once.Do(func() { b.done(generation, o) })
+	}, true
+}
+
+func (b *breaker) done(generation int64, o outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+	switch b.state {
+	case BreakerStateClosed:
+		switch o {
+		case outcomeSuccess:
+			b.failures = 0
+		case outcomeFailure:
+			b.failures++
+			if b.failures >= b.threshold {
+				b.trip()
+			}
+		}
+	case BreakerStateHalfOpen:
+		b.inFlight--
+		switch o {
+		case outcomeSuccess:
+			b.setState(BreakerStateClosed)
+		case outcomeFailure:
+			b.trip()
+		}
+	}
+}
+
+func (b *breaker) trip() {
+	b.setState(BreakerStateOpen)
+	b.openedAt = b.now()
+	b.trips++
+	log.Warn("reverse proxy breaker open", zap.String("backend", b.backend), zap.Int64("trips", b.trips))
+}
+
+func (b *breaker) setState(state string) {
+	b.state = state
+	b.failures = 0
+	b.inFlight = 0
+	b.generation++
+}
+
+// BreakerState 接口的后台服务的熔断器状态
+type BreakerState struct {
+	ServiceID           string `json:"service_id"`           // 接口ID
+	ServicePath         string `json:"service_path"`         // 接口路径
+	Backend             string `json:"backend"`              // 后台服务地址
+	State               string `json:"state"`                // 熔断器状态 closed 正常 open 熔断 half-open 半开
+	ConsecutiveFailures int    `json:"consecutive_failures"` // 连续失败次数
+	Trips               int64  `json:"trips"`                // 熔断的次数
+	OpenedAt            int64  `json:"opened_at,omitempty"`  // 最近一次熔断的时间，毫秒时间戳
+}
+
+func (b *breaker) snapshot() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state := b.state
+	if state == BreakerStateOpen && b.now().Sub(b.openedAt) >= b.openTimeout {
+		state = BreakerStateHalfOpen
+	}
+	s := BreakerState{
+		State:               state,
+		ConsecutiveFailures: b.failures,
+		Trips:               b.trips,
+	}
+	if !b.openedAt.IsZero() {
+		s.OpenedAt = b.openedAt.UnixMilli()
+	}
+	return s
+}
+
+type serviceBackend struct {
+	servicePath string
+	backend     string
+}
+
+// breakers 按后台服务地址区分的熔断器，同一个后台服务的接口共用熔断器
+type breakers struct {
+	conf settings.Breaker
+
+	mu       sync.Mutex
+	backends map[string]*breaker
+	// 接口使用的后台服务，key 为接口 ID
+	services map[string]serviceBackend
+}
+
+func newBreakers(conf settings.Breaker) *breakers {
+	return &breakers{
+		conf:     conf,
+		backends: make(map[string]*breaker),
+		services: make(map[string]serviceBackend),
+	}
+}
+
+// get 返回后台服务的熔断器，关闭熔断时返回 nil
+func (b *breakers) get(serviceID, servicePath, backend string) *breaker {
+	if b == nil || b.conf.Disabled {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if serviceID != "" {
+		b.services[serviceID] = serviceBackend{servicePath: servicePath, backend: backend}
+	}
+	br, ok := b.backends[backend]
+	if !ok {
+		br = newBreaker(b.conf, backend)
+		b.backends[backend] = br
+	}
+	return br
+}
+
+// states 返回访问过的接口的熔断器状态，按接口路径排序
+func (b *breakers) states() []BreakerState {
+	if b == nil {
+		return []BreakerState{}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	states := make([]BreakerState, 0, len(b.services))
+	for serviceID, sb := range b.services {
+		br, ok := b.backends[sb.backend]
+		if !ok {
+			continue
+		}
+		s := br.snapshot()
+		s.ServiceID, s.ServicePath, s.Backend = serviceID, sb.servicePath, sb.backend
+		states = append(states, s)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].ServicePath != states[j].ServicePath {
+			return states[i].ServicePath < states[j].ServicePath
+		}
+		return states[i].ServiceID < states[j].ServiceID
+	})
+	return states
+}
+
+// backendKey 后台服务的地址，不包括路径
+func backendKey(backendServiceHost string) string {
+	u, err := url.Parse(backendServiceHost)
+	if err != nil || u.Host == "" {
+		return backendServiceHost
+	}
+	return u.Scheme + "://" + u.Host
+}
@@ -0,0 +1,181 @@
+package reverse_proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+	"github.com/kweaver-ai/idrm-go-frame/core/errorx/agerrors"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestBreaker() (*breaker, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	b := newBreaker(settings.Breaker{FailureThreshold: 2, OpenTimeout: 10, HalfOpenMaxRequests: 1}, "http://backend")
+	b.now = clock.now
+	return b, clock
+}
+
+func Test_breaker(t *testing.T) {
+	b, clock := newTestBreaker()
+	request := func(o outcome) bool {
+		done, ok := b.allow()
+		if ok {
+			done(o)
+		}
+		return ok
+	}
+
+	// 成功会清零连续失败次数
+	assert.True(t, request(outcomeFailure))
+	assert.True(t, request(outcomeSuccess))
+	assert.True(t, request(outcomeFailure))
+	assert.Equal(t, BreakerStateClosed, b.snapshot().State)
+
+	// 连续失败达到阈值后熔断
+	assert.True(t, request(outcomeFailure))
+	assert.Equal(t, BreakerStateOpen, b.snapshot().State)
+	assert.False(t, request(outcomeSuccess))
+
+	// 超过熔断时间后半开，只允许一个探测请求
+	clock.t = clock.t.Add(10 * time.Second)
+	done, ok := b.allow()
+	require.True(t, ok)
+	_, ok = b.allow()
+	assert.False(t, ok)
+	assert.Equal(t, BreakerStateHalfOpen, b.snapshot().State)
+
+	// 探测失败重新熔断
+	done(outcomeFailure)
+	assert.Equal(t, BreakerStateOpen, b.snapshot().State)
+	assert.Equal(t, int64(2), b.snapshot().Trips)
+
+	// 探测成功恢复
+	clock.t = clock.t.Add(10 * time.Second)
+	assert.True(t, request(outcomeSuccess))
+	assert.Equal(t, BreakerStateClosed, b.snapshot().State)
+}
+
+func Test_breaker_staleOutcome(t *testing.T) {
+	b, clock := newTestBreaker()
+
+	// 熔断前开始的请求，结束时不影响熔断后的状态
+	slow, ok := b.allow()
+	require.True(t, ok)
+	for i := 0; i < 2; i++ {
+		done, _ := b.allow()
+		done(outcomeFailure)
+	}
+	clock.t = clock.t.Add(10 * time.Second)
+	probe, ok := b.allow()
+	require.True(t, ok)
+	slow(outcomeSuccess)
+	assert.Equal(t, BreakerStateHalfOpen, b.snapshot().State)
+
+	// 调用方取消的请求不影响状态，探测名额释放
+	probe(outcomeIgnored)
+	assert.Equal(t, BreakerStateHalfOpen, b.snapshot().State)
+	_, ok = b.allow()
+	assert.True(t, ok)
+}
+
+func Test_breakers_states(t *testing.T) {
+	b := newBreakers(settings.Breaker{})
+	b.get("2", "/b", "http://backend")
+	b.get("1", "/a", "http://backend")
+	b.get("", "", "http://other")
+
+	states := b.states()
+	require.Len(t, states, 2)
+	assert.Equal(t, BreakerState{ServiceID: "1", ServicePath: "/a", Backend: "http://backend", State: BreakerStateClosed}, states[0])
+	assert.Equal(t, "/b", states[1].ServicePath)
+
+	assert.Nil(t, newBreakers(settings.Breaker{Disabled: true}).get("1", "/a", "http://backend"))
+}
+
+func Test_backendKey(t *testing.T) {
+	assert.Equal(t, "https://api.example.com:8443", backendKey("https://api.example.com:8443"))
+	assert.Equal(t, "http://api.example.com", backendKey("http://api.example.com/prefix"))
+	assert.Equal(t, "api.example.com", backendKey("api.example.com"))
+}
+
+func Test_retryPolicy(t *testing.T) {
+	p := newRetryPolicy(settings.Retry{MaxAttempts: 3, InitialBackoff: 100, MaxBackoff: 300})
+	assert.Equal(t, 3, p.attempts(http.MethodGet))
+	assert.Equal(t, 1, p.attempts(http.MethodPost))
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 300 * time.Millisecond, 10: 300 * time.Millisecond} {
+		d := p.backoff(attempt)
+		assert.GreaterOrEqual(t, d, want/2, attempt)
+		assert.LessOrEqual(t, d, want, attempt)
+	}
+
+	assert.Equal(t, 1, newRetryPolicy(settings.Retry{}).attempts(http.MethodGet))
+	assert.Equal(t, 2, newRetryPolicy(settings.Retry{MaxAttempts: 2, Methods: []string{"get", "put"}}).attempts(http.MethodPut))
+}
+
+func Test_reverseProxyRepo_Serve_retryAndBreaker(t *testing.T) {
+	var calls atomic.Int32
+	var failures atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if failures.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	r := NewReverseProxyRepo(nil, &settings.Settings{Proxy: settings.Proxy{
+		Breaker: settings.Breaker{FailureThreshold: 3, OpenTimeout: 60},
+		Retry:   settings.Retry{MaxAttempts: 3, InitialBackoff: 1, MaxBackoff: 1},
+	}})
+	serve := func(method string) error {
+		service := &model.ServiceAssociations{Service: model.Service{ServiceID: "1", ServicePath: "/a", HTTPMethod: method, BackendServiceHost: srv.URL, BackendServicePath: "/", Timeout: 5}}
+		res, err := r.Serve(context.Background(), nil, nil, service)
+		if err == nil {
+			res.Body.Close()
+		}
+		return err
+	}
+
+	// get 失败后重试成功
+	calls.Store(0)
+	failures.Store(2)
+	assert.NoError(t, serve("get"))
+	assert.Equal(t, int32(3), calls.Load())
+
+	// post 不重试
+	calls.Store(0)
+	failures.Store(1)
+	assert.Error(t, serve("post"))
+	assert.Equal(t, int32(1), calls.Load())
+
+	// 连续失败后熔断，不再请求后台服务
+	calls.Store(0)
+	failures.Store(100)
+	err := serve("get")
+	assert.Equal(t, errorcode.BackendCircuitOpen, agerrors.Code(err).GetErrorCode())
+	assert.Equal(t, int32(2), calls.Load())
+	err = serve("post")
+	assert.Equal(t, errorcode.BackendCircuitOpen, agerrors.Code(err).GetErrorCode())
+	assert.Equal(t, int32(2), calls.Load())
+
+	states := r.BreakerStates(context.Background())
+	require.Len(t, states, 1)
+	assert.Equal(t, BreakerStateOpen, states[0].State)
+	assert.Equal(t, int64(1), states[0].Trips)
+}
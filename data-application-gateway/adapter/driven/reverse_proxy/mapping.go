@@ -0,0 +1,138 @@
+package reverse_proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/util"
+)
+
+// strippedHeaders 不转发给后台服务的请求头：逐跳请求头，以及由网关重新生成的请求头
+var strippedHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+	"Host",
+	"Content-Length",
+	"Accept-Encoding",
+	"Content-Type",
+}
+
+// credentialHeaders 调用方访问网关的凭证，默认不转发，需要在 allow 中显式允许
+var credentialHeaders = []string{"Authorization", "Cookie"}
+
+// requestMapping 解析后的请求映射，请求头名称统一为规范格式
+type requestMapping struct {
+	allow      map[string]bool
+	deny       map[string]bool
+	rename     map[string]string
+	inject     []dto.StaticHeader
+	multiValue bool
+	rawBody    bool
+}
+
+// parseRequestMapping 解析接口保存的请求映射，没有配置时使用默认的映射
+func parseRequestMapping(s *string) (*requestMapping, error) {
+	var m dto.RequestMapping
+	if s != nil && *s != "" {
+		if err := json.Unmarshal([]byte(*s), &m); err != nil {
+			return nil, err
+		}
+	}
+	return newRequestMapping(&m), nil
+}
+
+func newRequestMapping(m *dto.RequestMapping) *requestMapping {
+	r := &requestMapping{
+		allow:      canonicalSet(m.Headers.Allow),
+		deny:       canonicalSet(m.Headers.Deny),
+		rename:     make(map[string]string, len(m.Headers.Rename)),
+		inject:     m.Headers.Inject,
+		multiValue: m.Query.MultiValue,
+		rawBody:    m.Body.Mode == dto.BodyModeRaw,
+	}
+	for k, v := range m.Headers.Rename {
+		r.rename[http.CanonicalHeaderKey(k)] = v
+	}
+	return r
+}
+
+func canonicalSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[http.CanonicalHeaderKey(name)] = true
+	}
+	return set
+}
+
+// forward 请求头是否转发给后台服务。deny 优先于 allow，allow 不为空时只转发 allow 中的请求头
+func (m *requestMapping) forward(name string) bool {
+	name = http.CanonicalHeaderKey(name)
+	for _, h := range strippedHeaders {
+		if name == h {
+			return false
+		}
+	}
+	if m.deny[name] {
+		return false
+	}
+	if len(m.allow) > 0 {
+		return m.allow[name]
+	}
+	for _, h := range credentialHeaders {
+		if name == h {
+			return false
+		}
+	}
+	return true
+}
+
+// header 按映射生成转发给后台服务的请求头，key 为解密密钥的 AES 密钥
+func (m *requestMapping) header(headers map[string]string, key string) (http.Header, error) {
+	h := make(http.Header, len(headers)+len(m.inject))
+	for name, value := range headers {
+		if !m.forward(name) {
+			continue
+		}
+		if to, ok := m.rename[http.CanonicalHeaderKey(name)]; ok {
+			name = to
+		}
+		h.Set(name, value)
+	}
+	for _, inject := range m.inject {
+		value := inject.Value
+		// 测试接口时密钥可能还没有加密，按明文使用
+		if inject.Secret && util.IsEncryptedSecret(value) {
+			var err error
+			if value, err = util.DecryptSecret(key, value); err != nil {
+				return nil, err
+			}
+		}
+		h.Set(inject.Name, value)
+	}
+	return h, nil
+}
+
+// query 生成转发给后台服务的 query 参数。转发所有值时以原始请求中有多个值的参数为准
+func (m *requestMapping) query(queryParams map[string]string, raw *http.Request) url.Values {
+	query := make(url.Values, len(queryParams))
+	for k, v := range queryParams {
+		query.Set(k, v)
+	}
+	if !m.multiValue || raw == nil {
+		return query
+	}
+	for k, vs := range raw.URL.Query() {
+		if len(vs) > 1 {
+			query[k] = vs
+		}
+	}
+	return query
+}
@@ -0,0 +1,160 @@
+package reverse_proxy
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/util"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+)
+
+func Test_requestMapping_header(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))
+	secret, err := util.EncryptSecret(key, "backend-key")
+	require.NoError(t, err)
+
+	headers := map[string]string{
+		"authorization":   "Bearer caller",
+		"cookie":          "session=1",
+		"connection":      "keep-alive",
+		"accept-encoding": "gzip",
+		"x-trace":         "t",
+		"x-tenant":        "a",
+		"x-api-key":       "caller-key",
+	}
+	tests := []struct {
+		name    string
+		mapping dto.RequestMapping
+		want    http.Header
+		wantErr bool
+	}{
+		{
+			name: "默认不转发凭证和逐跳请求头",
+			want: http.Header{"X-Trace": {"t"}, "X-Tenant": {"a"}, "X-Api-Key": {"caller-key"}},
+		},
+		{
+			name:    "allow 只转发允许的请求头，可以显式允许凭证",
+			mapping: dto.RequestMapping{Headers: dto.HeaderMapping{Allow: []string{"Authorization", "X-Trace", "Connection"}}},
+			want:    http.Header{"Authorization": {"Bearer caller"}, "X-Trace": {"t"}},
+		},
+		{
+			name:    "deny 优先于 allow",
+			mapping: dto.RequestMapping{Headers: dto.HeaderMapping{Allow: []string{"X-Trace", "x-tenant"}, Deny: []string{"X-TENANT"}}},
+			want:    http.Header{"X-Trace": {"t"}},
+		},
+		{
+			name: "重命名并注入密钥，注入的请求头覆盖调用方的同名请求头",
+			mapping: dto.RequestMapping{Headers: dto.HeaderMapping{
+				Rename: map[string]string{"X-Tenant": "X-Backend-Tenant"},
+				Inject: []dto.StaticHeader{{Name: "X-Api-Key", Value: secret, Secret: true}, {Name: "X-Static", Value: "s"}},
+			}},
+			want: http.Header{"X-Trace": {"t"}, "X-Backend-Tenant": {"a"}, "X-Api-Key": {"backend-key"}, "X-Static": {"s"}},
+		},
+		{
+			name:    "测试时密钥为明文",
+			mapping: dto.RequestMapping{Headers: dto.HeaderMapping{Allow: []string{"X-Trace"}, Inject: []dto.StaticHeader{{Name: "X-Api-Key", Value: "plain", Secret: true}}}},
+			want:    http.Header{"X-Trace": {"t"}, "X-Api-Key": {"plain"}},
+		},
+		{
+			name:    "密钥无法解密",
+			mapping: dto.RequestMapping{Headers: dto.HeaderMapping{Inject: []dto.StaticHeader{{Name: "X-Api-Key", Value: "enc:v1:broken", Secret: true}}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newRequestMapping(&tt.mapping).header(headers, key)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_requestMapping_query(t *testing.T) {
+	raw := httptest.NewRequest(http.MethodGet, "/svc?id=1&id=2&name=a&empty=", nil)
+	queryParams := map[string]string{"name": "a", "limit": "10"}
+
+	m := newRequestMapping(&dto.RequestMapping{})
+	assert.Equal(t, "limit=10&name=a", m.query(queryParams, raw).Encode())
+
+	m = newRequestMapping(&dto.RequestMapping{Query: dto.QueryMapping{MultiValue: true}})
+	assert.Equal(t, "id=1&id=2&limit=10&name=a", m.query(queryParams, raw).Encode())
+	// 测试接口没有原始请求
+	assert.Equal(t, "limit=10&name=a", m.query(queryParams, nil).Encode())
+}
+
+func Test_reverseProxyRepo_Serve_requestMapping(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"query":         r.URL.RawQuery,
+			"authorization": r.Header.Get("Authorization"),
+			"api_key":       r.Header.Get("X-Api-Key"),
+			"content_type":  r.Header.Get("Content-Type"),
+			"body":          string(body),
+		})
+	}))
+	defer srv.Close()
+
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))
+	secret, err := util.EncryptSecret(key, "backend-key")
+	require.NoError(t, err)
+	mapping, err := json.Marshal(dto.RequestMapping{
+		Headers: dto.HeaderMapping{Inject: []dto.StaticHeader{{Name: "X-Api-Key", Value: secret, Secret: true}}},
+		Query:   dto.QueryMapping{MultiValue: true},
+		Body:    dto.BodyMapping{Mode: dto.BodyModeRaw},
+	})
+	require.NoError(t, err)
+
+	raw := httptest.NewRequest(http.MethodPost, "/svc?tag=a&tag=b", strings.NewReader("<order id=\"1\"/>"))
+	raw.Header.Set("Content-Type", "application/xml")
+	params := map[string]*dto.Param{
+		"authorization": dto.NewParam("Bearer caller", dto.ParamPositionHeader, dto.ParamDataTypeString),
+		"content-type":  dto.NewParam("application/xml", dto.ParamPositionHeader, dto.ParamDataTypeString),
+	}
+
+	r := NewReverseProxyRepo(nil, &settings.Settings{Crypto: settings.Crypto{Key: key}})
+	serve := func(raw *http.Request) map[string]string {
+		service := &model.ServiceAssociations{Service: model.Service{HTTPMethod: "post", BackendServiceHost: srv.URL, BackendServicePath: "/orders", Timeout: 5, RequestMapping: lo.ToPtr(string(mapping))}}
+		res, err := r.Serve(context.Background(), params, raw, service)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		got := map[string]string{}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+		return got
+	}
+
+	assert.Equal(t, map[string]string{
+		"query":         "tag=a&tag=b",
+		"authorization": "",
+		"api_key":       "backend-key",
+		"content_type":  "application/xml",
+		"body":          `<order id="1"/>`,
+	}, serve(raw))
+
+	// 测试接口没有原始请求，没有请求参数时不发送请求体
+	assert.Equal(t, map[string]string{
+		"query":         "",
+		"authorization": "",
+		"api_key":       "backend-key",
+		"content_type":  "",
+		"body":          "",
+	}, serve(nil))
+}
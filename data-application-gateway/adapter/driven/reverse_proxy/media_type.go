@@ -0,0 +1,88 @@
+package reverse_proxy
+
+import (
+	"mime"
+	"strings"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/enum"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/errorcode"
+)
+
+const (
+	mediaTypeJSON        = "application/json"
+	mediaTypeOctetStream = "application/octet-stream"
+)
+
+// defaultReturnTypes 接口返回类型默认允许的后台服务 Content-Type
+var defaultReturnTypes = map[string][]string{
+	enum.ReturnTypeJSON: {mediaTypeJSON},
+	enum.ReturnTypeCSV:  {"text/csv", "application/csv"},
+	enum.ReturnTypeXML:  {"application/xml", "text/xml"},
+	enum.ReturnTypeFile: {
+		mediaTypeOctetStream,
+		"application/pdf",
+		"application/zip",
+		"application/vnd.ms-excel",
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	},
+}
+
+// returnTypes 接口返回类型允许的 Content-Type
+type returnTypes map[string][]string
+
+// newReturnTypes 合并配置和默认值，配置了的返回类型以配置为准
+func newReturnTypes(conf map[string][]string) returnTypes {
+	r := make(returnTypes, len(defaultReturnTypes)+len(conf))
+	for t, mediaTypes := range defaultReturnTypes {
+		r[t] = mediaTypes
+	}
+	for t, mediaTypes := range conf {
+		lower := make([]string, 0, len(mediaTypes))
+		for _, m := range mediaTypes {
+			lower = append(lower, strings.ToLower(strings.TrimSpace(m)))
+		}
+		r[strings.ToLower(t)] = lower
+	}
+	return r
+}
+
+// contentType 检查后台服务返回的 Content-Type 是否属于接口的返回类型，返回响应给调用方的 Content-Type。
+// 返回类型为空时按 json 处理，后台服务没有返回 Content-Type 时使用返回类型的第一个媒体类型
+func (r returnTypes) contentType(returnType, contentType string) (string, error) {
+	if returnType == "" {
+		returnType = enum.ReturnTypeJSON
+	}
+	allowed := r[returnType]
+	if len(allowed) == 0 {
+		return "", errorcode.Desc(errorcode.BackendReturnTypeMismatch, contentType, returnType)
+	}
+
+	if contentType == "" {
+		if strings.Contains(allowed[0], "*") {
+			return mediaTypeOctetStream, nil
+		}
+		return allowed[0], nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", errorcode.Detail(errorcode.BackendUnsupportedContentType, contentType)
+	}
+	if !matchMediaType(mediaType, allowed) {
+		return "", errorcode.Desc(errorcode.BackendReturnTypeMismatch, contentType, returnType)
+	}
+	return contentType, nil
+}
+
+// matchMediaType 检查媒体类型是否在允许的范围内，allowed 支持 image/* 和 */* 的写法
+func matchMediaType(mediaType string, allowed []string) bool {
+	for _, a := range allowed {
+		switch {
+		case a == mediaType, a == "*/*":
+			return true
+		case strings.HasSuffix(a, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(a, "*")):
+			return true
+		}
+	}
+	return false
+}
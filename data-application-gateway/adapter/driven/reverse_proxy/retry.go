@@ -0,0 +1,108 @@
+package reverse_proxy
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+)
+
+const (
+	defaultInitialBackoff = 100 * time.Millisecond
+	defaultMaxBackoff     = time.Second
+)
+
+// retryPolicy 后台服务请求的重试策略
+type retryPolicy struct {
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	methods        map[string]bool
+}
+
+func newRetryPolicy(conf settings.Retry) *retryPolicy {
+	p := &retryPolicy{
+		maxAttempts:    conf.MaxAttempts,
+		initialBackoff: time.Duration(conf.InitialBackoff) * time.Millisecond,
+		maxBackoff:     time.Duration(conf.MaxBackoff) * time.Millisecond,
+		methods:        make(map[string]bool),
+	}
+	if p.maxAttempts <= 0 {
+		p.maxAttempts = 1
+	}
+	if p.initialBackoff <= 0 {
+		p.initialBackoff = defaultInitialBackoff
+	}
+	if p.maxBackoff < p.initialBackoff {
+		p.maxBackoff = max(defaultMaxBackoff, p.initialBackoff)
+	}
+	methods := conf.Methods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet}
+	}
+	for _, m := range methods {
+		p.methods[strings.ToUpper(m)] = true
+	}
+	return p
+}
+
+// attempts 返回请求方式最多请求的次数
+func (p *retryPolicy) attempts(method string) int {
+	if p == nil || !p.methods[method] {
+		return 1
+	}
+	return p.maxAttempts
+}
+
+// backoff 返回第 attempt 次请求失败后，重试前等待的时间
+func (p *retryPolicy) backoff(attempt int) time.Duration {
+	d := p.initialBackoff << (attempt - 1)
+	if d <= 0 || d > p.maxBackoff {
+		d = p.maxBackoff
+	}
+	// 一半固定一半随机，避免多个请求同时重试
+	return d/2 + rand.N(d/2+1)
+}
+
+// wait 等待 d，ctx 结束时返回 false
+func wait(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// retryable 请求失败时是否可以重试：连接失败或后台服务暂时不可用
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// result 请求对熔断器的影响，parent 为调用方的 context
+func result(parent context.Context, resp *http.Response, err error) outcome {
+	if err != nil {
+		// 调用方取消请求与后台服务无关
+		if parent.Err() != nil && !errors.Is(parent.Err(), context.DeadlineExceeded) {
+			return outcomeIgnored
+		}
+		return outcomeFailure
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return outcomeFailure
+	}
+	return outcomeSuccess
+}
@@ -1,15 +1,15 @@
 package reverse_proxy
 
 import (
+	"bytes"
 	"context"
-	"crypto/tls"
+	"encoding/json"
 	"io"
-	"mime"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
-	"github.com/imroc/req/v2"
 	"github.com/spf13/cast"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
@@ -17,34 +17,72 @@ import (
 	"github.com/kweaver-ai/TelemetrySDK-Go/exporter/v2/ar_trace"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/dto"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/util"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/metrics"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/transport"
 	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
 	goframetrace "github.com/kweaver-ai/idrm-go-frame/core/telemetry/trace"
 )
 
 type ReverseProxyRepo interface {
-	Serve(ctx context.Context, params map[string]*dto.Param, HTTPMethod, backendServiceHost, backendServicePath string, timeout uint32) (length int64, res io.ReadCloser, err error)
+	// Serve 请求接口的后台服务，后台服务连续失败时熔断，幂等的请求失败时重试。
+	// 请求头、query 参数和请求体按接口的请求映射转发，raw 为调用方的原始请求，测试接口时为 nil。
+	// 返回的 Content-Type 需要属于接口的返回类型，响应体不读入内存，调用方负责关闭
+	Serve(ctx context.Context, params map[string]*dto.Param, raw *http.Request, service *model.ServiceAssociations) (res *Response, err error)
+	// BreakerStates 返回接口的后台服务的熔断器状态
+	BreakerStates(ctx context.Context) []BreakerState
 }
 
-func NewReverseProxyRepo() ReverseProxyRepo {
-	return &reverseProxyRepo{}
+// Response 后台服务的响应
+type Response struct {
+	// 数据长度，未知时为 -1
+	Length int64
+	// 返回给调用方的响应头，包括 Content-Type 和 Content-Disposition 等
+	Header http.Header
+	Body   io.ReadCloser
 }
 
-type reverseProxyRepo struct{}
+// passThroughHeaders 透传给调用方的后台服务响应头
+var passThroughHeaders = []string{"Content-Disposition", "Last-Modified", "ETag"}
 
-func (r reverseProxyRepo) Serve(ctx context.Context, params map[string]*dto.Param, HTTPMethod, backendServiceHost, backendServicePath string, timeout uint32) (length int64, res io.ReadCloser, err error) {
+func NewReverseProxyRepo(transports *transport.Transports, s *settings.Settings) ReverseProxyRepo {
+	return &reverseProxyRepo{
+		transports:  transports,
+		breakers:    newBreakers(s.Proxy.Breaker),
+		retry:       newRetryPolicy(s.Proxy.Retry),
+		returnTypes: newReturnTypes(s.Proxy.ReturnTypes),
+		cryptoKey:   s.Crypto.Key,
+	}
+}
+
+type reverseProxyRepo struct {
+	transports  *transport.Transports
+	breakers    *breakers
+	retry       *retryPolicy
+	returnTypes returnTypes
+	// 解密请求映射中后台服务密钥的 AES 密钥
+	cryptoKey string
+}
+
+// errorBodyLimit 读取后台服务错误响应的最大长度
+const errorBodyLimit = 1 << 20
+
+func (r *reverseProxyRepo) Serve(ctx context.Context, params map[string]*dto.Param, raw *http.Request, service *model.ServiceAssociations) (res *Response, err error) {
 	ctx, span := ar_trace.Tracer.Start(ctx, "reverseProxy", trace.WithSpanKind(trace.SpanKindClient))
 	defer func() { goframetrace.TelemetrySpanEnd(span, err) }()
 
 	headers := map[string]string{}
 	queryParams := map[string]string{}
 	body := map[string]interface{}{}
+	pathParams := map[string]string{}
 
 	for paramName, param := range params {
 		switch param.Position {
+		case dto.ParamPositionPath:
+			pathParams[paramName] = cast.ToString(param.Value)
 		case dto.ParamPositionHeader:
-			if paramName == "Accept-Encoding" {
-				continue
-			}
 			headers[paramName] = cast.ToString(param.Value)
 		case dto.ParamPositionQuery:
 			queryParams[paramName] = cast.ToString(param.Value)
@@ -53,53 +91,192 @@ func (r reverseProxyRepo) Serve(ctx context.Context, params map[string]*dto.Para
 		}
 	}
 
-	client := req.C().
-		SetTimeout(time.Duration(timeout) * time.Second).
-		SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true}).DisableAutoReadResponse(true)
+	method := strings.ToUpper(service.HTTPMethod)
+	backendPath, err := expandBackendPath(service.BackendServicePath, pathParams)
+	if err != nil {
+		log.WithContext(ctx).Error("Serve", zap.String("backend_service_path", service.BackendServicePath), zap.Error(err))
+		return nil, errorcode.Detail(errorcode.QueryError, err.Error())
+	}
 
-	request := client.R().SetContext(ctx)
-	if len(headers) > 0 {
-		request = request.SetHeaders(headers)
+	mapping, err := parseRequestMapping(service.RequestMapping)
+	if err != nil {
+		log.WithContext(ctx).Error("Serve parseRequestMapping", zap.String("service_path", service.ServicePath), zap.Error(err))
+		return nil, errorcode.Detail(errorcode.PublicInternalError, err.Error())
+	}
+	reqHeader, err := mapping.header(headers, r.cryptoKey)
+	if err != nil {
+		log.WithContext(ctx).Error("Serve decrypt header", zap.String("service_path", service.ServicePath), zap.Error(err))
+		return nil, errorcode.Detail(errorcode.PublicInternalError, err.Error())
 	}
-	if len(queryParams) > 0 {
-		request = request.SetQueryParams(queryParams)
+	query := mapping.query(queryParams, raw)
+	payload, payloadType, err := requestBody(method, mapping, body, raw)
+	if err != nil {
+		log.WithContext(ctx).Error("Serve", zap.Error(err))
+		return nil, errorcode.Detail(errorcode.QueryError, err.Error())
 	}
-	if len(body) > 0 {
-		request = request.SetBody(body)
+	url := service.BackendServiceHost + backendPath
+	backend := backendKey(service.BackendServiceHost)
+	br := r.breakers.get(service.ServiceID, service.ServicePath, backend)
+
+	// 读取响应的过程也受超时限制，响应体关闭时取消。重试的总时间不超过超时时间
+	reqCtx, cancel := ctx, context.CancelFunc(func() {})
+	if service.Timeout > 0 {
+		reqCtx, cancel = context.WithTimeout(ctx, time.Duration(service.Timeout)*time.Second)
 	}
 
-	url := backendServiceHost + backendServicePath
-	resp, err := request.Send(strings.ToUpper(HTTPMethod), url)
+	var resp *http.Response
+	attempts := r.retry.attempts(method)
+	for attempt := 1; ; attempt++ {
+		done := func(outcome) {}
+		if br != nil {
+			var ok bool
+			if done, ok = br.allow(); !ok {
+				cancel()
+				log.WithContext(ctx).Warn("Serve breaker open", zap.String("backend", backend), zap.String("service_path", service.ServicePath))
+				return nil, errorcode.Desc(errorcode.BackendCircuitOpen, backend)
+			}
+		}
+
+		var request *http.Request
+		request, err = newRequest(reqCtx, method, url, reqHeader, query, payload, payloadType)
+		if err != nil {
+			done(outcomeIgnored)
+			cancel()
+			log.WithContext(ctx).Error("Serve", zap.Error(err))
+			return nil, errorcode.Detail(errorcode.QueryError, err.Error())
+		}
+		sent := time.Now()
+		resp, err = r.transports.Client(url).Do(request)
+		done(result(ctx, resp, err))
+		if err != nil {
+			metrics.ObserveUpstream(backend, 0, sent)
+		} else {
+			metrics.ObserveUpstream(backend, resp.StatusCode, sent)
+		}
+
+		if attempt >= attempts || !retryable(resp, err) {
+			break
+		}
+		if err != nil {
+			log.WithContext(ctx).Warn("Serve retry", zap.Int("attempt", attempt), zap.Error(err))
+		} else {
+			log.WithContext(ctx).Warn("Serve retry", zap.Int("attempt", attempt), zap.Int("status", resp.StatusCode))
+			io.Copy(io.Discard, io.LimitReader(resp.Body, errorBodyLimit))
+			resp.Body.Close()
+		}
+		if !wait(reqCtx, r.retry.backoff(attempt)) {
+			// 超时前没有等到重试，返回最后一次的结果
+			if err == nil {
+				err = reqCtx.Err()
+			}
+			resp = nil
+			break
+		}
+	}
 	if err != nil {
+		cancel()
 		log.WithContext(ctx).Error("Serve", zap.Error(err))
-		return 0, nil, errorcode.Detail(errorcode.QueryError, err.Error())
+		return nil, errorcode.Detail(errorcode.QueryError, err.Error())
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		log.WithContext(ctx).Error("Serve", zap.String("body", resp.String()))
-		return 0, nil, errorcode.Detail(errorcode.QueryError, resp.String())
+		defer cancel()
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, errorBodyLimit))
+		log.WithContext(ctx).Error("Serve", zap.ByteString("body", b))
+		return nil, errorcode.Detail(errorcode.QueryError, string(b))
+	}
+
+	// 检查 response 的 Content-Type 是否属于接口的返回类型
+	contentType, err := r.returnTypes.contentType(service.ReturnType, resp.Header.Get("Content-Type"))
+	if err != nil {
+		cancel()
+		resp.Body.Close()
+		log.WithContext(ctx).Error("Serve", zap.String("return_type", service.ReturnType), zap.Error(err))
+		return nil, err
 	}
 
-	// 检查 response 是否为 json
-	if err := validateContentType(resp.Header.Get("Content-Type")); err != nil {
-		return 0, nil, err
+	header := http.Header{"Content-Type": {contentType}}
+	for _, k := range passThroughHeaders {
+		if v := resp.Header.Get(k); v != "" {
+			header.Set(k, v)
+		}
 	}
+	return &Response{
+		Length: resp.ContentLength,
+		Header: header,
+		Body:   &cancelReadCloser{ReadCloser: resp.Body, cancel: cancel},
+	}, nil
+}
 
-	return resp.ContentLength, resp.Body, nil
+func (r *reverseProxyRepo) BreakerStates(ctx context.Context) []BreakerState {
+	return r.breakers.states()
 }
 
-const mediaTypeJSON = "application/json"
+// expandBackendPath 用路径参数替换后台服务路径中的变量，如 /orders/{id}
+func expandBackendPath(backendPath string, pathParams map[string]string) (string, error) {
+	if !util.IsPathTemplate(backendPath) {
+		return backendPath, nil
+	}
+	t, err := util.ParsePathTemplate(backendPath)
+	if err != nil {
+		return "", err
+	}
+	return t.Expand(pathParams)
+}
 
-// validateContentType 检查 content-type 是否满足要求
-func validateContentType(contentType string) error {
-	if contentType == "" {
-		return nil
+// requestBody 生成发送到后台服务的请求体，GET 和 HEAD 请求不发送请求体。
+// 原样转发时使用调用方的请求体和 Content-Type，没有原始请求时按 JSON 发送请求参数
+func requestBody(method string, mapping *requestMapping, body map[string]interface{}, raw *http.Request) (payload []byte, contentType string, err error) {
+	if method == http.MethodGet || method == http.MethodHead {
+		return nil, "", nil
+	}
+	if mapping.rawBody && raw != nil {
+		if payload, err = util.GetBody(raw); err != nil {
+			return nil, "", err
+		}
+		return payload, raw.Header.Get("Content-Type"), nil
+	}
+	if len(body) == 0 {
+		return nil, "", nil
+	}
+	if payload, err = json.Marshal(body); err != nil {
+		return nil, "", err
 	}
+	return payload, mediaTypeJSON + "; charset=utf-8", nil
+}
 
-	mediaType, _, err := mime.ParseMediaType(contentType)
-	if err != nil || mediaType != mediaTypeJSON {
-		return errorcode.Detail(errorcode.BackendUnsupportedContentType, contentType)
+// newRequest 创建发送到后台服务的请求，每次重试都重新创建
+func newRequest(ctx context.Context, method, url string, header http.Header, query url.Values, payload []byte, contentType string) (*http.Request, error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
 	}
+	request.Header = header.Clone()
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	if len(query) > 0 {
+		q := request.URL.Query()
+		for k, vs := range query {
+			q[k] = vs
+		}
+		request.URL.RawQuery = q.Encode()
+	}
+	return request, nil
+}
+
+// cancelReadCloser 关闭时取消请求的 context
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
 
-	return nil
+func (r *cancelReadCloser) Close() error {
+	defer r.cancel()
+	return r.ReadCloser.Close()
 }
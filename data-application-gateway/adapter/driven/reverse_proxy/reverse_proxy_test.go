@@ -1,12 +1,34 @@
 package reverse_proxy
 
 import (
+	"context"
+	"encoding/json"
+	"io"
 	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/enum"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+	"github.com/kweaver-ai/idrm-go-frame/core/errorx/agerrors"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
 )
 
+func TestMain(m *testing.M) {
+	// 初始化日志，否则调用 log.Error 等方法会 panic
+	log.InitLogger(nil, &telemetry.Config{})
+	m.Run()
+}
+
 func TestContentType(t *testing.T) {
 	contentTypes := []string{
 		"application/json",
@@ -70,9 +92,203 @@ func Test_validateContentType(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := validateContentType(tt.args.contentType); (err != nil) != tt.wantErr {
-				t.Errorf("validateContentType() error = %v, wantErr %v", err, tt.wantErr)
+			if _, err := newReturnTypes(nil).contentType(enum.ReturnTypeJSON, tt.args.contentType); (err != nil) != tt.wantErr {
+				t.Errorf("contentType() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func Test_reverseProxyRepo_Serve(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error" {
+			http.Error(w, "backend error", http.StatusInternalServerError)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/html" {
+			w.Header().Set("Content-Type", "text/html")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"path":         r.URL.EscapedPath(),
+			"method":       r.Method,
+			"query":        r.URL.RawQuery,
+			"header":       r.Header.Get("X-Test"),
+			"content_type": r.Header.Get("Content-Type"),
+			"body":         string(body),
+		})
+	}))
+	defer srv.Close()
+
+	params := map[string]*dto.Param{
+		"X-Test": dto.NewParam("h", dto.ParamPositionHeader, dto.ParamDataTypeString),
+		"q":      dto.NewParam(1, dto.ParamPositionQuery, dto.ParamDataTypeInt),
+		"b":      dto.NewParam("v", dto.ParamPositionBody, dto.ParamDataTypeString),
+		"id":     dto.NewParam("a/1", dto.ParamPositionPath, dto.ParamDataTypeString),
+	}
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "post",
+			method: "post",
+			path:   "/json",
+			want: map[string]string{
+				"path":         "/json",
+				"method":       http.MethodPost,
+				"query":        "q=1",
+				"header":       "h",
+				"content_type": "application/json; charset=utf-8",
+				"body":         `{"b":"v"}`,
+			},
+		},
+		{
+			name:   "get 不发送 body",
+			method: "get",
+			path:   "/json",
+			want: map[string]string{
+				"path":   "/json",
+				"method": http.MethodGet,
+				"query":  "q=1",
+				"header": "h",
+			},
+		},
+		{
+			name:   "路径参数替换后台服务路径中的变量",
+			method: "get",
+			path:   "/orders/{id}",
+			want: map[string]string{
+				"path":   "/orders/a%2F1",
+				"method": http.MethodGet,
+				"query":  "q=1",
+				"header": "h",
+			},
+		},
+		{
+			name:    "缺少后台服务路径中的变量",
+			method:  "get",
+			path:    "/orders/{order_id}",
+			wantErr: true,
+		},
+		{
+			name:    "后台服务返回错误",
+			method:  "get",
+			path:    "/error",
+			wantErr: true,
+		},
+		{
+			name:    "后台服务返回的不是 json",
+			method:  "get",
+			path:    "/html",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReverseProxyRepo(nil, &settings.Settings{})
+			service := &model.ServiceAssociations{Service: model.Service{HTTPMethod: tt.method, BackendServiceHost: srv.URL, BackendServicePath: tt.path, Timeout: 5}}
+			res, err := r.Serve(context.Background(), params, nil, service)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer res.Body.Close()
+			got := map[string]string{}
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+			for k, v := range got {
+				if v == "" {
+					delete(got, k)
+				}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_returnTypes_contentType(t *testing.T) {
+	r := newReturnTypes(map[string][]string{
+		"csv":    {"Text/CSV"},
+		"binary": {"*/*"},
+		"image":  {"image/*"},
+	})
+	tests := []struct {
+		name        string
+		returnType  string
+		contentType string
+		want        string
+		wantCode    string
+	}{
+		{name: "返回类型为空按 json 处理", contentType: "application/json; charset=utf-8", want: "application/json; charset=utf-8"},
+		{name: "没有 Content-Type 时使用默认", returnType: "xml", want: "application/xml"},
+		{name: "允许的类型为通配时默认为二进制", returnType: "binary", want: "application/octet-stream"},
+		{name: "配置覆盖默认值", returnType: "csv", contentType: "text/csv; charset=gbk", want: "text/csv; charset=gbk"},
+		{name: "配置覆盖默认值后不再允许默认的类型", returnType: "csv", contentType: "application/csv", wantCode: errorcode.BackendReturnTypeMismatch},
+		{name: "通配", returnType: "image", contentType: "image/png", want: "image/png"},
+		{name: "通配不匹配其他类型", returnType: "image", contentType: "text/html", wantCode: errorcode.BackendReturnTypeMismatch},
+		{name: "默认不允许图片，避免 svg 等可执行脚本的类型", returnType: "file", contentType: "image/svg+xml", wantCode: errorcode.BackendReturnTypeMismatch},
+		{name: "json 接口返回 xml", returnType: "json", contentType: "text/xml", wantCode: errorcode.BackendReturnTypeMismatch},
+		{name: "未知的返回类型", returnType: "yaml", contentType: "application/yaml", wantCode: errorcode.BackendReturnTypeMismatch},
+		{name: "非法的 Content-Type", returnType: "xml", contentType: "invalid?media?type", wantCode: errorcode.BackendUnsupportedContentType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.contentType(tt.returnType, tt.contentType)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, agerrors.Code(err).GetErrorCode())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_reverseProxyRepo_Serve_returnType(t *testing.T) {
+	csv := strings.Repeat("id,name\n1,a\n", 1<<16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/csv":
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.Header().Set("Content-Disposition", `attachment; filename="export.csv"`)
+			w.Header().Set("Set-Cookie", "session=1")
+			io.WriteString(w, csv)
+		case "/raw":
+			// 不返回 Content-Type，避免 net/http 自动检测
+			w.Header()["Content-Type"] = nil
+			w.Write([]byte{0x89, 'P', 'N', 'G'})
+		}
+	}))
+	defer srv.Close()
+
+	r := NewReverseProxyRepo(nil, &settings.Settings{})
+	serve := func(path, returnType string) (*Response, error) {
+		service := &model.ServiceAssociations{Service: model.Service{HTTPMethod: "get", BackendServiceHost: srv.URL, BackendServicePath: path, ReturnType: returnType, Timeout: 5}}
+		return r.Serve(context.Background(), nil, nil, service)
+	}
+
+	res, err := serve("/csv", enum.ReturnTypeCSV)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, "text/csv; charset=utf-8", res.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="export.csv"`, res.Header.Get("Content-Disposition"))
+	assert.Empty(t, res.Header.Get("Set-Cookie"))
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, csv, string(body))
+
+	// 后台服务没有返回 Content-Type 时，按返回类型补充
+	res, err = serve("/raw", enum.ReturnTypeFile)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, "application/octet-stream", res.Header.Get("Content-Type"))
+
+	_, err = serve("/csv", enum.ReturnTypeJSON)
+	assert.Equal(t, errorcode.BackendReturnTypeMismatch, agerrors.Code(err).GetErrorCode())
+}
@@ -0,0 +1,187 @@
+package virtual_engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+	goframetrace "github.com/kweaver-ai/idrm-go-frame/core/telemetry/trace"
+)
+
+// Rows 查询结果的行迭代器，按行解码虚拟化引擎的响应，内存中只保留当前行
+type Rows interface {
+	// Next 读取下一条满足过滤条件的数据，没有更多数据或出错时返回 false
+	Next() bool
+	// Row 当前行，key 为列名
+	Row() map[string]interface{}
+	// Err 迭代过程中出现的错误
+	Err() error
+	// Close 关闭虚拟化引擎的响应
+	Close() error
+}
+
+// rows 解码 {"columns": [...], "data": [[...], ...], "total_count": n}，
+// columns 在 data 之前时逐行解码 data，否则只能先读取全部 data
+type rows struct {
+	v       *virtualEngineRepo
+	body    io.ReadCloser
+	decoder *json.Decoder
+	cancel  context.CancelFunc
+	span    trace.Span
+	// 关闭时记录查询的耗时
+	observe func(error)
+
+	filters map[string]model.ServiceResponseFilter
+	columns []Column
+	// 位于 columns 之前的 data
+	buffered [][]interface{}
+	// decoder 已经读到 data 数组内部
+	inData bool
+	done   bool
+
+	row    map[string]interface{}
+	err    error
+	closed bool
+}
+
+func newRows(v *virtualEngineRepo, body io.ReadCloser, cancel context.CancelFunc, span trace.Span, serviceResponseFilters []model.ServiceResponseFilter) (*rows, error) {
+	r := &rows{
+		v:       v,
+		body:    body,
+		decoder: json.NewDecoder(body),
+		cancel:  cancel,
+		span:    span,
+		filters: make(map[string]model.ServiceResponseFilter),
+	}
+	r.decoder.UseNumber()
+	//过滤结果
+	for _, filter := range serviceResponseFilters {
+		r.filters[filter.Param] = filter
+	}
+
+	if err := r.expectDelim('{'); err != nil {
+		return nil, err
+	}
+	for !r.inData && r.decoder.More() {
+		key, err := r.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case "columns":
+			if err := r.decoder.Decode(&r.columns); err != nil {
+				return nil, err
+			}
+		case "data":
+			if r.columns == nil {
+				if err := r.decoder.Decode(&r.buffered); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if err := r.expectDelim('['); err != nil {
+				return nil, err
+			}
+			r.inData = true
+		default:
+			var ignored json.RawMessage
+			if err := r.decoder.Decode(&ignored); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !r.inData && r.buffered == nil {
+		r.done = true
+	}
+	return r, nil
+}
+
+func (r *rows) expectDelim(delim json.Delim) error {
+	t, err := r.decoder.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := t.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("unexpected token %v, want %v", t, delim)
+	}
+	return nil
+}
+
+func (r *rows) next() (datum []interface{}, ok bool, err error) {
+	if !r.inData {
+		if len(r.buffered) == 0 {
+			return nil, false, nil
+		}
+		datum, r.buffered = r.buffered[0], r.buffered[1:]
+		return datum, true, nil
+	}
+	if !r.decoder.More() {
+		return nil, false, r.expectDelim(']')
+	}
+	if err = r.decoder.Decode(&datum); err != nil {
+		return nil, false, err
+	}
+	return datum, true, nil
+}
+
+func (r *rows) Next() bool {
+	for !r.done {
+		datum, ok, err := r.next()
+		if err != nil {
+			r.err = err
+		}
+		if !ok {
+			r.done = true
+			break
+		}
+		if len(datum) != len(r.columns) {
+			r.err = errors.New("the number of values does not match the columns")
+			r.done = true
+			break
+		}
+
+		//把数据值和数据类型都放到 Column 里
+		columns := make([]Column, len(r.columns))
+		for i, column := range r.columns {
+			columns[i] = Column{Name: column.Name, Type: column.Type, Value: datum[i]}
+		}
+		if !r.v.fetchResFilter(columns, r.filters) {
+			continue
+		}
+
+		r.row = make(map[string]interface{}, len(columns))
+		for _, column := range columns {
+			r.row[column.Name] = column.Value
+		}
+		return true
+	}
+	r.row = nil
+	return false
+}
+
+func (r *rows) Row() map[string]interface{} {
+	return r.row
+}
+
+func (r *rows) Err() error {
+	return r.err
+}
+
+func (r *rows) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	err := r.body.Close()
+	r.cancel()
+	goframetrace.TelemetrySpanEnd(r.span, r.err)
+	if r.observe != nil {
+		r.observe(r.err)
+	}
+	return err
+}
@@ -0,0 +1,145 @@
+package virtual_engine
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+func TestMain(m *testing.M) {
+	// 初始化日志，否则调用 log.Error 等方法会 panic
+	log.InitLogger(nil, &telemetry.Config{})
+	m.Run()
+}
+
+func collectRows(t *testing.T, r Rows) []map[string]interface{} {
+	t.Helper()
+	var got []map[string]interface{}
+	for r.Next() {
+		got = append(got, r.Row())
+	}
+	return got
+}
+
+func Test_rows(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		filters []model.ServiceResponseFilter
+		want    []map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "columns before data",
+			body: `{"columns":[{"name":"id","type":"bigint"},{"name":"name","type":"varchar"}],"data":[[1,"a"],[2,"b"]],"total_count":2}`,
+			want: []map[string]interface{}{{"id": "1", "name": "a"}, {"id": "2", "name": "b"}},
+		},
+		{
+			name: "data before columns",
+			body: `{"total_count":2,"data":[[1,"a"],[2,"b"]],"columns":[{"name":"id","type":"bigint"},{"name":"name","type":"varchar"}]}`,
+			want: []map[string]interface{}{{"id": "1", "name": "a"}, {"id": "2", "name": "b"}},
+		},
+		{
+			name: "empty data",
+			body: `{"columns":[{"name":"id","type":"bigint"}],"data":[],"total_count":0}`,
+		},
+		{
+			name: "no data",
+			body: `{"columns":[{"name":"id","type":"bigint"}],"total_count":0}`,
+		},
+		{
+			name:    "filters",
+			body:    `{"columns":[{"name":"id","type":"bigint"},{"name":"name","type":"varchar"}],"data":[[1,"a"],[2,"b"],[3,"c"]]}`,
+			filters: []model.ServiceResponseFilter{{Param: "id", Operator: ">=", Value: "2"}, {Param: "name", Operator: "!=", Value: "c"}},
+			want:    []map[string]interface{}{{"id": "2", "name": "b"}},
+		},
+		{
+			name:    "values do not match columns",
+			body:    `{"columns":[{"name":"id","type":"bigint"}],"data":[[1],[2,"b"]]}`,
+			want:    []map[string]interface{}{{"id": "1"}},
+			wantErr: true,
+		},
+		{
+			name:    "truncated",
+			body:    `{"columns":[{"name":"id","type":"bigint"}],"data":[[1],[2`,
+			want:    []map[string]interface{}{{"id": "1"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			span := trace.SpanFromContext(context.Background())
+			r, err := newRows(&virtualEngineRepo{}, io.NopCloser(strings.NewReader(tt.body)), func() {}, span, tt.filters)
+			require.NoError(t, err)
+			defer r.Close()
+
+			got := collectRows(t, r)
+			for _, row := range got {
+				for k, v := range row {
+					row[k] = toString(v)
+				}
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantErr, r.Err() != nil, "err: %v", r.Err())
+		})
+	}
+}
+
+func toString(v interface{}) interface{} {
+	if s, ok := v.(interface{ String() string }); ok {
+		return s.String()
+	}
+	return v
+}
+
+func Test_newRows_invalid(t *testing.T) {
+	for _, body := range []string{``, `[]`, `{"columns":1}`, `{"columns":[],"data":{}}`} {
+		span := trace.SpanFromContext(context.Background())
+		_, err := newRows(&virtualEngineRepo{}, io.NopCloser(strings.NewReader(body)), func() {}, span, nil)
+		assert.Error(t, err, body)
+	}
+}
+
+func Test_virtualEngineRepo_FetchRows(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.RawQuery, "fail") {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":"x","detail":"syntax error"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"columns":[{"name":"id","type":"bigint"}],"data":[[1],[2]],"total_count":2}`))
+	}))
+	defer server.Close()
+
+	old := settings.Instance.Services.VirtualEngine
+	settings.Instance.Services.VirtualEngine = server.URL
+	defer func() { settings.Instance.Services.VirtualEngine = old }()
+
+	v := &virtualEngineRepo{}
+	rows, err := v.FetchRows(context.Background(), "select id from t", 10, nil)
+	require.NoError(t, err)
+	got := collectRows(t, rows)
+	assert.NoError(t, rows.Err())
+	assert.NoError(t, rows.Close())
+	assert.Len(t, got, 2)
+
+	fetchRes, err := v.Fetch(context.Background(), "select id from t", 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, fetchRes.TotalCount)
+
+	settings.Instance.Services.VirtualEngine = server.URL + "/?fail"
+	_, err = v.FetchRows(context.Background(), "select id from t", 10, nil)
+	assert.Error(t, err)
+}
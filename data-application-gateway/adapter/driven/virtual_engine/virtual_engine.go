@@ -3,12 +3,12 @@ package virtual_engine
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
+	"io"
+	"net/http"
 	"strings"
 	"time"
 
-	"github.com/imroc/req/v2"
 	"github.com/spf13/cast"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
@@ -17,7 +17,9 @@ import (
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/errorcode"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/util"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/metrics"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/transport"
 	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
 	goframetrace "github.com/kweaver-ai/idrm-go-frame/core/telemetry/trace"
 )
@@ -57,19 +59,24 @@ type T struct {
 }
 type VirtualEngineRepo interface {
 	Fetch(ctx context.Context, script string, timeout uint32, serviceResponseFilters []model.ServiceResponseFilter) (fetchRes *FetchRes, err error)
+	// FetchRows 执行查询并返回行迭代器，调用方需要关闭 rows
+	FetchRows(ctx context.Context, script string, timeout uint32, serviceResponseFilters []model.ServiceResponseFilter) (rows Rows, err error)
 	FetchCount(ctx context.Context, script string, timeout uint32) (totalCount int64, err error)
 }
 
-func NewVirtualEngineRepo() VirtualEngineRepo {
-	return &virtualEngineRepo{}
-}
+// fetchErrorBodyLimit 读取虚拟化引擎错误响应的最大长度
+const fetchErrorBodyLimit = 1 << 20
 
-type virtualEngineRepo struct{}
+func NewVirtualEngineRepo(transports *transport.Transports) VirtualEngineRepo {
+	return &virtualEngineRepo{transports: transports}
+}
 
-func (v *virtualEngineRepo) Fetch(ctx context.Context, script string, timeout uint32, serviceResponseFilters []model.ServiceResponseFilter) (fetchRes *FetchRes, err error) {
-	ctx, span := ar_trace.Tracer.Start(ctx, "virtualEngine", trace.WithSpanKind(trace.SpanKindClient))
-	defer func() { goframetrace.TelemetrySpanEnd(span, err) }()
+type virtualEngineRepo struct {
+	transports *transport.Transports
+}
 
+// fetch 调用虚拟化引擎的查询接口，调用方需要关闭响应体
+func (v *virtualEngineRepo) fetch(ctx context.Context, script string) (*http.Response, error) {
 	reqBody := struct {
 		SQL  string `json:"sql"`
 		TYPE int    `json:"type"`
@@ -82,157 +89,133 @@ func (v *virtualEngineRepo) Fetch(ctx context.Context, script string, timeout ui
 		return nil, err
 	}
 
-	client := req.
-		SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true}).
-		SetTimeout(time.Duration(timeout) * time.Second).
-		DevMode()
-
-	response, err := client.R().
-		// SetHeader("X-Presto-User", "admin").
-		SetHeader("Content-Type", "application/json").
-		SetHeader("Authorization", "Bearer "+util.GetToken(ctx)).
-		SetBodyString(string(jsonBody)).
-		// Post(settings.Instance.Services.VirtualEngine + "/api/virtual_engine_service/v1/fetch")
-		Post(settings.Instance.Services.VirtualEngine + "/api/data-connection/v1/gateway/fetch")
+	url := settings.Instance.Services.VirtualEngine + "/api/data-connection/v1/gateway/fetch"
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonBody))
 	if err != nil {
-		log.WithContext(ctx).Error("Fetch", zap.Error(err))
-		return nil, errorcode.Detail(errorcode.QueryError, err.Error())
+		return nil, err
 	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer "+util.GetToken(ctx))
+	return v.transports.Client(url).Do(request)
+}
 
-	if response.StatusCode != 200 {
-		fetchError := &FetchError{}
-		err := response.UnmarshalJson(fetchError)
-		if err != nil {
-			log.WithContext(ctx).Error("Fetch", zap.String("body", response.String()), zap.String("script", script))
-			return nil, err
-		}
-		log.WithContext(ctx).Error("Fetch", zap.String("body", response.String()), zap.String("script", script))
-		var e string
-		if fetchError.Solution != "" {
-			e = fetchError.Solution
-		} else if fetchError.Detail != "" {
-			e = fetchError.Detail
-		}
-		return nil, errorcode.Detail(errorcode.QueryError, e)
+// fetchError 将虚拟化引擎的错误响应转换为错误
+func (v *virtualEngineRepo) fetchError(ctx context.Context, response *http.Response, script string) error {
+	body, _ := io.ReadAll(io.LimitReader(response.Body, fetchErrorBodyLimit))
+	log.WithContext(ctx).Error("Fetch", zap.ByteString("body", body), zap.String("script", script))
+	fetchError := &FetchError{}
+	if err := json.Unmarshal(body, fetchError); err != nil {
+		return err
+	}
+	var e string
+	if fetchError.Solution != "" {
+		e = fetchError.Solution
+	} else if fetchError.Detail != "" {
+		e = fetchError.Detail
 	}
+	return errorcode.Detail(errorcode.QueryError, e)
+}
 
-	fetchRawRes := &FetchRawRes{}
-	d := json.NewDecoder(bytes.NewReader(response.Bytes()))
-	d.UseNumber()
-	err = d.Decode(fetchRawRes)
+func (v *virtualEngineRepo) Fetch(ctx context.Context, script string, timeout uint32, serviceResponseFilters []model.ServiceResponseFilter) (fetchRes *FetchRes, err error) {
+	rows, err := v.FetchRows(ctx, script, timeout, serviceResponseFilters)
 	if err != nil {
-		log.WithContext(ctx).Error("Fetch decode response fail", zap.Error(err), zap.ByteString("body", response.Bytes()))
 		return nil, err
 	}
+	defer rows.Close()
 
-	//把数据值和数据类型都放到 Column 里
-	fetchRawResTransform := &FetchRawResTransform{
-		TotalCount: cast.ToInt(fetchRawRes.TotalCount),
-		Data:       make([][]Column, 0),
+	fetchRes = &FetchRes{
+		Data: make([]map[string]interface{}, 0),
 	}
-
-	for _, datum := range fetchRawRes.Data {
-		item := make([]Column, 0)
-		for i, column := range fetchRawRes.Columns {
-			c := Column{
-				Name:  column.Name,
-				Type:  column.Type,
-				Value: datum[i],
-			}
-			item = append(item, c)
-		}
-		fetchRawResTransform.Data = append(fetchRawResTransform.Data, item)
+	for rows.Next() {
+		fetchRes.Data = append(fetchRes.Data, rows.Row())
 	}
-
-	//过滤结果
-	var filters = make(map[string]model.ServiceResponseFilter)
-	for _, filter := range serviceResponseFilters {
-		filters[filter.Param] = filter
+	if err = rows.Err(); err != nil {
+		log.WithContext(ctx).Error("Fetch decode response fail", zap.Error(err), zap.String("script", script))
+		return nil, errorcode.Detail(errorcode.QueryError, err.Error())
 	}
 
-	fetchRes = &FetchRes{
-		Data: make([]map[string]interface{}, 0),
-	}
+	fetchRes.TotalCount = len(fetchRes.Data)
 
-	for _, columns := range fetchRawResTransform.Data {
-		if !v.fetchResFilter(columns, filters) {
-			continue
-		}
-		item := make(map[string]interface{})
-		for _, column := range columns {
-			item[column.Name] = column.Value
+	return fetchRes, nil
+}
+
+func (v *virtualEngineRepo) FetchRows(ctx context.Context, script string, timeout uint32, serviceResponseFilters []model.ServiceResponseFilter) (rs Rows, err error) {
+	start := time.Now()
+	ctx, span := ar_trace.Tracer.Start(ctx, "virtualEngine", trace.WithSpanKind(trace.SpanKindClient))
+	// 读取响应的过程也受超时限制，cancel、span 和耗时在 rows 关闭时结束
+	var cancel context.CancelFunc = func() {}
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	}
+	defer func() {
+		if err != nil {
+			cancel()
+			goframetrace.TelemetrySpanEnd(span, err)
+			metrics.ObserveVirtualEngine(metrics.OperationFetch, start, err)
 		}
-		fetchRes.Data = append(fetchRes.Data, item)
+	}()
+
+	response, err := v.fetch(ctx, script)
+	if err != nil {
+		log.WithContext(ctx).Error("FetchRows", zap.Error(err))
+		return nil, errorcode.Detail(errorcode.QueryError, err.Error())
 	}
 
-	fetchRes.TotalCount = len(fetchRes.Data)
+	if response.StatusCode != http.StatusOK {
+		defer response.Body.Close()
+		return nil, v.fetchError(ctx, response, script)
+	}
 
-	return fetchRes, nil
+	r, err := newRows(v, response.Body, cancel, span, serviceResponseFilters)
+	if err != nil {
+		response.Body.Close()
+		log.WithContext(ctx).Error("FetchRows decode response fail", zap.Error(err), zap.String("script", script))
+		return nil, errorcode.Detail(errorcode.QueryError, err.Error())
+	}
+	r.observe = func(err error) { metrics.ObserveVirtualEngine(metrics.OperationFetch, start, err) }
+	return r, nil
 }
 
 func (v *virtualEngineRepo) FetchCount(ctx context.Context, script string, timeout uint32) (totalCount int64, err error) {
+	defer func(start time.Time) { metrics.ObserveVirtualEngine(metrics.OperationFetchCount, start, err) }(time.Now())
 	ctx, span := ar_trace.Tracer.Start(ctx, "virtualEngine", trace.WithSpanKind(trace.SpanKindClient))
 	defer func() { goframetrace.TelemetrySpanEnd(span, err) }()
 
-	reqBody := struct {
-		SQL  string `json:"sql"`
-		TYPE int    `json:"type"`
-	}{
-		SQL:  script,
-		TYPE: 0,
-	}
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return 0, err
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
 	}
 
-	client := req.
-		SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true}).
-		SetTimeout(time.Duration(timeout) * time.Second).
-		DevMode()
-
-	response, err := client.R().
-		// SetHeader("X-Presto-User", "admin").
-		SetHeader("Authorization", "Bearer "+util.GetToken(ctx)).
-		SetHeader("Content-Type", "application/json").
-		SetBodyString(string(jsonBody)).
-		// Post(settings.Instance.Services.VirtualEngine + "/api/virtual_engine_service/v1/fetch")
-		Post(settings.Instance.Services.VirtualEngine + "/api/data-connection/v1/gateway/fetch")
+	response, err := v.fetch(ctx, script)
 	if err != nil {
 		log.WithContext(ctx).Error("Fetch", zap.Error(err))
 		return 0, errorcode.Detail(errorcode.QueryError, err.Error())
 	}
+	defer response.Body.Close()
 
-	if response.StatusCode != 200 {
-		fetchError := &FetchError{}
-		err := response.UnmarshalJson(fetchError)
-		if err != nil {
-			log.WithContext(ctx).Error("Fetch", zap.String("body", response.String()), zap.String("script", script))
-			return 0, err
-		}
-		log.WithContext(ctx).Error("Fetch", zap.String("body", response.String()), zap.String("script", script))
-		var e string
-		if fetchError.Solution != "" {
-			e = fetchError.Solution
-		} else if fetchError.Detail != "" {
-			e = fetchError.Detail
-		}
-		return 0, errorcode.Detail(errorcode.QueryError, e)
+	if response.StatusCode != http.StatusOK {
+		return 0, v.fetchError(ctx, response, script)
 	}
 
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		log.WithContext(ctx).Error("Fetch read response fail", zap.Error(err))
+		return 0, errorcode.Detail(errorcode.QueryError, err.Error())
+	}
 	fetchRawRes := &FetchRawRes{}
-	d := json.NewDecoder(bytes.NewReader(response.Bytes()))
+	d := json.NewDecoder(bytes.NewReader(body))
 	d.UseNumber()
 	err = d.Decode(fetchRawRes)
 	if err != nil {
-		log.WithContext(ctx).Error("Fetch decode response fail", zap.Error(err), zap.ByteString("body", response.Bytes()))
+		log.WithContext(ctx).Error("Fetch decode response fail", zap.Error(err), zap.ByteString("body", body))
 		return 0, err
 	}
 
 	b := (fetchRawRes.Data[0][0]).(json.Number)
 	totalCount, err = b.Int64()
 	if err != nil {
-		log.WithContext(ctx).Error("Fetch decode response fail", zap.Error(err), zap.ByteString("body", response.Bytes()))
+		log.WithContext(ctx).Error("Fetch decode response fail", zap.Error(err), zap.ByteString("body", body))
 		return 0, err
 	}
 	return totalCount, nil
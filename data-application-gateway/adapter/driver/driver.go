@@ -2,6 +2,8 @@ package driver
 
 import (
 	"github.com/google/wire"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driver/mq"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driver/v1/cache"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driver/v1/query"
 	"github.com/kweaver-ai/idrm-go-common"
 	"github.com/kweaver-ai/idrm-go-common/audit"
@@ -10,10 +12,12 @@ import (
 )
 
 // HttpProviderSet ProviderSet is server providers.
-var HttpProviderSet = wire.NewSet(NewHttpServer)
+var HttpProviderSet = wire.NewSet(NewHttpServer, NewMetricsServer)
 
 var ProviderSet = wire.NewSet(
 	query.NewQueryController,
+	cache.NewCacheController,
+	mq.NewCacheConsumer,
 	httpclient.NewMiddlewareHTTPClient,
 	GoCommon.Middleware,
 	audit.Discard,
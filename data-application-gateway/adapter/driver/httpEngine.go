@@ -13,6 +13,7 @@ import (
 
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/cmd/server/docs"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/metrics"
 	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
 	"github.com/kweaver-ai/idrm-go-frame/core/transport/rest"
 )
@@ -26,6 +27,23 @@ func NewHttpServer(s *settings.Settings, r IRouter) *rest.Server {
 	return httpSrv
 }
 
+// MetricsServer 只提供 Prometheus 指标的内部 HTTP 服务，与网关入口使用不同的端口，
+// 指标中的调用应用等信息不会对外暴露
+type MetricsServer struct {
+	*rest.Server
+}
+
+// NewMetricsServer 没有配置指标服务的地址时返回 nil
+func NewMetricsServer(s *settings.Settings) *MetricsServer {
+	if s.Server.Metrics == nil || s.Server.Metrics.Addr == "" {
+		return nil
+	}
+	engine := gin.New()
+	engine.Use(gin.Recovery())
+	engine.GET("/metrics", gin.WrapH(metrics.Handler()))
+	return &MetricsServer{Server: rest.NewServer(engine, rest.Address(s.Server.Metrics.Addr))}
+}
+
 // NewHttpEngine 创建了一个绑定了路由的Web引擎
 func NewHttpEngine(s *settings.Settings, r IRouter) *gin.Engine {
 	// 设置为Release，为的是默认在启动中不输出调试信息
@@ -0,0 +1,23 @@
+package driver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+)
+
+func TestNewMetricsServer(t *testing.T) {
+	assert.Nil(t, NewMetricsServer(&settings.Settings{}))
+	assert.Nil(t, NewMetricsServer(&settings.Settings{Server: settings.Server{Metrics: &settings.HTTPServer{}}}))
+
+	ms := NewMetricsServer(&settings.Settings{Server: settings.Server{Metrics: &settings.HTTPServer{Addr: "127.0.0.1:0"}}})
+	require.NotNil(t, ms)
+	w := httptest.NewRecorder()
+	ms.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
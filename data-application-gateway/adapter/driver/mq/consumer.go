@@ -0,0 +1,78 @@
+// Package mq 处理订阅的消息
+package mq
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.uber.org/zap"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/mq"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/domain"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+const (
+	// data-application-service 接口变更时发布，包括发布、上下线、变更和删除
+	TopicServiceChange = "af.interface-svc.es-index"
+	// auth-service 授权变更时发布
+	TopicAuthedUserUpdate = "af.auth-service.authed_user_update"
+)
+
+// CacheConsumer 订阅接口和授权的变更，使网关中的缓存失效
+type CacheConsumer struct {
+	*mq.KafkaConsumer
+	queryDomain *domain.QueryDomain
+}
+
+func NewCacheConsumer(s *settings.Settings, queryDomain *domain.QueryDomain) *CacheConsumer {
+	c := &CacheConsumer{
+		KafkaConsumer: mq.NewKafkaConsumer(s, "cache"),
+		queryDomain:   queryDomain,
+	}
+	c.Handle(TopicServiceChange, c.ServiceChange)
+	c.Handle(TopicAuthedUserUpdate, c.AuthedUserUpdate)
+	return c
+}
+
+// serviceChangeMsg 接口变更消息，只解析需要的字段
+type serviceChangeMsg struct {
+	Type string `json:"type"` // 消息类型 create | update | delete
+	Body struct {
+		Docid string `json:"docid"`
+		ID    string `json:"id"`
+	} `json:"body"`
+}
+
+// ServiceChange 接口变更，删除接口相关的缓存
+func (c *CacheConsumer) ServiceChange(ctx context.Context, msg []byte) error {
+	var m serviceChangeMsg
+	if err := json.Unmarshal(msg, &m); err != nil {
+		return err
+	}
+	serviceID := m.Body.ID
+	if serviceID == "" {
+		serviceID = m.Body.Docid
+	}
+	log.Info("invalidate service cache", zap.String("type", m.Type), zap.String("service_id", serviceID))
+	c.queryDomain.InvalidateServiceCache(ctx, serviceID)
+	return nil
+}
+
+// authedUserUpdateMsg 授权变更消息，只解析需要的字段
+type authedUserUpdateMsg struct {
+	Payload struct {
+		ServiceID string `json:"service_id"`
+	} `json:"payload"`
+}
+
+// AuthedUserUpdate 授权变更，删除接口的鉴权结果缓存
+func (c *CacheConsumer) AuthedUserUpdate(ctx context.Context, msg []byte) error {
+	var m authedUserUpdateMsg
+	if err := json.Unmarshal(msg, &m); err != nil {
+		return err
+	}
+	c.queryDomain.InvalidateEnforceCache(ctx, m.Payload.ServiceID)
+	return nil
+}
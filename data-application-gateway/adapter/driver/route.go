@@ -2,17 +2,23 @@ package driver
 
 import (
 	"bytes"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/wire"
 	"go.uber.org/zap"
 
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driver/v1/cache"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driver/v1/query"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/errorcode"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/idrm-go-common/access_control"
+	"github.com/kweaver-ai/idrm-go-common/interception"
 	"github.com/kweaver-ai/idrm-go-common/middleware"
 	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/common"
 	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
 	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/trace"
+	"github.com/kweaver-ai/idrm-go-frame/core/transport/rest/ginx"
 )
 
 var _ IRouter = (*Router)(nil)
@@ -78,6 +84,7 @@ type IRouter interface {
 type Router struct {
 	Middleware      middleware.Middleware
 	QueryController *query.QueryController
+	CacheController *cache.CacheController
 }
 
 func (r *Router) Register(s *settings.Settings, engine *gin.Engine) error {
@@ -91,10 +98,28 @@ func (r *Router) NoTokenInterception() gin.HandlerFunc {
 	}
 }
 
+// ManagerOnly 运维类接口仅允许具有接口服务管理权限的用户访问。通用的权限中间件
+// 会放行应用令牌，这里先拒绝应用令牌，再交给权限中间件校验用户权限。
+func (r *Router) ManagerOnly() gin.HandlerFunc {
+	accessControl := r.Middleware.AccessControl(access_control.ServiceManagement)
+	return func(c *gin.Context) {
+		if tokenType, ok := c.Get(interception.TokenType); ok && tokenType == interception.TokenTypeClient {
+			ginx.ResErrJsonWithCode(c, http.StatusForbidden, errorcode.Desc(errorcode.AccessForbidden))
+			c.Abort()
+			return
+		}
+		accessControl(c)
+	}
+}
+
 func (r *Router) RegisterApi(s *settings.Settings, engine *gin.Engine) {
 	engine.Use(trace.MiddlewareTrace(), ResponseLoggerMiddleware(s.Telemetry.LogLevel))
 	//数据查询
 	engine.Any("/data-application-gateway/*service_path", r.Middleware.ShouldTokenInterception(), r.QueryController.Query)
 	//数据查询测试
 	engine.Any("/api/data-application-gateway/v1/query-test", r.Middleware.TokenInterception(), r.QueryController.QueryTest)
+	//后台服务熔断器状态
+	engine.GET("/api/data-application-gateway/v1/breakers", r.Middleware.TokenInterception(), r.ManagerOnly(), r.QueryController.BreakerStates)
+	//缓存命中统计
+	engine.GET("/api/data-application-gateway/v1/cache/stats", r.Middleware.TokenInterception(), r.ManagerOnly(), r.CacheController.Stats)
 }
@@ -0,0 +1,63 @@
+package driver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kweaver-ai/idrm-go-common/access_control"
+	"github.com/kweaver-ai/idrm-go-common/interception"
+	"github.com/kweaver-ai/idrm-go-common/middleware"
+)
+
+// fakeMiddleware 仅实现 AccessControl，按 allow 决定是否放行
+type fakeMiddleware struct {
+	middleware.Middleware
+	allow    bool
+	resource access_control.Resource
+}
+
+func (m *fakeMiddleware) AccessControl(resource access_control.Resource) gin.HandlerFunc {
+	m.resource = resource
+	return func(c *gin.Context) {
+		if !m.allow {
+			c.AbortWithStatus(http.StatusForbidden)
+		}
+	}
+}
+
+func TestRouter_ManagerOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		tokenType any
+		allow     bool
+		want      int
+	}{
+		{name: "用户有权限", tokenType: interception.TokenTypeUser, allow: true, want: http.StatusOK},
+		{name: "用户无权限", tokenType: interception.TokenTypeUser, allow: false, want: http.StatusForbidden},
+		{name: "应用令牌", tokenType: interception.TokenTypeClient, allow: true, want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &fakeMiddleware{allow: tt.allow}
+			r := &Router{Middleware: m}
+
+			engine := gin.New()
+			engine.GET("/breakers", func(c *gin.Context) {
+				c.Set(interception.TokenType, tt.tokenType)
+			}, r.ManagerOnly(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/breakers", nil))
+			assert.Equal(t, tt.want, w.Code)
+			assert.Equal(t, access_control.ServiceManagement, m.resource)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package cache
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/cache"
+	"github.com/kweaver-ai/idrm-go-frame/core/transport/rest/ginx"
+)
+
+type CacheController struct{}
+
+func NewCacheController() *CacheController {
+	return &CacheController{}
+}
+
+// Stats 缓存命中统计
+//
+//	@Summary	缓存命中统计
+//	@Tags		缓存
+//	@Success	200	{array}	cache.Stats
+//	@Router		/api/data-application-gateway/v1/cache/stats [get]
+func (s *CacheController) Stats(c *gin.Context) {
+	ginx.ResOKJson(c, cache.AllStats())
+}
@@ -2,10 +2,13 @@ package query
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"io"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,14 +17,20 @@ import (
 
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/gorm"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/enum"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/errorcode"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/form_validator"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/util"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/domain"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/metrics"
+	"github.com/kweaver-ai/idrm-go-frame/core/errorx/agerrors"
 	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
 	"github.com/kweaver-ai/idrm-go-frame/core/transport/rest/ginx"
 )
 
+// queryTestResponseLimit 测试接口返回内容的最大长度
+const queryTestResponseLimit = 1 << 20
+
 type QueryController struct {
 	domain                  *domain.QueryDomain
 	serviceCallRecordDomain *domain.ServiceCallRecordDomain
@@ -35,16 +44,28 @@ func NewQueryController(d *domain.QueryDomain, serviceCallRecordDomain *domain.S
 	}
 }
 
-// Query 数据查询外部接口
+// BreakerStates 注册接口的后台服务熔断器状态
+//
+//	@Summary	注册接口的后台服务熔断器状态
+//	@Tags		数据查询
+//	@Success	200	{array}	reverse_proxy.BreakerState
+//	@Router		/api/data-application-gateway/v1/breakers [get]
+func (s *QueryController) BreakerStates(c *gin.Context) {
+	ginx.ResOKJson(c, s.domain.BreakerStates(c))
+}
+
+// Query 数据查询外部接口，返回的 Content-Type 由接口的返回类型决定
 //
 //	@Summary	数据查询外部接口
 //	@Tags		数据查询
+//	@Produce	json,text/csv,application/xml,application/octet-stream
 //	@Param		service_path	path		string	true	"服务路径"
 //	@Success	200				{object}	object
 //	@Router		/data-application-gateway/{service_path} [post]
 func (s *QueryController) Query(c *gin.Context) {
 	// 记录调用开始时间
 	callStartTime := time.Now()
+	defer metrics.TrackInFlight()()
 
 	// 获取配置中心配置，如果配置中心配置cssjj为true，则不进行鉴权
 	cssjj, err := s.configurationRepo.GetConf(nil, c, "cssjj")
@@ -56,7 +77,9 @@ func (s *QueryController) Query(c *gin.Context) {
 
 	log.WithContext(c).Info("Query")
 	req := &dto.QueryReq{
-		Params: make(map[string]*dto.Param),
+		Params:   make(map[string]*dto.Param),
+		Request:  c.Request,
+		ClientIP: c.ClientIP(),
 	}
 
 	_, err = form_validator.BindUriAndValid(c, req)
@@ -100,6 +123,11 @@ func (s *QueryController) Query(c *gin.Context) {
 		d := json.NewDecoder(bytes.NewReader(bodyBytes))
 		d.UseNumber()
 		err = d.Decode(&body)
+		// 不是 JSON 的请求体由请求映射原样转发给后台服务，不作为请求参数
+		if err != nil && !isJSONContentType(c.ContentType()) {
+			log.WithContext(c).Info("Query skip non-JSON body", zap.String("content_type", c.ContentType()))
+			body, err = nil, nil
+		}
 		if err != nil {
 			log.WithContext(c).Error("Query", zap.Error(err))
 			c.Writer.WriteHeader(http.StatusBadRequest)
@@ -120,7 +148,12 @@ func (s *QueryController) Query(c *gin.Context) {
 		}
 	}
 
-	length, res, err := s.domain.Query(c, req, cssjj)
+	// 配置中心配置 cssjj 为 true 时使用长沙的认证方案
+	authScheme := domain.AuthSchemeDefault
+	if cssjj == "true" {
+		authScheme = domain.AuthSchemeCssjj
+	}
+	res, err := s.domain.Query(c, req, authScheme)
 	if err != nil {
 		c.Writer.WriteHeader(http.StatusBadRequest)
 		if errors.As(err, &form_validator.ValidErrors{}) {
@@ -130,15 +163,28 @@ func (s *QueryController) Query(c *gin.Context) {
 			return
 		}
 
+		// 后台服务熔断，没有请求后台服务，单独记录调用状态
+		if agerrors.Code(err).GetErrorCode() == errorcode.BackendCircuitOpen {
+			ginx.ResErrJsonWithCode(c, http.StatusServiceUnavailable, err)
+			s.recordServiceCall(c, req, callStartTime, http.StatusServiceUnavailable, enum.CallStatusCircuitOpen, err.Error(), cssjj)
+			return
+		}
+
+		// 超出调用频次限制，Retry-After 以秒为单位，向上取整
+		var limited *domain.RateLimitedError
+		if errors.As(err, &limited) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Max(1, math.Ceil(limited.RetryAfter.Seconds())))))
+			ginx.ResErrJsonWithCode(c, http.StatusTooManyRequests, limited.Unwrap())
+			s.recordServiceCall(c, req, callStartTime, http.StatusTooManyRequests, 0, err.Error(), cssjj)
+			return
+		}
+
 		ginx.ResErrJson(c, err)
 		// 记录失败的调用
 		s.recordServiceCall(c, req, callStartTime, http.StatusBadRequest, 0, err.Error(), cssjj)
 		return
 	}
-	defer res.Close()
-
-	// 记录成功的调用
-	s.recordServiceCall(c, req, callStartTime, http.StatusOK, 1, "", cssjj)
+	defer res.Body.Close()
 
 	for _, k := range []string{"x-tif-signature", "x-tif-timestamp", "x-tif-nonce"} {
 		if param, ok := req.Params[k]; ok && param.Position == dto.ParamPositionHeader {
@@ -148,7 +194,38 @@ func (s *QueryController) Query(c *gin.Context) {
 		}
 	}
 
-	c.DataFromReader(http.StatusOK, length, "application/json", res, nil)
+	// 响应体直接流式返回，长度未知时使用分块传输
+	extraHeaders := make(map[string]string)
+	for k := range res.Header {
+		if k != "Content-Type" {
+			extraHeaders[k] = res.Header.Get(k)
+		}
+	}
+	resBody := &callResultReader{Reader: res.Body}
+	c.DataFromReader(http.StatusOK, res.Length, res.Header.Get("Content-Type"), resBody, extraHeaders)
+
+	// 状态码已经发送，返回响应体的过程中出错时响应不完整，记录为失败的调用
+	if resBody.err != nil {
+		log.WithContext(c).Error("Query stream response failed", zap.String("service_path", req.ServicePath), zap.Error(resBody.err))
+		s.recordServiceCall(c, req, callStartTime, http.StatusOK, 0, resBody.err.Error(), cssjj)
+		return
+	}
+	// 记录成功的调用
+	s.recordServiceCall(c, req, callStartTime, http.StatusOK, 1, "", cssjj)
+}
+
+// callResultReader 记录读取响应体时的错误，用于在流式返回结束后记录调用结果
+type callResultReader struct {
+	io.Reader
+	err error
+}
+
+func (r *callResultReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
 }
 
 // QueryTest 数据查询测试接口
@@ -204,7 +281,7 @@ func (s *QueryController) QueryTest(c *gin.Context) {
 	}
 	req.Params[dto.Limit] = dto.NewParam(req.PageSize, "", dto.ParamDataTypeInt)
 
-	_, rc, err := s.domain.QueryTest(c, req)
+	rc, err := s.domain.QueryTest(c, req)
 	if err != nil {
 		c.Writer.WriteHeader(http.StatusBadRequest)
 		if errors.As(err, &form_validator.ValidErrors{}) {
@@ -215,7 +292,7 @@ func (s *QueryController) QueryTest(c *gin.Context) {
 		ginx.ResErrJson(c, err)
 		return
 	}
-	defer rc.Close()
+	defer rc.Body.Close()
 
 	request := make(map[string]interface{})
 	for _, requestParam := range req.DataTableRequestParams {
@@ -233,22 +310,37 @@ func (s *QueryController) QueryTest(c *gin.Context) {
 		return
 	}
 
-	responseMarshal, err := io.ReadAll(rc)
+	// 测试只返回前一部分内容，避免读取过大的 CSV 或文件
+	responseMarshal, err := io.ReadAll(io.LimitReader(rc.Body, queryTestResponseLimit+1))
 	if err != nil {
 		log.WithContext(c).Error("QueryTest json.Marshal response", zap.Error(err))
 		c.Writer.WriteHeader(http.StatusBadRequest)
 		ginx.ResErrJson(c, err)
 		return
 	}
+	truncated := len(responseMarshal) > queryTestResponseLimit
+	if truncated {
+		responseMarshal = responseMarshal[:queryTestResponseLimit]
+	}
 
 	res := dto.QueryTestRes{
-		Request:  string(requestMarshal),
-		Response: string(responseMarshal),
+		Request:     string(requestMarshal),
+		Response:    string(responseMarshal),
+		ContentType: rc.Header.Get("Content-Type"),
+		Truncated:   truncated,
+	}
+	if req.ReturnType == enum.ReturnTypeFile {
+		res.Response = base64.StdEncoding.EncodeToString(responseMarshal)
 	}
 
 	ginx.ResOKJson(c, res)
 }
 
+// isJSONContentType 请求是否声明为 JSON，没有声明时按 JSON 处理
+func isJSONContentType(contentType string) bool {
+	return contentType == "" || contentType == "application/json" || strings.HasSuffix(contentType, "+json")
+}
+
 func (s *QueryController) queryTestReqCheck(req *dto.QueryTestReq) (err error) {
 	var validErrors form_validator.ValidErrors
 
@@ -310,61 +402,40 @@ func (s *QueryController) queryTestReqCheck(req *dto.QueryTestReq) (err error) {
 
 // recordServiceCall 记录服务调用信息
 func (s *QueryController) recordServiceCall(c *gin.Context, req *dto.QueryReq, callStartTime time.Time, httpCode int, callStatus int, errorMessage, cssjj string) {
-	// 长沙环境由里约网关记录
+	callEndTime := time.Now()
+
+	// 记录需要的请求信息在 handler 返回前复制，之后由队列异步批量写入，避免影响主流程性能
+	recordReq := &domain.RecordServiceCallReq{
+		ServicePath:         req.ServicePath,
+		ServiceID:           req.ServiceID,
+		ServiceType:         req.ServiceType,
+		ServiceDepartmentID: "", // 从服务信息中获取
+		ServiceSystemID:     "", // 需要从服务信息中获取,国开分支没有这个属性
+		ServiceAppID:        "", // 需要从服务信息中获取,国开分支没有这个属性
+		RemoteAddress:       c.RemoteIP(),
+		ForwardFor:          c.GetHeader("X-Forwarded-For"),
+		UserIdentification:  "", // 从调用者信息中获取
+		CallDepartmentID:    "", // 需要从外部接口中获取
+		CallInfoSystemID:    "", // 需要从外部接口中获取
+		CallAppID:           "", // 从调用者信息中获取
+		CallStartTime:       callStartTime,
+		CallEndTime:         &callEndTime,
+		CallHTTPCode:        &httpCode,
+		CallStatus:          callStatus,
+		ErrorMessage:        errorMessage,
+		CallOtherMessage:    "", // 可以记录其他相关信息
+		Caller:              req.Caller,
+		CallerSigned:        req.CallerSigned,
+	}
+
+	// 长沙环境由里约网关记录，只统计指标
 	if cssjj == "true" {
+		s.serviceCallRecordDomain.ObserveServiceCall(c, recordReq)
 		return
 	}
-	// 异步记录，避免影响主流程性能
-	go func() {
-		callEndTime := time.Now()
-
-		// // 从请求中提取用户信息
-		// userIdentification := c.GetHeader("User-Identification")
-		// if userIdentification == "" {
-		// 	userIdentification = c.GetHeader("X-User-ID")
-		// }
-
-		// // 从请求中提取部门信息
-		// callDepartmentID := c.GetHeader("Call-Department-ID")
-		// if callDepartmentID == "" {
-		// 	callDepartmentID = c.GetHeader("X-Department-ID")
-		// }
-
-		// // 从请求中提取系统信息
-		// callInfoSystemID := c.GetHeader("Call-Info-System-ID")
-		// if callInfoSystemID == "" {
-		// 	callInfoSystemID = c.GetHeader("X-System-ID")
-		// }
-
-		// // 从请求中提取应用信息
-		// callAppID := c.GetHeader("Call-App-ID")
-		// if callAppID == "" {
-		// 	callAppID = c.GetHeader("X-App-ID")
-		// }
-
-		// 构建记录请求
-		recordReq := &domain.RecordServiceCallReq{
-			ServiceID:           req.ServicePath,
-			ServiceDepartmentID: "", // 需要从服务信息中获取
-			ServiceSystemID:     "", // 需要从服务信息中获取,国开分支没有这个属性
-			ServiceAppID:        "", // 需要从服务信息中获取,国开分支没有这个属性
-			RemoteAddress:       c.RemoteIP(),
-			ForwardFor:          c.GetHeader("X-Forwarded-For"),
-			UserIdentification:  "", // 需要从外部接口中获取
-			CallDepartmentID:    "", // 需要从外部接口中获取
-			CallInfoSystemID:    "", // 需要从外部接口中获取
-			CallAppID:           "", // 需要从外部接口中获取
-			CallStartTime:       callStartTime,
-			CallEndTime:         &callEndTime,
-			CallHTTPCode:        &httpCode,
-			CallStatus:          callStatus,
-			ErrorMessage:        errorMessage,
-			CallOtherMessage:    "", // 可以记录其他相关信息
-		}
 
-		// 记录服务调用
-		if err := s.serviceCallRecordDomain.RecordServiceCall(c, recordReq); err != nil {
-			log.WithContext(c).Error("记录服务调用失败", zap.Error(err))
-		}
-	}()
+	// 记录服务调用
+	if err := s.serviceCallRecordDomain.RecordServiceCall(c, recordReq); err != nil {
+		log.WithContext(c).Error("记录服务调用失败", zap.Error(err))
+	}
 }
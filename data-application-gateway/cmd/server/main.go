@@ -6,11 +6,14 @@ import (
 
 	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/trace"
 
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driver"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driver/mq"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/form_validator"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
 	af_go_frame "github.com/kweaver-ai/idrm-go-frame"
 	"github.com/kweaver-ai/idrm-go-frame/core/config"
 	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+	"github.com/kweaver-ai/idrm-go-frame/core/transport"
 	"github.com/kweaver-ai/idrm-go-frame/core/transport/rest"
 )
 
@@ -27,11 +30,16 @@ type AppRunner struct {
 	App *af_go_frame.App
 }
 
-func newApp(hs *rest.Server) *af_go_frame.App {
+func newApp(hs *rest.Server, ms *driver.MetricsServer, cacheConsumer *mq.CacheConsumer) *af_go_frame.App {
+	servers := []transport.Server{hs, cacheConsumer}
+	// 配置了指标地址时在单独的端口提供指标
+	if ms != nil {
+		servers = append(servers, ms)
+	}
 
 	return af_go_frame.New(
 		af_go_frame.Name(Name),
-		af_go_frame.Server(hs),
+		af_go_frame.Server(servers...),
 	)
 }
 
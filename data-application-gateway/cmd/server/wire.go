@@ -13,6 +13,7 @@ import (
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/domain"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/transport"
 )
 
 var appRunnerSet = wire.NewSet(wire.Struct(new(AppRunner), "*"))
@@ -26,6 +27,7 @@ func InitApp(s *settings.Settings) (*AppRunner, func(), error) {
 		domain.ProviderSet,
 		repository.NewRedis,
 		db.NewData,
+		transport.NewTransports,
 		newApp,
 		appRunnerSet))
 }
@@ -18,15 +18,19 @@ import (
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/gorm"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/mdl-uniquery"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/microservice"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/rate_limiter"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/reverse_proxy"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/virtual_engine"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driver"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driver/mq"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driver/v1/cache"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driver/v1/query"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/util"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/domain"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/transport"
 	"github.com/google/wire"
 )
 
@@ -49,12 +53,17 @@ func InitApp(s *settings.Settings) (*AppRunner, func(), error) {
 		return nil, nil, err
 	}
 	appRepo := gorm.NewAppRepo(data)
-	serviceRepo := gorm.NewServiceRepo(data)
+	redis := repository.NewRedis(s)
+	serviceRepo := gorm.NewServiceRepo(data, redis, s)
 	serviceApplyRepo := gorm.NewServiceApplyRepo(data)
 	configurationRepo := gorm.NewConfigurationRepo(data)
-	virtualEngineRepo := virtual_engine.NewVirtualEngineRepo()
-	reverseProxyRepo := reverse_proxy.NewReverseProxyRepo()
-	redis := repository.NewRedis(s)
+	transports, err := transport.NewTransports(s)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	virtualEngineRepo := virtual_engine.NewVirtualEngineRepo(transports)
+	reverseProxyRepo := reverse_proxy.NewReverseProxyRepo(transports, s)
 	dataViewRepo := microservice.NewDataViewRepo()
 	httpClient := util.NewHTTPClient(client)
 	configurationCenterRepo := microservice.NewConfigurationCenterRepo(client, httpClient)
@@ -64,20 +73,26 @@ func InitApp(s *settings.Settings) (*AppRunner, func(), error) {
 	applicationService := driven.NewConfigurationCenterApplicationService(client)
 	dataApplicationServiceRepo := gorm.NewDataApplicationServiceRepo(data)
 	drivenMDLUniQuery := mdl_uniquery.NewMDLUniQuery()
-	queryDomain := domain.NewQueryDomain(appRepo, serviceRepo, serviceApplyRepo, configurationRepo, virtualEngineRepo, reverseProxyRepo, redis, dataViewRepo, configurationCenterRepo, authServiceRepo, data_viewDriven, labelService, applicationService, dataApplicationServiceRepo, drivenMDLUniQuery)
+	rateLimiterRepo := rate_limiter.NewRateLimiterRepo(redis)
+	queryDomain := domain.NewQueryDomain(appRepo, serviceRepo, serviceApplyRepo, configurationRepo, virtualEngineRepo, reverseProxyRepo, redis, dataViewRepo, configurationCenterRepo, authServiceRepo, data_viewDriven, labelService, applicationService, dataApplicationServiceRepo, drivenMDLUniQuery, rateLimiterRepo, s)
 	serviceCallRecordRepo := gorm.NewServiceCallRecordRepo(data)
-	serviceCallRecordDomain := domain.NewServiceCallRecordDomain(serviceCallRecordRepo, serviceRepo, configurationCenterRepo, dataApplicationServiceRepo)
+	serviceCallRecordDomain, cleanup2 := domain.NewServiceCallRecordDomain(serviceCallRecordRepo, serviceRepo, configurationCenterRepo, dataApplicationServiceRepo, s)
 	queryController := query.NewQueryController(queryDomain, serviceCallRecordDomain, configurationRepo)
+	cacheController := cache.NewCacheController()
 	router := &driver.Router{
 		Middleware:      middleware,
 		QueryController: queryController,
+		CacheController: cacheController,
 	}
 	server := driver.NewHttpServer(s, router)
-	app := newApp(server)
+	metricsServer := driver.NewMetricsServer(s)
+	cacheConsumer := mq.NewCacheConsumer(s, queryDomain)
+	app := newApp(server, metricsServer, cacheConsumer)
 	appRunner := &AppRunner{
 		App: app,
 	}
 	return appRunner, func() {
+		cleanup2()
 		cleanup()
 	}, nil
 }
@@ -1,5 +1,11 @@
 package dto
 
+import (
+	"net/http"
+
+	v1 "github.com/kweaver-ai/idrm-go-common/api/auth-service/v1"
+)
+
 type ParamPosition string
 
 const (
@@ -28,6 +34,19 @@ const (
 type QueryReq struct {
 	ServicePath string `json:"service_path" uri:"service_path" binding:"required,URL"`
 	Params      map[string]*Param
+	// 原始请求，签名认证时用于计算签名
+	Request *http.Request `json:"-"`
+	// 匹配到的接口，用于调用记录和指标
+	ServiceID   string `json:"-"`
+	ServiceType string `json:"-"`
+	// 认证方案认证出的调用者，用于调用记录。签名认证的调用者为签名的 app 所属的用户
+	Caller *v1.Subject `json:"-"`
+	// 认证方案认证出的调用应用，用于限流。令牌认证为令牌的应用，签名认证为签名的 app，长沙认证为接口的应用
+	CallerApp string `json:"-"`
+	// 调用者是否由 HMAC 签名认证，只记录应用和签名认证的调用者的调用
+	CallerSigned bool `json:"-"`
+	// 客户端 IP，没有认证出调用应用时按客户端 IP 限流
+	ClientIP string `json:"-"`
 }
 
 type Param struct {
@@ -57,11 +76,58 @@ type QueryTestReq struct {
 	Script             string            `json:"script" binding:"omitempty"`                                                        // 脚本（仅脚本模式需要此参数）
 	PageSize           int               `json:"page_size" binding:"omitempty,number,min=1,max=1000"`                               // 分页大小
 	HTTPMethod         string            `json:"http_method" binding:"omitempty,oneof=post get put delete"`                         // 请求方式 post get put delete
+	ReturnType         string            `json:"return_type" binding:"omitempty,oneof=json csv xml file"`                           // 返回类型 json csv xml file 文件下载，默认 json
 	BackendServiceHost string            `json:"backend_service_host" form:"backend_service_host" binding:"omitempty,HOST,max=128"` // 后台服务域名/IP
 	BackendServicePath string            `json:"backend_service_path" form:"backend_service_path" binding:"omitempty,URL,max=128"`  // 后台服务路径
 	CurrentRules       *SubServiceDetail `json:"current_rules" form:"current_rules" binding:"omitempty,dive"`                       //当前接口测限定规则，授权管理页面用到的
+	RequestMapping     *RequestMapping   `json:"request_mapping" binding:"omitempty"`                                               // 请求映射，只对接口注册生效
+}
+
+// RequestMapping 注册接口转发到后台服务的请求映射，与接口服务保存的格式一致
+type RequestMapping struct {
+	Headers HeaderMapping `json:"headers"`
+	Query   QueryMapping  `json:"query"`
+	Body    BodyMapping   `json:"body"`
+}
+
+// HeaderMapping 请求头映射，请求头名称不区分大小写
+type HeaderMapping struct {
+	// 转发的请求头，为空时转发 deny 以外的请求头。Authorization 和 Cookie 默认不转发，需要在这里显式允许
+	Allow []string `json:"allow" binding:"omitempty,dive,required,max=128"`
+	// 不转发的请求头
+	Deny []string `json:"deny" binding:"omitempty,dive,required,max=128"`
+	// 转发时重命名请求头，key 为调用方的请求头，value 为后台服务的请求头
+	Rename map[string]string `json:"rename" binding:"omitempty,dive,keys,required,max=128,endkeys,required,max=128"`
+	// 固定添加的请求头，覆盖调用方的同名请求头
+	Inject []StaticHeader `json:"inject" binding:"omitempty,dive"`
+}
+
+// StaticHeader 固定添加的请求头
+type StaticHeader struct {
+	Name string `json:"name" binding:"required,max=128"`
+	// 请求头的值，secret 为 true 时为接口服务加密后的密文，测试时也可以是明文
+	Value  string `json:"value" binding:"required,max=4096"`
+	Secret bool   `json:"secret"`
+}
+
+// QueryMapping query 参数映射
+type QueryMapping struct {
+	// 转发 query 参数的所有值，否则只转发只有一个值的参数
+	MultiValue bool `json:"multi_value"`
+}
+
+// BodyMapping 请求体映射
+type BodyMapping struct {
+	// json 按请求参数重新编码为 JSON，默认；raw 原样转发调用方的请求体和 Content-Type
+	Mode string `json:"mode" binding:"omitempty,oneof=json raw"`
 }
 
+// 请求体映射方式
+const (
+	BodyModeJSON = "json"
+	BodyModeRaw  = "raw"
+)
+
 type Rule struct {
 	Param    string `json:"param" binding:"omitempty,VerifyNameEn"`                                             // 过滤字段
 	Operator string `json:"operator" binding:"omitempty,oneof='=' '!=' '>' '>=' '<' '<=' 'like' 'in' 'not in'"` // 运算逻辑 = 等于, != 不等于, > 大于, >= 大于等于, < 小于, <= 小于等于, like 模糊匹配, in 包含, not in 不包含
@@ -69,8 +135,10 @@ type Rule struct {
 }
 
 type QueryTestRes struct {
-	Request  interface{} `json:"request"`  // 请求详情
-	Response interface{} `json:"response"` // 返回内容
+	Request     interface{} `json:"request"`      // 请求详情
+	Response    interface{} `json:"response"`     // 返回内容，返回类型为 file 时为 base64 编码的内容
+	ContentType string      `json:"content_type"` // 返回内容的 Content-Type
+	Truncated   bool        `json:"truncated"`    // 返回内容过长，只返回了前一部分
 }
 
 type DataTableRequestParam struct {
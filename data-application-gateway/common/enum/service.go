@@ -29,3 +29,26 @@ const (
 	PublishStatusChangeReject   = "change-reject"   //变更审核未通过
 
 )
+
+// 接口调用状态，对应 service_call_record.call_status
+const (
+	CallStatusFailed      = 0 //失败
+	CallStatusSuccess     = 1 //成功
+	CallStatusCircuitOpen = 2 //后端服务熔断，未请求后端服务
+)
+
+// 脱敏规则
+const (
+	MaskingPlaintext = "plaintext" //不脱敏
+	MaskingHash      = "hash"      //哈希
+	MaskingOverride  = "override"  //覆盖
+	MaskingReplace   = "replace"   //替换
+)
+
+// 接口返回类型，对应 service.return_type
+const (
+	ReturnTypeJSON = "json" //JSON
+	ReturnTypeCSV  = "csv"  //CSV 导出
+	ReturnTypeXML  = "xml"  //XML
+	ReturnTypeFile = "file" //文件下载
+)
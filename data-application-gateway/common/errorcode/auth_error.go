@@ -15,9 +15,27 @@ const (
 	GetUserInfoFailed         = authPreCoder + "GetUserInfoFailed"
 	GetUserInfoFailedInterior = authPreCoder + "GetUserInfoFailedInterior"
 	GetTokenEmpty             = authPreCoder + "GetTokenEmpty"
+	OAuthDisabled             = authPreCoder + "OAuthDisabled"
+	AuthSchemeNotExist        = authPreCoder + "AuthSchemeNotExist"
+	AccessForbidden           = authPreCoder + "AccessForbidden"
 )
 
 var authErrorMap = errorCode{
+	OAuthDisabled: {
+		description: "未启用令牌认证",
+		cause:       "",
+		solution:    "请使用签名认证",
+	},
+	AuthSchemeNotExist: {
+		description: "认证方案[scheme]不存在或配置错误",
+		cause:       "",
+		solution:    "请检查认证方案配置",
+	},
+	AccessForbidden: {
+		description: "无权访问该接口",
+		cause:       "",
+		solution:    "请使用具有接口服务管理权限的用户访问",
+	},
 	TokenAuditFailed: {
 		description: "用户信息验证失败",
 		cause:       "",
@@ -16,6 +16,15 @@ const (
 	RateLimitError = queryPreCoder + "RateLimitError"
 	// 接口服务的后端返回不支持的 content-type
 	BackendUnsupportedContentType = queryPreCoder + "UnsupportedContentType"
+	// 后端返回的 Content-Type 与接口的返回类型不一致
+	BackendReturnTypeMismatch = queryPreCoder + "BackendReturnTypeMismatch"
+	// 接口服务的后端返回的数据需要脱敏，但数据过大或不是合法的 JSON
+	BackendResponseTooLarge = queryPreCoder + "BackendResponseTooLarge"
+	BackendResponseInvalid  = queryPreCoder + "BackendResponseInvalid"
+	// 接口的返回类型不是 JSON，但返回参数配置了脱敏或查询保护
+	BackendReturnTypeNotMaskable = queryPreCoder + "BackendReturnTypeNotMaskable"
+	// 接口服务的后端连续失败，已熔断
+	BackendCircuitOpen = queryPreCoder + "BackendCircuitOpen"
 )
 
 var queryErrorMap = errorCode{
@@ -32,4 +41,23 @@ var queryErrorMap = errorCode{
 	BackendUnsupportedContentType: {
 		description: "后端服务返回不支持的 Content-Type[%s]",
 	},
+	BackendReturnTypeMismatch: {
+		description: "后端服务返回的 Content-Type[content_type]与接口的返回类型[return_type]不一致",
+		solution:    "请检查接口的返回类型或后端服务",
+	},
+	BackendResponseTooLarge: {
+		description: "后端服务返回的数据超过 %d 字节，无法脱敏",
+		solution:    "请减少后端服务单次返回的数据量",
+	},
+	BackendResponseInvalid: {
+		description: "后端服务返回的数据不是合法的 JSON，无法脱敏",
+	},
+	BackendReturnTypeNotMaskable: {
+		description: "接口的返回类型[return_type]不支持脱敏和查询保护",
+		solution:    "请将接口的返回类型修改为 json，或取消返回参数的脱敏和查询保护",
+	},
+	BackendCircuitOpen: {
+		description: "后端服务[backend]连续请求失败，暂时停止访问",
+		solution:    "请稍后再试",
+	},
 }
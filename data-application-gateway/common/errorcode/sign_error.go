@@ -17,6 +17,8 @@ const (
 	TimestampExpired  = signPreCoder + "TimestampExpired"
 	AppIdRequired     = signPreCoder + "AppIdRequired"
 	AppIdNotExist     = signPreCoder + "AppIdNotExist"
+	SignNonceUsed     = signPreCoder + "SignNonceUsed"
+	SignAuthDisabled  = signPreCoder + "SignAuthDisabled"
 )
 
 var signErrorMap = errorCode{
@@ -50,4 +52,14 @@ var signErrorMap = errorCode{
 		cause:       "",
 		solution:    "请重新输入 AppId",
 	},
+	SignNonceUsed: {
+		description: "请求随机串已被使用",
+		cause:       "",
+		solution:    "请使用新的随机串重新签名",
+	},
+	SignAuthDisabled: {
+		description: "未启用签名认证",
+		cause:       "",
+		solution:    "请使用令牌认证",
+	},
 }
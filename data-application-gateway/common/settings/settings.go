@@ -16,6 +16,14 @@ type Settings struct {
 	Database        options.DBOptions `yaml:"database"`
 	Redis           Redis             `yaml:"redis"`
 	Services        Services          `yaml:"services"`
+	CallRecord      CallRecord        `yaml:"call_record"`
+	Auth            Auth              `yaml:"auth"`
+	Cache           Cache             `yaml:"cache"`
+	MQ              MQ                `yaml:"mq"`
+	Transport       Transport         `yaml:"transport"`
+	Proxy           Proxy             `yaml:"proxy"`
+	Crypto          Crypto            `yaml:"crypto"`
+	Masking         Masking           `yaml:"masking"`
 	zapx.LogConfigs `yaml:"logs"`
 	Telemetry       telemetry.Config `json:"telemetry"`
 }
@@ -23,6 +31,8 @@ type Settings struct {
 type Server struct {
 	Http *HTTPServer `yaml:"http"`
 	Grpc *GRPCServer `yaml:"grpc"`
+	// Prometheus 指标的内部 HTTP 服务，不经过网关入口，未配置时不提供指标
+	Metrics *HTTPServer `yaml:"metrics"`
 }
 
 type HTTPServer struct {
@@ -72,3 +82,115 @@ type Services struct {
 	DataSubject            string `json:"data_subject"`             //主题域管理服务
 	AuthService            string `json:"auth_service"`             //权限服务
 }
+
+// CallRecord 接口调用记录的写入配置，为 0 时使用默认值
+type CallRecord struct {
+	QueueSize     int    `json:"queue_size"`     // 内存队列长度，队列满时写入本地文件
+	BatchSize     int    `json:"batch_size"`     // 每批写入的记录数
+	FlushInterval int    `json:"flush_interval"` // 写入记录和每日统计的间隔，单位秒
+	SpillFile     string `json:"spill_file"`     // 队列满或写入数据库失败时保存记录的本地文件
+}
+
+// Cache 接口定义、鉴权结果和字段查询保护标签的缓存，进程内 LRU 加 Redis 两级。
+// 时间单位秒，为 0 时使用默认值
+type Cache struct {
+	LocalSize       int `json:"local_size"`        // 每种缓存在进程内保存的最大条数
+	LocalTTL        int `json:"local_ttl"`         // 进程内缓存的最长时间
+	ServiceTTL      int `json:"service_ttl"`       // 接口定义
+	EnforceTTL      int `json:"enforce_ttl"`       // auth-service 的鉴权结果
+	FieldProtectTTL int `json:"field_protect_ttl"` // 字段的查询保护标签
+}
+
+type MQ struct {
+	Kafka Kafka `json:"kafka"`
+}
+
+// Kafka 用于接收接口状态变化等消息，host 为空时不接收
+type Kafka struct {
+	Host      string `json:"host"`
+	Port      string `json:"port"`
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	Mechanism string `json:"mechanism"`
+}
+
+// Transport 网关访问虚拟化引擎和注册接口后台服务的 HTTP 连接。
+// 服务端证书使用内置的根证书和 CAFiles 校验
+type Transport struct {
+	CAFiles             []string `json:"ca_files"`                // 额外信任的 CA 证书文件，PEM 格式
+	MaxIdleConns        int      `json:"max_idle_conns"`          // 最大空闲连接数
+	MaxIdleConnsPerHost int      `json:"max_idle_conns_per_host"` // 每个后端的最大空闲连接数
+	IdleConnTimeout     int      `json:"idle_conn_timeout"`       // 空闲连接的超时时间，单位秒
+	// 后端单独的 TLS 配置，key 为后端地址的 host 或 host:port，与地址中的写法一致
+	Backends map[string]BackendTLS `json:"backends"`
+}
+
+// BackendTLS 后端单独的 TLS 配置
+type BackendTLS struct {
+	CAFiles    []string `json:"ca_files"`    // 额外信任的 CA 证书文件，PEM 格式
+	CertFile   string   `json:"cert_file"`   // mTLS 客户端证书
+	KeyFile    string   `json:"key_file"`    // mTLS 客户端私钥
+	ServerName string   `json:"server_name"` // 校验服务端证书使用的域名，为空时使用地址中的域名
+	// 不校验服务端证书，必须同时填写原因。启动时和每次请求都会记录
+	InsecureSkipVerify       bool   `json:"insecure_skip_verify"`
+	InsecureSkipVerifyReason string `json:"insecure_skip_verify_reason"`
+}
+
+// Proxy 注册接口访问后台服务的熔断、重试和允许返回的数据类型
+type Proxy struct {
+	Breaker Breaker `json:"breaker"`
+	Retry   Retry   `json:"retry"`
+	// 接口返回类型允许的后台服务 Content-Type，如 csv: ["text/csv"]，支持 image/* 的写法。
+	// 没有配置的返回类型使用默认值
+	ReturnTypes map[string][]string `json:"return_types"`
+}
+
+// Breaker 按后台服务地址熔断，为 0 时使用默认值
+type Breaker struct {
+	Disabled            bool `json:"disabled"`               // 关闭熔断
+	FailureThreshold    int  `json:"failure_threshold"`      // 连续失败多少次后熔断
+	OpenTimeout         int  `json:"open_timeout"`           // 熔断持续的时间，单位秒，之后进入半开状态
+	HalfOpenMaxRequests int  `json:"half_open_max_requests"` // 半开状态同时允许的探测请求数
+}
+
+// Retry 后台服务连接失败或返回 502、503、504 时重试，只重试幂等的请求
+type Retry struct {
+	MaxAttempts    int      `json:"max_attempts"`    // 最多请求的次数，包括第一次，为 0 或 1 时不重试
+	InitialBackoff int      `json:"initial_backoff"` // 第一次重试前等待的时间，单位毫秒，之后每次翻倍
+	MaxBackoff     int      `json:"max_backoff"`     // 重试前等待的最长时间，单位毫秒
+	Methods        []string `json:"methods"`         // 重试的请求方式，为空时只重试 get
+}
+
+// Crypto 解密注册接口请求映射中的后台服务密钥，需要与接口服务的配置一致
+type Crypto struct {
+	Key string `json:"key"` // base64 编码的 32 字节 AES 密钥
+}
+
+// Masking 返回参数的脱敏配置
+type Masking struct {
+	// hash 脱敏使用 HMAC-SHA256 的密钥，避免手机号、证件号等取值范围小的字段被字典还原。
+	// 为空时 hash 脱敏的字段与 override 一样输出固定的掩码
+	HashKey string `json:"hash_key"`
+}
+
+// Auth 数据查询接口的认证方式
+type Auth struct {
+	// 默认认证方案启用的认证方式，oauth 令牌认证，sign HMAC 签名认证，为空时只启用令牌认证。
+	// 签名认证只对 app 表中有 AppSecret 的应用生效
+	Modes []string `json:"modes"`
+	// 认证方案，key 为方案名称，value 为依次执行的认证方式，可覆盖内置的 default 和 cssjj 方案。
+	// 必须包含 enforce，或者包含 no-enforce 显式声明不鉴权
+	Schemes map[string][]string `json:"schemes"`
+	// 接口单独使用的认证方案，key 为接口路径，value 为认证方案名称
+	Services map[string]string `json:"services"`
+}
+
+// 认证方式
+const (
+	AuthModeOAuth   = "oauth"   // 令牌认证，调用者为应用
+	AuthModeSign    = "sign"    // HMAC 签名认证，调用者为签名的 app 所属的用户
+	AuthModeCssjj   = "cssjj"   // 长沙 x-tif-* 签名认证
+	AuthModeEnforce = "enforce" // 向 auth-service 鉴权
+	// 自定义的认证方案不向 auth-service 鉴权时必须显式配置，只在认证方式自身校验了调用权限时使用，例如 cssjj
+	AuthModeNoEnforce = "no-enforce"
+)
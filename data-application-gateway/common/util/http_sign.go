@@ -22,7 +22,8 @@ type Authorization struct {
 
 // HttpSignValidate Http 请求签名验证
 func HttpSignValidate(req *http.Request, appSecret string, authorization *Authorization) bool {
-	return HttpSignGenerate(req, appSecret, authorization) == authorization.Signature
+	// 使用 hmac.Equal 比较，避免通过比较耗时猜测签名
+	return hmac.Equal([]byte(HttpSignGenerate(req, appSecret, authorization)), []byte(authorization.Signature))
 }
 
 // HttpSignGenerate Http 请求签名生成
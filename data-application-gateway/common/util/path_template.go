@@ -0,0 +1,123 @@
+package util
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// 路径模板在接口服务和网关各有一份：接口服务在保存接口时校验模板和冲突，网关在请求时匹配和展开，
+// 两个服务是独立的 go module，没有共享的代码库。解析规则修改时需要同时修改两份
+
+// pathVarRegexp 路径变量名，变量必须占一整段路径，如 /orders/{id}
+var pathVarRegexp = regexp.MustCompile(`^\{([A-Za-z_][A-Za-z0-9_-]*)\}$`)
+
+// PathTemplate 带路径变量的接口路径，如 /orders/{id}/items/{item_id}
+type PathTemplate struct {
+	raw      string
+	segments []string
+	// 与 segments 一一对应，不是变量的段为空
+	vars []string
+}
+
+// IsPathTemplate 路径中是否包含路径变量
+func IsPathTemplate(path string) bool {
+	return strings.ContainsAny(path, "{}")
+}
+
+// ParsePathTemplate 解析路径模板，变量不是一整段路径、变量名不合法或重复时返回错误
+func ParsePathTemplate(path string) (*PathTemplate, error) {
+	t := &PathTemplate{raw: path, segments: strings.Split(path, "/")}
+	t.vars = make([]string, len(t.segments))
+	seen := make(map[string]bool)
+	for i, seg := range t.segments {
+		if !strings.ContainsAny(seg, "{}") {
+			continue
+		}
+		m := pathVarRegexp.FindStringSubmatch(seg)
+		if m == nil {
+			return nil, fmt.Errorf("invalid path variable %q in %q", seg, path)
+		}
+		if seen[m[1]] {
+			return nil, fmt.Errorf("duplicate path variable %q in %q", m[1], path)
+		}
+		seen[m[1]] = true
+		t.vars[i] = m[1]
+	}
+	return t, nil
+}
+
+// String 返回原始的路径模板
+func (t *PathTemplate) String() string {
+	return t.raw
+}
+
+// Vars 返回路径变量名，按出现的顺序
+func (t *PathTemplate) Vars() []string {
+	var vars []string
+	for _, v := range t.vars {
+		if v != "" {
+			vars = append(vars, v)
+		}
+	}
+	return vars
+}
+
+// Literals 返回不是变量的段数，多个模板匹配同一路径时优先使用固定段多的模板
+func (t *PathTemplate) Literals() int {
+	var n int
+	for _, v := range t.vars {
+		if v == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// validVarValue 路径变量的值不能为空，也不能是 . 或 ..，避免后台服务的路径被改写到其他目录
+func validVarValue(v string) bool {
+	return v != "" && v != "." && v != ".."
+}
+
+// Match 匹配请求路径，返回路径变量的值。变量不能匹配空的段、. 和 ..
+func (t *PathTemplate) Match(path string) (map[string]string, bool) {
+	segments := strings.Split(path, "/")
+	if len(segments) != len(t.segments) {
+		return nil, false
+	}
+	values := make(map[string]string)
+	for i, seg := range segments {
+		if t.vars[i] == "" {
+			if seg != t.segments[i] {
+				return nil, false
+			}
+			continue
+		}
+		if !validVarValue(seg) {
+			return nil, false
+		}
+		values[t.vars[i]] = seg
+	}
+	return values, true
+}
+
+// Expand 用变量的值替换路径中的变量，值经过路径转义。缺少变量的值或值为 . 和 .. 时返回错误
+func (t *PathTemplate) Expand(values map[string]string) (string, error) {
+	segments := make([]string, len(t.segments))
+	for i, seg := range t.segments {
+		if t.vars[i] == "" {
+			segments[i] = seg
+			continue
+		}
+		v, ok := values[t.vars[i]]
+		if !ok || v == "" {
+			return "", fmt.Errorf("missing path variable %q", t.vars[i])
+		}
+		if !validVarValue(v) {
+			return "", fmt.Errorf("invalid path variable %q value %q", t.vars[i], v)
+		}
+		segments[i] = url.PathEscape(v)
+	}
+	return strings.Join(segments, "/"), nil
+}
@@ -0,0 +1,88 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePathTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		vars     []string
+		literals int
+		wantErr  bool
+	}{
+		{name: "没有变量", path: "/orders", literals: 2},
+		{name: "一个变量", path: "/orders/{id}", vars: []string{"id"}, literals: 2},
+		{name: "多个变量", path: "/orders/{order_id}/items/{item-id}", vars: []string{"order_id", "item-id"}, literals: 3},
+		{name: "变量不是一整段", path: "/orders/id-{id}", wantErr: true},
+		{name: "变量名为空", path: "/orders/{}", wantErr: true},
+		{name: "括号不匹配", path: "/orders/{id", wantErr: true},
+		{name: "变量名重复", path: "/{id}/{id}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePathTemplate(tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.vars, got.Vars())
+			assert.Equal(t, tt.literals, got.Literals())
+			assert.Equal(t, tt.path, got.String())
+		})
+	}
+}
+
+func TestPathTemplate_Match(t *testing.T) {
+	tmpl, err := ParsePathTemplate("/orders/{id}/items/{item}")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		path   string
+		want   map[string]string
+		wantOk bool
+	}{
+		{name: "匹配", path: "/orders/1/items/a", want: map[string]string{"id": "1", "item": "a"}, wantOk: true},
+		{name: "固定段不同", path: "/order/1/items/a"},
+		{name: "段数不同", path: "/orders/1/items"},
+		{name: "多出的段", path: "/orders/1/items/a/b"},
+		{name: "变量为空", path: "/orders//items/a"},
+		{name: "变量为 .", path: "/orders/./items/a"},
+		{name: "变量为 ..", path: "/orders/../items/a"},
+		{name: "变量包含 ..", path: "/orders/a..b/items/a", want: map[string]string{"id": "a..b", "item": "a"}, wantOk: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tmpl.Match(tt.path)
+			assert.Equal(t, tt.wantOk, ok)
+			if tt.wantOk {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPathTemplate_Expand(t *testing.T) {
+	tmpl, err := ParsePathTemplate("/api/orders/{id}/detail")
+	require.NoError(t, err)
+
+	got, err := tmpl.Expand(map[string]string{"id": "a b/c"})
+	require.NoError(t, err)
+	assert.Equal(t, "/api/orders/a%20b%2Fc/detail", got)
+
+	for _, values := range []map[string]string{
+		{"other": "1"},
+		{"id": ""},
+		{"id": "."},
+		{"id": ".."},
+	} {
+		_, err = tmpl.Expand(values)
+		assert.Error(t, err, values)
+	}
+}
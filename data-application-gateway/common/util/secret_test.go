@@ -0,0 +1,32 @@
+package util
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptSecret(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))
+
+	ciphertext, err := EncryptSecret(key, "api-key")
+	require.NoError(t, err)
+	assert.True(t, IsEncryptedSecret(ciphertext))
+	assert.NotContains(t, ciphertext, "api-key")
+
+	plaintext, err := DecryptSecret(key, ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "api-key", plaintext)
+
+	otherKey := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("o", 32)))
+	_, err = DecryptSecret(otherKey, ciphertext)
+	assert.Error(t, err)
+
+	_, err = EncryptSecret("short", "api-key")
+	assert.Error(t, err)
+	_, err = DecryptSecret(key, "api-key")
+	assert.Error(t, err)
+}
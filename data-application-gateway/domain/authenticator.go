@@ -0,0 +1,491 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/cache"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/gorm"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/microservice"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/enum"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/util"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+	v1 "github.com/kweaver-ai/idrm-go-common/api/auth-service/v1"
+	"github.com/kweaver-ai/idrm-go-common/interception"
+	configuration_center_gocommon "github.com/kweaver-ai/idrm-go-common/rest/configuration_center"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+// 内置的认证方案
+const (
+	// AuthSchemeDefault 默认的认证方案，认证调用者后向 auth-service 鉴权
+	AuthSchemeDefault = "default"
+	// AuthSchemeCssjj 长沙的认证方案，校验 x-tif-* 签名，由里约网关鉴权
+	AuthSchemeCssjj = "cssjj"
+)
+
+// Authenticator 数据查询接口调用者的认证方式。
+//
+// 认证方案由多个认证方式组成，按顺序执行，任意一个返回错误时拒绝调用。
+// 认证方式通过 AuthContext 传递调用者和鉴权结果，例如 sign 设置调用者，enforce 根据调用者鉴权。
+type Authenticator interface {
+	Authenticate(c context.Context, ac *AuthContext) error
+}
+
+// AuthContext 一次调用的认证状态
+type AuthContext struct {
+	Req *dto.QueryReq
+	// 调用的接口，鉴权后替换为只包含已授权子接口的副本
+	Service *model.ServiceAssociations
+	// 调用者，未认证时为 nil
+	Subject *v1.Subject
+	// 调用的应用，未认证出应用时为空
+	App string
+	// 调用者是否由 HMAC 签名认证
+	Signed bool
+	// 是否已授权调用整个接口
+	Authorized bool
+}
+
+func newAuthenticators(
+	appRepo gorm.AppRepo,
+	serviceRepo gorm.ServiceRepo,
+	redis *repository.Redis,
+	authService microservice.AuthServiceRepo,
+	enforceCache *cache.Cache[bool],
+	applicationService configuration_center_gocommon.ApplicationService,
+) map[string]Authenticator {
+	return map[string]Authenticator{
+		settings.AuthModeCssjj:   &cssjjAuthenticator{applicationService: applicationService},
+		settings.AuthModeSign:    &signAuthenticator{appRepo: appRepo, redis: redis},
+		settings.AuthModeOAuth:   oauthAuthenticator{},
+		settings.AuthModeEnforce: &enforceAuthenticator{authService: authService, serviceRepo: serviceRepo, cache: enforceCache},
+	}
+}
+
+// authSchemes 认证方案，key 为方案名称
+type authSchemes struct {
+	schemes map[string][]Authenticator
+	// 接口单独配置的认证方案，key 为接口路径
+	services map[string]string
+}
+
+// newAuthSchemes 根据配置生成认证方案。
+// 未配置 default 时，default 为 modes 中的认证方式加上 auth-service 鉴权。
+// 包含未知认证方式，或者既没有 enforce 也没有显式配置 no-enforce 的方案不会生效，使用该方案的调用会被拒绝
+func newAuthSchemes(conf settings.Auth, authenticators map[string]Authenticator) *authSchemes {
+	modes := conf.Modes
+	if len(modes) == 0 {
+		modes = []string{settings.AuthModeOAuth}
+	}
+	var defaultChain []Authenticator
+	for _, mode := range modes {
+		mode = strings.ToLower(mode)
+		if mode != settings.AuthModeOAuth && mode != settings.AuthModeSign {
+			log.Warnf("unknown auth mode %q", mode)
+			continue
+		}
+		defaultChain = append(defaultChain, authenticators[mode])
+	}
+	defaultChain = append(defaultChain, authenticators[settings.AuthModeEnforce])
+
+	s := &authSchemes{
+		schemes: map[string][]Authenticator{
+			AuthSchemeDefault: defaultChain,
+			AuthSchemeCssjj:   {authenticators[settings.AuthModeCssjj]},
+		},
+		services: conf.Services,
+	}
+	for name, modes := range conf.Schemes {
+		chain := make([]Authenticator, 0, len(modes))
+		var enforce, noEnforce bool
+		for _, mode := range modes {
+			mode = strings.ToLower(mode)
+			if mode == settings.AuthModeNoEnforce {
+				noEnforce = true
+				continue
+			}
+			authenticator, ok := authenticators[mode]
+			if !ok {
+				log.Errorf("auth scheme %q contains unknown auth mode %q", name, mode)
+				chain = nil
+				break
+			}
+			enforce = enforce || mode == settings.AuthModeEnforce
+			chain = append(chain, authenticator)
+		}
+		if enforce == noEnforce {
+			log.Errorf("auth scheme %q must contain exactly one of %q and %q", name, settings.AuthModeEnforce, settings.AuthModeNoEnforce)
+			chain = nil
+		}
+		if len(chain) == 0 {
+			delete(s.schemes, name)
+			continue
+		}
+		s.schemes[name] = chain
+	}
+	return s
+}
+
+// resolve 返回接口使用的认证方式，接口单独配置的认证方案优先
+func (s *authSchemes) resolve(scheme, servicePath string) ([]Authenticator, error) {
+	if name, ok := s.services[servicePath]; ok {
+		scheme = name
+	}
+	if scheme == "" {
+		scheme = AuthSchemeDefault
+	}
+	chain, ok := s.schemes[scheme]
+	if !ok {
+		return nil, errorcode.Desc(errorcode.AuthSchemeNotExist, scheme)
+	}
+	return chain, nil
+}
+
+// authenticate 按认证方案依次执行认证方式，返回鉴权后的接口
+func (s *authSchemes) authenticate(c context.Context, scheme string, req *dto.QueryReq, service *model.ServiceAssociations) (*model.ServiceAssociations, error) {
+	authenticators, err := s.resolve(scheme, req.ServicePath)
+	if err != nil {
+		return nil, err
+	}
+	ac := &AuthContext{Req: req, Service: service}
+	for _, authenticator := range authenticators {
+		err := authenticator.Authenticate(c, ac)
+		// 鉴权失败的调用同样按认证出的调用者记录
+		req.Caller, req.CallerApp, req.CallerSigned = ac.Subject, ac.App, ac.Signed
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ac.Service, nil
+}
+
+// oauthAuthenticator 令牌认证，调用者必须是应用
+type oauthAuthenticator struct{}
+
+func (oauthAuthenticator) Authenticate(c context.Context, ac *AuthContext) error {
+	if ac.Subject != nil {
+		return nil
+	}
+	// 使用签名认证的请求没有令牌，前面的认证方式中没有 sign 时说明未启用签名认证
+	if isSignRequest(ac.Req) {
+		return errorcode.Desc(errorcode.SignAuthDisabled)
+	}
+	// 从 context 获取接调用者的信息，如果获取失败或调用者不是一个应用则禁止调用
+	subject, err := interception.AuthServiceSubjectFromContext(c)
+	if err != nil {
+		return err
+	}
+	if subject.Type != v1.SubjectAPP {
+		return errorcode.Desc(errorcode.ServiceApplyNotPass)
+	}
+	ac.Subject, ac.App = subject, subject.ID
+	return nil
+}
+
+// enforceAuthenticator 向 auth-service 鉴权。
+// 未授权整个接口时，查询调用者已授权的子接口(行列规则)，只能获取子接口授权的行和列
+type enforceAuthenticator struct {
+	authService microservice.AuthServiceRepo
+	serviceRepo gorm.ServiceRepo
+	// 鉴权结果的缓存，key 为 enforceCacheKey
+	cache *cache.Cache[bool]
+}
+
+func (a *enforceAuthenticator) Authenticate(c context.Context, ac *AuthContext) error {
+	// 前面的认证方式都没有认证调用者，说明请求没有签名且未启用令牌认证
+	if ac.Subject == nil {
+		return errorcode.Desc(errorcode.OAuthDisabled)
+	}
+	subject, service := ac.Subject, ac.Service
+
+	// 签名的 app 所属的用户是接口的 Owner 时，认为有调用权限，不需要向 auth-service 鉴权
+	authorized := ac.Authorized || subject.Type == v1.SubjectUser && subject.ID == service.OwnerID
+	if !authorized {
+		var err error
+		authorized, err = a.cache.GetOrLoad(c, enforceCacheKey(service.ServiceID, subject), func(ctx context.Context) (bool, error) {
+			enforce := microservice.Enforce{
+				SubjectType: string(subject.Type),
+				SubjectId:   subject.ID,
+				ObjectType:  "api",
+				ObjectId:    service.ServiceID,
+				Action:      "read",
+			}
+			resp, err := a.authService.Enforce(ctx, []microservice.Enforce{enforce})
+			if err != nil {
+				return false, err
+			}
+			return resp[0], nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	log.Infof("%v %v authorized result %v", subject.Type, subject.ID, authorized)
+
+	var subServices []model.SubService
+	if !authorized {
+		var err error
+		subServices, err = a.queryUserAuthedSubServices(c, service.ServiceID, subject)
+		if err != nil {
+			return err
+		}
+		if len(subServices) == 0 {
+			return errorcode.Desc(errorcode.ServiceApplyNotPass)
+		}
+	}
+
+	// ServiceGet 返回的是缓存中共享的对象，替换子接口前先复制一份
+	authedService := *service
+	authedService.SubServices = subServices
+	ac.Service = &authedService
+	ac.Authorized = authorized
+	return nil
+}
+
+func (a *enforceAuthenticator) queryUserAuthedSubServices(c context.Context, serviceID string, subject *v1.Subject) (subServiceModels []model.SubService, err error) {
+	//查询可授权的子规则
+	objectEntries, err := a.authService.SubjectObjects(c, "sub_service", subject.ID, string(subject.Type))
+	if err != nil {
+		return nil, err
+	}
+	if len(objectEntries.Entries) <= 0 {
+		return nil, nil
+	}
+	//查询用户有的子规则，如果有子规则的读取权限，才可以读取，然后将合并子规则，然后再执行
+	subServices, err := a.serviceRepo.GetSubServices(c, serviceID)
+	if err != nil {
+		return nil, errorcode.Detail(errorcode.PublicDatabaseError, err.Error())
+	}
+	if len(subServices) <= 0 {
+		return nil, nil
+	}
+	authedObjectDict := make(map[string]int)
+	for _, object := range objectEntries.Entries {
+		if len(object.Permissions) <= 0 {
+			continue
+		}
+		for _, p := range object.Permissions {
+			if !(p.Action == string(v1.ActionRead) && p.Effect == string(v1.PolicyAllow)) {
+				continue
+			}
+			authedObjectDict[object.ObjectId] = 1
+		}
+	}
+	subServices = lo.Filter(subServices, func(item *model.SubService, index int) bool {
+		return authedObjectDict[item.ID.String()] > 0
+	})
+	return lo.Times(len(subServices), func(index int) model.SubService {
+		return *subServices[index]
+	}), nil
+}
+
+// nonce 在 redis 中的 key 前缀，超过时间戳误差范围的请求会被拒绝，nonce 只需保存两倍的误差范围
+const (
+	signNonceKeyPrefix = "data-application-gateway-nonce:"
+	signNonceTTL       = timestampToleration * 2
+)
+
+// signAuthenticator HMAC 签名认证，调用者为签名的 app 所属的用户。
+// 请求没有签名时跳过，由后面的认证方式认证
+type signAuthenticator struct {
+	appRepo gorm.AppRepo
+	redis   *repository.Redis
+}
+
+func (a *signAuthenticator) Authenticate(c context.Context, ac *AuthContext) error {
+	if ac.Subject != nil || !isSignRequest(ac.Req) {
+		return nil
+	}
+	app, err := a.verify(c, ac.Req)
+	if err != nil {
+		return err
+	}
+	ac.Subject = &v1.Subject{ID: app.UID, Type: v1.SubjectUser}
+	ac.App = app.AppID
+	ac.Signed = true
+	return nil
+}
+
+// isSignRequest 请求是否使用 HMAC 签名认证，即 Authorization 以签名算法开头
+func isSignRequest(req *dto.QueryReq) bool {
+	if req.Request == nil {
+		return false
+	}
+	return strings.HasPrefix(req.Request.Header.Get(enum.HeaderAuthorization), enum.SignAlgorithm+" ")
+}
+
+// verify 校验时间戳、签名和 nonce，返回签名的 app
+func (a *signAuthenticator) verify(c context.Context, req *dto.QueryReq) (app *model.App, err error) {
+	authorization, err := util.ParseAuthorization(req.Request.Header.Get(enum.HeaderAuthorization))
+	if err != nil {
+		return nil, err
+	}
+
+	//时间戳是否存在
+	if authorization.Timestamp == "" {
+		return nil, errorcode.Desc(errorcode.TimestampRequired)
+	}
+	//时间戳解析
+	timestampInt, err := strconv.ParseInt(authorization.Timestamp, 10, 64)
+	if err != nil {
+		return nil, errorcode.Desc(errorcode.TimestampError)
+	}
+	// 检查时间戳是否处于误差范围内
+	if !isTimeInDelta(time.Now(), time.Unix(timestampInt, 0), timestampToleration) {
+		return nil, errorcode.Desc(errorcode.TimestampExpired)
+	}
+
+	//appid是否存在
+	if authorization.AppId == "" {
+		return nil, errorcode.Desc(errorcode.AppIdRequired)
+	}
+	app, err = a.appRepo.Get(c, authorization.AppId)
+	if err != nil {
+		return nil, errorcode.Detail(errorcode.PublicDatabaseError, err)
+	}
+	if app == nil || app.AppID == "" || app.AppSecret == "" {
+		return nil, errorcode.Desc(errorcode.AppIdNotExist)
+	}
+
+	//验证请求签名
+	if !util.HttpSignValidate(req.Request, app.AppSecret, authorization) {
+		log.WithContext(c).Warn("signAuthenticator signature mismatch", zap.String("appid", authorization.AppId))
+		return nil, errorcode.Desc(errorcode.SignValidateError)
+	}
+
+	//随机请求串是否使用过，签名通过后再记录，避免伪造的请求占用 nonce
+	if authorization.Nonce == "" {
+		return nil, errorcode.Desc(errorcode.SignValidateError)
+	}
+	ok, err := a.redis.Client.SetNX(c, signNonceKeyPrefix+authorization.AppId+":"+authorization.Nonce, 1, signNonceTTL).Result()
+	if err != nil {
+		log.WithContext(c).Error("signAuthenticator SetNX", zap.Error(err))
+		return nil, errorcode.Detail(errorcode.PublicInternalError, err.Error())
+	}
+	if !ok {
+		return nil, errorcode.Desc(errorcode.SignNonceUsed)
+	}
+
+	return app, nil
+}
+
+// cssjjAuthenticator 长沙鉴权逻辑，校验 x-tif-* 签名并生成响应的签名头
+type cssjjAuthenticator struct {
+	applicationService configuration_center_gocommon.ApplicationService
+}
+
+func (a *cssjjAuthenticator) Authenticate(c context.Context, ac *AuthContext) (err error) {
+	req, service := ac.Req, ac.Service
+	var xTifSignature, xTifTimestamp, xTifNonce string
+	var ok bool
+
+	// 获取参数
+	if param, exists := req.Params["x-tif-signature"]; exists {
+		xTifSignature, ok = param.Value.(string)
+		if !ok {
+			log.WithContext(c).Error("cssjjAuth", zap.String("x-tif-signature", "x-tif-signature值不存在"))
+			return errorcode.Desc(errorcode.ServiceApplyNotPassCssjj + "x-tif-signature值不存在")
+		}
+	} else {
+		log.WithContext(c).Error("cssjjAuth", zap.String("x-tif-signature", "x-tif-signature不存在"))
+		return errorcode.Desc(errorcode.ServiceApplyNotPassCssjj + "x-tif-signature不存在")
+	}
+	if param, exists := req.Params["x-tif-timestamp"]; exists {
+		xTifTimestamp, ok = param.Value.(string)
+		if !ok {
+			log.WithContext(c).Error("cssjjAuth", zap.String("x-tif-timestamp", "x-tif-timestamp值不存在"))
+			return errorcode.Desc(errorcode.ServiceApplyNotPassCssjj + "x-tif-timestamp值不存在")
+		}
+	} else {
+		log.WithContext(c).Error("cssjjAuth", zap.String("x-tif-timestamp", "x-tif-timestamp不存在"))
+		return errorcode.Desc(errorcode.ServiceApplyNotPassCssjj + "x-tif-timestamp不存在")
+	}
+	if param, exists := req.Params["x-tif-nonce"]; exists {
+		xTifNonce, ok = param.Value.(string)
+		if !ok {
+			log.WithContext(c).Error("cssjjAuth", zap.String("x-tif-nonce", "x-tif-nonce值不存在"))
+			return errorcode.Desc(errorcode.ServiceApplyNotPassCssjj + "x-tif-nonce值不存在")
+		}
+	} else {
+		log.WithContext(c).Error("cssjjAuth", zap.String("x-tif-nonce", "x-tif-nonce不存在"))
+		return errorcode.Desc(errorcode.ServiceApplyNotPassCssjj + "x-tif-nonce不存在")
+	}
+
+	// 获取 secret
+	if service.AppsID == nil {
+		log.WithContext(c).Error("cssjjAuth", zap.String("service.AppsID", "service.AppsID为空"))
+		return errorcode.Desc(errorcode.ServiceApplyNotPassCssjj + "service.AppsID为空")
+	}
+
+	application, err := a.applicationService.GetApplicationInternal(c, *service.AppsID)
+	if err != nil {
+		log.WithContext(c).Error("cssjjAuth", zap.String("application", "application不存在"))
+		return errorcode.Desc(errorcode.ServiceApplyNotPassCssjj + "application不存在")
+	}
+	if application == nil || application.Token == "" {
+		log.WithContext(c).Error("cssjjAuth", zap.String("application.Token", "application.Token不存在"))
+		return errorcode.Desc(errorcode.ServiceApplyNotPassCssjj + "application.Token不存在")
+	}
+	secret := application.Token
+
+	// 校验签名
+	ok, resHeaders := checkSign(secret, xTifTimestamp, xTifNonce, xTifSignature)
+	if !ok {
+		log.WithContext(c).Error("cssjjAuth", zap.String("签名校验失败", "x-tif-signature="+resHeaders["x-tif-signature"]+" x-tif-timestamp="+resHeaders["x-tif-timestamp"]+" x-tif-nonce="+resHeaders["x-tif-nonce"]))
+		return errorcode.Desc(errorcode.ServiceApplyNotPassCssjj + "签名校验失败：x-tif-signature=" + resHeaders["x-tif-signature"] + " x-tif-timestamp=" + resHeaders["x-tif-timestamp"] + " x-tif-nonce=" + resHeaders["x-tif-nonce"])
+	}
+
+	// 更新请求头
+	for k, v := range resHeaders {
+		req.Params[k] = dto.NewParam(v, dto.ParamPositionHeader, dto.ParamDataTypeString)
+	}
+	// 签名密钥是接口所属应用的令牌，调用应用即接口的应用
+	ac.App = *service.AppsID
+
+	return nil
+}
+
+// 长沙签名校验逻辑
+func checkSign(secret, timestamp, nonce, sign string) (bool, map[string]string) {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false, nil
+	}
+	now := time.Now().Unix()
+	if ts > now+180 || ts < now-180 {
+		return false, nil
+	}
+	log.Info("checkSign", zap.String("timestamp", timestamp), zap.String("secret", secret), zap.String("nonce", nonce), zap.String("sign", sign))
+	signData := fmt.Sprintf("%s%s%s%s", timestamp, secret, nonce, timestamp)
+	res := strings.ToUpper(fmt.Sprintf("%x", sha256.Sum256([]byte(signData))))
+
+	// 生成一个类似于 Math.random().toString(36).substr(2) 的随机字符串
+
+	resNonce := randomNonce()
+	resTimestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	resSign := strings.ToUpper(fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("%s%s%s%s", resTimestamp, secret, resNonce, resTimestamp)))))
+
+	resHeaders := map[string]string{
+		"x-tif-signature": resSign,
+		"x-tif-timestamp": resTimestamp,
+		"x-tif-nonce":     resNonce,
+	}
+	return res == strings.ToUpper(sign), resHeaders
+}
+func randomNonce() string {
+	n := rand.Int63() // 生成一个随机int64
+	return strings.TrimLeft(strconv.FormatInt(n, 36), "0")
+}
@@ -0,0 +1,448 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/cache"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/gorm"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/microservice"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/enum"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/util"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+	v1 "github.com/kweaver-ai/idrm-go-common/api/auth-service/v1"
+	"github.com/kweaver-ai/idrm-go-common/interception"
+	configuration_center_gocommon "github.com/kweaver-ai/idrm-go-common/rest/configuration_center"
+	"github.com/kweaver-ai/idrm-go-frame/core/errorx/agerrors"
+)
+
+type fakeAppRepo struct {
+	gorm.AppRepo
+	apps map[string]*model.App
+}
+
+func (f *fakeAppRepo) Get(_ context.Context, appId string) (*model.App, error) {
+	if app, ok := f.apps[appId]; ok {
+		return app, nil
+	}
+	return &model.App{}, nil
+}
+
+type fakeAuthServiceRepo struct {
+	// 是否授权整个接口
+	allow bool
+	// 已授权的子接口 ID
+	subServiceIDs []string
+
+	enforces []microservice.Enforce
+}
+
+func (f *fakeAuthServiceRepo) Enforce(_ context.Context, enforcesReq []microservice.Enforce) ([]bool, error) {
+	f.enforces = append(f.enforces, enforcesReq...)
+	return []bool{f.allow}, nil
+}
+
+func (f *fakeAuthServiceRepo) SubjectObjects(_ context.Context, objectType, subjectId, subjectType string) (*microservice.SubjectObjectsRes, error) {
+	res := &microservice.SubjectObjectsRes{}
+	for _, id := range f.subServiceIDs {
+		res.Entries = append(res.Entries, struct {
+			ObjectId    string `json:"object_id"`
+			ObjectType  string `json:"object_type"`
+			Permissions []struct {
+				Action string `json:"action"`
+				Effect string `json:"effect"`
+			} `json:"permissions"`
+		}{
+			ObjectId:   id,
+			ObjectType: objectType,
+			Permissions: []struct {
+				Action string `json:"action"`
+				Effect string `json:"effect"`
+			}{{Action: string(v1.ActionRead), Effect: string(v1.PolicyAllow)}},
+		})
+	}
+	res.TotalCount = len(res.Entries)
+	return res, nil
+}
+
+type fakeApplicationService struct {
+	configuration_center_gocommon.ApplicationService
+	apps map[string]*configuration_center_gocommon.Apps
+}
+
+func (f *fakeApplicationService) GetApplicationInternal(_ context.Context, id string) (*configuration_center_gocommon.Apps, error) {
+	if app, ok := f.apps[id]; ok {
+		return app, nil
+	}
+	return nil, errors.New("application not found")
+}
+
+type fakeSubServiceRepo struct {
+	gorm.ServiceRepo
+	subServices []*model.SubService
+}
+
+func (f *fakeSubServiceRepo) GetSubServices(_ context.Context, serviceID string) ([]*model.SubService, error) {
+	return f.subServices, nil
+}
+
+const (
+	testAppID     = "1662322293948416"
+	testAppSecret = "23d72b82191944e8a9a7f0cb4ffca3a9"
+	testAppsID    = "f5600699-b4c8-443e-a37e-39e3fd5d2159"
+	testAppsToken = "cssjj-token"
+)
+
+var (
+	testSubServiceA = uuid.MustParse("7f1c2a50-0000-4000-8000-00000000000a")
+	testSubServiceB = uuid.MustParse("7f1c2a50-0000-4000-8000-00000000000b")
+)
+
+func newTestAuthenticators(authService *fakeAuthServiceRepo) map[string]Authenticator {
+	return newAuthenticators(
+		&fakeAppRepo{apps: map[string]*model.App{
+			testAppID:   {AppID: testAppID, AppSecret: testAppSecret, UID: "user-1"},
+			"no-secret": {AppID: "no-secret"},
+		}},
+		&fakeSubServiceRepo{subServices: []*model.SubService{
+			{ID: testSubServiceA, RowFilterClause: "a = 1"},
+			{ID: testSubServiceB, RowFilterClause: "b = 1"},
+		}},
+		// 不可用的 redis，签名通过后记录 nonce 失败
+		&repository.Redis{Client: redis.NewUniversalClient(&redis.UniversalOptions{Addrs: []string{"127.0.0.1:1"}, DialTimeout: 100 * time.Millisecond})},
+		authService,
+		// 只使用进程内缓存
+		cache.New[bool](enforceCacheName, nil, cache.NewOptions(settings.Cache{}, 0, defaultEnforceCacheTTL)),
+		&fakeApplicationService{apps: map[string]*configuration_center_gocommon.Apps{
+			testAppsID: {ID: testAppsID, Token: testAppsToken},
+		}},
+	)
+}
+
+func newTestSignRequest(authorization *util.Authorization, secret string) *dto.QueryReq {
+	r := httptest.NewRequest(http.MethodPost, "/data-application-gateway/orders?b=2&a=1", strings.NewReader(`{"id":1}`))
+	if secret != "" {
+		authorization.Signature = util.HttpSignGenerate(r, secret, authorization)
+	}
+	r.Header.Set(enum.HeaderAuthorization, fmt.Sprintf("%s appid=%s,timestamp=%s,nonce=%s,signature=%s",
+		enum.SignAlgorithm, authorization.AppId, authorization.Timestamp, authorization.Nonce, authorization.Signature))
+	return &dto.QueryReq{ServicePath: "orders", Params: map[string]*dto.Param{}, Request: r}
+}
+
+func newTestCssjjRequest(secret string, timestamp time.Time) *dto.QueryReq {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	nonce := "abc123"
+	signature := fmt.Sprintf("%X", sha256.Sum256([]byte(ts+secret+nonce+ts)))
+	return &dto.QueryReq{ServicePath: "orders", Params: map[string]*dto.Param{
+		"x-tif-signature": dto.NewParam(signature, dto.ParamPositionHeader, dto.ParamDataTypeString),
+		"x-tif-timestamp": dto.NewParam(ts, dto.ParamPositionHeader, dto.ParamDataTypeString),
+		"x-tif-nonce":     dto.NewParam(nonce, dto.ParamPositionHeader, dto.ParamDataTypeString),
+	}}
+}
+
+func newTestService() *model.ServiceAssociations {
+	appsID := testAppsID
+	return &model.ServiceAssociations{Service: model.Service{ServiceID: "service-1", ServicePath: "orders", OwnerID: "owner-1", AppsID: &appsID}}
+}
+
+func Test_signAuthenticator_verify(t *testing.T) {
+	a := newTestAuthenticators(&fakeAuthServiceRepo{})[settings.AuthModeSign].(*signAuthenticator)
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	expired := strconv.FormatInt(time.Now().Add(-timestampToleration-time.Minute).Unix(), 10)
+	tests := []struct {
+		name          string
+		authorization util.Authorization
+		secret        string
+		wantCode      string
+	}{
+		{name: "no timestamp", authorization: util.Authorization{AppId: testAppID, Nonce: "n"}, secret: testAppSecret, wantCode: errorcode.TimestampRequired},
+		{name: "invalid timestamp", authorization: util.Authorization{AppId: testAppID, Timestamp: "abc", Nonce: "n"}, secret: testAppSecret, wantCode: errorcode.TimestampError},
+		{name: "expired timestamp", authorization: util.Authorization{AppId: testAppID, Timestamp: expired, Nonce: "n"}, secret: testAppSecret, wantCode: errorcode.TimestampExpired},
+		{name: "no appid", authorization: util.Authorization{Timestamp: now, Nonce: "n"}, secret: testAppSecret, wantCode: errorcode.AppIdRequired},
+		{name: "unknown appid", authorization: util.Authorization{AppId: "unknown", Timestamp: now, Nonce: "n"}, secret: testAppSecret, wantCode: errorcode.AppIdNotExist},
+		{name: "app without secret", authorization: util.Authorization{AppId: "no-secret", Timestamp: now, Nonce: "n"}, secret: testAppSecret, wantCode: errorcode.AppIdNotExist},
+		{name: "wrong secret", authorization: util.Authorization{AppId: testAppID, Timestamp: now, Nonce: "n"}, secret: "wrong", wantCode: errorcode.SignValidateError},
+		{name: "no nonce", authorization: util.Authorization{AppId: testAppID, Timestamp: now}, secret: testAppSecret, wantCode: errorcode.SignValidateError},
+		{name: "valid signature, redis unavailable", authorization: util.Authorization{AppId: testAppID, Timestamp: now, Nonce: "n"}, secret: testAppSecret, wantCode: errorcode.PublicInternalError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTestSignRequest(&tt.authorization, tt.secret)
+			require.True(t, isSignRequest(req))
+			_, err := a.verify(context.Background(), req)
+			require.Error(t, err)
+			assert.Equal(t, tt.wantCode, agerrors.Code(err).GetErrorCode())
+		})
+	}
+}
+
+func Test_authSchemes_resolve(t *testing.T) {
+	authenticators := newTestAuthenticators(&fakeAuthServiceRepo{})
+	sign, oauth, enforce, cssjj := authenticators[settings.AuthModeSign], authenticators[settings.AuthModeOAuth], authenticators[settings.AuthModeEnforce], authenticators[settings.AuthModeCssjj]
+
+	tests := []struct {
+		name        string
+		conf        settings.Auth
+		scheme      string
+		servicePath string
+		want        []Authenticator
+		wantErr     bool
+	}{
+		{name: "empty modes", conf: settings.Auth{}, want: []Authenticator{oauth, enforce}},
+		{name: "modes", conf: settings.Auth{Modes: []string{"Sign", "oauth", "unknown"}}, scheme: AuthSchemeDefault, want: []Authenticator{sign, oauth, enforce}},
+		{name: "cssjj", conf: settings.Auth{}, scheme: AuthSchemeCssjj, want: []Authenticator{cssjj}},
+		{
+			name:   "custom scheme",
+			conf:   settings.Auth{Schemes: map[string][]string{"region": {"cssjj", "oauth", "enforce"}}},
+			scheme: "region",
+			want:   []Authenticator{cssjj, oauth, enforce},
+		},
+		{
+			name:        "service scheme overrides configuration center",
+			conf:        settings.Auth{Services: map[string]string{"orders": AuthSchemeCssjj}},
+			scheme:      AuthSchemeDefault,
+			servicePath: "orders",
+			want:        []Authenticator{cssjj},
+		},
+		{
+			name:    "scheme with unknown mode is disabled",
+			conf:    settings.Auth{Schemes: map[string][]string{AuthSchemeDefault: {"sign", "ldap", "enforce"}}},
+			scheme:  AuthSchemeDefault,
+			wantErr: true,
+		},
+		{
+			name:    "scheme without enforce is disabled",
+			conf:    settings.Auth{Schemes: map[string][]string{AuthSchemeDefault: {"sign"}}},
+			scheme:  AuthSchemeDefault,
+			wantErr: true,
+		},
+		{
+			name:    "overridden cssjj scheme without enforce is disabled",
+			conf:    settings.Auth{Schemes: map[string][]string{AuthSchemeCssjj: {"cssjj"}}},
+			scheme:  AuthSchemeCssjj,
+			wantErr: true,
+		},
+		{
+			name:   "scheme opts out of enforce explicitly",
+			conf:   settings.Auth{Schemes: map[string][]string{"region": {"cssjj", "No-Enforce"}}},
+			scheme: "region",
+			want:   []Authenticator{cssjj},
+		},
+		{
+			name:    "scheme with both enforce and no-enforce is disabled",
+			conf:    settings.Auth{Schemes: map[string][]string{"region": {"oauth", "enforce", "no-enforce"}}},
+			scheme:  "region",
+			wantErr: true,
+		},
+		{
+			name:    "scheme with only no-enforce is disabled",
+			conf:    settings.Auth{Schemes: map[string][]string{"region": {"no-enforce"}}},
+			scheme:  "region",
+			wantErr: true,
+		},
+		{name: "unknown scheme", conf: settings.Auth{}, scheme: "unknown", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newAuthSchemes(tt.conf, authenticators).resolve(tt.scheme, tt.servicePath)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, errorcode.AuthSchemeNotExist, agerrors.Code(err).GetErrorCode())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_authSchemes_authenticate(t *testing.T) {
+	appSubject := &v1.Subject{ID: "app-1", Type: v1.SubjectAPP}
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		conf        settings.Auth
+		scheme      string
+		subject     *v1.Subject
+		req         *dto.QueryReq
+		authService *fakeAuthServiceRepo
+		// 期望的错误码，为空时期望认证通过
+		wantCode string
+		// 期望的已授权子接口，nil 表示授权整个接口
+		wantSubServices []uuid.UUID
+		wantEnforce     bool
+		// 期望记录到请求中的调用者
+		wantCaller *v1.Subject
+		// 期望记录到请求中的调用应用
+		wantCallerApp string
+	}{
+		{
+			name:          "oauth app authorized",
+			scheme:        AuthSchemeDefault,
+			subject:       appSubject,
+			req:           &dto.QueryReq{ServicePath: "orders"},
+			authService:   &fakeAuthServiceRepo{allow: true},
+			wantEnforce:   true,
+			wantCaller:    appSubject,
+			wantCallerApp: "app-1",
+		},
+		{
+			name:            "oauth app authorized sub services only",
+			scheme:          AuthSchemeDefault,
+			subject:         appSubject,
+			req:             &dto.QueryReq{ServicePath: "orders"},
+			authService:     &fakeAuthServiceRepo{subServiceIDs: []string{testSubServiceB.String()}},
+			wantSubServices: []uuid.UUID{testSubServiceB},
+			wantEnforce:     true,
+			wantCaller:      appSubject,
+			wantCallerApp:   "app-1",
+		},
+		{
+			name:          "oauth app not authorized",
+			scheme:        AuthSchemeDefault,
+			subject:       appSubject,
+			req:           &dto.QueryReq{ServicePath: "orders"},
+			authService:   &fakeAuthServiceRepo{},
+			wantCode:      errorcode.ServiceApplyNotPass,
+			wantEnforce:   true,
+			wantCaller:    appSubject,
+			wantCallerApp: "app-1",
+		},
+		{
+			name:        "oauth user is rejected",
+			scheme:      AuthSchemeDefault,
+			subject:     &v1.Subject{ID: "owner-1", Type: v1.SubjectUser},
+			req:         &dto.QueryReq{ServicePath: "orders"},
+			authService: &fakeAuthServiceRepo{allow: true},
+			wantCode:    errorcode.ServiceApplyNotPass,
+		},
+		{
+			name:        "sign request when sign disabled",
+			scheme:      AuthSchemeDefault,
+			req:         newTestSignRequest(&util.Authorization{AppId: testAppID, Timestamp: "1", Nonce: "n"}, testAppSecret),
+			authService: &fakeAuthServiceRepo{allow: true},
+			wantCode:    errorcode.SignAuthDisabled,
+		},
+		{
+			name:        "token request when oauth disabled",
+			conf:        settings.Auth{Modes: []string{settings.AuthModeSign}},
+			scheme:      AuthSchemeDefault,
+			subject:     appSubject,
+			req:         &dto.QueryReq{ServicePath: "orders"},
+			authService: &fakeAuthServiceRepo{allow: true},
+			wantCode:    errorcode.OAuthDisabled,
+		},
+		{
+			name:          "cssjj",
+			scheme:        AuthSchemeCssjj,
+			req:           newTestCssjjRequest(testAppsToken, now),
+			authService:   &fakeAuthServiceRepo{},
+			wantCallerApp: testAppsID,
+		},
+		{
+			name:        "cssjj wrong signature",
+			scheme:      AuthSchemeCssjj,
+			req:         newTestCssjjRequest("wrong", now),
+			authService: &fakeAuthServiceRepo{},
+			wantCode:    errorcode.PublicInternalError,
+		},
+		{
+			name:          "cssjj chained with enforce",
+			conf:          settings.Auth{Schemes: map[string][]string{"region": {"cssjj", "oauth", "enforce"}}},
+			scheme:        "region",
+			subject:       appSubject,
+			req:           newTestCssjjRequest(testAppsToken, now),
+			authService:   &fakeAuthServiceRepo{},
+			wantCode:      errorcode.ServiceApplyNotPass,
+			wantEnforce:   true,
+			wantCaller:    appSubject,
+			wantCallerApp: "app-1",
+		},
+		{
+			name:        "cssjj chained with enforce, signature checked first",
+			conf:        settings.Auth{Schemes: map[string][]string{"region": {"cssjj", "oauth", "enforce"}}},
+			scheme:      "region",
+			subject:     appSubject,
+			req:         newTestCssjjRequest("wrong", now),
+			authService: &fakeAuthServiceRepo{allow: true},
+			wantCode:    errorcode.PublicInternalError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := context.Background()
+			if tt.subject != nil {
+				c = interception.NewContextWithAuthServiceSubject(c, tt.subject)
+			}
+			s := newAuthSchemes(tt.conf, newTestAuthenticators(tt.authService))
+			service := newTestService()
+
+			got, err := s.authenticate(c, tt.scheme, tt.req, service)
+			assert.Equal(t, tt.wantEnforce, len(tt.authService.enforces) > 0)
+			assert.Equal(t, tt.wantCaller, tt.req.Caller)
+			assert.Equal(t, tt.wantCallerApp, tt.req.CallerApp)
+			if tt.wantCode != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantCode, agerrors.Code(err).GetErrorCode())
+				return
+			}
+			require.NoError(t, err)
+
+			var gotSubServices []uuid.UUID
+			for _, subService := range got.SubServices {
+				gotSubServices = append(gotSubServices, subService.ID)
+			}
+			assert.Equal(t, tt.wantSubServices, gotSubServices)
+			// 缓存中的接口不能被修改
+			assert.Nil(t, service.SubServices)
+		})
+	}
+}
+
+func Test_cssjjAuthenticator_responseHeaders(t *testing.T) {
+	a := newTestAuthenticators(&fakeAuthServiceRepo{})[settings.AuthModeCssjj]
+	req := newTestCssjjRequest(testAppsToken, time.Now())
+	signature := req.Params["x-tif-signature"].Value
+
+	require.NoError(t, a.Authenticate(context.Background(), &AuthContext{Req: req, Service: newTestService()}))
+	// 认证通过后替换为网关生成的签名，由 controller 写入响应头
+	assert.NotEqual(t, signature, req.Params["x-tif-signature"].Value)
+	ts := req.Params["x-tif-timestamp"].Value.(string)
+	nonce := req.Params["x-tif-nonce"].Value.(string)
+	assert.Equal(t, fmt.Sprintf("%X", sha256.Sum256([]byte(ts+testAppsToken+nonce+ts))), req.Params["x-tif-signature"].Value)
+
+	// 接口未关联应用
+	service := newTestService()
+	service.AppsID = nil
+	assert.Error(t, a.Authenticate(context.Background(), &AuthContext{Req: newTestCssjjRequest(testAppsToken, time.Now()), Service: service}))
+}
+
+func Test_enforceAuthenticator_owner(t *testing.T) {
+	authService := &fakeAuthServiceRepo{}
+	a := newTestAuthenticators(authService)[settings.AuthModeEnforce]
+	ac := &AuthContext{Req: &dto.QueryReq{}, Service: newTestService(), Subject: &v1.Subject{ID: "owner-1", Type: v1.SubjectUser}}
+
+	// 签名的 app 所属的用户是接口的 Owner 时不需要向 auth-service 鉴权
+	require.NoError(t, a.Authenticate(context.Background(), ac))
+	assert.True(t, ac.Authorized)
+	assert.Empty(t, authService.enforces)
+}
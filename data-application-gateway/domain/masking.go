@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/spf13/cast"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/enum"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+)
+
+// maskingOverrideValue override 规则统一输出的掩码，长度固定，避免泄露原值长度
+const maskingOverrideValue = "******"
+
+// responseMasker 按返回参数配置的脱敏规则处理查询结果
+type responseMasker struct {
+	// map[字段名(小写)]脱敏规则
+	rules map[string]string
+	// hash 脱敏的 HMAC 密钥
+	hashKey []byte
+}
+
+func newResponseMasker(serviceParams []model.ServiceParam, hashKey []byte) *responseMasker {
+	rules := make(map[string]string)
+	for _, p := range serviceParams {
+		if p.ParamType != "response" {
+			continue
+		}
+		if p.Masking == "" || p.Masking == enum.MaskingPlaintext {
+			continue
+		}
+		rules[strings.ToLower(p.EnName)] = p.Masking
+	}
+	return &responseMasker{rules: rules, hashKey: hashKey}
+}
+
+// mask 对查询结果逐行脱敏，直接修改传入的数据
+func (m *responseMasker) mask(rows []map[string]interface{}) {
+	if len(m.rules) == 0 {
+		return
+	}
+	for _, row := range rows {
+		m.maskRow(row)
+	}
+}
+
+// maskRow 对单行数据脱敏，虚拟化引擎返回的列名大小写可能与配置不一致，按小写匹配
+func (m *responseMasker) maskRow(row map[string]interface{}) {
+	if len(m.rules) == 0 {
+		return
+	}
+	for column, value := range row {
+		rule, ok := m.rules[strings.ToLower(column)]
+		if !ok {
+			continue
+		}
+		row[column] = maskValue(rule, value, m.hashKey)
+	}
+}
+
+// maskValue 按脱敏规则处理单个值，空值不处理，脱敏后的值统一为字符串。
+// hash 使用 HMAC-SHA256，未配置密钥时不输出摘要，与 override 一样输出固定的掩码
+func maskValue(rule string, value interface{}, hashKey []byte) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	switch rule {
+	case enum.MaskingHash:
+		if len(hashKey) == 0 {
+			return maskingOverrideValue
+		}
+		h := hmac.New(sha256.New, hashKey)
+		h.Write([]byte(cast.ToString(value)))
+		return hex.EncodeToString(h.Sum(nil))
+	case enum.MaskingOverride:
+		return maskingOverrideValue
+	case enum.MaskingReplace:
+		return maskMiddle(cast.ToString(value))
+	}
+
+	return value
+}
+
+// maskMiddle 保留首尾各 1/4 (至少 1 个字符)，中间用 * 替换，长度不变
+//
+//	13812345678 => 13*******78
+//	王小明 => 王*明
+//	张三 => 张*
+func maskMiddle(s string) string {
+	runes := []rune(s)
+	n := len(runes)
+	switch n {
+	case 0:
+		return s
+	case 1:
+		return "*"
+	case 2:
+		return string(runes[0]) + "*"
+	}
+
+	keep := n / 4
+	if keep < 1 {
+		keep = 1
+	}
+	return string(runes[:keep]) + strings.Repeat("*", n-2*keep) + string(runes[n-keep:])
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/enum"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+)
+
+// testHashKey 测试使用的 hash 脱敏密钥
+var testHashKey = []byte("test-key")
+
+func Test_maskValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		value   interface{}
+		hashKey []byte
+		want    interface{}
+	}{
+		// plaintext
+		{name: "plaintext string", rule: enum.MaskingPlaintext, value: "13812345678", want: "13812345678"},
+		{name: "plaintext int", rule: enum.MaskingPlaintext, value: json.Number("42"), want: json.Number("42")},
+		{name: "plaintext float", rule: enum.MaskingPlaintext, value: json.Number("3.14"), want: json.Number("3.14")},
+		{name: "plaintext boolean", rule: enum.MaskingPlaintext, value: true, want: true},
+		{name: "plaintext nil", rule: enum.MaskingPlaintext, value: nil, want: nil},
+		{name: "empty rule", rule: "", value: "abc", want: "abc"},
+		{name: "unknown rule", rule: "unknown", value: "abc", want: "abc"},
+
+		// hash
+		{name: "hash string", rule: enum.MaskingHash, value: "abc", want: "5d0ea494ece26078f3d279ea524a2dd1c6525fa4719127a4e72fac5fb0e3a9be"},
+		{name: "hash empty string", rule: enum.MaskingHash, value: "", want: "2711cc23e9ab1b8a9bc0fe991238da92671624a9ebdaf1c1abec06e7e9a14f9b"},
+		{name: "hash int", rule: enum.MaskingHash, value: json.Number("42"), want: "29a6eb6f6fbb448754e3d58fd69b4abf9dc0e7e82ede86a72cb5162a5aeaa648"},
+		{name: "hash float", rule: enum.MaskingHash, value: json.Number("3.14"), want: "9e1a74718eb66686776cd3fabf9808cdef7fc0add622970b28f82305bdbbd4cd"},
+		{name: "hash boolean", rule: enum.MaskingHash, value: true, want: "d0ce4dcd4c713596c357aefc1b582d84f74d2f89f7cff1dd26fd6adc687b80b6"},
+		{name: "hash nil", rule: enum.MaskingHash, value: nil, want: nil},
+		{name: "hash without key", rule: enum.MaskingHash, value: "abc", hashKey: []byte{}, want: "******"},
+
+		// override
+		{name: "override string", rule: enum.MaskingOverride, value: "13812345678", want: "******"},
+		{name: "override short string", rule: enum.MaskingOverride, value: "a", want: "******"},
+		{name: "override int", rule: enum.MaskingOverride, value: json.Number("42"), want: "******"},
+		{name: "override float", rule: enum.MaskingOverride, value: json.Number("3.14"), want: "******"},
+		{name: "override boolean", rule: enum.MaskingOverride, value: false, want: "******"},
+		{name: "override nil", rule: enum.MaskingOverride, value: nil, want: nil},
+
+		// replace
+		{name: "replace phone", rule: enum.MaskingReplace, value: "13812345678", want: "13*******78"},
+		{name: "replace id card", rule: enum.MaskingReplace, value: "110101199003070011", want: "1101**********0011"},
+		{name: "replace chinese name 3", rule: enum.MaskingReplace, value: "王小明", want: "王*明"},
+		{name: "replace chinese name 2", rule: enum.MaskingReplace, value: "张三", want: "张*"},
+		{name: "replace single char", rule: enum.MaskingReplace, value: "a", want: "*"},
+		{name: "replace empty string", rule: enum.MaskingReplace, value: "", want: ""},
+		{name: "replace int", rule: enum.MaskingReplace, value: json.Number("123456"), want: "1****6"},
+		{name: "replace float", rule: enum.MaskingReplace, value: json.Number("3.14"), want: "3**4"},
+		{name: "replace boolean", rule: enum.MaskingReplace, value: true, want: "t**e"},
+		{name: "replace nil", rule: enum.MaskingReplace, value: nil, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hashKey := testHashKey
+			if tt.hashKey != nil {
+				hashKey = tt.hashKey
+			}
+			assert.Equal(t, tt.want, maskValue(tt.rule, tt.value, hashKey))
+		})
+	}
+}
+
+func Test_responseMasker_mask(t *testing.T) {
+	serviceParams := []model.ServiceParam{
+		{ParamType: "request", EnName: "phone", Masking: enum.MaskingOverride},
+		{ParamType: "response", EnName: "name", Masking: enum.MaskingReplace},
+		{ParamType: "response", EnName: "Phone", Masking: enum.MaskingOverride},
+		{ParamType: "response", EnName: "id_card", Masking: enum.MaskingHash},
+		{ParamType: "response", EnName: "age", Masking: enum.MaskingPlaintext},
+		{ParamType: "response", EnName: "remark"},
+	}
+
+	rows := []map[string]interface{}{
+		{"name": "王小明", "phone": "13812345678", "id_card": "abc", "age": json.Number("18"), "remark": "r", "extra": "x"},
+		{"name": nil, "phone": nil, "id_card": nil, "age": nil, "remark": nil, "extra": nil},
+	}
+	want := []map[string]interface{}{
+		{"name": "王*明", "phone": "******", "id_card": "5d0ea494ece26078f3d279ea524a2dd1c6525fa4719127a4e72fac5fb0e3a9be", "age": json.Number("18"), "remark": "r", "extra": "x"},
+		{"name": nil, "phone": nil, "id_card": nil, "age": nil, "remark": nil, "extra": nil},
+	}
+
+	newResponseMasker(serviceParams, testHashKey).mask(rows)
+	assert.Equal(t, want, rows)
+}
+
+func Test_responseMasker_noRules(t *testing.T) {
+	m := newResponseMasker([]model.ServiceParam{
+		{ParamType: "response", EnName: "name", Masking: enum.MaskingPlaintext},
+		{ParamType: "request", EnName: "phone", Masking: enum.MaskingHash},
+	}, testHashKey)
+	assert.Empty(t, m.rules)
+
+	rows := []map[string]interface{}{{"name": "a", "phone": "b"}}
+	m.mask(rows)
+	assert.Equal(t, []map[string]interface{}{{"name": "a", "phone": "b"}}, rows)
+}
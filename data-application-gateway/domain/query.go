@@ -1,14 +1,12 @@
 package domain
 
 import (
-	"bytes"
 	"context"
-	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"math/rand"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -22,18 +20,20 @@ import (
 	"github.com/spf13/cast"
 	"go.uber.org/zap"
 
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/cache"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/gorm"
 	mdl_uniquery "github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/mdl-uniquery"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/microservice"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/rate_limiter"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/reverse_proxy"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/virtual_engine"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/dto"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/errorcode"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/form_validator"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/util"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
-	v1 "github.com/kweaver-ai/idrm-go-common/api/auth-service/v1"
-	"github.com/kweaver-ai/idrm-go-common/interception"
 	configuration_center_gocommon "github.com/kweaver-ai/idrm-go-common/rest/configuration_center"
 	data_view_gocommon "github.com/kweaver-ai/idrm-go-common/rest/data_view"
 	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
@@ -56,6 +56,11 @@ type QueryDomain struct {
 	applicationService         configuration_center_gocommon.ApplicationService
 	dataApplicationServiceRepo gorm.DataApplicationServiceRepo
 	mdl_uniquery               mdl_uniquery.DrivenMDLUniQuery
+	rateLimiterRepo            rate_limiter.RateLimiterRepo
+	authSchemes                *authSchemes
+	enforceCache               *cache.Cache[bool]
+	viewFieldCache             *cache.Cache[map[string]viewField]
+	maskingHashKey             []byte
 }
 
 func NewQueryDomain(
@@ -74,7 +79,14 @@ func NewQueryDomain(
 	applicationService configuration_center_gocommon.ApplicationService,
 	dataApplicationServiceRepo gorm.DataApplicationServiceRepo,
 	mdl_uniquery mdl_uniquery.DrivenMDLUniQuery,
+	rateLimiterRepo rate_limiter.RateLimiterRepo,
+	s *settings.Settings,
 ) *QueryDomain {
+	enforceCache := cache.New[bool](enforceCacheName, redis, cache.NewOptions(s.Cache, s.Cache.EnforceTTL, defaultEnforceCacheTTL))
+	if s.Masking.HashKey == "" {
+		log.Warn("masking hash key is not configured, hash masking outputs a fixed mask")
+	}
+	authenticators := newAuthenticators(appRepo, serviceRepo, redis, authService, enforceCache, applicationService)
 	return &QueryDomain{
 		appRepo:                    appRepo,
 		serviceRepo:                serviceRepo,
@@ -91,125 +103,57 @@ func NewQueryDomain(
 		applicationService:         applicationService,
 		dataApplicationServiceRepo: dataApplicationServiceRepo,
 		mdl_uniquery:               mdl_uniquery,
+		rateLimiterRepo:            rateLimiterRepo,
+		authSchemes:                newAuthSchemes(s.Auth, authenticators),
+		enforceCache:               enforceCache,
+		viewFieldCache:             cache.New[map[string]viewField](viewFieldCacheName, redis, cache.NewOptions(s.Cache, s.Cache.FieldProtectTTL, defaultViewFieldCacheTTL)),
+		maskingHashKey:             []byte(s.Masking.HashKey),
 	}
 }
 
-func (u *QueryDomain) queryUserAuthedSubServices(c context.Context, serviceID string, subject *v1.Subject) (subServiceModels []model.SubService, err error) {
-	//查询可授权的子规则
-	objectEntries, err := u.authService.SubjectObjects(c, "sub_service", subject.ID, string(subject.Type))
+// Query 调用接口，scheme 为配置中心指定的认证方案，接口单独配置了认证方案时以接口的配置为准
+func (u *QueryDomain) Query(c context.Context, req *dto.QueryReq, scheme string) (res *reverse_proxy.Response, err error) {
+
+	service, pathParams, err := u.serviceRepo.ServiceMatch(c, req.ServicePath)
 	if err != nil {
 		return nil, err
 	}
-	if len(objectEntries.Entries) <= 0 {
-		return nil, nil
-	}
-	//查询用户有的子规则，如果有子规则的读取权限，才可以读取，然后将合并子规则，然后再执行
-	subServices, err := u.serviceRepo.GetSubServices(c, serviceID)
-	if err != nil {
-		return nil, errorcode.Detail(errorcode.PublicDatabaseError, err.Error())
-	}
-	if len(subServices) <= 0 {
-		return nil, nil
-	}
-	authedObjectDict := make(map[string]int)
-	for _, object := range objectEntries.Entries {
-		if len(object.Permissions) <= 0 {
-			continue
-		}
-		for _, p := range object.Permissions {
-			if !(p.Action == string(v1.ActionRead) && p.Effect == string(v1.PolicyAllow)) {
-				continue
-			}
-			authedObjectDict[object.ObjectId] = 1
-		}
+	// 带路径变量的接口，之后的检查和调用记录使用接口路径，路径变量的值作为请求参数
+	if service.ServiceID != "" {
+		req.ServicePath = service.ServicePath
+		req.ServiceID, req.ServiceType = service.ServiceID, service.ServiceType
 	}
-	subServices = lo.Filter(subServices, func(item *model.SubService, index int) bool {
-		return authedObjectDict[item.ID.String()] > 0
-	})
-	return lo.Times(len(subServices), func(index int) model.SubService {
-		return *subServices[index]
-	}), nil
-}
-
-func (u *QueryDomain) Query(c context.Context, req *dto.QueryReq, cssjj string) (length int64, res io.ReadCloser, err error) {
-	// 获取配置中心配置，如果配置中心配置cssjj为true，则不进行鉴权
-	// cssjj, err := u.configurationRepo.GetConf(nil, c, "cssjj")
-	// log.WithContext(c).Info("cssjj", zap.String("cssjj", cssjj))
-	// if err != nil {
-	// 	return 0, nil, err
-	// }
-
-	service, err := u.serviceRepo.ServiceGet(c, req.ServicePath)
-	if err != nil {
-		return 0, nil, err
+	for k, v := range pathParams {
+		req.Params[k] = dto.NewParam(v, dto.ParamPositionPath, dto.ParamDataTypeString)
 	}
 	if service.Status != enum.ServiceStatusOnline &&
 		service.Status != enum.ServiceStatusDownAuditing &&
 		service.Status != enum.ServiceStatusDownReject {
-		return 0, nil, errorcode.Desc(errorcode.ServiceStatusNotAvailable)
+		return nil, errorcode.Desc(errorcode.ServiceStatusNotAvailable)
 	}
 
-	if cssjj == "true" {
-		//todo 长沙鉴权逻辑
-		if err := u.cssjjAuth(c, req, service); err != nil {
-			return 0, nil, err
-		}
-	} else {
-		// 从 context 获取接调用者的信息，如果获取失败或调用者不是一个应用则禁止调用
-		subject, err := interception.AuthServiceSubjectFromContext(c)
-		if err != nil {
-			return 0, nil, err
-		}
-		if subject.Type != v1.SubjectAPP {
-			return 0, nil, errorcode.Desc(errorcode.ServiceApplyNotPass)
-		}
-
-		//查询下子服务
-		//subServices, err := u.queryUserAuthedSubServices(c, service.ServiceID, subject)
-		//if err != nil {
-		//	return 0, nil, err
-		//}
-		//service.SubServices = subServices
-		enforce := microservice.Enforce{
-			SubjectType: string(subject.Type),
-			SubjectId:   subject.ID,
-			ObjectType:  "api",
-			ObjectId:    service.ServiceID,
-			Action:      "read",
-		}
-		resp, err := u.authService.Enforce(c, []microservice.Enforce{enforce})
-		if err != nil {
-			return 0, nil, err
-		}
-
-		authorized := resp[0]
+	// 按认证方案依次执行认证方式，鉴权后的接口只包含调用者已授权的子接口
+	service, err = u.authSchemes.authenticate(c, scheme, req, service)
+	if err != nil {
+		return nil, err
+	}
 
-		// var authorized bool
-		// enforce.Effect = "allow" // 期望的 effect 是 allow
-		// for _, e := range enforceRes {
-		// 	if e != enforce {
-		// 		continue
-		// 	}
-		// 	authorized = true
-		// 	break
-		// }
-		log.Infof("app %v authorized result %v", subject.ID, authorized)
-		if !authorized {
-			return 0, nil, errorcode.Desc(errorcode.ServiceApplyNotPass)
-		}
+	// 认证后按调用应用限流
+	if err = u.rateLimit(c, service, rateLimitCaller(req)); err != nil {
+		return nil, err
 	}
 
 	err = u.checkParams(c, req, service)
 	if err != nil {
-		return 0, nil, err
+		return nil, err
 	}
 
-	if err = u.getServiceParamDataProtectionQuery(c, service); err != nil {
-		return 0, nil, err
+	if service, err = u.getServiceParamDataProtectionQuery(c, service); err != nil {
+		return nil, err
 	}
 
 	// 执行查询
-	length, res, queryErr := u.query(c, req.Params, service)
+	res, queryErr := u.query(c, req.Params, req.Request, service)
 
 	// 异步统计埋点，不影响主流程
 	// go func() {
@@ -231,126 +175,54 @@ func (u *QueryDomain) Query(c context.Context, req *dto.QueryReq, cssjj string)
 	// 	}
 	// }()
 
-	return length, res, queryErr
+	return res, queryErr
 }
 
-// 长沙鉴权逻辑
-func (u *QueryDomain) cssjjAuth(c context.Context, req *dto.QueryReq, service *model.ServiceAssociations) (err error) {
-	var xTifSignature, xTifTimestamp, xTifNonce string
-	var ok bool
-
-	// 获取参数
-	if param, exists := req.Params["x-tif-signature"]; exists {
-		xTifSignature, ok = param.Value.(string)
-		if !ok {
-			log.WithContext(c).Error("cssjjAuth", zap.String("x-tif-signature", "x-tif-signature值不存在"))
-			return errorcode.Desc(errorcode.ServiceApplyNotPassCssjj + "x-tif-signature值不存在")
-		}
-	} else {
-		log.WithContext(c).Error("cssjjAuth", zap.String("x-tif-signature", "x-tif-signature不存在"))
-		return errorcode.Desc(errorcode.ServiceApplyNotPassCssjj + "x-tif-signature不存在")
-	}
-	if param, exists := req.Params["x-tif-timestamp"]; exists {
-		xTifTimestamp, ok = param.Value.(string)
-		if !ok {
-			log.WithContext(c).Error("cssjjAuth", zap.String("x-tif-timestamp", "x-tif-timestamp值不存在"))
-			return errorcode.Desc(errorcode.ServiceApplyNotPassCssjj + "x-tif-timestamp值不存在")
-		}
-	} else {
-		log.WithContext(c).Error("cssjjAuth", zap.String("x-tif-timestamp", "x-tif-timestamp不存在"))
-		return errorcode.Desc(errorcode.ServiceApplyNotPassCssjj + "x-tif-timestamp不存在")
-	}
-	if param, exists := req.Params["x-tif-nonce"]; exists {
-		xTifNonce, ok = param.Value.(string)
-		if !ok {
-			log.WithContext(c).Error("cssjjAuth", zap.String("x-tif-nonce", "x-tif-nonce值不存在"))
-			return errorcode.Desc(errorcode.ServiceApplyNotPassCssjj + "x-tif-nonce值不存在")
-		}
-	} else {
-		log.WithContext(c).Error("cssjjAuth", zap.String("x-tif-nonce", "x-tif-nonce不存在"))
-		return errorcode.Desc(errorcode.ServiceApplyNotPassCssjj + "x-tif-nonce不存在")
-	}
-
-	// 获取 secret
-	if service.AppsID == nil {
-		log.WithContext(c).Error("cssjjAuth", zap.String("service.AppsID", "service.AppsID为空"))
-		return errorcode.Desc(errorcode.ServiceApplyNotPassCssjj + "service.AppsID为空")
-	}
-
-	application, err := u.applicationService.GetApplicationInternal(c, *service.AppsID)
-	if err != nil {
-		log.WithContext(c).Error("cssjjAuth", zap.String("application", "application不存在"))
-		return errorcode.Desc(errorcode.ServiceApplyNotPassCssjj + "application不存在")
-	}
-	if application == nil || application.Token == "" {
-		log.WithContext(c).Error("cssjjAuth", zap.String("application.Token", "application.Token不存在"))
-		return errorcode.Desc(errorcode.ServiceApplyNotPassCssjj + "application.Token不存在")
-	}
-	secret := application.Token
-
-	// 校验签名
-	ok, resHeaders := checkSign(secret, xTifTimestamp, xTifNonce, xTifSignature)
-	if !ok {
-		log.WithContext(c).Error("cssjjAuth", zap.String("签名校验失败", "x-tif-signature="+resHeaders["x-tif-signature"]+" x-tif-timestamp="+resHeaders["x-tif-timestamp"]+" x-tif-nonce="+resHeaders["x-tif-nonce"]))
-		return errorcode.Desc(errorcode.ServiceApplyNotPassCssjj + "签名校验失败：x-tif-signature=" + resHeaders["x-tif-signature"] + " x-tif-timestamp=" + resHeaders["x-tif-timestamp"] + " x-tif-nonce=" + resHeaders["x-tif-nonce"])
-	}
-
-	// 更新请求头
-	for k, v := range resHeaders {
-		req.Params[k] = dto.NewParam(v, dto.ParamPositionHeader, dto.ParamDataTypeString)
-	}
-
-	return nil
+// viewField 逻辑视图字段的数据类型和是否开启查询保护
+type viewField struct {
+	DataType            string `json:"data_type"`
+	DataProtectionQuery bool   `json:"data_protection_query"`
 }
 
-// 长沙签名校验逻辑
-func checkSign(secret, timestamp, nonce, sign string) (bool, map[string]string) {
-	ts, err := strconv.ParseInt(timestamp, 10, 64)
+// getServiceParamDataProtectionQuery 返回设置了参数是否开启查询保护和字段类型的接口，逻辑视图的字段按接口缓存
+func (u *QueryDomain) getServiceParamDataProtectionQuery(c context.Context, service *model.ServiceAssociations) (*model.ServiceAssociations, error) {
+	fields, err := u.viewFieldCache.GetOrLoad(c, service.ServiceID, func(ctx context.Context) (map[string]viewField, error) {
+		return u.viewFields(ctx, service.ServiceDataSource.DataViewID)
+	})
 	if err != nil {
-		return false, nil
-	}
-	now := time.Now().Unix()
-	if ts > now+180 || ts < now-180 {
-		return false, nil
+		return nil, err
 	}
-	log.Info("checkSign", zap.String("timestamp", timestamp), zap.String("secret", secret), zap.String("nonce", nonce), zap.String("sign", sign))
-	signData := fmt.Sprintf("%s%s%s%s", timestamp, secret, nonce, timestamp)
-	res := strings.ToUpper(fmt.Sprintf("%x", sha256.Sum256([]byte(signData))))
 
-	// 生成一个类似于 Math.random().toString(36).substr(2) 的随机字符串
-
-	resNonce := randomNonce()
-	resTimestamp := strconv.FormatInt(time.Now().Unix(), 10)
-	resSign := strings.ToUpper(fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("%s%s%s%s", resTimestamp, secret, resNonce, resTimestamp)))))
-
-	resHeaders := map[string]string{
-		"x-tif-signature": resSign,
-		"x-tif-timestamp": resTimestamp,
-		"x-tif-nonce":     resNonce,
+	// service 可能是缓存中共享的对象，修改参数前先复制一份
+	authedService := *service
+	authedService.ServiceParams = make([]model.ServiceParam, len(service.ServiceParams))
+	copy(authedService.ServiceParams, service.ServiceParams)
+	for i := range authedService.ServiceParams {
+		if v, exist := fields[authedService.ServiceParams[i].EnName]; exist {
+			authedService.ServiceParams[i].DataProtectionQuery = v.DataProtectionQuery
+			authedService.ServiceParams[i].ColumnType = v.DataType
+		}
 	}
-	return res == strings.ToUpper(sign), resHeaders
-}
-func randomNonce() string {
-	n := rand.Int63() // 生成一个随机int64
-	return strings.TrimLeft(strconv.FormatInt(n, 36), "0")
+	return &authedService, nil
 }
 
-func (u *QueryDomain) getServiceParamDataProtectionQuery(c context.Context, service *model.ServiceAssociations) (err error) {
+// viewFields 查询逻辑视图字段的数据类型和是否开启查询保护，返回 map[字段技术名称]字段
+func (u *QueryDomain) viewFields(c context.Context, dataViewID string) (fields map[string]viewField, err error) {
 	// 查询逻辑视图字段
 	var dataViewFieldRes *data_view_gocommon.GetFieldsRes
-	if dataViewFieldRes, err = u.dataView.GetDataViewFieldByInternal(c, service.ServiceDataSource.DataViewID); err != nil {
+	if dataViewFieldRes, err = u.dataView.GetDataViewFieldByInternal(c, dataViewID); err != nil {
 		return
 	}
-	// 查询字段是否开启查询保护
-	// map[字段ID]是否开启查询保护
+	fields = make(map[string]viewField)
+	// map[字段技术名称]分级标签ID
 	fieldIDGradeIDMap := make(map[string]string)
-	fieldProtectionQueryMap := make(map[string]bool)
 	uniqueGradeIDMap := make(map[string]string)
 	uniqueGradeIDSlice := []string{}
 	for _, field := range dataViewFieldRes.FieldsRes {
+		fields[field.TechnicalName] = viewField{DataType: field.DataType}
 		if field.LabelID != "" {
+			fieldIDGradeIDMap[field.TechnicalName] = field.LabelID
 			if _, exist := uniqueGradeIDMap[field.LabelID]; !exist {
-				fieldIDGradeIDMap[field.TechnicalName] = field.LabelID
 				uniqueGradeIDMap[field.LabelID] = ""
 				uniqueGradeIDSlice = append(uniqueGradeIDSlice, field.LabelID)
 			}
@@ -363,33 +235,40 @@ func (u *QueryDomain) getServiceParamDataProtectionQuery(c context.Context, serv
 		if err != nil {
 			return
 		}
+		fieldProtectionQueryMap := make(map[string]bool)
 		for _, v := range labelByIdsRes.Entries {
 			fieldProtectionQueryMap[v.ID] = v.DataProtectionQuery
 		}
-		for i := range service.ServiceParams {
-			if labelID, exist := fieldIDGradeIDMap[service.ServiceParams[i].EnName]; exist {
-				service.ServiceParams[i].DataProtectionQuery = fieldProtectionQueryMap[labelID]
-			}
+		for technicalName, labelID := range fieldIDGradeIDMap {
+			field := fields[technicalName]
+			field.DataProtectionQuery = fieldProtectionQueryMap[labelID]
+			fields[technicalName] = field
 		}
 	}
 	return
 }
 
-func (u *QueryDomain) query(c context.Context, params map[string]*dto.Param, service *model.ServiceAssociations) (length int64, res io.ReadCloser, err error) {
+// query 按接口类型查询，raw 为调用方的原始请求，注册接口按请求映射转发，测试接口时为 nil
+func (u *QueryDomain) query(c context.Context, params map[string]*dto.Param, raw *http.Request, service *model.ServiceAssociations) (res *reverse_proxy.Response, err error) {
 	c, span := trace.StartInternalSpan(c)
 	defer func() { trace.TelemetrySpanEnd(span, err) }()
 
 	switch service.ServiceType {
 	case "service_generate":
-		length, res, err = u.serviceGenerateQuery(c, params, service)
+		var length int64
+		var body io.ReadCloser
+		if length, body, err = u.serviceGenerateQuery(c, params, service); err != nil {
+			return nil, err
+		}
+		res = &reverse_proxy.Response{Length: length, Header: http.Header{"Content-Type": {"application/json"}}, Body: body}
 	case "service_register":
-		length, res, err = u.serviceRegisterQuery(c, params, service)
+		res, err = u.serviceRegisterQuery(c, params, raw, service)
 	}
 
-	return length, res, err
+	return res, err
 }
 
-func (u *QueryDomain) QueryTest(c context.Context, req *dto.QueryTestReq) (length int64, res io.ReadCloser, err error) {
+func (u *QueryDomain) QueryTest(c context.Context, req *dto.QueryTestReq) (res *reverse_proxy.Response, err error) {
 	c, span := trace.StartInternalSpan(c)
 	defer func() { trace.TelemetrySpanEnd(span, err) }()
 
@@ -399,6 +278,7 @@ func (u *QueryDomain) QueryTest(c context.Context, req *dto.QueryTestReq) (lengt
 			BackendServicePath: req.BackendServicePath,
 			CreateModel:        req.CreateModel,
 			HTTPMethod:         req.HTTPMethod,
+			ReturnType:         req.ReturnType,
 			ServiceType:        req.ServiceType,
 		},
 		ServiceScriptModel: model.ServiceScriptModel{
@@ -407,28 +287,28 @@ func (u *QueryDomain) QueryTest(c context.Context, req *dto.QueryTestReq) (lengt
 		},
 	}
 	if req.CurrentRules != nil {
-		service.SubServices = []model.SubService{
-			{
-				RowFilterClause: genWhereClause(req.CurrentRules),
-			},
+		detail, err := json.Marshal(req.CurrentRules)
+		if err != nil {
+			return nil, errorcode.Detail(errorcode.PublicInternalError, err.Error())
 		}
+		service.SubServices = []model.SubService{{Detail: string(detail)}}
 	}
 	//接口生成
 	if req.ServiceType == "service_generate" {
 		//检查数据表视图id
 		dataViewListRes, err := u.dataViewRepo.DataViewList(c, []string{req.DataViewId})
 		if err != nil {
-			return 0, nil, err
+			return nil, err
 		}
 
 		if dataViewListRes.TotalCount == 0 {
-			return 0, nil, errorcode.Desc(errorcode.DataViewIdNotExist)
+			return nil, errorcode.Desc(errorcode.DataViewIdNotExist)
 		}
 
 		dataView := dataViewListRes.Entries[0]
 
 		if dataView.PublishAt == 0 {
-			return 0, nil, errorcode.Desc(errorcode.DataViewIdNotPublish)
+			return nil, errorcode.Desc(errorcode.DataViewIdNotPublish)
 		}
 
 		catalogName, schemaName := u.dataViewRepo.ParseViewSourceCatalogName(dataView.ViewSourceCatalogName)
@@ -437,6 +317,19 @@ func (u *QueryDomain) QueryTest(c context.Context, req *dto.QueryTestReq) (lengt
 			CatalogName:    catalogName,
 			DataSchemaName: schemaName,
 			DataTableName:  dataView.TechnicalName,
+			DataViewID:     req.DataViewId,
+		}
+	}
+
+	// 后台服务路径中的变量使用同名请求参数的默认值
+	pathVars := make(map[string]bool)
+	if util.IsPathTemplate(req.BackendServicePath) {
+		t, err := util.ParsePathTemplate(req.BackendServicePath)
+		if err != nil {
+			return nil, errorcode.Detail(errorcode.PublicInvalidParameter, err.Error())
+		}
+		for _, v := range t.Vars() {
+			pathVars[v] = true
 		}
 	}
 
@@ -460,10 +353,14 @@ func (u *QueryDomain) QueryTest(c context.Context, req *dto.QueryTestReq) (lengt
 
 		value, err := u.SetDefaultValue(p.EnName, p.DefaultValue, p.DataType)
 		if err != nil {
-			return 0, nil, err
+			return nil, err
 		}
 
-		req.Params[p.EnName] = dto.NewParam(value, dto.ParamPositionBody, dto.ParamDataType(p.DataType))
+		position := dto.ParamPositionBody
+		if pathVars[p.EnName] {
+			position = dto.ParamPositionPath
+		}
+		req.Params[p.EnName] = dto.NewParam(value, position, dto.ParamDataType(p.DataType))
 	}
 	for _, p := range req.DataTableResponseParams {
 		m := model.ServiceParam{
@@ -498,7 +395,16 @@ func (u *QueryDomain) QueryTest(c context.Context, req *dto.QueryTestReq) (lengt
 
 	service.ServiceResponseFilters = serviceResponseFilters
 
-	return u.query(c, req.Params, service)
+	// 测试时使用页面上的请求映射，没有原始请求，原样转发的请求体按 JSON 发送
+	if req.ServiceType == "service_register" && req.RequestMapping != nil {
+		mapping, err := json.Marshal(req.RequestMapping)
+		if err != nil {
+			return nil, errorcode.Detail(errorcode.PublicInternalError, err.Error())
+		}
+		service.RequestMapping = lo.ToPtr(string(mapping))
+	}
+
+	return u.query(c, req.Params, nil, service)
 }
 
 func (u *QueryDomain) serviceGenerateQuery(c context.Context, params map[string]*dto.Param, service *model.ServiceAssociations) (length int64, res io.ReadCloser, err error) {
@@ -511,26 +417,39 @@ func (u *QueryDomain) serviceGenerateQuery(c context.Context, params map[string]
 	scriptCount := ""
 	serviceParams := service.ServiceParams
 	serviceResponseFilters := service.ServiceResponseFilters
-	subServiceRule := strings.Join(lo.Times(len(service.SubServices), func(index int) string {
-		return service.SubServices[index].RowFilterClause
-	}), " or  ")
 
+	// 子接口限定了返回字段时，只返回授权字段的并集
+	scopeColumns, err := u.subServiceScopeColumns(c, service)
+	if err != nil {
+		return 0, nil, err
+	}
+	if scopeColumns != nil {
+		serviceParams = restrictResponseParams(serviceParams, scopeColumns)
+		if !hasResponseParam(serviceParams) {
+			return 0, nil, errorcode.Desc(errorcode.ServiceApplyNotPass)
+		}
+	}
+
+	// 过滤规则尽量下推到 SQL 中，保证分页和总数基于过滤后的结果，无法下推的规则仍在结果上过滤
 	switch service.CreateModel {
 	case "wizard":
-		script, err = u.serviceRepo.WizardModelScript(c, params, catalogName, schemaName, tableName, subServiceRule, serviceParams, false)
-		scriptCount, err = u.serviceRepo.WizardModelScript(c, params, catalogName, schemaName, tableName, subServiceRule, serviceParams, true)
+		script, serviceResponseFilters, err = u.serviceRepo.WizardModelScript(c, params, catalogName, schemaName, tableName, service.SubServices, serviceParams, service.ServiceResponseFilters, false)
+		if err != nil {
+			return 0, nil, err
+		}
+		scriptCount, _, err = u.serviceRepo.WizardModelScript(c, params, catalogName, schemaName, tableName, service.SubServices, serviceParams, service.ServiceResponseFilters, true)
 	case "script":
-		script, err = u.serviceRepo.ScriptModelScript(c, params, catalogName, schemaName, script, subServiceRule, serviceParams, false)
-		scriptCount, err = u.serviceRepo.ScriptModelScript(c, params, catalogName, schemaName, service.ServiceScriptModel.Script, subServiceRule, serviceParams, true)
-		scriptCount = replaceSelectWithCountSafe(scriptCount)
+		script, serviceResponseFilters, err = u.serviceRepo.ScriptModelScript(c, params, catalogName, schemaName, script, service.SubServices, serviceParams, service.ServiceResponseFilters, false)
+		if err != nil {
+			return 0, nil, err
+		}
+		scriptCount, _, err = u.serviceRepo.ScriptModelScript(c, params, catalogName, schemaName, service.ServiceScriptModel.Script, service.SubServices, serviceParams, service.ServiceResponseFilters, true)
 	}
 
 	if err != nil {
 		return 0, nil, err
 	}
 
-	script = strings.ReplaceAll(script, "`", `"`)
-
 	log.Info("serviceGenerateQuery",
 		zap.String("service_path", service.ServicePath),
 		zap.String("service_id", service.ServiceID),
@@ -562,22 +481,29 @@ func (u *QueryDomain) serviceGenerateQuery(c context.Context, params map[string]
 
 	// fetchRes := FetchRes{}
 
-	fetchRes, err := u.virtualEngineRepo.Fetch(c, script, service.Timeout, serviceResponseFilters)
+	rows, err := u.virtualEngineRepo.FetchRows(c, script, service.Timeout, serviceResponseFilters)
 	if err != nil {
 		return
 	}
-	fetchRes.TotalCount = int(length)
-	// fetchRes.Data = result2.Entries
 
-	fetchResJSON, err := json.Marshal(fetchRes)
-	if err != nil {
-		return
+	// 逐行处理并编码结果，长度未知时响应使用分块传输
+	masker := newResponseMasker(serviceParams, u.maskingHashKey)
+	reader := newRowsReader(rows, length, func(row map[string]interface{}) {
+		// 脚本模式的查询字段由脚本决定，需要在结果中去掉未授权的列
+		if scopeColumns != nil {
+			restrictRow(row, scopeColumns)
+		}
+		// 按返回参数配置的脱敏规则处理结果，QueryTest 同样经过这里
+		masker.maskRow(row)
+	})
+	if err = reader.prime(); err != nil {
+		reader.Close()
+		return 0, nil, err
 	}
-
-	return int64(len(fetchResJSON)), io.NopCloser(bytes.NewReader(fetchResJSON)), nil
+	return -1, reader, nil
 }
 
-func (u *QueryDomain) serviceRegisterQuery(c context.Context, params map[string]*dto.Param, service *model.ServiceAssociations) (length int64, res io.ReadCloser, err error) {
+func (u *QueryDomain) serviceRegisterQuery(c context.Context, params map[string]*dto.Param, raw *http.Request, service *model.ServiceAssociations) (res *reverse_proxy.Response, err error) {
 	c, span := trace.StartInternalSpan(c)
 	defer func() { trace.TelemetrySpanEnd(span, err) }()
 
@@ -587,7 +513,31 @@ func (u *QueryDomain) serviceRegisterQuery(c context.Context, params map[string]
 		zap.String("backend_service_path", service.BackendServicePath),
 		zap.Any("params", params),
 	)
-	return u.reverseProxyRepo.Serve(c, params, service.HTTPMethod, service.BackendServiceHost, service.BackendServicePath, service.Timeout)
+	res, err = u.reverseProxyRepo.Serve(c, params, raw, service)
+	if err != nil {
+		return
+	}
+
+	transformer := newResponseTransformer(service.ServiceParams, u.maskingHashKey)
+	// 脱敏和查询保护只处理 JSON，其他返回类型没有配置时原样流式返回，配置了时不返回数据
+	if service.ReturnType != "" && service.ReturnType != enum.ReturnTypeJSON {
+		if err = transformer.acceptReturnType(service.ReturnType); err != nil {
+			res.Body.Close()
+			return nil, err
+		}
+		return res, nil
+	}
+
+	// 后端返回的数据按返回参数配置的脱敏规则和查询保护处理，没有配置时原样返回
+	if res.Length, res.Body, err = transformer.transform(res.Length, res.Body); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// BreakerStates 返回注册接口的后台服务的熔断器状态
+func (u *QueryDomain) BreakerStates(c context.Context) []reverse_proxy.BreakerState {
+	return u.reverseProxyRepo.BreakerStates(c)
 }
 
 func (u *QueryDomain) checkParams(c context.Context, req *dto.QueryReq, service *model.ServiceAssociations) (err error) {
@@ -675,6 +625,15 @@ func (u *QueryDomain) checkParams(c context.Context, req *dto.QueryReq, service
 			}
 		}
 
+		// 检查路径参数的数据类型
+		if reqParam.Position == dto.ParamPositionPath {
+			if value, ok := pathParamValue(reqParam.Value, serviceParam.DataType); ok {
+				req.Params[serviceParam.EnName] = dto.NewParam(value, dto.ParamPositionPath, dto.ParamDataType(serviceParam.DataType))
+			} else {
+				validErrors = append(validErrors, u.newValidError(req.ServicePath, serviceParam.EnName, dto.ParamDataType(serviceParam.DataType)))
+			}
+		}
+
 		// 检查body参数的数据类型
 		if reqParam.Position == dto.ParamPositionBody {
 			switch serviceParam.DataType {
@@ -736,6 +695,25 @@ func (u *QueryDomain) checkParams(c context.Context, req *dto.QueryReq, service
 	return validErrors
 }
 
+// pathParamValue 将路径参数的值转换为参数声明的数据类型
+func pathParamValue(value interface{}, dataType string) (interface{}, bool) {
+	var (
+		v   interface{}
+		err error
+	)
+	switch dto.ParamDataType(dataType) {
+	case dto.ParamDataTypeInt, dto.ParamDataTypeLong:
+		v, err = cast.ToInt64E(value)
+	case dto.ParamDataTypeFloat, dto.ParamDataTypeDouble:
+		v, err = cast.ToFloat64E(value)
+	case dto.ParamDataTypeBoolean:
+		v, err = strconv.ParseBool(cast.ToString(value))
+	default:
+		v = value
+	}
+	return v, err == nil
+}
+
 // SetDefaultValue default_value 字段以字符串类型传值, 可能和数据库中实际的字段数据类型不一致, 需要转成参数的实际类型
 func (u *QueryDomain) SetDefaultValue(name string, value interface{}, dataType string) (newValue interface{}, err error) {
 	switch dto.ParamDataType(dataType) {
@@ -825,129 +803,6 @@ func (u *QueryDomain) IsEnableResourceDirectory(ctx context.Context) (isEnable b
 // 容忍的时间戳误差范围
 const timestampToleration = time.Minute * 5
 
-// func (u *QueryDomain) SignValidate(c *gin.Context, req *dto.QueryReq) error {
-// 	authorizationHeader := c.GetHeader(enum.HeaderAuthorization)
-// 	if authorizationHeader == "" {
-// 		return errorcode.Desc(errorcode.SignValidateError)
-// 	}
-
-// 	authorization, err := util.ParseAuthorization(authorizationHeader)
-// 	if err != nil {
-// 		return errorcode.Desc(errorcode.SignValidateError)
-// 	}
-
-// 	//时间戳是否存在
-// 	if authorization.Timestamp == "" {
-// 		return errorcode.Desc(errorcode.TimestampRequired)
-// 	}
-// 	//时间戳解析
-// 	timestampInt, err := strconv.ParseInt(authorization.Timestamp, 10, 64)
-// 	if err != nil {
-// 		return errorcode.Desc(errorcode.TimestampError)
-// 	}
-// 	timestamp := time.Unix(timestampInt, 0)
-// 	// 检查时间戳是否处于误差范围内
-// 	if !isTimeInDelta(time.Now(), timestamp, timestampToleration) {
-// 		return errorcode.Desc(errorcode.TimestampExpired)
-// 	}
-
-// 	//appid是否存在
-// 	if authorization.AppId == "" {
-// 		return errorcode.Desc(errorcode.AppIdRequired)
-// 	}
-// 	app, err := u.appRepo.Get(c, authorization.AppId)
-// 	if err != nil {
-// 		return errorcode.Detail(errorcode.PublicDatabaseError, err)
-// 	}
-// 	if app.AppID == "" {
-// 		return errorcode.Desc(errorcode.AppIdNotExist)
-// 	}
-
-// 	//验证请求签名
-// 	validate := util.HttpSignValidate(c.Request, app.AppSecret, authorization)
-// 	if !validate {
-// 		return errorcode.Desc(errorcode.SignValidateError)
-// 	}
-
-// 	//检查接口状态
-// 	isEnable, err := u.IsEnableResourceDirectory(c)
-// 	if err != nil {
-// 		log.Error("SignValidate: 查询启用数据资源管理方式配置出错：", zap.Error(err))
-// 		isEnable = true
-// 	}
-
-// 	service, err := u.serviceRepo.ServiceGetFields(c, c.Request.Method, req.ServicePath, []string{"service_id", "service_path", "publish_status", "status", "rate_limiting", "owner_id"})
-// 	if err != nil {
-// 		return errorcode.Detail(errorcode.PublicDatabaseError, err)
-// 	}
-// 	if service.ServiceID == "" {
-// 		return errorcode.Desc(errorcode.ServicePathNotExist)
-// 	}
-// 	//启用数据资源目录
-// 	if isEnable {
-// 		if service.PublishStatus != enum.PublishStatusPublished {
-// 			return errorcode.Desc(errorcode.ServiceQueryPublishError)
-// 		}
-// 	} else {
-// 		if service.Status != enum.ServiceStatusOnline {
-// 			return errorcode.Desc(errorcode.ServiceQueryPublishError)
-// 		}
-// 	}
-
-// 	//频次限制
-// 	if service.RateLimiting != 0 {
-// 		limiterKey := app.AppID + service.ServiceID
-// 		limiter := redis_rate.NewLimiter(u.redis.Client)
-// 		res, err := limiter.Allow(c, limiterKey, redis_rate.PerSecond(int(service.RateLimiting)))
-// 		if err != nil {
-// 			return errorcode.Detail(errorcode.PublicInternalError, err)
-// 		}
-// 		if res.Allowed == 0 {
-// 			return errorcode.Desc(errorcode.RateLimitError)
-// 		}
-// 	}
-
-// 	//随机请求串是否使用过
-// 	if authorization.Nonce == "" {
-// 		return errorcode.Desc(errorcode.SignValidateError)
-// 	}
-// 	key := "data-application-gateway-nonce:" + authorization.Nonce
-// 	setNX := u.redis.Client.SetNX(c, key, authorization.Nonce, 300*time.Second)
-// 	if !setNX.Val() {
-// 		return errorcode.Desc(errorcode.SignValidateError)
-// 	}
-
-// 	// 如果发起请求的用户是否为接口服务的 Owner，认为有调用权限，不需要向 auth-service 鉴权
-// 	if app.UID == service.OwnerID {
-// 		return nil
-// 	}
-
-// 	//appid所属用户是否有接口权限
-
-// 	enforcesReq := []microservice.Enforce{
-// 		{
-// 			Action:      "read",
-// 			ObjectId:    service.ServiceID,
-// 			ObjectType:  "api",
-// 			SubjectId:   app.UID,
-// 			SubjectType: "user",
-// 		},
-// 	}
-
-// 	enforces, err := u.authService.Enforce(c, enforcesReq)
-// 	if err != nil {
-// 		return err
-// 	}
-// 	if len(enforces) == 0 {
-// 		return errorcode.Desc(errorcode.ServiceApplyNotPass)
-// 	}
-// 	if enforces[0].Effect == "deny" {
-// 		return errorcode.Desc(errorcode.ServiceApplyNotPass)
-// 	}
-
-// 	return nil
-// }
-
 // isTimeInDelta 判断两个时间是相差在范围内，闭区间。
 func isTimeInDelta(expected, actual time.Time, delta time.Duration) bool {
 	if expected.After(actual.Add(delta)) {
@@ -958,33 +813,3 @@ func isTimeInDelta(expected, actual time.Time, delta time.Duration) bool {
 	}
 	return true
 }
-
-// 替换sql为count(*)
-func replaceSelectWithCountSafe(sql string) string {
-	// 转换为小写方便查找，但保留原始大小写用于返回结果
-	lowerSQL := strings.ToLower(sql)
-
-	// 查找SELECT的位置
-	selectIndex := strings.Index(lowerSQL, "select")
-	if selectIndex == -1 {
-		return sql // 没有找到SELECT，返回原SQL
-	}
-
-	// 从SELECT之后查找FROM
-	fromIndex := strings.Index(lowerSQL[selectIndex+len("select"):], "from")
-	if fromIndex == -1 {
-		return sql // 没有找到FROM，返回原SQL
-	}
-
-	// 计算FROM在原字符串中的实际位置
-	fromIndex += selectIndex + len("select")
-
-	// 构造新的SQL
-	// 保留SELECT之前的部分
-	beforeSelect := sql[:selectIndex+len("select")]
-	// 保留FROM及其之后的部分
-	afterFrom := sql[fromIndex:]
-
-	// 组合结果: SELECT + COUNT(*) + FROM及其后的内容
-	return beforeSelect + " COUNT(*) " + afterFrom
-}
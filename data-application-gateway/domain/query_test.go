@@ -1,10 +1,16 @@
 package domain
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/form_validator"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
 )
 
 func Test_isTimeInDelta(t *testing.T) {
@@ -92,3 +98,31 @@ func Test_isTimeInDelta(t *testing.T) {
 		})
 	}
 }
+
+func Test_QueryDomain_checkParams_pathParams(t *testing.T) {
+	service := &model.ServiceAssociations{
+		Service: model.Service{ServicePath: "/orders/{id}/{flag}"},
+		ServiceParams: []model.ServiceParam{
+			{ParamType: "request", EnName: "id", DataType: "long", Required: "yes"},
+			{ParamType: "request", EnName: "flag", DataType: "boolean", Required: "yes"},
+		},
+	}
+	newReq := func(id, flag string) *dto.QueryReq {
+		return &dto.QueryReq{ServicePath: service.ServicePath, Params: map[string]*dto.Param{
+			"id":   dto.NewParam(id, dto.ParamPositionPath, dto.ParamDataTypeString),
+			"flag": dto.NewParam(flag, dto.ParamPositionPath, dto.ParamDataTypeString),
+		}}
+	}
+
+	u := &QueryDomain{}
+	req := newReq("42", "true")
+	require.NoError(t, u.checkParams(context.Background(), req, service))
+	assert.Equal(t, dto.NewParam(int64(42), dto.ParamPositionPath, dto.ParamDataTypeLong), req.Params["id"])
+	assert.Equal(t, dto.NewParam(true, dto.ParamPositionPath, dto.ParamDataTypeBoolean), req.Params["flag"])
+
+	err := u.checkParams(context.Background(), newReq("abc", "yes"), service)
+	var validErrors form_validator.ValidErrors
+	require.ErrorAs(t, err, &validErrors)
+	assert.Len(t, validErrors, 2)
+	assert.Equal(t, "id", validErrors[0].Key)
+}
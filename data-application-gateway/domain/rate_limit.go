@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+// RateLimitedError 超出接口的调用频次限制，RetryAfter 为距离下次可调用的时间
+type RateLimitedError struct {
+	RetryAfter time.Duration
+	err        error
+}
+
+func (e *RateLimitedError) Error() string { return e.err.Error() }
+
+func (e *RateLimitedError) Unwrap() error { return e.err }
+
+// rateLimitCaller 限流的调用方，优先使用认证出的调用应用，其次是调用者，都没有时使用客户端 IP
+func rateLimitCaller(req *dto.QueryReq) string {
+	if req.CallerApp != "" {
+		return req.CallerApp
+	}
+	if req.Caller != nil && req.Caller.ID != "" {
+		return req.Caller.ID
+	}
+	return req.ClientIP
+}
+
+// rateLimit 按接口配置的调用频次(次/秒)对调用方限流，调用方和接口分别计数。
+// 超出限制时返回 *RateLimitedError
+func (u *QueryDomain) rateLimit(c context.Context, service *model.ServiceAssociations, caller string) error {
+	if service.RateLimiting == 0 {
+		return nil
+	}
+
+	res, err := u.rateLimiterRepo.Allow(c, caller+":"+service.ServiceID, int(service.RateLimiting))
+	if err != nil {
+		// 限流器不可用时不影响接口调用
+		log.WithContext(c).Error("RateLimit", zap.Error(err), zap.String("service_id", service.ServiceID))
+		return nil
+	}
+	if res.Allowed {
+		return nil
+	}
+
+	log.WithContext(c).Warn("RateLimit exceeded",
+		zap.String("service_id", service.ServiceID),
+		zap.String("caller", caller),
+		zap.Uint32("rate_limiting", service.RateLimiting),
+	)
+	return &RateLimitedError{RetryAfter: res.RetryAfter, err: errorcode.Desc(errorcode.RateLimitError)}
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/dto"
+	v1 "github.com/kweaver-ai/idrm-go-common/api/auth-service/v1"
+)
+
+func Test_rateLimitCaller(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *dto.QueryReq
+		want string
+	}{
+		{
+			name: "caller app",
+			req:  &dto.QueryReq{CallerApp: "app-1", Caller: &v1.Subject{ID: "user-1", Type: v1.SubjectUser}, ClientIP: "10.0.0.1"},
+			want: "app-1",
+		},
+		{
+			name: "caller without app",
+			req:  &dto.QueryReq{Caller: &v1.Subject{ID: "user-1", Type: v1.SubjectUser}, ClientIP: "10.0.0.1"},
+			want: "user-1",
+		},
+		{
+			name: "not authenticated",
+			req:  &dto.QueryReq{ClientIP: "10.0.0.1"},
+			want: "10.0.0.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, rateLimitCaller(tt.req))
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/enum"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+)
+
+// maxTransformBodySize 需要脱敏的后端返回最多读取的字节数，脱敏需要解析完整的 JSON
+const maxTransformBodySize = 32 << 20
+
+// responseTransformer 处理注册接口的后端返回：按返回参数的路径脱敏或去掉字段。
+//
+// 返回参数的英文名称作为路径，以 . 分隔，数组透明，按路径的后缀匹配(不区分大小写)：
+//
+//	phone        匹配任意层级的 phone
+//	user.phone   匹配任意层级的 user 对象下的 phone
+//	$.data.phone 只匹配根对象 data 下的 phone，data 为数组时匹配每个元素的 phone
+type responseTransformer struct {
+	fields []transformField
+	// hash 脱敏的 HMAC 密钥
+	hashKey []byte
+}
+
+type transformField struct {
+	// 小写的路径
+	path []string
+	// 是否从根开始匹配
+	anchored bool
+	// 开启了查询保护的字段直接去掉
+	drop    bool
+	masking string
+}
+
+func newResponseTransformer(serviceParams []model.ServiceParam, hashKey []byte) *responseTransformer {
+	t := &responseTransformer{hashKey: hashKey}
+	for _, p := range serviceParams {
+		if p.ParamType != "response" || p.EnName == "" {
+			continue
+		}
+		masking := p.Masking
+		if masking == enum.MaskingPlaintext {
+			masking = ""
+		}
+		if !p.DataProtectionQuery && masking == "" {
+			continue
+		}
+
+		name := strings.ToLower(p.EnName)
+		anchored := strings.HasPrefix(name, "$.")
+		name = strings.TrimPrefix(name, "$.")
+		t.fields = append(t.fields, transformField{
+			path:     strings.Split(name, "."),
+			anchored: anchored,
+			drop:     p.DataProtectionQuery,
+			masking:  masking,
+		})
+	}
+	return t
+}
+
+// acceptReturnType 检查非 JSON 的返回类型能否返回。非 JSON 的数据无法按路径脱敏，
+// 配置了脱敏或查询保护时返回错误，避免未脱敏的数据返回给调用方
+func (t *responseTransformer) acceptReturnType(returnType string) error {
+	if len(t.fields) == 0 {
+		return nil
+	}
+	return errorcode.Desc(errorcode.BackendReturnTypeNotMaskable, returnType)
+}
+
+// transform 处理后端返回的数据，没有需要处理的字段时原样返回。
+// 接口的返回类型为 JSON，配置了脱敏或查询保护时不能解析为 JSON 的数据不返回，避免未脱敏的数据返回给调用方。
+// 处理后的数据长度改变，返回新的长度
+func (t *responseTransformer) transform(length int64, res io.ReadCloser) (int64, io.ReadCloser, error) {
+	if len(t.fields) == 0 {
+		return length, res, nil
+	}
+	defer res.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res, maxTransformBodySize+1))
+	if err != nil {
+		return 0, nil, errorcode.Detail(errorcode.QueryError, err.Error())
+	}
+	if len(body) > maxTransformBodySize {
+		return 0, nil, errorcode.Desc(errorcode.BackendResponseTooLarge, maxTransformBodySize)
+	}
+	// 没有返回数据时没有需要处理的字段
+	if len(bytes.TrimSpace(body)) == 0 {
+		return int64(len(body)), io.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	var doc interface{}
+	if err := d.Decode(&doc); err != nil {
+		return 0, nil, errorcode.Detail(errorcode.BackendResponseInvalid, err.Error())
+	}
+
+	doc = t.walk(doc, nil)
+
+	var buf bytes.Buffer
+	e := json.NewEncoder(&buf)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(doc); err != nil {
+		return 0, nil, errorcode.Detail(errorcode.PublicInternalError, err.Error())
+	}
+	// 去掉 Encode 追加的换行
+	buf.Truncate(buf.Len() - 1)
+	return int64(buf.Len()), io.NopCloser(&buf), nil
+}
+
+// walk 遍历 JSON，path 为当前节点的路径(不含数组下标)
+func (t *responseTransformer) walk(v interface{}, path []string) interface{} {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		for k, child := range node {
+			childPath := append(path[:len(path):len(path)], strings.ToLower(k))
+			field, ok := t.match(childPath)
+			switch {
+			case !ok:
+				node[k] = t.walk(child, childPath)
+			case field.drop:
+				delete(node, k)
+			default:
+				node[k] = maskAll(field.masking, child, t.hashKey)
+			}
+		}
+	case []interface{}:
+		for i, child := range node {
+			node[i] = t.walk(child, path)
+		}
+	}
+	return v
+}
+
+// match 查找匹配路径的字段，去掉字段优先于脱敏
+func (t *responseTransformer) match(path []string) (*transformField, bool) {
+	var matched *transformField
+	for i := range t.fields {
+		f := &t.fields[i]
+		if !f.matchPath(path) {
+			continue
+		}
+		if f.drop {
+			return f, true
+		}
+		if matched == nil {
+			matched = f
+		}
+	}
+	return matched, matched != nil
+}
+
+func (f *transformField) matchPath(path []string) bool {
+	if len(path) < len(f.path) || (f.anchored && len(path) != len(f.path)) {
+		return false
+	}
+	suffix := path[len(path)-len(f.path):]
+	for i := range f.path {
+		if f.path[i] != suffix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// maskAll 脱敏值，值为对象或数组时脱敏其中所有的值
+func maskAll(rule string, v interface{}, hashKey []byte) interface{} {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		for k, child := range node {
+			node[k] = maskAll(rule, child, hashKey)
+		}
+		return node
+	case []interface{}:
+		for i, child := range node {
+			node[i] = maskAll(rule, child, hashKey)
+		}
+		return node
+	}
+	return maskValue(rule, v, hashKey)
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/enum"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+	"github.com/kweaver-ai/idrm-go-frame/core/errorx/agerrors"
+)
+
+func Test_responseTransformer_transform(t *testing.T) {
+	serviceParams := []model.ServiceParam{
+		{ParamType: "request", EnName: "name", Masking: enum.MaskingOverride},
+		{ParamType: "response", EnName: "name", Masking: enum.MaskingReplace},
+		{ParamType: "response", EnName: "contact.Phone", Masking: enum.MaskingOverride},
+		{ParamType: "response", EnName: "$.data.id_card", Masking: enum.MaskingHash},
+		{ParamType: "response", EnName: "salary", DataProtectionQuery: true},
+		{ParamType: "response", EnName: "address", Masking: enum.MaskingOverride},
+		{ParamType: "response", EnName: "age", Masking: enum.MaskingPlaintext},
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "nested object and array",
+			body: `{"total_count":2,"data":[` +
+				`{"name":"王小明","contact":{"phone":"13812345678"},"id_card":"abc","salary":100,"age":18,"big":12345678901234567890},` +
+				`{"name":null,"contact":{"phone":null},"id_card":null,"salary":null,"age":null}` +
+				`]}`,
+			want: `{"data":[` +
+				`{"age":18,"big":12345678901234567890,"contact":{"phone":"******"},"id_card":"5d0ea494ece26078f3d279ea524a2dd1c6525fa4719127a4e72fac5fb0e3a9be","name":"王*明"},` +
+				`{"age":null,"contact":{"phone":null},"id_card":null,"name":null}` +
+				`],"total_count":2}`,
+		},
+		{
+			name: "anchored path does not match deeper fields",
+			body: `{"data":{"owner":{"id_card":"abc"}},"phone":"13812345678"}`,
+			want: `{"data":{"owner":{"id_card":"abc"}},"phone":"13812345678"}`,
+		},
+		{
+			name: "mask all values of object",
+			body: `[{"address":{"city":"上海","lines":["a","b"]}}]`,
+			want: `[{"address":{"city":"******","lines":["******","******"]}}]`,
+		},
+		{
+			name: "html is not escaped",
+			body: ` {"url":"<a>&"}`,
+			want: `{"url":"<a>&"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			length, res, err := newResponseTransformer(serviceParams, testHashKey).transform(-1, io.NopCloser(strings.NewReader(tt.body)))
+			require.NoError(t, err)
+			got, err := io.ReadAll(res)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+			assert.Equal(t, int64(len(got)), length)
+		})
+	}
+}
+
+func Test_responseTransformer_passThrough(t *testing.T) {
+	masked := newResponseTransformer([]model.ServiceParam{{ParamType: "response", EnName: "name", Masking: enum.MaskingHash}}, testHashKey)
+	tests := []struct {
+		name        string
+		transformer *responseTransformer
+		body        string
+	}{
+		{name: "no rules", transformer: newResponseTransformer(nil, testHashKey), body: `{"name":"a"}`},
+		{name: "empty", transformer: masked, body: ""},
+		{name: "blank", transformer: masked, body: " \n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			length, res, err := tt.transformer.transform(int64(len(tt.body)), io.NopCloser(strings.NewReader(tt.body)))
+			require.NoError(t, err)
+			got, err := io.ReadAll(res)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(got))
+			assert.Equal(t, int64(len(tt.body)), length)
+		})
+	}
+}
+
+func Test_responseTransformer_invalidJSON(t *testing.T) {
+	transformer := newResponseTransformer([]model.ServiceParam{{ParamType: "response", EnName: "name", Masking: enum.MaskingHash}}, testHashKey)
+	// 配置了脱敏时不能解析为 JSON 的数据不原样返回
+	for _, body := range []string{`{"name":`, "  name,age\na,1\n", "\x89PNG\r\n", "\ufeff{\"name\":\"a\"}"} {
+		_, _, err := transformer.transform(-1, io.NopCloser(strings.NewReader(body)))
+		assert.Error(t, err, body)
+	}
+}
+
+func Test_responseTransformer_acceptReturnType(t *testing.T) {
+	// 非 JSON 的返回类型没有配置脱敏和查询保护时原样返回
+	assert.NoError(t, newResponseTransformer([]model.ServiceParam{{ParamType: "response", EnName: "name", Masking: enum.MaskingPlaintext}}, testHashKey).acceptReturnType("csv"))
+	for _, p := range []model.ServiceParam{
+		{ParamType: "response", EnName: "name", Masking: enum.MaskingHash},
+		{ParamType: "response", EnName: "name", DataProtectionQuery: true},
+	} {
+		err := newResponseTransformer([]model.ServiceParam{p}, testHashKey).acceptReturnType("csv")
+		assert.Equal(t, errorcode.BackendReturnTypeNotMaskable, agerrors.Code(err).GetErrorCode())
+	}
+}
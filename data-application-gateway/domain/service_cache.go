@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/kweaver-ai/idrm-go-common/api/auth-service/v1"
+)
+
+// 缓存名称，与 Redis key 的前缀相关，修改后已有的缓存会失效
+const (
+	enforceCacheName   = "enforce"
+	viewFieldCacheName = "view-field"
+
+	defaultEnforceCacheTTL   = time.Minute
+	defaultViewFieldCacheTTL = 5 * time.Minute
+)
+
+// enforceCacheKey 鉴权结果缓存的 key，以接口 ID 开头，便于按接口失效
+func enforceCacheKey(serviceID string, subject *v1.Subject) string {
+	return serviceID + ":" + string(subject.Type) + ":" + subject.ID
+}
+
+// InvalidateServiceCache 删除接口定义、鉴权结果和逻辑视图字段的缓存，接口状态变化时调用
+func (u *QueryDomain) InvalidateServiceCache(ctx context.Context, serviceID string) {
+	if serviceID == "" {
+		return
+	}
+	u.serviceRepo.InvalidateCache(ctx, serviceID)
+	u.enforceCache.DeletePrefix(ctx, serviceID+":")
+	u.viewFieldCache.Delete(ctx, serviceID)
+}
+
+// InvalidateEnforceCache 删除接口鉴权结果的缓存，接口的授权变化时调用
+func (u *QueryDomain) InvalidateEnforceCache(ctx context.Context, serviceID string) {
+	if serviceID == "" {
+		return
+	}
+	u.enforceCache.DeletePrefix(ctx, serviceID+":")
+}
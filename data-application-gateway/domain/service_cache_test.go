@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/cache"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/gorm"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+	v1 "github.com/kweaver-ai/idrm-go-common/api/auth-service/v1"
+	configuration_center_gocommon "github.com/kweaver-ai/idrm-go-common/rest/configuration_center"
+	data_view_gocommon "github.com/kweaver-ai/idrm-go-common/rest/data_view"
+)
+
+type fakeDataView struct {
+	data_view_gocommon.Driven
+	fields []*data_view_gocommon.FieldsRes
+	calls  int
+}
+
+func (f *fakeDataView) GetDataViewFieldByInternal(_ context.Context, id string) (*data_view_gocommon.GetFieldsRes, error) {
+	f.calls++
+	return &data_view_gocommon.GetFieldsRes{ID: id, FieldsRes: f.fields}, nil
+}
+
+type fakeLabelService struct {
+	configuration_center_gocommon.LabelService
+	labels []*configuration_center_gocommon.GetLabelByIdRes
+}
+
+func (f *fakeLabelService) GetLabelByIds(_ context.Context, ids string) (*configuration_center_gocommon.GetLabelByIdsRes, error) {
+	return &configuration_center_gocommon.GetLabelByIdsRes{Entries: f.labels}, nil
+}
+
+type fakeCacheServiceRepo struct {
+	gorm.ServiceRepo
+	invalidated []string
+}
+
+func (f *fakeCacheServiceRepo) InvalidateCache(_ context.Context, serviceID string) {
+	f.invalidated = append(f.invalidated, serviceID)
+}
+
+func newTestCacheQueryDomain(dataView *fakeDataView, serviceRepo gorm.ServiceRepo) *QueryDomain {
+	return &QueryDomain{
+		serviceRepo: serviceRepo,
+		dataView:    dataView,
+		gradeLabel: &fakeLabelService{labels: []*configuration_center_gocommon.GetLabelByIdRes{
+			{ID: "label-protected", DataProtectionQuery: true},
+			{ID: "label-public"},
+		}},
+		enforceCache:   cache.New[bool](enforceCacheName, nil, cache.NewOptions(settings.Cache{}, 0, defaultEnforceCacheTTL)),
+		viewFieldCache: cache.New[map[string]viewField](viewFieldCacheName, nil, cache.NewOptions(settings.Cache{}, 0, defaultViewFieldCacheTTL)),
+	}
+}
+
+func Test_QueryDomain_getServiceParamDataProtectionQuery(t *testing.T) {
+	dataView := &fakeDataView{fields: []*data_view_gocommon.FieldsRes{
+		{TechnicalName: "id_card", DataType: "char", LabelID: "label-protected"},
+		{TechnicalName: "phone", DataType: "char", LabelID: "label-protected"},
+		{TechnicalName: "name", DataType: "char", LabelID: "label-public"},
+		{TechnicalName: "age", DataType: "int"},
+	}}
+	u := newTestCacheQueryDomain(dataView, &fakeCacheServiceRepo{})
+	service := &model.ServiceAssociations{
+		Service:           model.Service{ServiceID: "service-1"},
+		ServiceDataSource: model.ServiceDataSource{DataViewID: "view-1"},
+		ServiceParams: []model.ServiceParam{
+			{EnName: "id_card"}, {EnName: "phone"}, {EnName: "name"}, {EnName: "age"},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		got, err := u.getServiceParamDataProtectionQuery(context.Background(), service)
+		require.NoError(t, err)
+		var protected []string
+		for _, p := range got.ServiceParams {
+			if p.DataProtectionQuery {
+				protected = append(protected, p.EnName)
+			}
+		}
+		// 同一个标签的多个字段都开启查询保护
+		assert.Equal(t, []string{"id_card", "phone"}, protected)
+		assert.Equal(t, "int", got.ServiceParams[3].ColumnType)
+	}
+	// 第二次从缓存读取
+	assert.Equal(t, 1, dataView.calls)
+	// 不修改传入的、可能被缓存共享的接口
+	for _, p := range service.ServiceParams {
+		assert.False(t, p.DataProtectionQuery, p.EnName)
+	}
+}
+
+func Test_QueryDomain_InvalidateServiceCache(t *testing.T) {
+	ctx := context.Background()
+	serviceRepo := &fakeCacheServiceRepo{}
+	u := newTestCacheQueryDomain(&fakeDataView{}, serviceRepo)
+	subject := &v1.Subject{ID: "user-1", Type: v1.SubjectUser}
+	for _, serviceID := range []string{"service-1", "service-2"} {
+		u.enforceCache.Set(ctx, enforceCacheKey(serviceID, subject), true)
+		u.viewFieldCache.Set(ctx, serviceID, map[string]viewField{})
+	}
+
+	u.InvalidateServiceCache(ctx, "service-1")
+	u.InvalidateServiceCache(ctx, "")
+
+	assert.Equal(t, []string{"service-1"}, serviceRepo.invalidated)
+	_, ok := u.enforceCache.Get(ctx, enforceCacheKey("service-1", subject))
+	assert.False(t, ok)
+	_, ok = u.viewFieldCache.Get(ctx, "service-1")
+	assert.False(t, ok)
+	_, ok = u.enforceCache.Get(ctx, enforceCacheKey("service-2", subject))
+	assert.True(t, ok)
+	_, ok = u.viewFieldCache.Get(ctx, "service-2")
+	assert.True(t, ok)
+
+	u.InvalidateEnforceCache(ctx, "service-2")
+	_, ok = u.enforceCache.Get(ctx, enforceCacheKey("service-2", subject))
+	assert.False(t, ok)
+	_, ok = u.viewFieldCache.Get(ctx, "service-2")
+	assert.True(t, ok)
+}
+
+func Test_enforceAuthenticator_cache(t *testing.T) {
+	authService := &fakeAuthServiceRepo{allow: true}
+	a := newTestAuthenticators(authService)[settings.AuthModeEnforce]
+	for i := 0; i < 2; i++ {
+		ac := &AuthContext{
+			Service: newTestService(),
+			Subject: &v1.Subject{ID: "user-2", Type: v1.SubjectUser},
+		}
+		require.NoError(t, a.Authenticate(context.Background(), ac))
+		assert.True(t, ac.Authorized)
+	}
+	// 第二次使用缓存的鉴权结果
+	assert.Len(t, authService.enforces, 1)
+}
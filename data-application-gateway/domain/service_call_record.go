@@ -7,9 +7,10 @@ import (
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/gorm"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/microservice"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/metrics"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
 	v1 "github.com/kweaver-ai/idrm-go-common/api/auth-service/v1"
-	"github.com/kweaver-ai/idrm-go-common/interception"
 	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
 	"go.uber.org/zap"
 )
@@ -19,6 +20,33 @@ type ServiceCallRecordDomain struct {
 	serviceRepo                gorm.ServiceRepo
 	configurationCenterRepo    microservice.ConfigurationCenterRepo
 	dataApplicationServiceRepo gorm.DataApplicationServiceRepo
+
+	queue *callRecordQueue
+	// 接口调用测量结果的消费者：Prometheus 指标，以及写入调用记录和每日统计的队列
+	observers []callObserver
+}
+
+// callObserver 接口调用测量结果的消费者
+type callObserver interface {
+	observeCall(req *RecordServiceCallReq)
+}
+
+// metricsObserver 将接口调用记录到 Prometheus 指标，统计所有调用方的调用
+type metricsObserver struct{}
+
+func (metricsObserver) observeCall(req *RecordServiceCallReq) {
+	r := &metrics.Request{
+		ServiceID:   req.ServiceID,
+		ServiceType: req.ServiceType,
+		CallerApp:   req.CallAppID,
+	}
+	if req.CallHTTPCode != nil {
+		r.Code = *req.CallHTTPCode
+	}
+	if req.CallEndTime != nil {
+		r.Duration = req.CallEndTime.Sub(req.CallStartTime)
+	}
+	metrics.ObserveRequest(r)
 }
 
 func NewServiceCallRecordDomain(
@@ -26,113 +54,55 @@ func NewServiceCallRecordDomain(
 	serviceRepo gorm.ServiceRepo,
 	configurationCenterRepo microservice.ConfigurationCenterRepo,
 	dataApplicationServiceRepo gorm.DataApplicationServiceRepo,
-) *ServiceCallRecordDomain {
-	return &ServiceCallRecordDomain{
+	s *settings.Settings,
+) (*ServiceCallRecordDomain, func()) {
+	d := &ServiceCallRecordDomain{
 		serviceRepo:                serviceRepo,
 		serviceCallRecordRepo:      serviceCallRecordRepo,
 		configurationCenterRepo:    configurationCenterRepo,
 		dataApplicationServiceRepo: dataApplicationServiceRepo,
+		queue:                      newCallRecordQueue(serviceCallRecordRepo, serviceRepo, dataApplicationServiceRepo, s.CallRecord),
 	}
+	d.observers = []callObserver{metricsObserver{}, d.queue}
+	d.queue.start()
+	// 服务退出时写完队列中的记录
+	return d, d.queue.close
 }
 
-// RecordServiceCall 记录服务调用信息
+// RecordServiceCall 记录服务调用信息，测量结果交给所有的消费者。调用者信息在调用方的 goroutine 中获取，
+// 指标统计所有的调用；调用记录记录调用者为应用或签名认证的用户的调用，放入队列后异步批量写入，不会阻塞调用方
 func (s *ServiceCallRecordDomain) RecordServiceCall(ctx context.Context, req *RecordServiceCallReq) error {
-
-	// 从 context 获取接调用者的信息，如果获取失败或调用者不是一个应用则禁止调用
-	subject, err := interception.AuthServiceSubjectFromContext(ctx)
+	subject, ok := req.caller()
 	log.WithContext(ctx).Warn("subject Info:", zap.Any("subject", subject))
-	if err != nil || subject.Type != v1.SubjectAPP {
-		return errorcode.Desc(errorcode.ServiceApplyNotPass)
-	}
-	req.UserIdentification = subject.ID
-	req.CallAppID = subject.ID
-
-	// if subject != nil {
-	// 	app, err := s.configurationCenterRepo.AppsGetById(ctx, &microservice.AppsID{Id: subject.ID})
-	// 	if err != nil {
-	// 		return err
-	// 	}
-	// 	req.CallAppID = app.ID
-	// 	req.CallInfoSystemID = app.InfoSystem.ID
-	// 	req.UserIdentification = app.AccountID
-	// 	uid := app.ApplicationDeveloper.UID
-	// 	if uid != "" {
-	// 		userDepart, err := s.configurationCenterRepo.GetUserIdDepart(ctx, uid)
-	// 		// 打印userDepart
-	// 		log.WithContext(ctx).Warn("userDepart Info:", zap.Any("userDepart", userDepart))
-	// 		if err != nil {
-	// 			return err
-	// 		}
-	// 		if len(userDepart) > 0 {
-	// 			//拼接部门id
-	// 			departmentID := ""
-	// 			for _, depart := range userDepart {
-	// 				departmentID += depart.ID + ","
-	// 			}
-	// 			//去掉最后一个逗号
-	// 			if departmentID != "" {
-	// 				departmentID = departmentID[:len(departmentID)-1]
-	// 			}
-	// 			req.CallDepartmentID = departmentID
-	// 		}
-	// 	}
-	// }
-
-	service, err := s.serviceRepo.ServiceGet(ctx, req.ServiceID)
-	if err != nil {
-		return err
-	}
-
-	if service != nil {
-		req.ServiceDepartmentID = service.DepartmentID
+	if ok {
+		req.UserIdentification = subject.ID
+		if subject.Type == v1.SubjectAPP {
+			req.CallAppID = subject.ID
+		}
 	}
 
-	record := &model.ServiceCallRecord{
-		ServiceID:           service.ServiceID,
-		ServiceDepartmentID: req.ServiceDepartmentID,
-		ServiceSystemID:     req.ServiceSystemID,
-		ServiceAppID:        req.ServiceAppID,
-		RemoteAddress:       req.RemoteAddress,
-		ForwardFor:          req.ForwardFor,
-		UserIdentification:  req.UserIdentification,
-		CallDepartmentID:    req.CallDepartmentID,
-		CallInfoSystemID:    req.CallInfoSystemID,
-		CallAppID:           req.CallAppID,
-		CallStartTime:       req.CallStartTime,
-		CallEndTime:         req.CallEndTime,
-		CallHTTPCode:        req.CallHTTPCode,
-		CallStatus:          req.CallStatus,
-		ErrorMessage:        req.ErrorMessage,
-		CallOtherMessage:    req.CallOtherMessage,
-		RecordTime:          time.Now(),
+	for _, o := range s.observers {
+		o.observeCall(req)
 	}
-
-	// 记录服务调用详情
-	if err := s.serviceCallRecordRepo.Create(ctx, record); err != nil {
-		return err
+	if !ok {
+		return errorcode.Desc(errorcode.ServiceApplyNotPass)
 	}
+	return nil
+}
 
-	// 根据调用状态统计成功或失败次数
-	if req.CallStatus == 1 {
-		// 调用成功，增加成功次数
-		if err := s.dataApplicationServiceRepo.IncrementSuccessCount(ctx, service.ServiceID); err != nil {
-			log.WithContext(ctx).Error("记录成功调用次数失败", zap.Error(err), zap.String("serviceID", req.ServiceID))
-			// 不返回错误，避免影响主流程
-		}
-	} else {
-		// 调用失败，增加失败次数
-		if err := s.dataApplicationServiceRepo.IncrementFailCount(ctx, service.ServiceID); err != nil {
-			log.WithContext(ctx).Error("记录失败调用次数失败", zap.Error(err), zap.String("serviceID", req.ServiceID))
-			// 不返回错误，避免影响主流程
-		}
+// ObserveServiceCall 只统计服务调用的指标，不记录调用。长沙环境由里约网关记录调用
+func (s *ServiceCallRecordDomain) ObserveServiceCall(ctx context.Context, req *RecordServiceCallReq) {
+	if subject, ok := req.caller(); ok && subject.Type == v1.SubjectAPP {
+		req.CallAppID = subject.ID
 	}
-
-	return nil
+	metricsObserver{}.observeCall(req)
 }
 
 // RecordServiceCallReq 记录服务调用请求参数
 type RecordServiceCallReq struct {
+	ServicePath         string     `json:"service_path"`
 	ServiceID           string     `json:"service_id"`
+	ServiceType         string     `json:"service_type"`
 	ServiceDepartmentID string     `json:"service_department_id"`
 	ServiceSystemID     string     `json:"service_system_id"`
 	ServiceAppID        string     `json:"service_app_id"`
@@ -148,4 +118,45 @@ type RecordServiceCallReq struct {
 	CallStatus          int        `json:"call_status"`
 	ErrorMessage        string     `json:"error_message"`
 	CallOtherMessage    string     `json:"call_other_message"`
+	// 接口不存在时重新写入的次数
+	LookupAttempts int `json:"lookup_attempts,omitempty"`
+	// 认证方案认证出的调用者，未认证时为 nil
+	Caller *v1.Subject `json:"-"`
+	// 调用者是否由 HMAC 签名认证
+	CallerSigned bool `json:"-"`
+}
+
+// caller 返回记录调用的调用者：认证方案认证出的应用，或签名认证的用户。
+// 不从 context 获取调用者，令牌认证的用户等其他调用者返回 false
+func (req *RecordServiceCallReq) caller() (*v1.Subject, bool) {
+	if req.Caller == nil || req.Caller.ID == "" {
+		return nil, false
+	}
+	if req.Caller.Type != v1.SubjectAPP && !req.CallerSigned {
+		return nil, false
+	}
+	return req.Caller, true
+}
+
+// toModel 转换为调用记录，接口 ID 和部门从接口信息中获取
+func (req *RecordServiceCallReq) toModel(service *model.ServiceAssociations) *model.ServiceCallRecord {
+	return &model.ServiceCallRecord{
+		ServiceID:           service.ServiceID,
+		ServiceDepartmentID: service.DepartmentID,
+		ServiceSystemID:     req.ServiceSystemID,
+		ServiceAppID:        req.ServiceAppID,
+		RemoteAddress:       req.RemoteAddress,
+		ForwardFor:          req.ForwardFor,
+		UserIdentification:  req.UserIdentification,
+		CallDepartmentID:    req.CallDepartmentID,
+		CallInfoSystemID:    req.CallInfoSystemID,
+		CallAppID:           req.CallAppID,
+		CallStartTime:       req.CallStartTime,
+		CallEndTime:         req.CallEndTime,
+		CallHTTPCode:        req.CallHTTPCode,
+		CallStatus:          req.CallStatus,
+		ErrorMessage:        req.ErrorMessage,
+		CallOtherMessage:    req.CallOtherMessage,
+		RecordTime:          time.Now(),
+	}
 }
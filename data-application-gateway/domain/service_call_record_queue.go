@@ -0,0 +1,346 @@
+package domain
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/gorm"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+const (
+	defaultCallRecordQueueSize     = 10000
+	defaultCallRecordBatchSize     = 200
+	defaultCallRecordFlushInterval = 5 * time.Second
+	// 关闭时等待队列写完的最长时间
+	callRecordDrainTimeout = 30 * time.Second
+	// 接口不存在的记录最多重新写入的次数，超过后丢弃，避免已删除接口的记录一直留在本地文件。
+	// 查询接口出错不计入次数
+	callRecordMaxLookupAttempts = 100
+)
+
+// callRecordQueue 异步批量写入接口调用记录。
+//
+// 调用记录先放入有界的内存队列，由一个 goroutine 按批写入 service_call_record，
+// 每日的成功、失败次数在内存中累加后定期写入 service_daily_record。
+// 队列满、写入数据库失败或查询接口失败的记录追加到本地文件，数据库可以写入时定期重新写入。
+type callRecordQueue struct {
+	callRecordRepo             gorm.ServiceCallRecordRepo
+	serviceRepo                gorm.ServiceRepo
+	dataApplicationServiceRepo gorm.DataApplicationServiceRepo
+
+	batchSize     int
+	flushInterval time.Duration
+	spill         *callRecordSpill
+
+	// mu 保护 closed 和关闭 ch
+	mu     sync.RWMutex
+	closed bool
+	ch     chan *RecordServiceCallReq
+	done   chan struct{}
+
+	// 以下字段只在写入的 goroutine 中访问
+	counts map[dailyCountKey]*dailyCount
+}
+
+type dailyCountKey struct {
+	serviceID  string
+	recordDate string
+}
+
+type dailyCount struct {
+	success, fail int64
+}
+
+func newCallRecordQueue(
+	callRecordRepo gorm.ServiceCallRecordRepo,
+	serviceRepo gorm.ServiceRepo,
+	dataApplicationServiceRepo gorm.DataApplicationServiceRepo,
+	conf settings.CallRecord,
+) *callRecordQueue {
+	q := &callRecordQueue{
+		callRecordRepo:             callRecordRepo,
+		serviceRepo:                serviceRepo,
+		dataApplicationServiceRepo: dataApplicationServiceRepo,
+		batchSize:                  conf.BatchSize,
+		flushInterval:              time.Duration(conf.FlushInterval) * time.Second,
+		spill:                      &callRecordSpill{path: conf.SpillFile},
+		done:                       make(chan struct{}),
+		counts:                     make(map[dailyCountKey]*dailyCount),
+	}
+	if q.batchSize <= 0 {
+		q.batchSize = defaultCallRecordBatchSize
+	}
+	if q.flushInterval <= 0 {
+		q.flushInterval = defaultCallRecordFlushInterval
+	}
+	if q.spill.path == "" {
+		q.spill.path = filepath.Join(os.TempDir(), "data-application-gateway", "service_call_record.jsonl")
+	}
+	queueSize := conf.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultCallRecordQueueSize
+	}
+	q.ch = make(chan *RecordServiceCallReq, queueSize)
+	return q
+}
+
+// start 启动写入的 goroutine
+func (q *callRecordQueue) start() {
+	go q.run()
+}
+
+// observeCall 只记录调用者为应用或签名认证的用户的调用，每日统计由写入的记录累加
+func (q *callRecordQueue) observeCall(req *RecordServiceCallReq) {
+	if _, ok := req.caller(); !ok {
+		return
+	}
+	q.add(req)
+}
+
+// add 将记录放入队列，不阻塞调用方。队列满或已关闭时写入本地文件
+func (q *callRecordQueue) add(req *RecordServiceCallReq) {
+	q.mu.RLock()
+	if !q.closed {
+		select {
+		case q.ch <- req:
+			q.mu.RUnlock()
+			return
+		default:
+		}
+	}
+	q.mu.RUnlock()
+
+	if err := q.spill.write([]*RecordServiceCallReq{req}); err != nil {
+		log.Error("callRecordQueue spill failed, record dropped", zap.Error(err), zap.String("serviceID", req.ServiceID))
+	}
+}
+
+// close 停止接收记录，等待队列中的记录和每日统计写完
+func (q *callRecordQueue) close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	close(q.ch)
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+	case <-time.After(callRecordDrainTimeout):
+		log.Error("callRecordQueue drain timeout", zap.Int("pending", len(q.ch)))
+	}
+}
+
+func (q *callRecordQueue) run() {
+	defer close(q.done)
+
+	ctx := context.Background()
+	ticker := time.NewTicker(q.flushInterval)
+	defer ticker.Stop()
+
+	// 启动时写入上次没有写入的记录
+	q.replay(ctx)
+
+	batch := make([]*RecordServiceCallReq, 0, q.batchSize)
+	for {
+		select {
+		case req, ok := <-q.ch:
+			if !ok {
+				q.write(ctx, batch)
+				q.flushCounts(ctx)
+				return
+			}
+			batch = append(batch, req)
+			if len(batch) >= q.batchSize {
+				q.write(ctx, batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			ok := q.write(ctx, batch)
+			batch = batch[:0]
+			q.flushCounts(ctx)
+			// 数据库可以写入时重新写入本地文件中的记录，没有新记录时由重新写入的第一批探测
+			if ok {
+				q.replay(ctx)
+			}
+		}
+	}
+}
+
+// write 写入一批记录，写入数据库失败时写入本地文件并返回 false。
+// 查询接口失败的记录写入本地文件，之后重新写入
+func (q *callRecordQueue) write(ctx context.Context, batch []*RecordServiceCallReq) bool {
+	if len(batch) == 0 {
+		return true
+	}
+
+	records := make([]*model.ServiceCallRecord, 0, len(batch))
+	written := make([]*RecordServiceCallReq, 0, len(batch))
+	pending := make([]*RecordServiceCallReq, 0)
+	for _, req := range batch {
+		// ServiceGet 有缓存，同一接口的记录不会重复查询数据库
+		service, err := q.serviceRepo.ServiceGet(ctx, req.ServicePath)
+		if err == nil && service != nil && service.ServiceID != "" {
+			records = append(records, req.toModel(service))
+			written = append(written, req)
+			continue
+		}
+		if err == nil {
+			// 接口不存在，可能已经删除
+			req.LookupAttempts++
+			if req.LookupAttempts > callRecordMaxLookupAttempts {
+				log.WithContext(ctx).Error("callRecordQueue service not found, record dropped", zap.String("servicePath", req.ServicePath), zap.Int("attempts", req.LookupAttempts))
+				continue
+			}
+		}
+		log.WithContext(ctx).Warn("callRecordQueue service lookup failed, spill to file", zap.Error(err), zap.String("servicePath", req.ServicePath))
+		pending = append(pending, req)
+	}
+
+	if len(records) > 0 {
+		if err := q.callRecordRepo.BatchCreate(ctx, records); err != nil {
+			log.WithContext(ctx).Error("callRecordQueue BatchCreate failed, spill to file", zap.Error(err), zap.Int("count", len(written)))
+			q.spillRecords(ctx, append(written, pending...))
+			return false
+		}
+	}
+	q.spillRecords(ctx, pending)
+
+	// 记录写入成功后再统计，写入失败的记录重新写入时统计
+	for _, record := range records {
+		key := dailyCountKey{serviceID: record.ServiceID, recordDate: record.CallStartTime.Format("2006-01-02")}
+		count, ok := q.counts[key]
+		if !ok {
+			count = &dailyCount{}
+			q.counts[key] = count
+		}
+		if record.CallStatus == 1 {
+			count.success++
+		} else {
+			count.fail++
+		}
+	}
+	return true
+}
+
+// spillRecords 将记录写入本地文件，写入失败时丢弃
+func (q *callRecordQueue) spillRecords(ctx context.Context, reqs []*RecordServiceCallReq) {
+	if err := q.spill.write(reqs); err != nil {
+		log.WithContext(ctx).Error("callRecordQueue spill failed, records dropped", zap.Error(err), zap.Int("count", len(reqs)))
+	}
+}
+
+// flushCounts 将内存中的每日统计写入数据库，失败的保留到下次写入
+func (q *callRecordQueue) flushCounts(ctx context.Context) {
+	for key, count := range q.counts {
+		recordDate, err := time.ParseInLocation("2006-01-02", key.recordDate, time.Local)
+		if err != nil {
+			delete(q.counts, key)
+			continue
+		}
+		if err := q.dataApplicationServiceRepo.AddCounts(ctx, key.serviceID, recordDate, count.success, count.fail); err != nil {
+			log.WithContext(ctx).Error("callRecordQueue AddCounts failed", zap.Error(err), zap.String("serviceID", key.serviceID))
+			continue
+		}
+		delete(q.counts, key)
+	}
+}
+
+// replay 重新写入本地文件中的记录，写入失败时剩余的记录直接写回文件
+func (q *callRecordQueue) replay(ctx context.Context) {
+	reqs, err := q.spill.take()
+	if err != nil {
+		log.WithContext(ctx).Error("callRecordQueue read spill file failed", zap.Error(err))
+		return
+	}
+	for len(reqs) > 0 {
+		n := q.batchSize
+		if n > len(reqs) {
+			n = len(reqs)
+		}
+		if !q.write(ctx, reqs[:n]) {
+			q.spillRecords(ctx, reqs[n:])
+			return
+		}
+		reqs = reqs[n:]
+	}
+}
+
+// callRecordSpill 保存没有写入数据库的记录，每行一条 JSON
+type callRecordSpill struct {
+	path string
+	mu   sync.Mutex
+}
+
+func (s *callRecordSpill) write(reqs []*RecordServiceCallReq) error {
+	if len(reqs) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	e := json.NewEncoder(w)
+	for _, req := range reqs {
+		if err := e.Encode(req); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// take 读取并删除文件中的所有记录，无法解析的行会被忽略
+func (s *callRecordSpill) take() ([]*RecordServiceCallReq, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var reqs []*RecordServiceCallReq
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for scanner.Scan() {
+		req := &RecordServiceCallReq{}
+		if err := json.Unmarshal(scanner.Bytes(), req); err != nil {
+			log.Warn("callRecordSpill skip invalid line", zap.Error(err))
+			continue
+		}
+		reqs = append(reqs, req)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return reqs, os.Remove(s.path)
+}
@@ -0,0 +1,250 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/gorm"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+	v1 "github.com/kweaver-ai/idrm-go-common/api/auth-service/v1"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+func TestMain(m *testing.M) {
+	// 初始化日志，否则调用 log.Warn 等方法会 panic
+	log.InitLogger(nil, &telemetry.Config{})
+	m.Run()
+}
+
+type fakeCallRecordRepo struct {
+	gorm.ServiceCallRecordRepo
+
+	mu      sync.Mutex
+	err     error
+	batches [][]*model.ServiceCallRecord
+}
+
+func (f *fakeCallRecordRepo) setErr(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+func (f *fakeCallRecordRepo) BatchCreate(_ context.Context, records []*model.ServiceCallRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.batches = append(f.batches, records)
+	return nil
+}
+
+func (f *fakeCallRecordRepo) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var n int
+	for _, b := range f.batches {
+		n += len(b)
+	}
+	return n
+}
+
+type fakeCallRecordServiceRepo struct {
+	gorm.ServiceRepo
+}
+
+func (fakeCallRecordServiceRepo) ServiceGet(_ context.Context, servicePath string) (*model.ServiceAssociations, error) {
+	switch servicePath {
+	case "missing":
+		return &model.ServiceAssociations{}, nil
+	case "broken":
+		return nil, errors.New("db down")
+	}
+	return &model.ServiceAssociations{Service: model.Service{ServiceID: "id-" + servicePath, DepartmentID: "dept"}}, nil
+}
+
+type fakeDailyCountRepo struct {
+	gorm.DataApplicationServiceRepo
+
+	mu     sync.Mutex
+	counts map[string][2]int64
+}
+
+func (f *fakeDailyCountRepo) AddCounts(_ context.Context, serviceID string, recordDate time.Time, successCount, failCount int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := serviceID + "@" + recordDate.Format("2006-01-02")
+	c := f.counts[key]
+	f.counts[key] = [2]int64{c[0] + successCount, c[1] + failCount}
+	return nil
+}
+
+func newTestCallRecordQueue(t *testing.T, records *fakeCallRecordRepo, conf settings.CallRecord) (*callRecordQueue, *fakeDailyCountRepo) {
+	counts := &fakeDailyCountRepo{counts: make(map[string][2]int64)}
+	if conf.SpillFile == "" {
+		conf.SpillFile = filepath.Join(t.TempDir(), "spill.jsonl")
+	}
+	if conf.FlushInterval == 0 {
+		conf.FlushInterval = 3600
+	}
+	return newCallRecordQueue(records, fakeCallRecordServiceRepo{}, counts, conf), counts
+}
+
+func newTestCallRecordReq(servicePath string, status int, start time.Time) *RecordServiceCallReq {
+	return &RecordServiceCallReq{ServicePath: servicePath, CallStatus: status, CallStartTime: start}
+}
+
+func Test_callRecordQueue_batchAndDrain(t *testing.T) {
+	records := &fakeCallRecordRepo{}
+	q, counts := newTestCallRecordQueue(t, records, settings.CallRecord{BatchSize: 2})
+	q.start()
+
+	day := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	q.add(newTestCallRecordReq("a", 1, day))
+	q.add(newTestCallRecordReq("a", 0, day))
+	q.add(newTestCallRecordReq("a", 1, day.AddDate(0, 0, 1)))
+	q.add(newTestCallRecordReq("b", 1, day))
+	q.add(newTestCallRecordReq("missing", 1, day))
+	q.close()
+
+	// 满两条写一批，关闭时写入剩余的记录
+	require.Len(t, records.batches, 2)
+	assert.Len(t, records.batches[0], 2)
+	assert.Equal(t, 4, records.count())
+	assert.Equal(t, "id-a", records.batches[0][0].ServiceID)
+	assert.Equal(t, "dept", records.batches[0][0].ServiceDepartmentID)
+
+	assert.Equal(t, map[string][2]int64{
+		"id-a@2024-05-01": {1, 1},
+		"id-a@2024-05-02": {1, 0},
+		"id-b@2024-05-01": {1, 0},
+	}, counts.counts)
+
+	// 接口不存在的记录和关闭后的记录写入本地文件
+	q.add(newTestCallRecordReq("a", 1, day))
+	reqs, err := q.spill.take()
+	require.NoError(t, err)
+	require.Len(t, reqs, 2)
+	assert.Equal(t, "missing", reqs[0].ServicePath)
+	assert.Equal(t, 1, reqs[0].LookupAttempts)
+}
+
+func Test_callRecordQueue_spill(t *testing.T) {
+	spillFile := filepath.Join(t.TempDir(), "spill.jsonl")
+	day := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+
+	// 队列满和写入失败的记录写入本地文件
+	failed := &fakeCallRecordRepo{err: errors.New("db down")}
+	q, _ := newTestCallRecordQueue(t, failed, settings.CallRecord{QueueSize: 1, SpillFile: spillFile})
+	q.add(newTestCallRecordReq("a", 1, day))
+	q.add(newTestCallRecordReq("b", 0, day))
+	q.start()
+	q.close()
+	assert.Empty(t, failed.batches)
+
+	// 启动时重新写入本地文件中的记录
+	records := &fakeCallRecordRepo{}
+	q, counts := newTestCallRecordQueue(t, records, settings.CallRecord{SpillFile: spillFile})
+	q.start()
+	q.close()
+	assert.Equal(t, 2, records.count())
+	assert.Equal(t, map[string][2]int64{
+		"id-a@2024-05-01": {1, 0},
+		"id-b@2024-05-01": {0, 1},
+	}, counts.counts)
+
+	reqs, err := q.spill.take()
+	require.NoError(t, err)
+	assert.Empty(t, reqs)
+}
+
+func Test_callRecordQueue_observeCall(t *testing.T) {
+	records := &fakeCallRecordRepo{}
+	q, counts := newTestCallRecordQueue(t, records, settings.CallRecord{})
+	q.start()
+
+	// 只记录有调用者的调用：应用或签名认证的用户
+	day := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	app := newTestCallRecordReq("a", 1, day)
+	app.Caller = &v1.Subject{ID: "app", Type: v1.SubjectAPP}
+	q.observeCall(app)
+	signed := newTestCallRecordReq("a", 1, day)
+	signed.Caller, signed.CallerSigned = &v1.Subject{ID: "user", Type: v1.SubjectUser}, true
+	q.observeCall(signed)
+	// 令牌认证的用户和没有调用者的调用不记录
+	user := newTestCallRecordReq("a", 1, day)
+	user.Caller = &v1.Subject{ID: "user", Type: v1.SubjectUser}
+	q.observeCall(user)
+	q.observeCall(newTestCallRecordReq("a", 1, day))
+	q.close()
+
+	assert.Equal(t, 2, records.count())
+	assert.Equal(t, map[string][2]int64{"id-a@2024-05-01": {2, 0}}, counts.counts)
+}
+
+func Test_callRecordQueue_lookupFailed(t *testing.T) {
+	records := &fakeCallRecordRepo{}
+	q, _ := newTestCallRecordQueue(t, records, settings.CallRecord{})
+	ctx := context.Background()
+	day := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+
+	// 查询接口出错的记录保留，不计入次数
+	broken := newTestCallRecordReq("broken", 1, day)
+	missing := newTestCallRecordReq("missing", 1, day)
+	missing.LookupAttempts = callRecordMaxLookupAttempts - 1
+	assert.True(t, q.write(ctx, []*RecordServiceCallReq{broken, missing, newTestCallRecordReq("a", 1, day)}))
+	assert.Equal(t, 1, records.count())
+	reqs, err := q.spill.take()
+	require.NoError(t, err)
+	require.Len(t, reqs, 2)
+	assert.Equal(t, "broken", reqs[0].ServicePath)
+	assert.Zero(t, reqs[0].LookupAttempts)
+	assert.Equal(t, callRecordMaxLookupAttempts, reqs[1].LookupAttempts)
+
+	// 接口一直不存在的记录超过次数后丢弃
+	assert.True(t, q.write(ctx, reqs[1:]))
+	reqs, err = q.spill.take()
+	require.NoError(t, err)
+	assert.Empty(t, reqs)
+
+	// 写入数据库失败时，查询接口失败的记录也写入本地文件
+	records.setErr(errors.New("db down"))
+	assert.False(t, q.write(ctx, []*RecordServiceCallReq{newTestCallRecordReq("a", 1, day), newTestCallRecordReq("broken", 1, day)}))
+	reqs, err = q.spill.take()
+	require.NoError(t, err)
+	assert.Len(t, reqs, 2)
+}
+
+func Test_callRecordQueue_periodicReplay(t *testing.T) {
+	records := &fakeCallRecordRepo{err: errors.New("db down")}
+	q, counts := newTestCallRecordQueue(t, records, settings.CallRecord{})
+	q.flushInterval = 10 * time.Millisecond
+	q.start()
+	defer q.close()
+
+	day := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	q.add(newTestCallRecordReq("a", 1, day))
+	q.add(newTestCallRecordReq("b", 0, day))
+
+	// 数据库恢复后，不需要重启也会重新写入本地文件中的记录
+	time.Sleep(50 * time.Millisecond)
+	assert.Zero(t, records.count())
+	records.setErr(nil)
+	assert.Eventually(t, func() bool { return records.count() == 2 }, time.Second, 10*time.Millisecond)
+
+	q.close()
+	assert.Equal(t, map[string][2]int64{
+		"id-a@2024-05-01": {1, 0},
+		"id-b@2024-05-01": {0, 1},
+	}, counts.counts)
+}
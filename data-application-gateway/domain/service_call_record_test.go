@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	v1 "github.com/kweaver-ai/idrm-go-common/api/auth-service/v1"
+)
+
+func Test_RecordServiceCallReq_caller(t *testing.T) {
+	app := &v1.Subject{ID: "app-1", Type: v1.SubjectAPP}
+	user := &v1.Subject{ID: "user-1", Type: v1.SubjectUser}
+	tests := []struct {
+		name   string
+		caller *v1.Subject
+		signed bool
+		want   *v1.Subject
+	}{
+		{name: "token app", caller: app, want: app},
+		{name: "signed user", caller: user, signed: true, want: user},
+		{name: "token user", caller: user},
+		{name: "no caller"},
+		{name: "caller without id", caller: &v1.Subject{Type: v1.SubjectUser}, signed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := (&RecordServiceCallReq{Caller: tt.caller, CallerSigned: tt.signed}).caller()
+			assert.Equal(t, tt.want != nil, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
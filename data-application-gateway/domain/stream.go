@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/virtual_engine"
+)
+
+// rowsReader 逐行读取查询结果并编码为 {"total_count": n, "data": [...]}，
+// 内存中只保留当前行，与 page size 无关
+type rowsReader struct {
+	rows       virtual_engine.Rows
+	totalCount int64
+	// handle 在编码前处理每一行，如去掉未授权的列、脱敏
+	handle func(row map[string]interface{})
+
+	buf     bytes.Buffer
+	started bool
+	done    bool
+	count   int
+}
+
+func newRowsReader(rows virtual_engine.Rows, totalCount int64, handle func(row map[string]interface{})) *rowsReader {
+	return &rowsReader{rows: rows, totalCount: totalCount, handle: handle}
+}
+
+// prime 编码到第一行数据或结果结束。查询的错误大多在读取第一行时返回，
+// 在发送状态码之前调用，出错时可以返回错误响应，而不是状态码 200 和不完整的 JSON
+func (r *rowsReader) prime() error {
+	for !r.done && r.count == 0 {
+		if err := r.fill(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *rowsReader) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.fill(); err != nil {
+			return 0, err
+		}
+	}
+	return r.buf.Read(p)
+}
+
+// fill 编码下一部分数据到 buf
+func (r *rowsReader) fill() error {
+	if !r.started {
+		r.started = true
+		r.buf.WriteString(`{"total_count":`)
+		r.buf.WriteString(strconv.FormatInt(r.totalCount, 10))
+		r.buf.WriteString(`,"data":[`)
+		return nil
+	}
+
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		r.buf.WriteString("]}")
+		r.done = true
+		return nil
+	}
+
+	row := r.rows.Row()
+	if r.handle != nil {
+		r.handle(row)
+	}
+	b, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	if r.count > 0 {
+		r.buf.WriteByte(',')
+	}
+	r.buf.Write(b)
+	r.count++
+	return nil
+}
+
+func (r *rowsReader) Close() error {
+	return r.rows.Close()
+}
@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/adapter/driven/virtual_engine"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/enum"
+)
+
+type fakeRows struct {
+	rows   []map[string]interface{}
+	err    error
+	row    map[string]interface{}
+	closed bool
+}
+
+func (f *fakeRows) Next() bool {
+	if len(f.rows) == 0 {
+		return false
+	}
+	f.row, f.rows = f.rows[0], f.rows[1:]
+	return true
+}
+
+func (f *fakeRows) Row() map[string]interface{} { return f.row }
+
+func (f *fakeRows) Err() error { return f.err }
+
+func (f *fakeRows) Close() error {
+	f.closed = true
+	return nil
+}
+
+func Test_rowsReader(t *testing.T) {
+	tests := []struct {
+		name       string
+		rows       []map[string]interface{}
+		totalCount int64
+	}{
+		{name: "empty", totalCount: 0},
+		{name: "one row", rows: []map[string]interface{}{{"a": "1", "b": json.Number("2")}}, totalCount: 5},
+		{
+			name:       "rows",
+			rows:       []map[string]interface{}{{"a": "<x>"}, {"a": nil}, {"a": "王"}},
+			totalCount: 100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 与整体编码的结果一致
+			want, err := json.Marshal(&virtual_engine.FetchRes{TotalCount: int(tt.totalCount), Data: append(make([]map[string]interface{}, 0), tt.rows...)})
+			assert.NoError(t, err)
+
+			rows := &fakeRows{rows: tt.rows}
+			r := newRowsReader(rows, tt.totalCount, nil)
+			// 每次只读一个字节
+			got, err := io.ReadAll(iotest.OneByteReader(r))
+			assert.NoError(t, err)
+			assert.JSONEq(t, string(want), string(got))
+			assert.Equal(t, string(want), string(got))
+
+			assert.NoError(t, r.Close())
+			assert.True(t, rows.closed)
+		})
+	}
+}
+
+func Test_rowsReader_handle(t *testing.T) {
+	rows := &fakeRows{rows: []map[string]interface{}{{"name": "王小明", "phone": "13812345678"}, {"name": "张三", "phone": "1"}}}
+	masker := newResponseMasker(nil, testHashKey)
+	masker.rules = map[string]string{"name": enum.MaskingReplace}
+	r := newRowsReader(rows, 2, func(row map[string]interface{}) {
+		restrictRow(row, map[string]struct{}{"name": {}})
+		masker.maskRow(row)
+	})
+
+	got, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"total_count":2,"data":[{"name":"王*明"},{"name":"张*"}]}`, string(got))
+}
+
+func Test_rowsReader_error(t *testing.T) {
+	rows := &fakeRows{rows: []map[string]interface{}{{"a": "1"}}, err: errors.New("broken")}
+	r := newRowsReader(rows, 2, nil)
+
+	got, err := io.ReadAll(r)
+	assert.EqualError(t, err, "broken")
+	assert.Equal(t, `{"total_count":2,"data":[{"a":"1"}`, string(got))
+}
+
+func Test_rowsReader_prime(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    []map[string]interface{}
+		err     error
+		want    string
+		wantErr string
+	}{
+		{name: "empty", want: `{"total_count":2,"data":[]}`},
+		{name: "rows", rows: []map[string]interface{}{{"a": "1"}, {"a": "2"}}, want: `{"total_count":2,"data":[{"a":"1"},{"a":"2"}]}`},
+		{name: "读取第一行出错", err: errors.New("broken"), wantErr: "broken"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRowsReader(&fakeRows{rows: tt.rows, err: tt.err}, 2, nil)
+			err := r.prime()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			got, err := io.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+// subServiceScopeFieldIDs 返回已授权子接口(行列规则)限定的字段ID的并集。
+// 没有子接口，或任一子接口未限定字段时 restricted 为 false，表示不限制返回的列
+func subServiceScopeFieldIDs(subServices []model.SubService) (fieldIDs []string, restricted bool, err error) {
+	if len(subServices) == 0 {
+		return nil, false, nil
+	}
+
+	unique := make(map[string]struct{})
+	for _, subService := range subServices {
+		if subService.Detail == "" {
+			return nil, false, nil
+		}
+		detail := &dto.SubServiceDetail{}
+		if err = json.Unmarshal([]byte(subService.Detail), detail); err != nil {
+			return nil, false, err
+		}
+		if len(detail.ScopeFields) == 0 {
+			return nil, false, nil
+		}
+		for _, id := range detail.ScopeFields {
+			if _, ok := unique[id]; ok {
+				continue
+			}
+			unique[id] = struct{}{}
+			fieldIDs = append(fieldIDs, id)
+		}
+	}
+
+	return fieldIDs, true, nil
+}
+
+// subServiceScopeColumns 返回已授权子接口允许返回的列名(小写)，返回 nil 表示不限制返回的列
+func (u *QueryDomain) subServiceScopeColumns(c context.Context, service *model.ServiceAssociations) (columns map[string]struct{}, err error) {
+	fieldIDs, restricted, err := subServiceScopeFieldIDs(service.SubServices)
+	if err != nil {
+		log.WithContext(c).Error("subServiceScopeColumns decode sub service detail", zap.Error(err), zap.String("service_id", service.ServiceID))
+		return nil, errorcode.Detail(errorcode.PublicInternalError, err.Error())
+	}
+	if !restricted {
+		return nil, nil
+	}
+
+	// 子接口中保存的是逻辑视图的字段ID，需要转换为字段的技术名称
+	fieldsRes, err := u.dataView.GetDataViewFieldByInternal(c, service.ServiceDataSource.DataViewID)
+	if err != nil {
+		return nil, err
+	}
+	technicalNames := make(map[string]string, len(fieldsRes.FieldsRes))
+	for _, field := range fieldsRes.FieldsRes {
+		technicalNames[field.ID] = field.TechnicalName
+	}
+
+	columns = make(map[string]struct{}, len(fieldIDs))
+	for _, id := range fieldIDs {
+		name, ok := technicalNames[id]
+		if !ok {
+			continue
+		}
+		columns[strings.ToLower(name)] = struct{}{}
+	}
+	return columns, nil
+}
+
+// restrictResponseParams 去掉不在授权范围内的返回参数，请求参数保持不变
+func restrictResponseParams(serviceParams []model.ServiceParam, columns map[string]struct{}) (restricted []model.ServiceParam) {
+	restricted = make([]model.ServiceParam, 0, len(serviceParams))
+	for _, p := range serviceParams {
+		if p.ParamType == "response" {
+			if _, ok := columns[strings.ToLower(p.EnName)]; !ok {
+				continue
+			}
+		}
+		restricted = append(restricted, p)
+	}
+	return restricted
+}
+
+// restrictRow 去掉查询结果中不在授权范围内的列。脚本模式的 SELECT 由用户编写，只能在结果上处理
+func restrictRow(row map[string]interface{}, columns map[string]struct{}) {
+	for column := range row {
+		if _, ok := columns[strings.ToLower(column)]; !ok {
+			delete(row, column)
+		}
+	}
+}
+
+// hasResponseParam 是否存在返回参数
+func hasResponseParam(serviceParams []model.ServiceParam) bool {
+	for _, p := range serviceParams {
+		if p.ParamType == "response" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/infrastructure/repository/db/model"
+)
+
+func Test_subServiceScopeFieldIDs(t *testing.T) {
+	tests := []struct {
+		name           string
+		subServices    []model.SubService
+		wantFieldIDs   []string
+		wantRestricted bool
+		wantErr        bool
+	}{
+		{name: "no sub service"},
+		{
+			name:        "empty detail",
+			subServices: []model.SubService{{Detail: `{"scope_fields":["a"]}`}, {}},
+		},
+		{
+			name:        "scope fields not set",
+			subServices: []model.SubService{{Detail: `{"scope_fields":["a"]}`}, {Detail: `{"scope_fields":[]}`}},
+		},
+		{
+			name:           "single sub service",
+			subServices:    []model.SubService{{Detail: `{"scope_fields":["a","b"]}`}},
+			wantFieldIDs:   []string{"a", "b"},
+			wantRestricted: true,
+		},
+		{
+			name:           "union of sub services",
+			subServices:    []model.SubService{{Detail: `{"scope_fields":["a","b"]}`}, {Detail: `{"scope_fields":["b","c"]}`}},
+			wantFieldIDs:   []string{"a", "b", "c"},
+			wantRestricted: true,
+		},
+		{
+			name:        "invalid detail",
+			subServices: []model.SubService{{Detail: `{"scope_fields":`}},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fieldIDs, restricted, err := subServiceScopeFieldIDs(tt.subServices)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantFieldIDs, fieldIDs)
+			assert.Equal(t, tt.wantRestricted, restricted)
+		})
+	}
+}
+
+func Test_restrictResponseParams(t *testing.T) {
+	serviceParams := []model.ServiceParam{
+		{ParamType: "request", EnName: "phone"},
+		{ParamType: "response", EnName: "Name"},
+		{ParamType: "response", EnName: "phone"},
+		{ParamType: "response", EnName: "age"},
+	}
+	columns := map[string]struct{}{"name": {}, "age": {}}
+
+	got := restrictResponseParams(serviceParams, columns)
+	assert.Equal(t, []model.ServiceParam{
+		{ParamType: "request", EnName: "phone"},
+		{ParamType: "response", EnName: "Name"},
+		{ParamType: "response", EnName: "age"},
+	}, got)
+	assert.True(t, hasResponseParam(got))
+
+	got = restrictResponseParams(serviceParams, map[string]struct{}{})
+	assert.Equal(t, []model.ServiceParam{{ParamType: "request", EnName: "phone"}}, got)
+	assert.False(t, hasResponseParam(got))
+}
+
+func Test_restrictRow(t *testing.T) {
+	columns := map[string]struct{}{"name": {}, "age": {}}
+
+	row := map[string]interface{}{"NAME": "a", "phone": "b", "age": 1}
+	restrictRow(row, columns)
+	assert.Equal(t, map[string]interface{}{"NAME": "a", "age": 1}, row)
+
+	row = map[string]interface{}{"name": nil, "phone": nil}
+	restrictRow(row, columns)
+	assert.Equal(t, map[string]interface{}{"name": nil}, row)
+}
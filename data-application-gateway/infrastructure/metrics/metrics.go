@@ -0,0 +1,135 @@
+// Package metrics 网关的 Prometheus 指标，通过单独的内部端口的 /metrics 暴露，不经过网关入口。
+//
+// 接口调用按接口 ID、接口类型、调用方应用和返回码统计次数和耗时，
+// 虚拟化引擎查询和注册接口访问后台服务的耗时单独统计。
+// 指标注册在独立的 Registry 中，不使用 prometheus 的全局 Registry。
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "data_application_gateway"
+
+// 结果标签的取值
+const (
+	ResultOK    = "ok"
+	ResultError = "error"
+)
+
+// 虚拟化引擎的操作
+const (
+	OperationFetch      = "fetch"
+	OperationFetchCount = "fetch_count"
+)
+
+// Registry 网关的指标
+var Registry = prometheus.NewRegistry()
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "requests_total",
+		Help:      "数据查询接口的调用次数",
+	}, []string{"service_id", "service_type", "caller_app", "code"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "request_duration_seconds",
+		Help:      "数据查询接口的调用耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"service_id", "service_type", "caller_app", "code"})
+
+	requestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "requests_in_flight",
+		Help:      "正在处理的数据查询接口请求数",
+	})
+
+	virtualEngineDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "virtual_engine_duration_seconds",
+		Help:      "虚拟化引擎查询的耗时，fetch 包括读取全部结果的时间",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "result"})
+
+	upstreamDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_duration_seconds",
+		Help:      "注册接口请求后台服务的耗时，每次重试单独统计，不包括读取响应体的时间",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"backend", "code"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requestsTotal,
+		requestDuration,
+		requestsInFlight,
+		virtualEngineDuration,
+		upstreamDuration,
+	)
+}
+
+// Handler 返回 /metrics 的处理函数
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Request 一次数据查询接口调用的测量结果
+type Request struct {
+	ServiceID   string
+	ServiceType string
+	// 调用方应用 ID，调用方不是应用时为空
+	CallerApp string
+	// 返回给调用方的 HTTP 状态码
+	Code     int
+	Duration time.Duration
+}
+
+// ObserveRequest 记录一次数据查询接口调用
+func ObserveRequest(r *Request) {
+	labels := prometheus.Labels{
+		"service_id":   r.ServiceID,
+		"service_type": r.ServiceType,
+		"caller_app":   r.CallerApp,
+		"code":         strconv.Itoa(r.Code),
+	}
+	requestsTotal.With(labels).Inc()
+	requestDuration.With(labels).Observe(r.Duration.Seconds())
+}
+
+// TrackInFlight 增加正在处理的请求数，返回的函数在请求处理完成时调用
+func TrackInFlight() func() {
+	requestsInFlight.Inc()
+	return requestsInFlight.Dec
+}
+
+// ObserveVirtualEngine 记录一次虚拟化引擎查询的耗时
+func ObserveVirtualEngine(operation string, start time.Time, err error) {
+	virtualEngineDuration.WithLabelValues(operation, result(err)).Observe(time.Since(start).Seconds())
+}
+
+// ObserveUpstream 记录一次后台服务请求的耗时，code 为后台服务返回的状态码，请求失败时为 0
+func ObserveUpstream(backend string, code int, start time.Time) {
+	c := ResultError
+	if code != 0 {
+		c = strconv.Itoa(code)
+	}
+	upstreamDuration.WithLabelValues(backend, c).Observe(time.Since(start).Seconds())
+}
+
+func result(err error) string {
+	if err != nil {
+		return ResultError
+	}
+	return ResultOK
+}
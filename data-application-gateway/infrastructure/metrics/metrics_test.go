@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveRequest(t *testing.T) {
+	ObserveRequest(&Request{ServiceID: "s1", ServiceType: "service_register", CallerApp: "app", Code: http.StatusOK, Duration: 20 * time.Millisecond})
+	ObserveRequest(&Request{ServiceID: "s1", ServiceType: "service_register", CallerApp: "app", Code: http.StatusOK, Duration: 30 * time.Millisecond})
+	ObserveRequest(&Request{ServiceID: "s1", ServiceType: "service_register", CallerApp: "app", Code: http.StatusBadRequest})
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(requestsTotal.WithLabelValues("s1", "service_register", "app", "200")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(requestsTotal.WithLabelValues("s1", "service_register", "app", "400")))
+	assert.Equal(t, 2, testutil.CollectAndCount(requestDuration, "data_application_gateway_request_duration_seconds"))
+}
+
+func TestTrackInFlight(t *testing.T) {
+	done := TrackInFlight()
+	assert.Equal(t, float64(1), testutil.ToFloat64(requestsInFlight))
+	done()
+	assert.Equal(t, float64(0), testutil.ToFloat64(requestsInFlight))
+}
+
+func TestHandler(t *testing.T) {
+	ObserveVirtualEngine(OperationFetchCount, time.Now(), errors.New("timeout"))
+	ObserveUpstream("http://backend", http.StatusBadGateway, time.Now())
+	ObserveUpstream("http://backend", 0, time.Now())
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `data_application_gateway_virtual_engine_duration_seconds_count{operation="fetch_count",result="error"} 1`)
+	assert.Contains(t, body, `data_application_gateway_upstream_duration_seconds_count{backend="http://backend",code="502"} 1`)
+	assert.Contains(t, body, `data_application_gateway_upstream_duration_seconds_count{backend="http://backend",code="error"} 1`)
+	assert.Contains(t, body, "go_goroutines")
+}
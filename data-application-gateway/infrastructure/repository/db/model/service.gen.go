@@ -47,6 +47,7 @@ type Service struct {
 	HTTPMethod         string    `gorm:"column:http_method;type:varchar(10);not null" json:"http_method"`                          // 请求方式 post get
 	ReturnType         string    `gorm:"column:return_type;type:varchar(10);not null" json:"return_type"`                          // 返回类型 json
 	Protocol           string    `gorm:"column:protocol;type:varchar(10);not null" json:"protocol"`                                // 协议 http
+	RequestMapping     *string   `gorm:"column:request_mapping;type:text" json:"request_mapping"`                                  // 请求映射 JSON
 	FileID             string    `gorm:"column:file_id;type:varchar(255);not null" json:"file_id"`                                 // 接口文档id
 	Description        string    `gorm:"column:description;type:text" json:"description"`                                          // 接口说明
 	DeveloperID        string    `gorm:"column:developer_id;type:varchar(255);not null" json:"developer_id"`                       // 开发商id
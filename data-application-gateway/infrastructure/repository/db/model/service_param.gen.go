@@ -30,6 +30,7 @@ type ServiceParam struct {
 	Sort                string `gorm:"column:sort;type:varchar(10);not null" json:"sort"`                    // 排序方式 unsorted 不排序 asc 升序 desc 降序 默认 unsorted
 	Masking             string `gorm:"column:masking;type:varchar(10);not null" json:"masking"`              // 脱敏规则 plaintext 不脱敏 hash 哈希 override 覆盖 replace 替换 默认 plaintext
 	DataProtectionQuery bool `gorm:"-"` 
+	ColumnType          string `gorm:"-"` // 逻辑视图中同名字段的数据类型，查询时设置
 	CreateTime          time.Time `gorm:"column:create_time;type:datetime;not null;default:current_timestamp()" json:"create_time"` // 创建时间
 	UpdateTime          time.Time `gorm:"column:update_time;type:datetime;not null;autoUpdateTime" json:"update_time"`              // 更新时间
 	DeleteTime          uint64    `gorm:"column:delete_time;type:bigint(20) unsigned;not null" json:"delete_time"`                  // 删除时间
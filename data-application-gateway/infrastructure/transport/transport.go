@@ -0,0 +1,181 @@
+// Package transport 网关访问后端服务共享的 HTTP 连接。
+//
+// 服务端证书使用内置的根证书（common/cacert）和配置的 CA 证书校验，
+// 后端可以单独配置 mTLS 客户端证书；不校验服务端证书需要显式配置并填写原因。
+// 相同 TLS 配置的请求共享同一个 http.Transport，在请求之间复用连接。
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/cacert"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+const (
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 20
+	defaultIdleConnTimeout     = 90 * time.Second
+)
+
+// Transports 按后端区分的 HTTP 客户端，零值和 nil 使用只信任内置根证书的默认客户端
+type Transports struct {
+	def      *http.Client
+	backends map[string]*http.Client
+}
+
+// NewTransports 根据配置创建 HTTP 客户端，证书文件不存在、格式错误或不校验证书但未填写原因时返回错误
+func NewTransports(s *settings.Settings) (*Transports, error) {
+	conf := s.Transport
+	roots, err := newCertPool(conf.CAFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Transports{
+		def:      newClient(conf, &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}),
+		backends: make(map[string]*http.Client, len(conf.Backends)),
+	}
+	for host, backend := range conf.Backends {
+		client, err := newBackendClient(conf, roots, host, backend)
+		if err != nil {
+			return nil, fmt.Errorf("transport backend %q: %w", host, err)
+		}
+		t.backends[host] = client
+	}
+	return t, nil
+}
+
+// Client 返回访问 rawURL 使用的 HTTP 客户端。超时由调用方通过 context 控制
+func (t *Transports) Client(rawURL string) *http.Client {
+	if t == nil || t.def == nil {
+		return defaultClient
+	}
+	if u, err := url.Parse(rawURL); err == nil {
+		if c, ok := t.backends[u.Host]; ok {
+			return c
+		}
+		if c, ok := t.backends[u.Hostname()]; ok {
+			return c
+		}
+	}
+	return t.def
+}
+
+// defaultClient 未配置时使用的客户端，只信任内置的根证书
+var defaultClient = newClient(settings.Transport{}, &tls.Config{RootCAs: bundledCertPool(), MinVersion: tls.VersionTLS12})
+
+func newBackendClient(conf settings.Transport, roots *x509.CertPool, host string, backend settings.BackendTLS) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		RootCAs:    roots,
+		ServerName: backend.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+	if len(backend.CAFiles) > 0 {
+		pool, err := newCertPool(append(append([]string{}, conf.CAFiles...), backend.CAFiles...))
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if backend.CertFile != "" || backend.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(backend.CertFile, backend.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	client := newClient(conf, tlsConfig)
+	if backend.InsecureSkipVerify {
+		if backend.InsecureSkipVerifyReason == "" {
+			return nil, errors.New("insecure_skip_verify requires insecure_skip_verify_reason")
+		}
+		tlsConfig.InsecureSkipVerify = true
+		log.Warn("transport skip tls verify", zap.String("backend", host), zap.String("reason", backend.InsecureSkipVerifyReason))
+		client.Transport = &insecureRoundTripper{
+			next:   client.Transport,
+			host:   host,
+			reason: backend.InsecureSkipVerifyReason,
+		}
+	}
+	return client, nil
+}
+
+func newClient(conf settings.Transport, tlsConfig *tls.Config) *http.Client {
+	t := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          conf.MaxIdleConns,
+		MaxIdleConnsPerHost:   conf.MaxIdleConnsPerHost,
+		IdleConnTimeout:       time.Duration(conf.IdleConnTimeout) * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	if t.MaxIdleConns <= 0 {
+		t.MaxIdleConns = defaultMaxIdleConns
+	}
+	if t.MaxIdleConnsPerHost <= 0 {
+		t.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	if t.IdleConnTimeout <= 0 {
+		t.IdleConnTimeout = defaultIdleConnTimeout
+	}
+	return &http.Client{Transport: t}
+}
+
+// insecureRoundTripper 记录不校验服务端证书的请求
+type insecureRoundTripper struct {
+	next         http.RoundTripper
+	host, reason string
+}
+
+func (r *insecureRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "https" {
+		trace.SpanFromContext(req.Context()).AddEvent("tls insecure skip verify", trace.WithAttributes(
+			attribute.String("backend", r.host),
+			attribute.String("reason", r.reason),
+		))
+		log.WithContext(req.Context()).Info("transport request skip tls verify", zap.String("backend", r.host), zap.String("url", req.URL.Redacted()))
+	}
+	return r.next.RoundTrip(req)
+}
+
+func bundledCertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM([]byte(cacert.CaCert))
+	return pool
+}
+
+// newCertPool 返回内置的根证书加上 files 中的证书
+func newCertPool(files []string) (*x509.CertPool, error) {
+	pool := bundledCertPool()
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificate found in %s", file)
+		}
+	}
+	return pool, nil
+}
@@ -0,0 +1,166 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-gateway/common/settings"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+func TestMain(m *testing.M) {
+	// 初始化日志，否则调用 log.Warn 等方法会 panic
+	log.InitLogger(nil, &telemetry.Config{})
+	m.Run()
+}
+
+// writeServerCert 将测试服务端的证书和私钥写入文件，返回证书和私钥文件路径
+func writeServerCert(t *testing.T, srv *httptest.Server) (certFile, keyFile string) {
+	dir := t.TempDir()
+	cert := srv.TLS.Certificates[0]
+	certFile = filepath.Join(dir, "cert.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0o600))
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	require.NoError(t, err)
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0o600))
+	return certFile, keyFile
+}
+
+func newTLSServer(t *testing.T, clientAuth tls.ClientAuthType) *httptest.Server {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) > 0 {
+			w.Header().Set("X-Client-Cert", "true")
+		}
+	}))
+	srv.TLS = &tls.Config{ClientAuth: clientAuth}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestTransports_Client(t *testing.T) {
+	srv := newTLSServer(t, tls.NoClientCert)
+	certFile, _ := writeServerCert(t, srv)
+	host := mustParseURL(t, srv.URL).Host
+
+	tests := []struct {
+		name    string
+		conf    settings.Transport
+		wantErr bool
+	}{
+		{
+			name:    "只信任内置根证书",
+			wantErr: true,
+		},
+		{
+			name: "全局 CA 证书",
+			conf: settings.Transport{CAFiles: []string{certFile}},
+		},
+		{
+			name: "后端 CA 证书",
+			conf: settings.Transport{Backends: map[string]settings.BackendTLS{host: {CAFiles: []string{certFile}}}},
+		},
+		{
+			name:    "后端 CA 证书只对该后端生效",
+			conf:    settings.Transport{Backends: map[string]settings.BackendTLS{"other:443": {CAFiles: []string{certFile}}}},
+			wantErr: true,
+		},
+		{
+			name: "不校验服务端证书",
+			conf: settings.Transport{Backends: map[string]settings.BackendTLS{host: {InsecureSkipVerify: true, InsecureSkipVerifyReason: "test"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transports, err := NewTransports(&settings.Settings{Transport: tt.conf})
+			require.NoError(t, err)
+			resp, err := transports.Client(srv.URL).Get(srv.URL)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		})
+	}
+}
+
+func TestTransports_mTLS(t *testing.T) {
+	srv := newTLSServer(t, tls.RequireAnyClientCert)
+	certFile, keyFile := writeServerCert(t, srv)
+	host := mustParseURL(t, srv.URL).Host
+
+	transports, err := NewTransports(&settings.Settings{Transport: settings.Transport{
+		CAFiles:  []string{certFile},
+		Backends: map[string]settings.BackendTLS{host: {CertFile: certFile, KeyFile: keyFile}},
+	}})
+	require.NoError(t, err)
+	resp, err := transports.Client(srv.URL).Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "true", resp.Header.Get("X-Client-Cert"))
+
+	// 没有配置客户端证书的后端无法访问
+	transports, err = NewTransports(&settings.Settings{Transport: settings.Transport{CAFiles: []string{certFile}}})
+	require.NoError(t, err)
+	_, err = transports.Client(srv.URL).Get(srv.URL)
+	assert.Error(t, err)
+}
+
+func TestNewTransports_invalid(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "not.pem")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0o600))
+
+	tests := []struct {
+		name string
+		conf settings.Transport
+	}{
+		{name: "CA 文件不存在", conf: settings.Transport{CAFiles: []string{filepath.Join(dir, "missing.pem")}}},
+		{name: "CA 文件没有证书", conf: settings.Transport{CAFiles: []string{notPEM}}},
+		{name: "后端 CA 文件没有证书", conf: settings.Transport{Backends: map[string]settings.BackendTLS{"a": {CAFiles: []string{notPEM}}}}},
+		{name: "客户端证书不存在", conf: settings.Transport{Backends: map[string]settings.BackendTLS{"a": {CertFile: notPEM, KeyFile: notPEM}}}},
+		{name: "不校验证书但没有原因", conf: settings.Transport{Backends: map[string]settings.BackendTLS{"a": {InsecureSkipVerify: true}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTransports(&settings.Settings{Transport: tt.conf})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestTransports_backendLookup(t *testing.T) {
+	transports, err := NewTransports(&settings.Settings{Transport: settings.Transport{Backends: map[string]settings.BackendTLS{
+		"a.example.com:8443": {ServerName: "a"},
+		"b.example.com":      {ServerName: "b"},
+	}}})
+	require.NoError(t, err)
+
+	serverName := func(c *http.Client) string {
+		return c.Transport.(*http.Transport).TLSClientConfig.ServerName
+	}
+	assert.Equal(t, "a", serverName(transports.Client("https://a.example.com:8443/api")))
+	assert.Equal(t, "", serverName(transports.Client("https://a.example.com/api")))
+	assert.Equal(t, "b", serverName(transports.Client("https://b.example.com:9443/api")))
+	assert.Same(t, defaultClient, (*Transports)(nil).Client("https://b.example.com"))
+}
+
+func mustParseURL(t *testing.T, rawURL string) *url.URL {
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	return u
+}
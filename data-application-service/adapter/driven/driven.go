@@ -23,6 +23,7 @@ var Set = wire.NewSet(
 	gorm.NewAuditProcessBindRepo,
 	gorm.NewServiceStatsRepo,
 	gorm.NewServiceApplyRepo,
+	gorm.NewServiceVersionRepo,
 	gorm.NewAppRepo,
 	gorm.NewServiceGateway,
 	gorm.NewSubServiceImpl,
@@ -56,6 +56,8 @@ type ServiceRepo interface {
 	ServiceFormToScript(ctx context.Context, req *dto.ServiceFormToSqlReq) (res *dto.ServiceFormToSqlRes, err error)
 	IsServiceNameExist(ctx context.Context, serviceName, serviceID string) (exist bool, err error)
 	IsServicePathExist(ctx context.Context, servicePath, serviceID string) (exist bool, err error)
+	// ServicePathConflict 返回与带路径变量的接口路径冲突的其他接口路径，没有冲突时返回空
+	ServicePathConflict(ctx context.Context, servicePath, serviceID string) (conflict string, err error)
 	IsServiceIDExist(ctx context.Context, serviceID string) (exist bool, err error)
 	IsServiceIDStatusExist(ctx context.Context, serviceID, status string) (exist bool, err error)
 	IsServiceIDInStatusesExist(ctx context.Context, serviceID string, statuses []string) (exist bool, err error)
@@ -64,7 +66,7 @@ type ServiceRepo interface {
 	ServiceESIndexCreate(ctx context.Context, service *model.Service) (err error)
 	ServiceESIndexDelete(ctx context.Context, service *model.Service) (err error)
 	ServiceESIndex(ctx context.Context, service *model.Service, indexType string) (err error)
-	AuditProcessInstanceCreate(ctx context.Context, serviceID string, audit *model.Service) (err error)
+	AuditProcessInstanceCreate(ctx context.Context, serviceID string, audit *model.Service, transitions ...*LifecycleTransition) (err error)
 	IsExistAuditing(ctx context.Context, serviceID string) (exist bool, err error)
 	GetSubjectDomainIdsByUserId(ctx context.Context, userId string) (subjectDomainIds []string, err error)
 	ConsumerWorkflowAuditResultPublish(ctx context.Context, msg *common.AuditResultMsg) error
@@ -76,17 +78,17 @@ type ServiceRepo interface {
 	ConsumerWorkflowAuditProcDeleteChange(ctx context.Context, msg *common.AuditProcDefDelMsg) error
 	ConsumerWorkflowAuditProcDeleteOffline(ctx context.Context, msg *common.AuditProcDefDelMsg) error
 	ConsumerWorkflowAuditProcDeleteOnline(ctx context.Context, msg *common.AuditProcDefDelMsg) error
-	ServiceVersionBack(ctx context.Context, serviceID string) (resp *dto.ServiceIdRes, err error)
-	ServiceUpdateStatus(ctx context.Context, serviceID string, lineStatus string) (resp *dto.ServiceIdRes, err error)
+	ServiceVersionBack(ctx context.Context, serviceID string, transitions ...*LifecycleTransition) (resp *dto.ServiceIdRes, err error)
+	ServiceUpdateStatus(ctx context.Context, serviceID string, lineStatus string, transitions ...*LifecycleTransition) (resp *dto.ServiceIdRes, err error)
 	// 更新指定接口服务的上线状态 status 和发布状态 publish_status 为指定值
-	ServiceUpdateStatusAndPublishStatus(ctx context.Context, status, publishStatus string, opts ServiceUpdateOptions) error
+	ServiceUpdateStatusAndPublishStatus(ctx context.Context, status, publishStatus string, opts ServiceUpdateOptions, transitions ...*LifecycleTransition) error
 	UndoChangeAuditToUpdateService(ctx context.Context, serviceID string) (resp *dto.ServiceIdRes, err error)
-	UpdateServicePublishStatus(ctx context.Context, serviceID string, publishStatus string) (resp *dto.ServiceIdRes, err error)
-	ServiceChangeInPublished(ctx context.Context, req *dto.ServiceChangeReq) (resp *dto.ServiceIdRes, err error)
-	ServiceChangeInChangeAuditReject(ctx context.Context, req *dto.ServiceChangeReq) (resp *dto.ServiceIdRes, err error)
+	UpdateServicePublishStatus(ctx context.Context, serviceID string, publishStatus string, transitions ...*LifecycleTransition) (resp *dto.ServiceIdRes, err error)
+	ServiceChangeInPublished(ctx context.Context, req *dto.ServiceChangeReq, transitions ...*LifecycleTransition) (resp *dto.ServiceIdRes, err error)
+	ServiceChangeInChangeAuditReject(ctx context.Context, req *dto.ServiceChangeReq, transitions ...*LifecycleTransition) (resp *dto.ServiceIdRes, err error)
 	GetDraftService(ctx context.Context, serviceID string) (resp *dto.ServiceGetRes, err error)
 	IsExistDraftService(ctx context.Context, serviceID string) (exist bool, err error)
-	RecoverToPublished(ctx context.Context, serviceID string) (resp *dto.ServiceIdRes, err error)
+	RecoverToPublished(ctx context.Context, serviceID string, transitions ...*LifecycleTransition) (resp *dto.ServiceIdRes, err error)
 	GetAllUndeleteServiceByOffset(ctx context.Context, offset int, limit int) (services []*model.Service, err error)
 	GetAllUndeleteServiceByServices(ctx context.Context, servicesIds ...string) (Services []*model.Service, err error)
 	GetAllUndeleteServiceCount(ctx context.Context) (count int64, err error)
@@ -109,6 +111,12 @@ type ServiceRepo interface {
 	GetServicesByIDs(ctx context.Context, ids []string) (res []*dto.ServiceInfoAndDraftFlag, err error)
 	// 处理回调事件
 	HandleCallbackEvent(ctx context.Context, serviceID string) error
+	// 接口生命周期的迁移记录
+	ListLifecycleHistory(ctx context.Context, serviceID string) ([]*model.ServiceLifecycleHistory, error)
+	// 子服务（行列规则）变更后记录接口的版本
+	RecordSubServiceVersion(ctx context.Context, serviceID string) error
+	// 将接口的子服务恢复为版本快照中的子服务，并记录接口的版本
+	RestoreSubServices(ctx context.Context, serviceID string, subServices []dto.ServiceVersionSubService) error
 }
 
 // ServiceStatusStatistics 服务状态统计结果
@@ -225,6 +233,17 @@ func (r *serviceRepo) ServiceCreate(ctx context.Context, req *dto.ServiceCreateO
 			log.WithContext(ctx).Error("ServiceCreate", zap.Error(errorcode.Desc(errorcode.ServicePathExist)))
 			return nil, errorcode.Desc(errorcode.ServicePathExist)
 		}
+
+		//检查带路径变量的接口路径是否与其他接口冲突
+		conflict, err := r.ServicePathConflict(ctx, req.ServiceInfo.ServicePath, "")
+		if err != nil {
+			log.WithContext(ctx).Error("ServiceCreate", zap.Error(err))
+			return nil, err
+		}
+		if conflict != "" {
+			log.WithContext(ctx).Error("ServiceCreate", zap.String("conflict", conflict))
+			return nil, errorcode.Desc(errorcode.ServicePathConflict, conflict)
+		}
 	}
 
 	ownerIDs := make([]string, 0, len(req.ServiceInfo.Owners))
@@ -303,6 +322,13 @@ func (r *serviceRepo) ServiceCreate(ctx context.Context, req *dto.ServiceCreateO
 		}
 	}
 
+	//请求映射，密钥加密后保存
+	requestMapping, err := encodeRequestMapping(req.ServiceInfo.ServiceType, req.ServiceInfo.RequestMapping)
+	if err != nil {
+		log.WithContext(ctx).Error("ServiceCreate", zap.Error(err))
+		return nil, err
+	}
+
 	//生成接口编码
 	serviceCode := ""
 	codeGeneration, err := r.configurationCenterRepo.CodeGeneration(ctx, microservice.CodeGenerationRuleApiID, 1)
@@ -333,8 +359,9 @@ func (r *serviceRepo) ServiceCreate(ctx context.Context, req *dto.ServiceCreateO
 		PrePath:           &req.ServiceInfo.PrePath,
 		CreateModel:       req.ServiceParam.CreateModel,
 		HTTPMethod:        req.ServiceInfo.HTTPMethod,
-		ReturnType:        "json",
+		ReturnType:        serviceReturnType(req.ServiceInfo.ServiceType, req.ServiceInfo.ReturnType),
 		Protocol:          "http",
+		RequestMapping:    requestMapping,
 		Description:       &req.ServiceInfo.Description,
 		DeveloperID:       req.ServiceInfo.Developer.ID,
 		DeveloperName:     req.ServiceInfo.Developer.Name,
@@ -1520,6 +1547,7 @@ func (r *serviceRepo) ServiceGet(ctx context.Context, serviceID string) (res *dt
 			HTTPMethod:         s.HTTPMethod,
 			ReturnType:         s.ReturnType,
 			Protocol:           s.Protocol,
+			RequestMapping:     decodeRequestMapping(ctx, s.RequestMapping),
 			File: dto.File{
 				FileID:   s.File.FileID,
 				FileName: s.File.FileName,
@@ -1823,6 +1851,17 @@ func (r *serviceRepo) ServiceUpdate(ctx context.Context, req *dto.ServiceUpdateR
 			log.WithContext(ctx).Error("ServiceUpdate", zap.Error(errorcode.Desc(errorcode.ServicePathExist)))
 			return errorcode.Desc(errorcode.ServicePathExist)
 		}
+
+		//检查带路径变量的接口路径是否与其他接口冲突
+		conflict, err := r.ServicePathConflict(ctx, req.ServiceInfo.ServicePath, req.ServiceID)
+		if err != nil {
+			log.WithContext(ctx).Error("ServiceUpdate", zap.Error(err))
+			return err
+		}
+		if conflict != "" {
+			log.WithContext(ctx).Error("ServiceUpdate", zap.String("conflict", conflict))
+			return errorcode.Desc(errorcode.ServicePathConflict, conflict)
+		}
 	}
 
 	//检查接口状态 只有处于[草稿]状态的接口 才能编辑
@@ -1961,6 +2000,13 @@ func (r *serviceRepo) ServiceUpdate(ctx context.Context, req *dto.ServiceUpdateR
 		}
 	}
 
+	//请求映射，密钥加密后保存
+	requestMapping, err := encodeRequestMapping(req.ServiceInfo.ServiceType, req.ServiceInfo.RequestMapping)
+	if err != nil {
+		log.WithContext(ctx).Error("ServiceUpdate", zap.Error(err))
+		return err
+	}
+
 	//更新人
 	userId := util.GetUser(ctx).Id
 
@@ -1979,8 +2025,9 @@ func (r *serviceRepo) ServiceUpdate(ctx context.Context, req *dto.ServiceUpdateR
 			"subject_domain_id": req.ServiceInfo.SubjectDomainId,
 			"create_model":      req.ServiceParam.CreateModel,
 			"http_method":       req.ServiceInfo.HTTPMethod,
-			"return_type":       "json",
+			"return_type":       serviceReturnType(req.ServiceInfo.ServiceType, req.ServiceInfo.ReturnType),
 			"protocol":          "http",
+			"request_mapping":   requestMapping,
 			"description":       req.ServiceInfo.Description,
 			"developer_id":      req.ServiceInfo.Developer.ID,
 			"developer_name":    req.ServiceInfo.Developer.Name,
@@ -2410,6 +2457,58 @@ func (r *serviceRepo) IsServicePathExist(ctx context.Context, servicePath, servi
 	var count int64
 	tx := r.data.DB.WithContext(ctx).Model(&model.Service{}).Scopes(Undeleted()).Where(&model.Service{ServicePath: servicePath})
 
+	tx, err = r.excludeServiceAndDraft(ctx, tx, serviceID)
+	if err != nil {
+		return false, err
+	}
+
+	tx.Count(&count)
+	if tx.Error != nil {
+		log.WithContext(ctx).Error("IsServicePathExist", zap.Error(tx.Error))
+		return false, tx.Error
+	}
+
+	if count > 0 {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (r *serviceRepo) ServicePathConflict(ctx context.Context, servicePath, serviceID string) (conflict string, err error) {
+	if !util.IsPathTemplate(servicePath) {
+		return "", nil
+	}
+	t, err := util.ParsePathTemplate(servicePath)
+	if err != nil {
+		return "", errorcode.Detail(errorcode.PublicInvalidParameter, err.Error())
+	}
+
+	tx := r.data.DB.WithContext(ctx).Model(&model.Service{}).Scopes(Undeleted()).Where("service_path LIKE ?", "%{%")
+	tx, err = r.excludeServiceAndDraft(ctx, tx, serviceID)
+	if err != nil {
+		return "", err
+	}
+
+	var paths []string
+	if err = tx.Distinct("service_path").Pluck("service_path", &paths).Error; err != nil {
+		log.WithContext(ctx).Error("ServicePathConflict", zap.Error(err))
+		return "", err
+	}
+	for _, p := range paths {
+		o, err := util.ParsePathTemplate(p)
+		if err != nil {
+			continue
+		}
+		if t.Conflicts(o) {
+			return p, nil
+		}
+	}
+	return "", nil
+}
+
+// excludeServiceAndDraft 排除接口本身和它的草稿，允许草稿和其Vn版本使用相同的接口路径
+func (r *serviceRepo) excludeServiceAndDraft(ctx context.Context, tx *gorm.DB, serviceID string) (*gorm.DB, error) {
 	if serviceID != "" {
 		tx = tx.Where("service_id != ?", serviceID)
 	}
@@ -2420,24 +2519,13 @@ func (r *serviceRepo) IsServicePathExist(ctx context.Context, servicePath, servi
 		Where("changed_service_id = ?", serviceID).
 		Find(&DraftService)
 	if t.Error != nil {
-		return false, t.Error
+		return nil, t.Error
 	}
 	//当前有草稿，允许草稿和其Vn版本重名
 	if DraftService.ServiceID != "" {
 		tx = tx.Where("service_id != ?", DraftService.ServiceID)
 	}
-
-	tx.Count(&count)
-	if tx.Error != nil {
-		log.WithContext(ctx).Error("IsServicePathExist", zap.Error(tx.Error))
-		return false, tx.Error
-	}
-
-	if count > 0 {
-		return true, nil
-	}
-
-	return false, nil
+	return tx, nil
 }
 
 func (r *serviceRepo) IsServiceIDExist(ctx context.Context, serviceID string) (exist bool, err error) {
@@ -2714,7 +2802,8 @@ func (r *serviceRepo) ServiceESIndex(ctx context.Context, service *model.Service
 	return nil
 }
 
-func (r *serviceRepo) AuditProcessInstanceCreate(ctx context.Context, serviceID string, audit *model.Service) (err error) {
+// AuditProcessInstanceCreate 标记接口的审核状态并发起审核，transitions 与接口状态在同一个事务中记录
+func (r *serviceRepo) AuditProcessInstanceCreate(ctx context.Context, serviceID string, audit *model.Service, transitions ...*LifecycleTransition) (err error) {
 	service := &model.Service{}
 	tx := r.data.DB.WithContext(ctx).Model(&model.Service{}).
 		Select([]string{"service_id", "service_code", "service_name", "changed_service_id"}).
@@ -2730,6 +2819,9 @@ func (r *serviceRepo) AuditProcessInstanceCreate(ctx context.Context, serviceID
 			log.WithContext(ctx).Error("AuditProcessInstanceCreate", zap.Error(tx.Error))
 			return tx.Error
 		}
+		if err := createLifecycleHistories(ctx, tx, transitions...); err != nil {
+			return err
+		}
 
 		audit.ServiceID = service.ServiceID
 		audit.ServiceCode = service.ServiceCode
@@ -2746,6 +2838,12 @@ func (r *serviceRepo) AuditProcessInstanceCreate(ctx context.Context, serviceID
 	if audit.AuditStatus == enum.AuditStatusPass {
 		if audit.AuditType == enum.AuditTypeChange {
 			err = r.HandleChangeAuditPass(ctx, audit.ApplyID)
+			if err == nil {
+				if err = r.recordServiceVersion(ctx, service.ChangedServiceId, audit.AuditType); err != nil {
+					log.WithContext(ctx).Error("AuditProcessInstanceCreate 记录接口版本失败", zap.String("serviceID", service.ChangedServiceId), zap.Error(err))
+					return err
+				}
+			}
 			if err = r.ServiceESIndexCreate(ctx, &model.Service{ServiceID: service.ChangedServiceId}); err != nil {
 				return err
 			}
@@ -2753,6 +2851,12 @@ func (r *serviceRepo) AuditProcessInstanceCreate(ctx context.Context, serviceID
 				return err
 			}
 		} else {
+			if audit.AuditType == enum.AuditTypePublish {
+				if err = r.recordServiceVersion(ctx, service.ServiceID, audit.AuditType); err != nil {
+					log.WithContext(ctx).Error("AuditProcessInstanceCreate 记录接口版本失败", zap.String("serviceID", service.ServiceID), zap.Error(err))
+					return err
+				}
+			}
 			if err = r.PushCatalogMessage(context.Background(), service.ServiceID, "create"); err != nil { //Create AuditInstance
 				return err
 			}
@@ -2978,85 +3082,83 @@ func (r *serviceRepo) ConsumerWorkflowAuditResultOnline(_ context.Context, resul
 	return r.consumerWorkflowAuditResult(enum.AuditTypeOnline, result)
 }
 
+// rejectIllegalAuditResult 审核结果不能迁移接口当前的状态时，接口的状态不变，审核中的申请记为未通过并记录原因，
+// 避免接口一直处于审核中。已经处理过的审核结果重复消费时申请不在审核中，不会修改
+func (r *serviceRepo) rejectIllegalAuditResult(auditType string, result *common.AuditResultMsg, fireErr error) error {
+	log.Error("consumerWorkflowAuditResult 审核结果与接口状态不符，审核记为未通过", zap.Error(fireErr), zap.Any("msg", fmt.Sprintf("%#v", result)))
+
+	advice := "审核结果与接口当前状态不符：" + fireErr.Error()
+	service := &model.Service{
+		AuditStatus: enum.AuditStatusReject,
+		UpdateTime:  time.Now(),
+	}
+	if auditType == enum.AuditTypeOnline || auditType == enum.AuditTypeOffline {
+		service.OnlineAuditAdvice = advice
+	} else {
+		service.AuditAdvice = advice
+	}
+
+	tx := r.data.DB.Model(&model.Service{}).Scopes(Undeleted()).
+		Where(&model.Service{ApplyID: result.ApplyID, AuditStatus: enum.AuditStatusAuditing}).
+		Updates(service)
+	if tx.Error != nil {
+		// 更新失败时返回错误，消息重新消费
+		log.Error("consumerWorkflowAuditResult reject audit", zap.Error(tx.Error), zap.Any("msg", fmt.Sprintf("%#v", result)))
+		return tx.Error
+	}
+	return nil
+}
+
 func (r *serviceRepo) consumerWorkflowAuditResult(auditType string, result *common.AuditResultMsg) error {
 	t := time.Now()
 	service := &model.Service{
 		UpdateTime: t,
 	}
 	log.Info("consumerWorkflowAuditResult", zap.Any("result", result))
-	switch result.Result {
-	case enum.AuditStatusPass: //审核通过
-		service.AuditStatus = enum.AuditStatusPass // 更新审核状态
-		switch auditType {
-		//发布审核的结果
-		case enum.AuditTypePublish:
-			service.PublishStatus = enum.PublishStatusPublished
-			service.PublishTime = &t // 更新发布时间
-		//变更审核的结果
-		case enum.AuditTypeChange:
-			service.PublishStatus = enum.PublishStatusPublished
-			service.UpdateTime = time.Now()
-		//上线审核的结果
-		case enum.AuditTypeOnline:
-			service.Status = enum.LineStatusOnLine
-			service.UpdateTime = time.Now()
-			service.OnlineTime = &t
-		//下线审核的结果
-		case enum.AuditTypeOffline:
-			service.Status = enum.LineStatusOffLine
-			service.UpdateTime = time.Now()
-		}
-	case enum.AuditStatusReject: // 审核拒绝，结果为reject
-		service.AuditStatus = enum.AuditStatusReject
-		switch auditType {
-		//发布审核的结果
-		case enum.AuditTypePublish:
-			service.PublishStatus = enum.PublishStatusPubReject
-			service.UpdateTime = time.Now()
-		//变更审核的结果
-		case enum.AuditTypeChange:
-			service.PublishStatus = enum.PublishStatusChangeReject
-			service.UpdateTime = time.Now()
-		//上线审核的结果
-		case enum.AuditTypeOnline:
-			service.Status = enum.LineStatusUpReject
-			service.UpdateTime = time.Now()
-		//下线审核的结果
-		case enum.AuditTypeOffline:
-			service.Status = enum.LineStatusDownReject
-			service.UpdateTime = time.Now()
-		}
-	case enum.AuditStatusUndone:
-		service.AuditStatus = enum.AuditStatusUndone
-		switch auditType {
-		//发布审核的结果
-		case enum.AuditTypePublish:
-			service.PublishStatus = enum.PublishStatusUnPublished
-			service.UpdateTime = time.Now()
-		//变更审核的结果
-		case enum.AuditTypeChange:
-			service.PublishStatus = enum.PublishStatusPublished
-			service.UpdateTime = time.Now()
-		//上线审核的结果
-		case enum.AuditTypeOnline:
-			service.Status = enum.LineStatusNotLine
-			service.UpdateTime = time.Now()
-		//下线审核的结果
-		case enum.AuditTypeOffline:
-			service.Status = enum.LineStatusOnLine
-			service.UpdateTime = time.Now()
-		}
-	}
 
-	// 查询旧状态用于埋点
+	// 查询旧状态，用于生命周期迁移和埋点
 	var oldService model.Service
 	tx := r.data.DB.Scopes(Undeleted()).
-		Select([]string{"service_id", "status"}).
+		Select([]string{"service_id", "publish_status", "status", "changed_service_id", "flow_id"}).
 		Where(&model.Service{ApplyID: result.ApplyID}).
 		First(&oldService)
+	if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		log.Warn("consumerWorkflowAuditResult 忽略不存在的接口的审核结果", zap.Any("msg", fmt.Sprintf("%#v", result)))
+		return nil
+	}
 	if tx.Error != nil {
+		// 查询失败时返回错误，消息重新消费，避免按错误的旧状态迁移
 		log.Error("consumerWorkflowAuditResult get old status", zap.Error(tx.Error))
-		// 不影响主流程，继续执行
+		return tx.Error
+	}
+
+	switch result.Result {
+	case enum.AuditStatusPass, enum.AuditStatusReject, enum.AuditStatusUndone:
+		service.AuditStatus = result.Result // 更新审核状态
+	}
+
+	// 由生命周期状态机根据审核结果确定接口的目标状态
+	trigger := enum.AuditLifecycleTrigger(auditType, result.Result)
+	from := enum.NewLifecycleState(oldService.PublishStatus, oldService.Status)
+	to, fireErr := enum.FireLifecycle(from, trigger)
+	if trigger != "" && fireErr != nil {
+		if result.Result != enum.AuditStatusUndone {
+			return r.rejectIllegalAuditResult(auditType, result, fireErr)
+		}
+		// 发布、上线、下线审核撤回时，撤回操作已经迁移了接口状态，只更新审核状态
+		to = from
+	}
+	if to.PublishStatus != from.PublishStatus {
+		service.PublishStatus = to.PublishStatus
+	}
+	if to.Status != from.Status {
+		service.Status = to.Status
+	}
+	switch trigger {
+	case enum.LifecycleTriggerPublishPass:
+		service.PublishTime = &t // 更新发布时间
+	case enum.LifecycleTriggerOnlinePass:
+		service.OnlineTime = &t
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -3066,8 +3168,8 @@ func (r *serviceRepo) consumerWorkflowAuditResult(auditType string, result *comm
 			Where(&model.Service{ApplyID: result.ApplyID}).
 			Updates(service)
 		if t.Error != nil {
-			log.Error("serviceRepo consumerWorkflowAuditResult Update", zap.Any("msg", fmt.Sprintf("%#v", result)))
-			return tx.Error
+			log.Error("serviceRepo consumerWorkflowAuditResult Update", zap.Error(t.Error), zap.Any("msg", fmt.Sprintf("%#v", result)))
+			return t.Error
 		}
 
 		// 在事务内查询
@@ -3076,8 +3178,8 @@ func (r *serviceRepo) consumerWorkflowAuditResult(auditType string, result *comm
 			Where(&model.Service{ApplyID: result.ApplyID}).
 			Find(&service)
 		if t.Error != nil {
-			log.Error("serviceRepo consumerWorkflowAuditResult Update", zap.Any("msg", fmt.Sprintf("%#v", result)))
-			return tx.Error
+			log.Error("serviceRepo consumerWorkflowAuditResult Update", zap.Error(t.Error), zap.Any("msg", fmt.Sprintf("%#v", result)))
+			return t.Error
 		}
 		log.Info("r.data.DB.Transaction", zap.Any("service", service))
 
@@ -3097,6 +3199,10 @@ func (r *serviceRepo) consumerWorkflowAuditResult(auditType string, result *comm
 			t := tx.Model(service).
 				Where(&model.Service{ID: service.ID}).
 				Updates(service)
+			if t.Error != nil {
+				log.Error("ServiceUpdate", zap.Error(t.Error))
+				return t.Error
+			}
 			//把请求与响应表的ServiceID更新为Vn版本的ServiceID
 			// 使用事务内的 tx 而不是 r.data.DB
 			t = tx.Model(&model.ServiceDataSource{}).
@@ -3127,8 +3233,8 @@ func (r *serviceRepo) consumerWorkflowAuditResult(auditType string, result *comm
 				Where(&model.ServiceScriptModel{ServiceID: Vn1ServiceId}).
 				Update("service_id", Vn1ChangeServiceID)
 			if t.Error != nil {
-				log.Error("serviceRepo consumerWorkflowAuditResult Update", zap.Any("msg", fmt.Sprintf("%#v", result)))
-				return tx.Error
+				log.Error("serviceRepo consumerWorkflowAuditResult Update", zap.Error(t.Error), zap.Any("msg", fmt.Sprintf("%#v", result)))
+				return t.Error
 			}
 
 			// 先删除vn1ChangeServiceID版本类目信息
@@ -3136,16 +3242,16 @@ func (r *serviceRepo) consumerWorkflowAuditResult(auditType string, result *comm
 				Where(&model.ServiceCategoryRelation{ServiceID: Vn1ChangeServiceID}).
 				Delete(&model.ServiceCategoryRelation{})
 			if t.Error != nil {
-				log.Error("serviceRepo consumerWorkflowAuditResult Update", zap.Any("msg", fmt.Sprintf("%#v", result)))
-				return tx.Error
+				log.Error("serviceRepo consumerWorkflowAuditResult Update", zap.Error(t.Error), zap.Any("msg", fmt.Sprintf("%#v", result)))
+				return t.Error
 			}
 			// 更新类目信息
 			t = tx.Model(&model.ServiceCategoryRelation{}).
 				Where(&model.ServiceCategoryRelation{ServiceID: Vn1ServiceId}).
 				Update("service_id", Vn1ChangeServiceID)
 			if t.Error != nil {
-				log.Error("serviceRepo consumerWorkflowAuditResult Update", zap.Any("msg", fmt.Sprintf("%#v", result)))
-				return tx.Error
+				log.Error("serviceRepo consumerWorkflowAuditResult Update", zap.Error(t.Error), zap.Any("msg", fmt.Sprintf("%#v", result)))
+				return t.Error
 			}
 
 		}
@@ -3161,46 +3267,41 @@ func (r *serviceRepo) consumerWorkflowAuditResult(auditType string, result *comm
 				Where("publish_status in ?", []string{"change-auditing", "change-reject"}).
 				Find(&ServiceIdV2)
 			if t.Error != nil || len(ServiceIdV2.ServiceID) == 0 {
-				log.Error("serviceRepo consumerWorkflowAuditResult --> 查询变更审核中或变更审核未通过版本（Vn+1版本）的数据失败，已发布版本ServiceId为："+service.ServiceID, zap.Any("msg", fmt.Sprintf("%#v", result)))
-				return tx.Error
+				log.Error("serviceRepo consumerWorkflowAuditResult --> 查询变更审核中或变更审核未通过版本（Vn+1版本）的数据失败，已发布版本ServiceId为："+service.ServiceID, zap.Error(t.Error), zap.Any("msg", fmt.Sprintf("%#v", result)))
+				return t.Error
 			}
 			serviceV2 := &model.Service{
+				Status:     to.Status,
 				UpdateTime: time.Now(),
 			}
-			switch auditType {
-			case enum.AuditTypeOnline:
-				switch result.Result {
-				case enum.AuditStatusPass:
-					serviceV2.Status = enum.LineStatusOnLine
-				case enum.AuditStatusReject:
-					serviceV2.Status = enum.LineStatusUpReject
-				case enum.AuditStatusUndone:
-					serviceV2.Status = enum.LineStatusNotLine
-
-				}
-			case enum.AuditTypeOffline:
-				switch result.Result {
-				case enum.AuditStatusPass:
-					serviceV2.Status = enum.LineStatusOffLine
-				case enum.AuditStatusReject:
-					serviceV2.Status = enum.LineStatusDownReject
-				case enum.AuditStatusUndone:
-					serviceV2.Status = enum.LineStatusOnLine
-
-				}
-			}
 			t := tx.Model(serviceV2).
 				Where(&model.Service{ServiceID: ServiceIdV2.ServiceID}).
 				Updates(serviceV2)
 			if t.Error != nil {
-				log.Error("serviceRepo consumerWorkflowAuditResult --> 更新变更审核中或变更审核未通过版本（Vn+1版本）的上线状态失败", zap.Any("msg", fmt.Sprintf("%#v", result)))
-				return tx.Error
+				log.Error("serviceRepo consumerWorkflowAuditResult --> 更新变更审核中或变更审核未通过版本（Vn+1版本）的上线状态失败", zap.Error(t.Error), zap.Any("msg", fmt.Sprintf("%#v", result)))
+				return t.Error
 			}
 
 		}
 
+		// 在同一个事务中记录生命周期的迁移，记录失败时状态一起回滚，消息重新消费
+		if trigger != "" {
+			return createLifecycleHistories(ctx, tx, &LifecycleTransition{
+				ServiceID: LifecycleServiceID(oldService.ServiceID, oldService.ChangedServiceId),
+				Trigger:   trigger,
+				From:      from,
+				To:        to,
+				ApplyID:   result.ApplyID,
+				FlowID:    oldService.FlowID,
+			})
+		}
 		return nil
 	})
+	if err != nil {
+		// 事务回滚时返回错误，消息重新消费
+		log.Error("consumerWorkflowAuditResult Transaction", zap.Error(err), zap.Any("msg", fmt.Sprintf("%#v", result)))
+		return err
+	}
 
 	// 异步埋点：监听状态变更并更新每日统计
 	if err == nil && service.Status != oldService.Status {
@@ -3259,6 +3360,14 @@ func (r *serviceRepo) consumerWorkflowAuditResult(auditType string, result *comm
 	// 	}
 	// }
 
+	// 发布审核、变更审核通过后记录接口版本，记录失败时返回错误重新消费消息，同一个审核申请只记录一次
+	if err == nil && service.AuditStatus == enum.AuditStatusPass && (auditType == enum.AuditTypePublish || auditType == enum.AuditTypeChange) {
+		if err := r.recordServiceVersion(context.Background(), service.ServiceID, auditType); err != nil {
+			log.Error("consumerWorkflowAuditResult 记录接口版本失败", zap.String("serviceID", service.ServiceID), zap.Error(err))
+			return err
+		}
+	}
+
 	log.Info("consumerWorkflowAuditResult 发布审核通过、上线审核通过、变更审核通过后，创建es索引", zap.Any("auditType", auditType), zap.Any("service.AuditStatus", service.AuditStatus))
 
 	if service.AuditStatus == enum.AuditStatusPass && (auditType == enum.AuditTypePublish || auditType == enum.AuditTypeChange || auditType == enum.AuditTypeOnline || auditType == enum.AuditTypeOffline) {
@@ -3408,7 +3517,7 @@ func (r *serviceRepo) consumerWorkflowAuditProcDelete(auditType string, result *
 	return nil
 }
 
-func (r *serviceRepo) ServiceVersionBack(ctx context.Context, serviceID string) (resp *dto.ServiceIdRes, err error) {
+func (r *serviceRepo) ServiceVersionBack(ctx context.Context, serviceID string, transitions ...*LifecycleTransition) (resp *dto.ServiceIdRes, err error) {
 	//变更审核不通过的版本(serviceID为变更不通过的ID）
 	var ChangedService model.Service
 	tx := r.data.DB.WithContext(ctx).
@@ -3452,7 +3561,7 @@ func (r *serviceRepo) ServiceVersionBack(ctx context.Context, serviceID string)
 			log.Error("serviceRepo ServiceVersionBack Update", zap.Error(t.Error))
 			return tx.Error
 		}
-		return nil
+		return createLifecycleHistories(ctx, tx, transitions...)
 	})
 	if err != nil {
 		return nil, err
@@ -3461,7 +3570,7 @@ func (r *serviceRepo) ServiceVersionBack(ctx context.Context, serviceID string)
 
 }
 
-func (r *serviceRepo) ServiceUpdateStatus(ctx context.Context, serviceID string, lineStatus string) (resp *dto.ServiceIdRes, err error) {
+func (r *serviceRepo) ServiceUpdateStatus(ctx context.Context, serviceID string, lineStatus string, transitions ...*LifecycleTransition) (resp *dto.ServiceIdRes, err error) {
 	// 查询旧状态用于埋点
 	oldService, err := r.ServiceGetFields(ctx, serviceID, []string{"status"})
 	if err != nil {
@@ -3488,12 +3597,16 @@ func (r *serviceRepo) ServiceUpdateStatus(ctx context.Context, serviceID string,
 	} else {
 		Service.AuditStatus = enum.AuditStatusAuditing
 	}
-	tx := r.data.DB.Model(Service).
-		Where("service_id = ?", serviceID).
-		Updates(Service)
-	if tx.Error != nil {
-		log.Error("ServiceUpdateStatus Update Service", zap.Error(tx.Error))
-		return nil, tx.Error
+	// 上线状态和生命周期的迁移在同一个事务中记录
+	err = r.data.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(Service).Where("service_id = ?", serviceID).Updates(Service).Error; err != nil {
+			log.Error("ServiceUpdateStatus Update Service", zap.Error(err))
+			return err
+		}
+		return createLifecycleHistories(ctx, tx, transitions...)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// 异步埋点：监听状态变更并更新每日统计
@@ -3511,51 +3624,46 @@ func (r *serviceRepo) ServiceUpdateStatus(ctx context.Context, serviceID string,
 }
 
 // 更新指定接口服务的上线状态 status 和发布状态 publish_status 为指定值
-func (r *serviceRepo) ServiceUpdateStatusAndPublishStatus(ctx context.Context, status, publishStatus string, opts ServiceUpdateOptions) error {
+func (r *serviceRepo) ServiceUpdateStatusAndPublishStatus(ctx context.Context, status, publishStatus string, opts ServiceUpdateOptions, transitions ...*LifecycleTransition) error {
 	log.Debug("update service status and publish status", zap.String("status", status), zap.String("publishStatus", publishStatus), zap.Any("opts", opts))
-	tx := r.data.DB.WithContext(ctx).Model(&model.Service{})
-
-	if opts.Filter != nil {
-		tx = opts.Filter.Filter(tx)
-	}
-	if opts.OrderBy != nil {
-		tx = opts.OrderBy.OrderBy(tx)
-	}
-	if opts.Limit != 0 {
-		tx = tx.Limit(opts.Limit)
+	scope := func(tx *gorm.DB) *gorm.DB {
+		tx = tx.WithContext(ctx).Model(&model.Service{})
+		if opts.Filter != nil {
+			tx = opts.Filter.Filter(tx)
+		}
+		if opts.OrderBy != nil {
+			tx = opts.OrderBy.OrderBy(tx)
+		}
+		if opts.Limit != 0 {
+			tx = tx.Limit(opts.Limit)
+		}
+		return tx
 	}
 
 	// 1. Update执行前,根据tx查询待修改的记录
 	var beforeServices []*model.Service
-	beforeTx := tx.Session(&gorm.Session{})
-	if err := beforeTx.Find(&beforeServices).Error; err != nil {
+	if err := scope(r.data.DB).Find(&beforeServices).Error; err != nil {
 		log.WithContext(ctx).Error("ServiceUpdateStatusAndPublishStatus 查询修改前记录失败", zap.Error(err))
 		return err
 	}
 
-	// 2. Update执行后，如果不报错，根据tx查询已修改的记录
-	err := tx.Updates(&model.Service{
-		Status:        status,
-		PublishStatus: publishStatus,
-	}).Error
-
+	// 2. Update执行后，如果不报错，根据tx查询已修改的记录。状态和生命周期的迁移在同一个事务中记录
+	err := r.data.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := scope(tx).Updates(&model.Service{
+			Status:        status,
+			PublishStatus: publishStatus,
+		}).Error; err != nil {
+			return err
+		}
+		return createLifecycleHistories(ctx, tx, transitions...)
+	})
 	if err != nil {
 		return err
 	}
 
 	// 查询修改后的记录
 	var afterServices []*model.Service
-	afterTx := r.data.DB.WithContext(ctx).Model(&model.Service{})
-	if opts.Filter != nil {
-		afterTx = opts.Filter.Filter(afterTx)
-	}
-	if opts.OrderBy != nil {
-		afterTx = opts.OrderBy.OrderBy(afterTx)
-	}
-	if opts.Limit != 0 {
-		afterTx = afterTx.Limit(opts.Limit)
-	}
-	if err := afterTx.Find(&afterServices).Error; err != nil {
+	if err := scope(r.data.DB).Find(&afterServices).Error; err != nil {
 		log.WithContext(ctx).Error("ServiceUpdateStatusAndPublishStatus 查询修改后记录失败", zap.Error(err))
 		return err
 	}
@@ -3700,7 +3808,7 @@ func (r *serviceRepo) UndoChangeAuditToUpdateService(ctx context.Context, servic
 	return &dto.ServiceIdRes{ServiceID: PreChangedService.ServiceID}, nil
 }
 
-func (r *serviceRepo) UpdateServicePublishStatus(ctx context.Context, serviceID string, publishStatus string) (resp *dto.ServiceIdRes, err error) {
+func (r *serviceRepo) UpdateServicePublishStatus(ctx context.Context, serviceID string, publishStatus string, transitions ...*LifecycleTransition) (resp *dto.ServiceIdRes, err error) {
 	var Service model.Service
 	tx := r.data.DB.WithContext(ctx).
 		Model(&model.Service{}).
@@ -3712,17 +3820,22 @@ func (r *serviceRepo) UpdateServicePublishStatus(ctx context.Context, serviceID
 	}
 	Service.PublishStatus = publishStatus
 	Service.UpdateTime = time.Now()
-	tx = r.data.DB.Model(Service).
-		Where("service_id = ?", serviceID).
-		Updates(Service)
-	if tx.Error != nil {
-		log.Error("UpdateServicePublishStatus Update Service", zap.Error(tx.Error))
-		return nil, tx.Error
+	// 发布状态和生命周期的迁移在同一个事务中记录
+	err = r.data.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(Service).Where("service_id = ?", serviceID).Updates(Service).Error; err != nil {
+			log.Error("UpdateServicePublishStatus Update Service", zap.Error(err))
+			return err
+		}
+		return createLifecycleHistories(ctx, tx, transitions...)
+	})
+	if err != nil {
+		return nil, err
 	}
 	return &dto.ServiceIdRes{ServiceID: serviceID}, nil
 }
 
-func (r *serviceRepo) ServiceChangeInPublished(ctx context.Context, req *dto.ServiceChangeReq) (resp *dto.ServiceIdRes, err error) {
+// ServiceChangeInPublished 已发布状态下进行变更和暂存，transitions 在发起变更的事务中记录，暂存时不记录
+func (r *serviceRepo) ServiceChangeInPublished(ctx context.Context, req *dto.ServiceChangeReq, transitions ...*LifecycleTransition) (resp *dto.ServiceIdRes, err error) {
 	service := dto.ServiceCreateOrTempReq{
 		IsTemp:          req.IsTemp,
 		ServiceInfo:     req.ServiceInfo,
@@ -3821,6 +3934,9 @@ func (r *serviceRepo) ServiceChangeInPublished(ctx context.Context, req *dto.Ser
 			if err != nil {
 				return err
 			}
+			if err := createLifecycleHistories(ctx, tx, transitions...); err != nil {
+				return err
+			}
 			//提交事务
 			return nil
 		})
@@ -3830,7 +3946,7 @@ func (r *serviceRepo) ServiceChangeInPublished(ctx context.Context, req *dto.Ser
 }
 
 // ServiceChangeInChangeAuditReject 适配需求变动，变更审核未通过时可以编辑、暂存，继续更新当前Vn+1版本数据，作为新的Vn+1数据。不是将Vn+1当作新的Vn，重新insert一条数据
-func (r *serviceRepo) ServiceChangeInChangeAuditReject(ctx context.Context, req *dto.ServiceChangeReq) (resp *dto.ServiceIdRes, err error) {
+func (r *serviceRepo) ServiceChangeInChangeAuditReject(ctx context.Context, req *dto.ServiceChangeReq, transitions ...*LifecycleTransition) (resp *dto.ServiceIdRes, err error) {
 	service := dto.ServiceUpdateReqOrTemp{
 		ServiceUpdateUriReq: req.ServiceUpdateUriReq,
 		ServiceUpdateOrTempBodyReq: dto.ServiceUpdateOrTempBodyReq{
@@ -3868,10 +3984,15 @@ func (r *serviceRepo) ServiceChangeInChangeAuditReject(ctx context.Context, req
 		}
 		resp = &dto.ServiceIdRes{ServiceID: req.ServiceID}
 	} else {
-		// 不是暂存，直接发布，置为变更审核中
+		// 不是暂存，直接发布，置为变更审核中，transitions 与变更版本的状态一起记录
 		service.ServiceInfo.PublishStatus = enum.PublishStatusChangeAuditing
 		service.ServiceInfo.UpdateTime = time.Now().String()
-		err = r.ServiceUpdate(ctx, &service, false)
+		err = r.data.DB.Transaction(func(tx *gorm.DB) error {
+			if err := r.ServiceUpdate(ctx, &service, false); err != nil {
+				return err
+			}
+			return createLifecycleHistories(ctx, tx, transitions...)
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -3982,7 +4103,9 @@ func (r *serviceRepo) DeleteDraftService(ctx context.Context, serviceID string)
 	return nil
 
 }
-func (r *serviceRepo) RecoverToPublished(ctx context.Context, serviceID string) (resp *dto.ServiceIdRes, err error) {
+
+// RecoverToPublished 放弃变更，恢复到已发布的内容。transitions 在回退或删除草稿时一起记录
+func (r *serviceRepo) RecoverToPublished(ctx context.Context, serviceID string, transitions ...*LifecycleTransition) (resp *dto.ServiceIdRes, err error) {
 	var Service model.Service
 	tx := r.data.DB.WithContext(ctx).
 		Model(&model.Service{}).
@@ -3998,11 +4121,16 @@ func (r *serviceRepo) RecoverToPublished(ctx context.Context, serviceID string)
 	}
 	//变更审核未通过且没有草稿时回退
 	if Service.PublishStatus == enum.PublishStatusChangeReject && !exist {
-		resp, err = r.ServiceVersionBack(ctx, serviceID)
+		resp, err = r.ServiceVersionBack(ctx, serviceID, transitions...)
 	}
 	//变更审核未通过且有草稿时回退，前面暂存编辑时已放出已发布版本，此时只需删掉草稿
 	if Service.PublishStatus == enum.PublishStatusChangeReject && exist {
-		err = r.DeleteDraftService(ctx, serviceID)
+		err = r.data.DB.Transaction(func(tx *gorm.DB) error {
+			if err := r.DeleteDraftService(ctx, serviceID); err != nil {
+				return err
+			}
+			return createLifecycleHistories(ctx, tx, transitions...)
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -4701,3 +4829,54 @@ func (r *serviceRepo) HandleCallbackEvent(ctx context.Context, serviceID string)
 
 	return nil
 }
+
+// serviceReturnType 返回接口的返回类型，接口生成只返回 json，接口注册没有指定时默认 json
+func serviceReturnType(serviceType, returnType string) string {
+	if serviceType != "service_register" || returnType == "" {
+		return "json"
+	}
+	return returnType
+}
+
+// encodeRequestMapping 将请求映射编码为 JSON，标记为密钥的请求头的值加密后保存，已加密的值保持不变。
+// 接口生成没有后台服务，不保存请求映射
+func encodeRequestMapping(serviceType string, m *dto.RequestMapping) (*string, error) {
+	if m == nil || serviceType != "service_register" {
+		return nil, nil
+	}
+
+	mapping := *m
+	mapping.Headers.Inject = make([]dto.StaticHeader, len(m.Headers.Inject))
+	for i, h := range m.Headers.Inject {
+		if h.Secret && !util.IsEncryptedSecret(h.Value) {
+			if settings.Instance.Crypto.Key == "" {
+				return nil, errorcode.Detail(errorcode.PublicInternalError, "crypto key is not configured")
+			}
+			value, err := util.EncryptSecret(settings.Instance.Crypto.Key, h.Value)
+			if err != nil {
+				return nil, errorcode.Detail(errorcode.PublicInternalError, err.Error())
+			}
+			h.Value = value
+		}
+		mapping.Headers.Inject[i] = h
+	}
+
+	b, err := json.Marshal(mapping)
+	if err != nil {
+		return nil, errorcode.Detail(errorcode.PublicInternalError, err.Error())
+	}
+	return lo.ToPtr(string(b)), nil
+}
+
+// decodeRequestMapping 解析保存的请求映射，密钥返回密文
+func decodeRequestMapping(ctx context.Context, s *string) *dto.RequestMapping {
+	if s == nil || *s == "" {
+		return nil
+	}
+	m := &dto.RequestMapping{}
+	if err := json.Unmarshal([]byte(*s), m); err != nil {
+		log.WithContext(ctx).Warn("decodeRequestMapping", zap.Error(err))
+		return nil
+	}
+	return m
+}
@@ -2,6 +2,7 @@ package gorm
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -45,6 +46,12 @@ type ServiceApplyRepo interface {
 	ConsumerWorkflowAuditResultRequest(ctx context.Context, msg *common.AuditResultMsg) (err error)
 	ConsumerWorkflowAuditProcDeleteRequest(ctx context.Context, msg *common.AuditProcDefDelMsg) (err error)
 	AvailableServiceIDs(ctx context.Context, AppID string) (serviceIDs []string, err error)
+	ListExpired(ctx context.Context, now time.Time) (res []*model.ServiceApply, err error)
+	ListExpiring(ctx context.Context, now time.Time, before time.Time) (res []*model.ServiceApplyAssociations, err error)
+	MarkExpired(ctx context.Context, applyID string) (marked bool, err error)
+	MarkNotified(ctx context.Context, applyID string, t time.Time) (marked bool, err error)
+	UnmarkNotified(ctx context.Context, applyID string) (err error)
+	ProduceApplyExpiring(ctx context.Context, msg *dto.ServiceApplyExpiringMsg) (err error)
 }
 
 func NewServiceApplyRepo(
@@ -270,6 +277,7 @@ func (r *serviceApplyRepo) produceWorkflowAuditApply(ctx context.Context, apply
 			"submitter_id":   user.Id,
 			"submitter_name": user.Name,
 			"submit_time":    util.TimeFormat(&t),
+			"apply_days":     apply.ApplyDays,
 			"apply_reason":   apply.ApplyReason,
 		},
 		Workflow: common.AuditApplyWorkflowInfo{
@@ -531,21 +539,92 @@ func (r *serviceApplyRepo) ConsumerWorkflowAuditResultRequest(ctx context.Contex
 	apply := &model.ServiceApply{}
 	switch result.Result {
 	case enum.AuditStatusPass: //审核通过
+		var origin model.ServiceApply
+		tx := r.data.DB.WithContext(ctx).
+			Where(&model.ServiceApply{ApplyID: result.ApplyID}).
+			Limit(1).
+			Find(&origin)
+		if tx.Error != nil {
+			log.Error("serviceApplyRepo ConsumerWorkflowAuditResultRequest get apply", zap.Error(tx.Error), zap.String("apply_id", result.ApplyID))
+			return tx.Error
+		}
+		// Find 查不到记录时不返回错误，申请不存在时不授权，消息不再重新消费
+		if origin.ApplyID == "" {
+			log.Warn("serviceApplyRepo ConsumerWorkflowAuditResultRequest apply not found", zap.String("apply_id", result.ApplyID))
+			return nil
+		}
+
 		apply.AuditStatus = enum.AuditStatusPass
 		apply.AuthTime = &t
+		apply.ExpiredTime, err = r.expiredTime(ctx, &origin, t)
+		if err != nil {
+			log.Error("serviceApplyRepo ConsumerWorkflowAuditResultRequest expiredTime", zap.Error(err), zap.String("apply_id", result.ApplyID))
+			return err
+		}
+
+		// 授予申请人调用接口的权限，策略的过期时间与申请相同，由 auth-service 在过期后拒绝访问。
+		// 续期时以新的过期时间重新授权，失败时返回错误重新消费消息
+		policy := microservice.ServiceApplyPolicy(origin.UID, origin.ServiceID, apply.ExpiredTime)
+		if err = r.authServiceRepo.PolicyCreate(ctx, []microservice.Policy{policy}); err != nil {
+			log.Error("serviceApplyRepo ConsumerWorkflowAuditResultRequest PolicyCreate", zap.Error(err), zap.String("apply_id", result.ApplyID))
+			return err
+		}
 	case enum.AuditStatusReject: // 审核拒绝和撤销 都标记为拒绝
 		apply.AuditStatus = enum.AuditStatusReject
 	case enum.AuditStatusUndone:
 		apply.AuditStatus = enum.AuditStatusReject
 	}
 
-	r.data.DB.Model(&model.ServiceApply{}).
+	tx := r.data.DB.Model(&model.ServiceApply{}).
 		Where(&model.ServiceApply{ApplyID: result.ApplyID}).
 		Updates(apply)
+	if tx.Error != nil {
+		log.Error("serviceApplyRepo ConsumerWorkflowAuditResultRequest Updates", zap.Error(tx.Error), zap.String("apply_id", result.ApplyID))
+		return tx.Error
+	}
 
 	return nil
 }
 
+// expiredTime 计算审核通过的申请的过期时间，申请天数为 0 时长期有效。
+// 同一用户对同一接口还有未过期的授权时视为续期，从该授权的过期时间起算
+func (r *serviceApplyRepo) expiredTime(ctx context.Context, apply *model.ServiceApply, authTime time.Time) (*time.Time, error) {
+	if apply.ApplyDays == 0 {
+		return nil, nil
+	}
+
+	var current []*model.ServiceApply
+	tx := r.data.DB.WithContext(ctx).
+		Where(&model.ServiceApply{UID: apply.UID, ServiceID: apply.ServiceID, AuditStatus: enum.AuditStatusPass}).
+		Where("apply_id <> ?", apply.ApplyID).
+		Where("expired_time > ?", authTime).
+		Order("expired_time desc").
+		Limit(1).
+		Find(&current)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	var from *time.Time
+	if len(current) > 0 {
+		from = current[0].ExpiredTime
+	}
+	return applyExpiredTime(authTime, apply.ApplyDays, from), nil
+}
+
+// applyExpiredTime 从授权时间和当前授权的过期时间中较晚的一个起算 days 天
+func applyExpiredTime(authTime time.Time, days uint32, current *time.Time) *time.Time {
+	if days == 0 {
+		return nil
+	}
+	start := authTime
+	if current != nil && current.After(start) {
+		start = *current
+	}
+	expired := start.AddDate(0, 0, int(days))
+	return &expired
+}
+
 func (r *serviceApplyRepo) ConsumerWorkflowAuditProcDeleteRequest(_ context.Context, result *common.AuditProcDefDelMsg) error {
 	// var result dto.AuditProcDefDelMsg
 	// if err := json.Unmarshal(msg, &result); err != nil {
@@ -666,3 +745,96 @@ func (r *serviceApplyRepo) AvailableServiceIDs(ctx context.Context, AppID string
 
 	return
 }
+
+// ListExpired 返回已通过但已经过期的申请
+func (r *serviceApplyRepo) ListExpired(ctx context.Context, now time.Time) (res []*model.ServiceApply, err error) {
+	tx := r.data.DB.WithContext(ctx).
+		Model(&model.ServiceApply{}).
+		Where(&model.ServiceApply{AuditStatus: enum.AuditStatusPass}).
+		Where("expired_time is not null and expired_time <= ?", now).
+		Order("expired_time").
+		Find(&res)
+	if tx.Error != nil {
+		log.WithContext(ctx).Error("serviceApplyRepo ListExpired", zap.Error(tx.Error))
+		return nil, tx.Error
+	}
+
+	return res, nil
+}
+
+// ListExpiring 返回在 before 之前过期且还没有提醒过的申请
+func (r *serviceApplyRepo) ListExpiring(ctx context.Context, now time.Time, before time.Time) (res []*model.ServiceApplyAssociations, err error) {
+	tx := r.data.DB.WithContext(ctx).
+		Model(&model.ServiceApply{}).
+		Preload("Service").
+		Where(&model.ServiceApply{AuditStatus: enum.AuditStatusPass}).
+		Where("expired_time > ? and expired_time <= ?", now, before).
+		Where("notify_time is null").
+		Order("expired_time").
+		Find(&res)
+	if tx.Error != nil {
+		log.WithContext(ctx).Error("serviceApplyRepo ListExpiring", zap.Error(tx.Error))
+		return nil, tx.Error
+	}
+
+	return res, nil
+}
+
+// MarkExpired 将已通过的申请标记为已过期，返回是否由本次标记。多个实例同时处理同一个申请时只有一个能标记成功
+func (r *serviceApplyRepo) MarkExpired(ctx context.Context, applyID string) (marked bool, err error) {
+	tx := r.data.DB.WithContext(ctx).
+		Model(&model.ServiceApply{}).
+		Where(&model.ServiceApply{ApplyID: applyID, AuditStatus: enum.AuditStatusPass}).
+		Update("audit_status", enum.AuditStatusExpired)
+	if tx.Error != nil {
+		log.WithContext(ctx).Error("serviceApplyRepo MarkExpired", zap.Error(tx.Error))
+		return false, tx.Error
+	}
+
+	return tx.RowsAffected > 0, nil
+}
+
+// MarkNotified 记录申请的过期提醒时间，返回是否由本次标记。已经提醒过的申请不会重复标记
+func (r *serviceApplyRepo) MarkNotified(ctx context.Context, applyID string, t time.Time) (marked bool, err error) {
+	tx := r.data.DB.WithContext(ctx).
+		Model(&model.ServiceApply{}).
+		Where(&model.ServiceApply{ApplyID: applyID}).
+		Where("notify_time is null").
+		Update("notify_time", t)
+	if tx.Error != nil {
+		log.WithContext(ctx).Error("serviceApplyRepo MarkNotified", zap.Error(tx.Error))
+		return false, tx.Error
+	}
+
+	return tx.RowsAffected > 0, nil
+}
+
+// UnmarkNotified 发送提醒失败时清除提醒时间，下次执行时重试
+func (r *serviceApplyRepo) UnmarkNotified(ctx context.Context, applyID string) (err error) {
+	tx := r.data.DB.WithContext(ctx).
+		Model(&model.ServiceApply{}).
+		Where(&model.ServiceApply{ApplyID: applyID}).
+		Update("notify_time", nil)
+	if tx.Error != nil {
+		log.WithContext(ctx).Error("serviceApplyRepo UnmarkNotified", zap.Error(tx.Error))
+		return tx.Error
+	}
+
+	return nil
+}
+
+func (r *serviceApplyRepo) ProduceApplyExpiring(ctx context.Context, msg *dto.ServiceApplyExpiringMsg) (err error) {
+	bts, err := json.Marshal(msg)
+	if err != nil {
+		log.WithContext(ctx).Error("ProduceApplyExpiring json.Marshal", zap.Error(err))
+		return err
+	}
+
+	err = r.mq.KafkaClient.Pub(mq.TopicServiceApplyExpiring, bts)
+	if err != nil {
+		log.WithContext(ctx).Error("ProduceApplyExpiring", zap.Error(err), zap.Any("msg", msg))
+		return err
+	}
+	log.Info("producer apply expiring msg", zap.String("topic", mq.TopicServiceApplyExpiring), zap.Any("msg", msg))
+	return nil
+}
@@ -0,0 +1,49 @@
+package gorm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_applyExpiredTime(t *testing.T) {
+	authTime := time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local)
+	later := time.Date(2024, 3, 20, 10, 0, 0, 0, time.Local)
+	earlier := time.Date(2024, 2, 20, 10, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name    string
+		days    uint32
+		current *time.Time
+		want    *time.Time
+	}{
+		{
+			name: "长期有效",
+			days: 0,
+		},
+		{
+			name: "从授权时间起算",
+			days: 30,
+			want: lo.ToPtr(time.Date(2024, 3, 31, 10, 0, 0, 0, time.Local)),
+		},
+		{
+			name:    "续期从当前授权的过期时间起算",
+			days:    30,
+			current: &later,
+			want:    lo.ToPtr(time.Date(2024, 4, 19, 10, 0, 0, 0, time.Local)),
+		},
+		{
+			name:    "当前授权已过期时从授权时间起算",
+			days:    30,
+			current: &earlier,
+			want:    lo.ToPtr(time.Date(2024, 3, 31, 10, 0, 0, 0, time.Local)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, applyExpiredTime(authTime, tt.days, tt.current))
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driven/microservice"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/enum"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/infrastructure/repository/db"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/infrastructure/repository/db/model"
 	configuration_center "github.com/kweaver-ai/idrm-go-common/rest/configuration_center"
@@ -104,18 +105,9 @@ func (r *serviceCallRecordRepo) MonitorList(ctx context.Context, req *dto.Monito
 		tx = tx.Where("call_app_id = ?", req.CallAppID)
 	}
 
-	// 根据调用状态过滤
+	// 根据调用状态过滤，失败包括后端服务熔断
 	if req.Status != "" {
-		var callStatus int
-		switch req.Status {
-		case "success":
-			callStatus = 1
-		case "fail":
-			callStatus = 0
-		default:
-			callStatus = 2 // 未知状态
-		}
-		tx = tx.Where("call_status = ?", callStatus)
+		tx = tx.Where("call_status IN ?", enum.CallStatusFilter(req.Status))
 	}
 
 	// 根据调用开始时间过滤
@@ -256,13 +248,7 @@ func (r *serviceCallRecordRepo) MonitorList(ctx context.Context, req *dto.Monito
 		}
 
 		// 确定状态
-		status := "unknown"
-		switch record.CallStatus {
-		case 1:
-			status = "success"
-		case 0:
-			status = "fail"
-		}
+		status := enum.CallStatusName(record.CallStatus)
 
 		monitorRecord := &dto.MonitorRecord{
 			ServiceID:             record.ServiceID,
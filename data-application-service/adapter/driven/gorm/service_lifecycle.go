@@ -0,0 +1,74 @@
+package gorm
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/enum"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/util"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/infrastructure/repository/db/model"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+// LifecycleTransition 接口生命周期的一次迁移
+type LifecycleTransition struct {
+	ServiceID string // 接口ID，变更版本（Vn+1）使用已发布版本的接口ID，见 LifecycleServiceID
+	Trigger   string // 触发动作
+	From      enum.LifecycleState
+	To        enum.LifecycleState
+	ApplyID   string // 审核申请id
+	FlowID    string // 审批流程实例id，只有审核结果触发的迁移才有
+}
+
+// LifecycleServiceID 返回记录生命周期的接口ID。变更版本（Vn+1）审核通过后会使用已发布版本的接口ID，
+// 所以变更版本的迁移也记录在已发布版本的接口ID下，使接口的生命周期连续
+func LifecycleServiceID(serviceID, changedServiceID string) string {
+	if changedServiceID != "" && !strings.EqualFold(changedServiceID, "null") {
+		return changedServiceID
+	}
+	return serviceID
+}
+
+// createLifecycleHistories 在 db 中记录接口生命周期的迁移，db 为更新接口状态的事务，使状态和迁移记录一起提交。
+// 状态没有变化的迁移不记录。操作人从 ctx 中获取，审核结果触发的迁移操作人为空
+func createLifecycleHistories(ctx context.Context, db *gorm.DB, transitions ...*LifecycleTransition) error {
+	user := util.GetUser(ctx)
+	for _, transition := range transitions {
+		if transition == nil || transition.From == transition.To {
+			continue
+		}
+		history := &model.ServiceLifecycleHistory{
+			ServiceID:         transition.ServiceID,
+			TriggerType:       transition.Trigger,
+			FromPublishStatus: transition.From.PublishStatus,
+			FromStatus:        transition.From.Status,
+			ToPublishStatus:   transition.To.PublishStatus,
+			ToStatus:          transition.To.Status,
+			ApplyID:           transition.ApplyID,
+			FlowID:            transition.FlowID,
+			OperatorID:        user.Id,
+			OperatorName:      user.Name,
+		}
+		if err := db.WithContext(ctx).Create(history).Error; err != nil {
+			log.WithContext(ctx).Error("createLifecycleHistories", zap.Error(err), zap.String("service_id", transition.ServiceID), zap.String("trigger", transition.Trigger))
+			return err
+		}
+	}
+	return nil
+}
+
+// ListLifecycleHistory 按迁移时间顺序返回接口生命周期的迁移记录
+func (r *serviceRepo) ListLifecycleHistory(ctx context.Context, serviceID string) (histories []*model.ServiceLifecycleHistory, err error) {
+	err = r.data.DB.WithContext(ctx).
+		Where("service_id = ?", serviceID).
+		Order("create_time, id").
+		Find(&histories).Error
+	if err != nil {
+		log.WithContext(ctx).Error("ListLifecycleHistory", zap.Error(err))
+		return nil, err
+	}
+	return histories, nil
+}
@@ -0,0 +1,25 @@
+package gorm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycleServiceID(t *testing.T) {
+	tests := []struct {
+		name             string
+		serviceID        string
+		changedServiceID string
+		want             string
+	}{
+		{name: "未变更的接口", serviceID: "s1", changedServiceID: "", want: "s1"},
+		{name: "变更审核通过后重置为NULL", serviceID: "s1", changedServiceID: "NULL", want: "s1"},
+		{name: "变更版本", serviceID: "s2", changedServiceID: "s1", want: "s1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, LifecycleServiceID(tt.serviceID, tt.changedServiceID))
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package gorm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/enum"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/util"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/infrastructure/repository/db"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/infrastructure/repository/db/model"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+// ServiceVersionRepo 接口的版本历史，版本在发布审核、变更审核通过和子服务变更时由 ServiceRepo 记录，只读
+type ServiceVersionRepo interface {
+	List(ctx context.Context, serviceID string, offset, limit int) (versions []*model.ServiceVersion, count int64, err error)
+	Get(ctx context.Context, serviceID string, version int) (*model.ServiceVersion, error)
+}
+
+type serviceVersionRepo struct {
+	data *db.Data
+}
+
+func NewServiceVersionRepo(data *db.Data) ServiceVersionRepo {
+	return &serviceVersionRepo{data: data}
+}
+
+// List 按版本号倒序返回接口的版本，不包括版本快照
+func (r *serviceVersionRepo) List(ctx context.Context, serviceID string, offset, limit int) (versions []*model.ServiceVersion, count int64, err error) {
+	tx := r.data.DB.WithContext(ctx).Model(&model.ServiceVersion{}).Where("service_id = ?", serviceID)
+	if err = tx.Count(&count).Error; err != nil {
+		log.WithContext(ctx).Error("serviceVersionRepo List", zap.Error(err))
+		return nil, 0, err
+	}
+
+	err = tx.Omit("content").Order("version desc").Scopes(Paginate(offset, limit)).Find(&versions).Error
+	if err != nil {
+		log.WithContext(ctx).Error("serviceVersionRepo List", zap.Error(err))
+		return nil, 0, err
+	}
+	return versions, count, nil
+}
+
+func (r *serviceVersionRepo) Get(ctx context.Context, serviceID string, version int) (*model.ServiceVersion, error) {
+	v := &model.ServiceVersion{}
+	err := r.data.DB.WithContext(ctx).Where("service_id = ? AND version = ?", serviceID, version).First(v).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errorcode.Desc(errorcode.ServiceVersionNotExist)
+	}
+	if err != nil {
+		log.WithContext(ctx).Error("serviceVersionRepo Get", zap.Error(err))
+		return nil, err
+	}
+	return v, nil
+}
+
+// RecordSubServiceVersion 子服务（行列规则）新建、修改或删除后记录接口的版本。
+// 还没有版本的接口未发布，发布审核通过时的版本会包括子服务，这里不记录
+func (r *serviceRepo) RecordSubServiceVersion(ctx context.Context, serviceID string) error {
+	var count int64
+	err := r.data.DB.WithContext(ctx).Model(&model.ServiceVersion{}).Where("service_id = ?", serviceID).Count(&count).Error
+	if err != nil {
+		log.WithContext(ctx).Error("RecordSubServiceVersion", zap.Error(err), zap.String("service_id", serviceID))
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+	return r.recordServiceVersion(ctx, serviceID, enum.ServiceVersionTypeSubService)
+}
+
+// RestoreSubServices 将接口的子服务恢复为版本快照中的子服务并记录接口的版本。
+// 子服务的 ID 不变，已删除的子服务恢复原来的记录，快照中没有的子服务删除
+func (r *serviceRepo) RestoreSubServices(ctx context.Context, serviceID string, subServices []dto.ServiceVersionSubService) error {
+	models, err := newSubServiceModels(serviceID, subServices)
+	if err != nil {
+		return errorcode.Detail(errorcode.PublicInternalError, err.Error())
+	}
+
+	err = r.data.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		keep := lo.Map(models, func(m *model.SubService, _ int) uuid.UUID { return m.ID })
+		remove := tx.Where("service_id = ?", serviceID)
+		if len(keep) > 0 {
+			remove = remove.Where("id NOT IN ?", keep)
+		}
+		if err := remove.Delete(&model.SubService{}).Error; err != nil {
+			return err
+		}
+
+		for _, m := range models {
+			t := tx.Unscoped().Model(&model.SubService{}).Where("id = ?", m.ID).Updates(map[string]any{
+				"service_id":        m.ServiceID,
+				"name":              m.Name,
+				"auth_scope_id":     m.AuthScopeID,
+				"detail":            m.Detail,
+				"row_filter_clause": m.RowFilterClause,
+				"deleted_at":        0,
+			})
+			if t.Error != nil {
+				return t.Error
+			}
+			if t.RowsAffected > 0 {
+				continue
+			}
+			if err := tx.Create(m).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithContext(ctx).Error("RestoreSubServices", zap.Error(err), zap.String("service_id", serviceID))
+		return err
+	}
+
+	return r.recordServiceVersion(ctx, serviceID, enum.ServiceVersionTypeSubService)
+}
+
+// newSubServiceModels 将版本快照中的子服务转换为子服务记录
+func newSubServiceModels(serviceID string, subServices []dto.ServiceVersionSubService) ([]*model.SubService, error) {
+	sid, err := uuid.Parse(serviceID)
+	if err != nil {
+		return nil, err
+	}
+	models := make([]*model.SubService, 0, len(subServices))
+	for _, s := range subServices {
+		id, err := uuid.Parse(s.ID)
+		if err != nil {
+			return nil, err
+		}
+		var scopeID uuid.UUID
+		if s.AuthScopeID != "" {
+			if scopeID, err = uuid.Parse(s.AuthScopeID); err != nil {
+				return nil, err
+			}
+		}
+		models = append(models, &model.SubService{
+			ID:              id,
+			Name:            s.Name,
+			ServiceID:       sid,
+			AuthScopeID:     scopeID,
+			Detail:          s.Detail,
+			RowFilterClause: s.RowFilterClause,
+		})
+	}
+	return models, nil
+}
+
+// recordServiceVersion 发布审核、变更审核通过或子服务变更后记录接口的版本，serviceID 为已发布版本的接口ID。
+// 同一个审核申请只记录一次，重复消费审核结果时不会产生新的版本；子服务变更的版本不关联审核申请
+func (r *serviceRepo) recordServiceVersion(ctx context.Context, serviceID, auditType string) error {
+	service, err := r.ServiceGetFields(ctx, serviceID, []string{"service_id", "apply_id", "flow_id", "created_by", "update_by"})
+	if err != nil {
+		return err
+	}
+	if service.ServiceID == "" {
+		return errorcode.Desc(errorcode.ServiceIDNotExist)
+	}
+	if auditType == enum.ServiceVersionTypeSubService {
+		service.ApplyID, service.FlowID = "", ""
+	}
+
+	if service.ApplyID != "" {
+		var count int64
+		err = r.data.DB.WithContext(ctx).Model(&model.ServiceVersion{}).
+			Where("service_id = ? AND apply_id = ?", serviceID, service.ApplyID).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+	}
+
+	detail, err := r.ServiceGet(ctx, serviceID)
+	if err != nil {
+		return err
+	}
+	var subServices []*model.SubService
+	if err = r.data.DB.WithContext(ctx).Where("service_id = ?", serviceID).Order("created_at").Find(&subServices).Error; err != nil {
+		return err
+	}
+	content, err := json.Marshal(newServiceVersionContent(detail, subServices))
+	if err != nil {
+		return err
+	}
+
+	authorID := service.UpdateBy
+	if auditType == enum.ServiceVersionTypeSubService {
+		// 子服务变更的提交人为当前用户，没有用户时（如内部调用）使用接口的更新人
+		if userInfo, err := util.GetUserInfo(ctx); err == nil && userInfo.ID != "" {
+			authorID = userInfo.ID
+		}
+	}
+	if authorID == "" {
+		authorID = service.CreatedBy
+	}
+	var authorName string
+	if authorID != "" {
+		userInfo, err := r.userManagementRepo.GetUserById(ctx, authorID)
+		if err != nil {
+			log.WithContext(ctx).Warn("recordServiceVersion 查询提交人失败", zap.String("user_id", authorID), zap.Error(err))
+		} else {
+			authorName = userInfo.Name
+		}
+	}
+
+	return r.data.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var latest int
+		err := tx.Model(&model.ServiceVersion{}).
+			Select("COALESCE(MAX(version), 0)").
+			Where("service_id = ?", serviceID).
+			Scan(&latest).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&model.ServiceVersion{
+			ServiceID:  serviceID,
+			Version:    latest + 1,
+			AuditType:  auditType,
+			ApplyID:    service.ApplyID,
+			FlowID:     service.FlowID,
+			AuthorID:   authorID,
+			AuthorName: authorName,
+			Content:    string(content),
+		}).Error
+	})
+}
+
+// newServiceVersionContent 生成版本快照，去掉状态、统计、时间等与接口定义无关的字段，避免比较版本时产生无意义的差异
+func newServiceVersionContent(detail *dto.ServiceGetRes, subServices []*model.SubService) *dto.ServiceVersionContent {
+	content := &dto.ServiceVersionContent{
+		ServiceGetRes: *detail,
+		SubServices:   make([]dto.ServiceVersionSubService, 0, len(subServices)),
+	}
+
+	content.ServiceInfo.ClearRuntimeFields()
+
+	for _, s := range subServices {
+		content.SubServices = append(content.SubServices, dto.ServiceVersionSubService{
+			ID:              s.ID.String(),
+			Name:            s.Name,
+			AuthScopeID:     s.AuthScopeID.String(),
+			Detail:          s.Detail,
+			RowFilterClause: s.RowFilterClause,
+		})
+	}
+	return content
+}
@@ -0,0 +1,59 @@
+package gorm
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/infrastructure/repository/db/model"
+)
+
+func Test_newServiceVersionContent(t *testing.T) {
+	detail := &dto.ServiceGetRes{
+		ServiceInfo: dto.ServiceInfo{
+			ServiceID:     "s1",
+			ServiceName:   "接口",
+			ApplyNum:      3,
+			Status:        "online",
+			PublishStatus: "published",
+			AuditStatus:   "pass",
+			PublishTime:   "2024-12-27 18:43:59",
+			UpdateTime:    "2024-12-27 18:43:59",
+			GatewayUrl:    "http://gateway",
+			IsFavored:     true,
+		},
+		ServiceParam: dto.ServiceParamRead{CreateModel: "script", Script: "SELECT 1"},
+	}
+	scopeID := uuid.New()
+	subServices := []*model.SubService{{ID: uuid.New(), Name: "子接口", AuthScopeID: scopeID, Detail: "{}"}}
+
+	got := newServiceVersionContent(detail, subServices)
+
+	assert.Equal(t, dto.ServiceInfo{ServiceID: "s1", ServiceName: "接口"}, got.ServiceInfo)
+	assert.Equal(t, detail.ServiceParam, got.ServiceParam)
+	assert.Equal(t, []dto.ServiceVersionSubService{{ID: subServices[0].ID.String(), Name: "子接口", AuthScopeID: scopeID.String(), Detail: "{}"}}, got.SubServices)
+	// 不修改原始的接口详情
+	assert.Equal(t, "online", detail.ServiceInfo.Status)
+}
+
+func Test_newSubServiceModels(t *testing.T) {
+	serviceID, id, scopeID := uuid.New(), uuid.New(), uuid.New()
+	snapshot := []dto.ServiceVersionSubService{
+		{ID: id.String(), Name: "子接口", AuthScopeID: scopeID.String(), Detail: "{}", RowFilterClause: "a = 1"},
+		{ID: uuid.NewString(), Name: "未设置授权范围"},
+	}
+
+	got, err := newSubServiceModels(serviceID.String(), snapshot)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, &model.SubService{ID: id, Name: "子接口", ServiceID: serviceID, AuthScopeID: scopeID, Detail: "{}", RowFilterClause: "a = 1"}, got[0])
+	assert.Equal(t, uuid.Nil, got[1].AuthScopeID)
+
+	_, err = newSubServiceModels(serviceID.String(), []dto.ServiceVersionSubService{{ID: "invalid"}})
+	assert.Error(t, err)
+	_, err = newSubServiceModels("invalid", nil)
+	assert.Error(t, err)
+}
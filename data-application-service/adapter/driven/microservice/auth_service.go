@@ -3,6 +3,7 @@ package microservice
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/imroc/req/v2"
 	"go.uber.org/zap"
@@ -11,6 +12,7 @@ import (
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/errorcode"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/settings"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/util"
+	meta_v1 "github.com/kweaver-ai/idrm-go-common/api/meta/v1"
 	"github.com/kweaver-ai/idrm-go-common/interception"
 	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
 )
@@ -46,9 +48,38 @@ func EnforceEqualWithoutEffect(a, b *Enforce) bool {
 
 type SubjectObjectsRes dto.SubjectObjectsRes
 
+// Policy 权限策略，用于授予或撤销访问者对资源的授权
+type Policy struct {
+	Action      string `json:"action"`
+	ObjectId    string `json:"object_id"`
+	ObjectType  string `json:"object_type"`
+	SubjectId   string `json:"subject_id"`
+	SubjectType string `json:"subject_type"`
+	// 过期时间，为空时长期有效。过期后由 auth-service 拒绝访问
+	ExpiredAt *meta_v1.Time `json:"expired_at,omitempty"`
+}
+
+// ServiceApplyPolicy 接口调用申请审核通过后授予申请人的权限策略，过期时间与申请相同，为空时长期有效
+func ServiceApplyPolicy(uid, serviceID string, expiredTime *time.Time) Policy {
+	policy := Policy{
+		Action:      "read",
+		ObjectId:    serviceID,
+		ObjectType:  "api",
+		SubjectId:   uid,
+		SubjectType: "user",
+	}
+	if expiredTime != nil {
+		expiredAt := meta_v1.NewTime(*expiredTime)
+		policy.ExpiredAt = &expiredAt
+	}
+	return policy
+}
+
 type AuthServiceRepo interface {
 	Enforce(ctx context.Context, enforcesReq []Enforce) (enforcesRes []bool, err error)
 	SubjectObjects(ctx context.Context, objectType, subjectId, subjectType string) (res *SubjectObjectsRes, err error)
+	PolicyCreate(ctx context.Context, policies []Policy) (err error)
+	PolicyDelete(ctx context.Context, policies []Policy) (err error)
 }
 
 func NewAuthServiceRepo() AuthServiceRepo {
@@ -112,3 +143,37 @@ func (b *authServiceRepo) SubjectObjects(ctx context.Context, objectType, subjec
 
 	return
 }
+
+// PolicyCreate 创建权限策略，调用内部接口，消费审核结果时没有用户的 token
+func (b *authServiceRepo) PolicyCreate(ctx context.Context, policies []Policy) (err error) {
+	url := settings.Instance.Services.AuthService + "/api/internal/auth-service/v1/policies"
+	resp, err := req.SetContext(ctx).SetBodyJsonMarshal(policies).Post(url)
+	if err != nil {
+		log.WithContext(ctx).Error("authServiceRepo PolicyCreate", zap.Error(err))
+		return errorcode.Detail(errorcode.PublicInternalError, err.Error())
+	}
+
+	if resp.StatusCode != 200 && resp.StatusCode != 201 && resp.StatusCode != 204 {
+		log.WithContext(ctx).Error("authServiceRepo PolicyCreate", zap.Error(errors.New(resp.String())))
+		return errorcode.Detail(errorcode.PublicInternalError, resp.String())
+	}
+
+	return nil
+}
+
+// PolicyDelete 删除权限策略，调用内部接口，没有用户的 token
+func (b *authServiceRepo) PolicyDelete(ctx context.Context, policies []Policy) (err error) {
+	url := settings.Instance.Services.AuthService + "/api/internal/auth-service/v1/policies"
+	resp, err := req.SetContext(ctx).SetBodyJsonMarshal(policies).Delete(url)
+	if err != nil {
+		log.WithContext(ctx).Error("authServiceRepo PolicyDelete", zap.Error(err))
+		return errorcode.Detail(errorcode.PublicInternalError, err.Error())
+	}
+
+	if resp.StatusCode != 200 && resp.StatusCode != 204 {
+		log.WithContext(ctx).Error("authServiceRepo PolicyDelete", zap.Error(errors.New(resp.String())))
+		return errorcode.Detail(errorcode.PublicInternalError, resp.String())
+	}
+
+	return nil
+}
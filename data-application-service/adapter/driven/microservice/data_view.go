@@ -81,6 +81,8 @@ type DataViewRepo interface {
 	DataViewGet(ctx context.Context, id string) (res *DataViewGetRes, err error)
 	// DataViewList 数据视图列表
 	DataViewList(ctx context.Context, ids []string) (res *DataViewListRes, err error)
+	// DataViewSearch 按关键字搜索数据视图，关键字匹配技术名称和业务名称
+	DataViewSearch(ctx context.Context, keyword string) (res *DataViewListRes, err error)
 	// ParseViewSourceCatalogName 解析 catalog 名称
 	ParseViewSourceCatalogName(viewSourceCatalogName string) (catalogName, schemaName string)
 }
@@ -142,6 +144,35 @@ func (u *dataViewRepo) DataViewList(ctx context.Context, ids []string) (res *Dat
 	return res, nil
 }
 
+func (u *dataViewRepo) DataViewSearch(ctx context.Context, keyword string) (res *DataViewListRes, err error) {
+	resp, err := req.SetContext(ctx).
+		SetBearerAuthToken(util.GetToken(ctx)).
+		SetQueryParams(map[string]string{
+			"keyword": keyword,
+			"offset":  "1",
+			"limit":   "1000",
+		}).
+		Get(settings.Instance.Services.DataView + "/api/data-view/v1/form-view")
+	if err != nil {
+		log.WithContext(ctx).Error("DataViewSearch", zap.Error(err))
+		return nil, errorcode.Detail(errorcode.PublicInternalError, err.Error())
+	}
+
+	if resp.StatusCode != 200 {
+		log.WithContext(ctx).Error("DataViewSearch", zap.Error(errors.New(resp.String())))
+		return nil, errorcode.Detail(errorcode.PublicInternalError, resp.String())
+	}
+
+	res = &DataViewListRes{}
+	err = resp.UnmarshalJson(&res)
+	if err != nil {
+		log.WithContext(ctx).Error("DataViewSearch", zap.Error(err))
+		return nil, errorcode.Detail(errorcode.PublicInternalError, err.Error())
+	}
+
+	return res, nil
+}
+
 func (u *dataViewRepo) ParseViewSourceCatalogName(viewSourceCatalogName string) (catalogName, schemaName string) {
 	if viewSourceCatalogName == "" {
 		return
@@ -4,6 +4,8 @@ package mq
 const (
 	TopicWorkflowAuditApply  = "workflow.audit.apply"  // 发起审核申请
 	TopicWorkflowAuditCancel = "workflow.audit.cancel" // 发起审核撤销
+
+	TopicServiceApplyExpiring = "af.data-application-service.apply.expiring" // 接口授权即将过期提醒
 )
 
 // 消费者 topic
@@ -9,9 +9,12 @@ import (
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/file"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_apply"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_bundle"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_call_record"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_daily_record"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_openapi"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_stats"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_version"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/subject_domain"
 	"github.com/kweaver-ai/idrm-go-common/audit"
 	"github.com/kweaver-ai/idrm-go-frame/core/utils/httpclient"
@@ -29,9 +32,12 @@ var ProviderSet = wire.NewSet(
 	file.NewFileController,
 	audit_process_bind.NewAuditProcessBindController,
 	service_apply.NewServiceApplyController,
+	service_bundle.NewServiceBundleController,
 	service_call_record.NewServiceCallRecordController,
 	service_daily_record.NewServiceDailyRecordController,
+	service_openapi.NewServiceOpenAPIController,
 	service_stats.NewServiceStatsController,
+	service_version.NewServiceVersionController,
 	subject_domain.NewSubjectDomainController,
 	sub_service.NewSubServiceService,
 
@@ -21,9 +21,12 @@ import (
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/file"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_apply"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_bundle"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_call_record"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_daily_record"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_openapi"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_stats"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_version"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/sub_service"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/subject_domain"
 	"github.com/kweaver-ai/idrm-go-common/audit"
@@ -106,6 +109,9 @@ type Router struct {
 	// 配置中心客户端
 	ConfigurationCenterDriven configuration_center.Driven
 	SubServiceDomainApi       *sub_service.SubServiceService
+	ServiceVersionController  *service_version.ServiceVersionController
+	ServiceBundleController   *service_bundle.ServiceBundleController
+	ServiceOpenAPIController  *service_openapi.ServiceOpenAPIController
 }
 
 func (r *Router) Register(engine *gin.Engine) error {
@@ -165,6 +171,21 @@ func (r *Router) RegisterApi(engine *gin.Engine) {
 	serviceRouter.GET("/max-response", r.ServiceController.GetServicesMaxResponse)
 	serviceRouter.POST("/api-doc/export", r.ServiceController.ExportAPIDoc)                           //导出API接口文档PDF/ZIP
 	serviceRouter.GET("/:service_id/api-doc/example-code", r.ServiceController.ServiceGetExampleCode) //接口使用示例代码
+	serviceRouter.GET("/:service_id/lifecycle", r.ServiceController.ServiceLifecycleTimeline)         //接口生命周期时间线
+
+	// 接口版本历史
+	serviceRouter.GET("/:service_id/versions", r.ServiceVersionController.ServiceVersionList)                        //接口版本列表
+	serviceRouter.GET("/:service_id/versions/diff", r.ServiceVersionController.ServiceVersionDiff)                   //比较接口版本
+	serviceRouter.GET("/:service_id/versions/:version", r.ServiceVersionController.ServiceVersionGet)                //接口版本详情
+	serviceRouter.POST("/:service_id/versions/:version/rollback", r.ServiceVersionController.ServiceVersionRollback) //回滚到历史版本
+
+	// 接口导入导出
+	serviceRouter.POST("/bundle/export", r.ServiceBundleController.ServiceBundleExport) //导出接口
+	serviceRouter.POST("/bundle/import", r.ServiceBundleController.ServiceBundleImport) //导入接口
+
+	// 接口 OpenAPI 文档
+	serviceRouter.GET("/openapi", r.ServiceOpenAPIController.ServiceOpenAPIList)         //部门或主题域的 OpenAPI 文档
+	serviceRouter.GET("/:service_id/openapi", r.ServiceOpenAPIController.ServiceOpenAPI) //接口的 OpenAPI 文档
 
 	//审核流程实例
 	auditProcessInstanceRouter := router.Group("/audit-process-instance")
@@ -209,6 +230,7 @@ func (r *Router) RegisterFrontendApi(engine *gin.Engine) {
 	applyRouter.POST("", r.ServiceApplyController.ServiceApplyCreate)                  //申请接口
 	applyRouter.GET("", r.ServiceApplyController.ServiceApplyList)                     //申请列表
 	applyRouter.GET("/:apply_id", r.ServiceApplyController.ServiceApplyGet)            //申请详情
+	applyRouter.POST("/:apply_id/renew", r.ServiceApplyController.ServiceApplyRenew)   //申请续期
 	applyRouter.GET("/available-assets", r.ServiceApplyController.AvailableAssetsList) //可用资产
 
 	//接口统计数据
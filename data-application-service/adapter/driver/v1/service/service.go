@@ -772,6 +772,38 @@ func (s *ServiceController) DraftServiceGet(c *gin.Context) {
 	ginx.ResOKJson(c, resp)
 }
 
+// ServiceLifecycleTimeline 接口的生命周期时间线
+//
+//	@Description	接口的生命周期时间线，按时间顺序返回发布状态、上线状态的每一次迁移
+//	@Tags			接口
+//	@Summary		接口的生命周期时间线
+//	@Accept			json
+//	@Produce		json
+//	@Param			service_id	path		string					true	"接口ID"
+//	@Success		200			{object}	dto.ServiceLifecycleRes	"成功响应参数"
+//	@Failure		400			{object}	rest.HttpError			"失败响应参数"
+//	@Router			/api/data-application-service/v1/services/{service_id}/lifecycle [get]
+func (s *ServiceController) ServiceLifecycleTimeline(c *gin.Context) {
+	req := &dto.ServiceIdReq{}
+	_, err := form_validator.BindUriAndValid(c, req)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		if errors.As(err, &form_validator.ValidErrors{}) {
+			ginx.ResErrJson(c, errorcode.Detail(errorcode.PublicInvalidParameter, err))
+			return
+		}
+		ginx.ResErrJson(c, errorcode.Desc(errorcode.PublicRequestParameterError))
+		return
+	}
+	resp, err := s.domain.ServiceLifecycleTimeline(c, req.ServiceID)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		ginx.ResErrJson(c, err)
+		return
+	}
+	ginx.ResOKJson(c, resp)
+}
+
 // ChangePublishedService 变更已发布的需求（包括变更暂存）
 //
 //	@Description	变更已发布的需求
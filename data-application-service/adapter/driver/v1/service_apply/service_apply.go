@@ -59,6 +59,55 @@ func (s *ServiceApplyController) ServiceApplyCreate(c *gin.Context) {
 	ginx.ResOKJson(c, errorcode.Success)
 }
 
+// ServiceApplyRenew 接口申请续期
+//
+//	@Description	续期已通过或已过期的限时申请，续期申请走接口申请的审核流程
+//	@Tags			接口申请
+//	@Summary		接口申请续期
+//	@Accept			json
+//	@Produce		json
+//	@Param			apply_id	path		string							true	"申请id"
+//	@Param			_			body		dto.ServiceApplyRenewBodyReq	true	"请求参数"
+//	@Success		200			{object}	rest.HttpError					"成功响应参数"
+//	@Failure		400			{object}	rest.HttpError					"失败响应参数"
+//	@Router			/api/data-application-service/frontend/v1/apply/{apply_id}/renew [post]
+func (s *ServiceApplyController) ServiceApplyRenew(c *gin.Context) {
+	req := &dto.ServiceApplyRenewReq{}
+
+	_, err := form_validator.BindUriAndValid(c, &req.ServiceApplyRenewUriReq)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		if errors.As(err, &form_validator.ValidErrors{}) {
+			ginx.ResErrJson(c, errorcode.Detail(errorcode.PublicInvalidParameter, err))
+			return
+		}
+
+		ginx.ResErrJson(c, errorcode.Desc(errorcode.PublicRequestParameterError))
+		return
+	}
+
+	_, err = form_validator.BindJsonAndValid(c, &req.ServiceApplyRenewBodyReq)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		if errors.As(err, &form_validator.ValidErrors{}) {
+			ginx.ResErrJson(c, errorcode.Detail(errorcode.PublicInvalidParameter, err))
+			return
+		}
+
+		ginx.ResErrJson(c, errorcode.Desc(errorcode.PublicRequestParameterError))
+		return
+	}
+
+	err = s.domain.ServiceApplyRenew(c, req)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		ginx.ResErrJson(c, err)
+		return
+	}
+
+	ginx.ResOKJson(c, errorcode.Success)
+}
+
 // ServiceApplyList 接口申请列表
 //
 //	@Description	接口申请列表
@@ -0,0 +1,111 @@
+package service_bundle
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/form_validator"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/domain"
+	"github.com/kweaver-ai/idrm-go-frame/core/transport/rest/ginx"
+)
+
+type ServiceBundleController struct {
+	domain *domain.ServiceBundleDomain
+}
+
+func NewServiceBundleController(domain *domain.ServiceBundleDomain) *ServiceBundleController {
+	return &ServiceBundleController{
+		domain: domain,
+	}
+}
+
+// ServiceBundleExport 导出接口
+//
+//	@Description	导出选中的接口为 json 或 yaml 格式的导出包，用于在不同环境之间迁移接口定义。部门和数据视图以路径、名称表示，导入时在目标环境中解析
+//	@Tags			接口导入导出
+//	@Summary		导出接口
+//	@Accept			json
+//	@Produce		application/json,application/yaml
+//	@Param			_	body		dto.ServiceBundleExportReq	true	"请求参数"
+//	@Success		200	{object}	dto.ServiceBundle			"成功时返回导出包文件"
+//	@Failure		400	{object}	rest.HttpError				"失败响应参数"
+//	@Router			/api/data-application-service/v1/services/bundle/export [post]
+func (s *ServiceBundleController) ServiceBundleExport(c *gin.Context) {
+	req := &dto.ServiceBundleExportReq{}
+
+	_, err := form_validator.BindJsonAndValid(c, req)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		if errors.As(err, &form_validator.ValidErrors{}) {
+			ginx.ResErrJson(c, errorcode.Detail(errorcode.PublicInvalidParameter, err))
+			return
+		}
+
+		ginx.ResErrJson(c, errorcode.Desc(errorcode.PublicRequestParameterError))
+		return
+	}
+
+	res, err := s.domain.Export(c, req)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		ginx.ResErrJson(c, err)
+		return
+	}
+
+	disposition := fmt.Sprintf("attachment; filename=\"%s\"; filename*=utf-8''%s",
+		strings.ReplaceAll(res.FileName, "\"", "\\\""),
+		url.QueryEscape(res.FileName))
+	c.Writer.Header().Set("Content-Disposition", disposition)
+	c.Data(http.StatusOK, res.ContentType, res.Buffer)
+}
+
+// ServiceBundleImport 导入接口
+//
+//	@Description	导入导出包中的接口，每个接口单独校验和创建。dry_run 为 true 时只校验不创建；名称、路径冲突时按 conflict_strategy 处理；auto_publish 为 true 时创建后自动提交发布审核，否则暂存
+//	@Tags			接口导入导出
+//	@Summary		导入接口
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			file				formData	file						true	"导出包文件，支持 .json .yaml .yml"
+//	@Param			dry_run				formData	bool						false	"是否只做预检查"
+//	@Param			conflict_strategy	formData	string						false	"名称、路径冲突的处理方式 fail 失败 skip 跳过 rename 重命名，默认 fail"
+//	@Param			auto_publish		formData	bool						false	"是否自动提交发布审核"
+//	@Success		200					{object}	dto.ServiceBundleImportRes	"成功响应参数"
+//	@Failure		400					{object}	rest.HttpError				"失败响应参数"
+//	@Router			/api/data-application-service/v1/services/bundle/import [post]
+func (s *ServiceBundleController) ServiceBundleImport(c *gin.Context) {
+	req := &dto.ServiceBundleImportReq{}
+
+	_, err := form_validator.BindAndValid(c, req)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		if errors.As(err, &form_validator.ValidErrors{}) {
+			ginx.ResErrJson(c, errorcode.Detail(errorcode.PublicInvalidParameter, err))
+			return
+		}
+
+		ginx.ResErrJson(c, errorcode.Desc(errorcode.PublicRequestParameterError))
+		return
+	}
+	if req.File.Size > 10<<20 {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		ginx.ResErrJson(c, errorcode.Desc(errorcode.FileSizeMax))
+		return
+	}
+
+	res, err := s.domain.Import(c, req)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		ginx.ResErrJson(c, err)
+		return
+	}
+
+	ginx.ResOKJson(c, res)
+}
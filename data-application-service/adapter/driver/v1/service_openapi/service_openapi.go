@@ -0,0 +1,130 @@
+package service_openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/form_validator"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/util"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/domain"
+	"github.com/kweaver-ai/idrm-go-frame/core/transport/rest/ginx"
+)
+
+type ServiceOpenAPIController struct {
+	domain *domain.ServiceOpenAPIDomain
+}
+
+func NewServiceOpenAPIController(domain *domain.ServiceOpenAPIDomain) *ServiceOpenAPIController {
+	return &ServiceOpenAPIController{
+		domain: domain,
+	}
+}
+
+// ServiceOpenAPI 接口的 OpenAPI 文档
+//
+//	@Description	已发布接口的 OpenAPI 3.0 文档，包括通过网关调用接口的地址、认证方式、请求参数和返回结果
+//	@Tags			接口文档
+//	@Summary		接口的 OpenAPI 文档
+//	@Accept			json
+//	@Produce		application/json,application/yaml
+//	@Param			service_id	path		string						true	"接口ID"
+//	@Param			_			query		dto.ServiceOpenAPIQueryReq	true	"请求参数"
+//	@Success		200			{object}	dto.OpenAPI					"成功响应参数"
+//	@Failure		400			{object}	rest.HttpError				"失败响应参数"
+//	@Router			/api/data-application-service/v1/services/{service_id}/openapi [get]
+func (s *ServiceOpenAPIController) ServiceOpenAPI(c *gin.Context) {
+	req := &dto.ServiceOpenAPIReq{}
+
+	_, err := form_validator.BindUriAndValid(c, &req.ServiceIdReq)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		if errors.As(err, &form_validator.ValidErrors{}) {
+			ginx.ResErrJson(c, errorcode.Detail(errorcode.PublicInvalidParameter, err))
+			return
+		}
+
+		ginx.ResErrJson(c, errorcode.Desc(errorcode.PublicRequestParameterError))
+		return
+	}
+
+	_, err = form_validator.BindQueryAndValid(c, &req.ServiceOpenAPIQueryReq)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		if errors.As(err, &form_validator.ValidErrors{}) {
+			ginx.ResErrJson(c, errorcode.Detail(errorcode.PublicInvalidParameter, err))
+			return
+		}
+
+		ginx.ResErrJson(c, errorcode.Desc(errorcode.PublicRequestParameterError))
+		return
+	}
+
+	res, err := s.domain.ServiceOpenAPI(c, req.ServiceID)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		ginx.ResErrJson(c, err)
+		return
+	}
+
+	resOpenAPI(c, req.Format, res)
+}
+
+// ServiceOpenAPIList 部门或主题域的 OpenAPI 文档
+//
+//	@Description	部门或主题域下所有已发布接口的 OpenAPI 3.0 文档，包括子部门、子主题域的接口。department_id 和 subject_domain_id 二选一
+//	@Tags			接口文档
+//	@Summary		部门或主题域的 OpenAPI 文档
+//	@Accept			json
+//	@Produce		application/json,application/yaml
+//	@Param			_	query		dto.ServiceOpenAPIListReq	true	"请求参数"
+//	@Success		200	{object}	dto.OpenAPI					"成功响应参数"
+//	@Failure		400	{object}	rest.HttpError				"失败响应参数"
+//	@Router			/api/data-application-service/v1/services/openapi [get]
+func (s *ServiceOpenAPIController) ServiceOpenAPIList(c *gin.Context) {
+	req := &dto.ServiceOpenAPIListReq{}
+
+	_, err := form_validator.BindQueryAndValid(c, req)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		if errors.As(err, &form_validator.ValidErrors{}) {
+			ginx.ResErrJson(c, errorcode.Detail(errorcode.PublicInvalidParameter, err))
+			return
+		}
+
+		ginx.ResErrJson(c, errorcode.Desc(errorcode.PublicRequestParameterError))
+		return
+	}
+
+	res, err := s.domain.ServiceOpenAPIList(c, req)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		ginx.ResErrJson(c, err)
+		return
+	}
+
+	resOpenAPI(c, req.Format, res)
+}
+
+// resOpenAPI 按请求的格式返回文档，yaml 由 json 转换，保持字段顺序
+func resOpenAPI(c *gin.Context, format string, doc *dto.OpenAPI) {
+	if format != "yaml" {
+		ginx.ResOKJson(c, doc)
+		return
+	}
+
+	content, err := json.Marshal(doc)
+	if err == nil {
+		content, err = util.JSONToYAML(content)
+	}
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		ginx.ResErrJson(c, errorcode.Detail(errorcode.ServiceOpenAPIGenerateError, err.Error()))
+		return
+	}
+	c.Data(http.StatusOK, "application/yaml", content)
+}
@@ -0,0 +1,196 @@
+package service_version
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/form_validator"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/domain"
+	"github.com/kweaver-ai/idrm-go-frame/core/transport/rest/ginx"
+)
+
+type ServiceVersionController struct {
+	domain *domain.ServiceVersionDomain
+}
+
+func NewServiceVersionController(domain *domain.ServiceVersionDomain) *ServiceVersionController {
+	return &ServiceVersionController{
+		domain: domain,
+	}
+}
+
+// ServiceVersionList 接口版本列表
+//
+//	@Description	接口版本列表，发布审核、变更审核通过时产生新的版本，按版本号倒序
+//	@Tags			接口版本
+//	@Summary		接口版本列表
+//	@Accept			json
+//	@Produce		json
+//	@Param			service_id	path		string							true	"接口ID"
+//	@Param			_			query		dto.ServiceVersionListQueryReq	true	"请求参数"
+//	@Success		200			{object}	dto.ServiceVersionListRes		"成功响应参数"
+//	@Failure		400			{object}	rest.HttpError					"失败响应参数"
+//	@Router			/api/data-application-service/v1/services/{service_id}/versions [get]
+func (s *ServiceVersionController) ServiceVersionList(c *gin.Context) {
+	req := &dto.ServiceVersionListReq{}
+
+	_, err := form_validator.BindUriAndValid(c, &req.ServiceIdReq)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		if errors.As(err, &form_validator.ValidErrors{}) {
+			ginx.ResErrJson(c, errorcode.Detail(errorcode.PublicInvalidParameter, err))
+			return
+		}
+
+		ginx.ResErrJson(c, errorcode.Desc(errorcode.PublicRequestParameterError))
+		return
+	}
+
+	_, err = form_validator.BindQueryAndValid(c, &req.ServiceVersionListQueryReq)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		if errors.As(err, &form_validator.ValidErrors{}) {
+			ginx.ResErrJson(c, errorcode.Detail(errorcode.PublicInvalidParameter, err))
+			return
+		}
+
+		ginx.ResErrJson(c, errorcode.Desc(errorcode.PublicRequestParameterError))
+		return
+	}
+
+	res, err := s.domain.List(c, req)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		ginx.ResErrJson(c, err)
+		return
+	}
+
+	ginx.ResOKJson(c, res)
+}
+
+// ServiceVersionGet 接口版本详情
+//
+//	@Description	接口版本详情，包括版本快照
+//	@Tags			接口版本
+//	@Summary		接口版本详情
+//	@Accept			json
+//	@Produce		json
+//	@Param			service_id	path		string						true	"接口ID"
+//	@Param			version		path		int							true	"版本号"
+//	@Success		200			{object}	dto.ServiceVersionGetRes	"成功响应参数"
+//	@Failure		400			{object}	rest.HttpError				"失败响应参数"
+//	@Router			/api/data-application-service/v1/services/{service_id}/versions/{version} [get]
+func (s *ServiceVersionController) ServiceVersionGet(c *gin.Context) {
+	req := &dto.ServiceVersionUriReq{}
+
+	_, err := form_validator.BindUriAndValid(c, req)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		if errors.As(err, &form_validator.ValidErrors{}) {
+			ginx.ResErrJson(c, errorcode.Detail(errorcode.PublicInvalidParameter, err))
+			return
+		}
+
+		ginx.ResErrJson(c, errorcode.Desc(errorcode.PublicRequestParameterError))
+		return
+	}
+
+	res, err := s.domain.Get(c, req)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		ginx.ResErrJson(c, err)
+		return
+	}
+
+	ginx.ResOKJson(c, res)
+}
+
+// ServiceVersionDiff 比较接口版本
+//
+//	@Description	比较接口的任意两个版本，返回按字段路径排序的差异
+//	@Tags			接口版本
+//	@Summary		比较接口版本
+//	@Accept			json
+//	@Produce		json
+//	@Param			service_id	path		string							true	"接口ID"
+//	@Param			_			query		dto.ServiceVersionDiffQueryReq	true	"请求参数"
+//	@Success		200			{object}	dto.ServiceVersionDiffRes		"成功响应参数"
+//	@Failure		400			{object}	rest.HttpError					"失败响应参数"
+//	@Router			/api/data-application-service/v1/services/{service_id}/versions/diff [get]
+func (s *ServiceVersionController) ServiceVersionDiff(c *gin.Context) {
+	req := &dto.ServiceVersionDiffReq{}
+
+	_, err := form_validator.BindUriAndValid(c, &req.ServiceIdReq)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		if errors.As(err, &form_validator.ValidErrors{}) {
+			ginx.ResErrJson(c, errorcode.Detail(errorcode.PublicInvalidParameter, err))
+			return
+		}
+
+		ginx.ResErrJson(c, errorcode.Desc(errorcode.PublicRequestParameterError))
+		return
+	}
+
+	_, err = form_validator.BindQueryAndValid(c, &req.ServiceVersionDiffQueryReq)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		if errors.As(err, &form_validator.ValidErrors{}) {
+			ginx.ResErrJson(c, errorcode.Detail(errorcode.PublicInvalidParameter, err))
+			return
+		}
+
+		ginx.ResErrJson(c, errorcode.Desc(errorcode.PublicRequestParameterError))
+		return
+	}
+
+	res, err := s.domain.Diff(c, req)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		ginx.ResErrJson(c, err)
+		return
+	}
+
+	ginx.ResOKJson(c, res)
+}
+
+// ServiceVersionRollback 回滚到接口的历史版本
+//
+//	@Description	以历史版本的内容发起变更审核，审核通过后产生新的版本。子服务（行列规则）不回滚
+//	@Tags			接口版本
+//	@Summary		回滚到接口的历史版本
+//	@Accept			json
+//	@Produce		json
+//	@Param			service_id	path		string				true	"接口ID"
+//	@Param			version		path		int					true	"版本号"
+//	@Success		200			{object}	dto.ServiceIdRes	"成功响应参数"
+//	@Failure		400			{object}	rest.HttpError		"失败响应参数"
+//	@Router			/api/data-application-service/v1/services/{service_id}/versions/{version}/rollback [post]
+func (s *ServiceVersionController) ServiceVersionRollback(c *gin.Context) {
+	req := &dto.ServiceVersionUriReq{}
+
+	_, err := form_validator.BindUriAndValid(c, req)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		if errors.As(err, &form_validator.ValidErrors{}) {
+			ginx.ResErrJson(c, errorcode.Detail(errorcode.PublicInvalidParameter, err))
+			return
+		}
+
+		ginx.ResErrJson(c, errorcode.Desc(errorcode.PublicRequestParameterError))
+		return
+	}
+
+	res, err := s.domain.Rollback(c, req)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		ginx.ResErrJson(c, err)
+		return
+	}
+
+	ginx.ResOKJson(c, res)
+}
@@ -41,6 +41,8 @@ type AppRunner struct {
 	Callbacks  *callbacks.Transports
 	// 每日统计领域服务
 	ServiceDailyRecordDomain *domain.ServiceDailyRecordDomain
+	// 限时授权过期处理
+	ServiceApplyExpireDomain *domain.ServiceApplyExpireDomain
 }
 
 func newApp(hs *rest.Server) *af_go_frame.App {
@@ -98,6 +100,9 @@ func main() {
 	appRunner.ServiceDailyRecordDomain.StartDailyRecordJob()
 	log.Info("每日统计初始化任务执行完成")
 
+	// 启动限时授权过期处理定时任务
+	appRunner.ServiceApplyExpireDomain.StartApplyExpireJob()
+
 	// 启动 Workflow Consumer
 	log.Info("开始启动Workflow消费者")
 	if err := appRunner.Consumer.Start(); err != nil {
@@ -137,6 +142,10 @@ func main() {
 			appRunner.ServiceDailyRecordDomain.StopDailyRecordJob()
 			log.Info("定时任务已停止")
 		}
+		if appRunner.ServiceApplyExpireDomain != nil {
+			appRunner.ServiceApplyExpireDomain.StopApplyExpireJob()
+			log.Info("限时授权过期处理定时任务已停止")
+		}
 
 		log.Info("应用优雅关闭完成")
 	}()
@@ -169,6 +178,9 @@ func main() {
 						log.Warn("定时任务状态异常")
 					}
 				}
+				if appRunner.ServiceApplyExpireDomain != nil && !appRunner.ServiceApplyExpireDomain.IsRunning() {
+					log.Warn("限时授权过期处理定时任务状态异常")
+				}
 
 				// 添加资源监控
 				var m runtime.MemStats
@@ -30,9 +30,12 @@ import (
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/file"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_apply"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_bundle"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_call_record"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_daily_record"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_openapi"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_stats"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/service_version"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/sub_service"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driver/v1/subject_domain"
 	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/settings"
@@ -132,8 +135,16 @@ func InitApp(s *settings.Settings) (*AppRunner, func(), error) {
 	serviceCallRecordController := service_call_record.NewServiceCallRecordController(serviceCallRecordDomain)
 	serviceDailyRecordDomain := domain.NewServiceDailyRecordDomain(serviceDailyRecordRepo, serviceCallRecordRepo)
 	serviceDailyRecordController := service_daily_record.NewServiceDailyRecordController(serviceDailyRecordDomain)
+	serviceApplyExpireDomain := domain.NewServiceApplyExpireDomain(serviceApplyRepo)
 	useCase := impl5.NewSubServiceUseCase(serviceRepo, subServiceRepo, mqMQ, authServiceInternalV1Interface)
 	subServiceService := sub_service.NewSubServiceService(useCase)
+	serviceVersionRepo := gorm.NewServiceVersionRepo(data)
+	serviceVersionDomain := domain.NewServiceVersionDomain(serviceDomain, serviceRepo, serviceVersionRepo)
+	serviceVersionController := service_version.NewServiceVersionController(serviceVersionDomain)
+	serviceBundleDomain := domain.NewServiceBundleDomain(serviceDomain, serviceRepo, subServiceRepo, useCase, dataViewRepo, configurationCenterRepo)
+	serviceBundleController := service_bundle.NewServiceBundleController(serviceBundleDomain)
+	serviceOpenAPIDomain := domain.NewServiceOpenAPIDomain(serviceDomain, serviceRepo, configurationCenterRepo, dataSubjectRepo, drivenDeployMgm, serviceVersionRepo)
+	serviceOpenAPIController := service_openapi.NewServiceOpenAPIController(serviceOpenAPIDomain)
 	router := &driver.Router{
 		Middleware:                   middleware,
 		DeveloperController:          developerController,
@@ -148,6 +159,9 @@ func InitApp(s *settings.Settings) (*AppRunner, func(), error) {
 		AuditLogger:                  logger,
 		ConfigurationCenterDriven:    driven,
 		SubServiceDomainApi:          subServiceService,
+		ServiceVersionController:     serviceVersionController,
+		ServiceBundleController:      serviceBundleController,
+		ServiceOpenAPIController:     serviceOpenAPIController,
 	}
 	server := driver.NewHttpServer(s, router)
 	app := newApp(server)
@@ -162,6 +176,7 @@ func InitApp(s *settings.Settings) (*AppRunner, func(), error) {
 		MQConsumer:               consumerConsumer,
 		Callbacks:                transports,
 		ServiceDailyRecordDomain: serviceDailyRecordDomain,
+		ServiceApplyExpireDomain: serviceApplyExpireDomain,
 	}
 	return appRunner, func() {
 		cleanup2()
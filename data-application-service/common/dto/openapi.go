@@ -0,0 +1,125 @@
+package dto
+
+import "encoding/json"
+
+// OpenAPIVersion 生成的接口文档遵循的 OpenAPI 规范版本
+const OpenAPIVersion = "3.0.3"
+
+// OpenAPI 文档的认证方案名称，与网关的认证方式对应
+const (
+	OpenAPISecurityOAuth          = "oauth"           // 令牌认证
+	OpenAPISecuritySign           = "sign"            // HMAC 签名认证
+	OpenAPISecurityCssjjSignature = "cssjj_signature" // 长沙 x-tif-* 签名认证的签名
+	OpenAPISecurityCssjjTimestamp = "cssjj_timestamp" // 长沙 x-tif-* 签名认证的时间戳
+	OpenAPISecurityCssjjNonce     = "cssjj_nonce"     // 长沙 x-tif-* 签名认证的随机数
+)
+
+// OpenAPI OpenAPI 3.0 文档，只包含生成接口文档用到的字段
+type OpenAPI struct {
+	OpenAPI    string                      `json:"openapi"`
+	Info       OpenAPIInfo                 `json:"info"`
+	Servers    []OpenAPIServer             `json:"servers,omitempty"`
+	Paths      map[string]*OpenAPIPathItem `json:"paths"`
+	Components OpenAPIComponents           `json:"components"`
+	Security   []map[string][]string       `json:"security,omitempty"`
+}
+
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type OpenAPIServer struct {
+	URL         string                           `json:"url"`
+	Description string                           `json:"description,omitempty"`
+	Variables   map[string]OpenAPIServerVariable `json:"variables,omitempty"`
+}
+
+type OpenAPIServerVariable struct {
+	Default     string `json:"default"`
+	Description string `json:"description,omitempty"`
+}
+
+type OpenAPIPathItem struct {
+	Get    *OpenAPIOperation `json:"get,omitempty"`
+	Put    *OpenAPIOperation `json:"put,omitempty"`
+	Post   *OpenAPIOperation `json:"post,omitempty"`
+	Delete *OpenAPIOperation `json:"delete,omitempty"`
+}
+
+type OpenAPIOperation struct {
+	Tags        []string                    `json:"tags,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	OperationID string                      `json:"operationId,omitempty"`
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+	// 与文档的认证要求不同时声明，指向空列表表示网关不接受调用
+	Security *[]map[string][]string `json:"security,omitempty"`
+}
+
+type OpenAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"` // query header path
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *OpenAPISchema `json:"schema"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                         `json:"required,omitempty"`
+	Content  map[string]*OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema  *OpenAPISchema  `json:"schema,omitempty"`
+	Example json.RawMessage `json:"example,omitempty"`
+}
+
+type OpenAPISchema struct {
+	Type        string                    `json:"type,omitempty"`
+	Format      string                    `json:"format,omitempty"`
+	Title       string                    `json:"title,omitempty"`
+	Description string                    `json:"description,omitempty"`
+	Default     any                       `json:"default,omitempty"`
+	Minimum     *int64                    `json:"minimum,omitempty"`
+	Maximum     *int64                    `json:"maximum,omitempty"`
+	Properties  map[string]*OpenAPISchema `json:"properties,omitempty"`
+	Required    []string                  `json:"required,omitempty"`
+	Items       *OpenAPISchema            `json:"items,omitempty"`
+}
+
+type OpenAPIComponents struct {
+	SecuritySchemes map[string]*OpenAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type OpenAPISecurityScheme struct {
+	Type         string `json:"type"` // http apiKey
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+type ServiceOpenAPIReq struct {
+	ServiceIdReq
+	ServiceOpenAPIQueryReq
+}
+
+type ServiceOpenAPIQueryReq struct {
+	Format string `json:"format" form:"format,default=json" binding:"omitempty,oneof=json yaml" default:"json"` // 文档格式 json yaml，默认 json
+}
+
+type ServiceOpenAPIListReq struct {
+	DepartmentID    string `json:"department_id" form:"department_id" binding:"required_without=SubjectDomainId,omitempty,uuid" example:"019407c1-cf4e-7161-82cc-a97b906d1df0"`      // 部门ID，与主题域ID二选一
+	SubjectDomainId string `json:"subject_domain_id" form:"subject_domain_id" binding:"required_without=DepartmentID,omitempty,uuid" example:"019407c1-f33a-7f39-83af-4647ac3967d3"` // 主题域ID，与部门ID二选一
+	Format          string `json:"format" form:"format,default=json" binding:"omitempty,oneof=json yaml" default:"json"`                                                             // 文档格式 json yaml，默认 json
+}
@@ -230,10 +230,12 @@ type ServiceInfo struct {
 	BackendServicePath string `json:"backend_service_path" binding:"omitempty,URL,max=128" example:"/api/backend/path"`
 	// 请求方式 post get
 	HTTPMethod string `json:"http_method" binding:"omitempty,oneof=post get put delete"`
-	// 返回类型 json
-	ReturnType string `json:"return_type" binding:"omitempty,oneof=json"`
+	// 返回类型 json csv xml file 文件下载，默认 json，接口生成只支持 json
+	ReturnType string `json:"return_type" binding:"omitempty,oneof=json csv xml file"`
 	// 协议 http
 	Protocol string `json:"protocol" binding:"omitempty,oneof=http"`
+	// 请求映射，只对接口注册生效
+	RequestMapping *RequestMapping `json:"request_mapping,omitempty"`
 	// 接口文档
 	File File `json:"file"`
 	// 接口说明
@@ -265,6 +267,58 @@ type ServiceInfo struct {
 	CanAuth   bool   `json:"can_auth"`                  // 是否可以授权给其他人
 }
 
+// ClearRuntimeFields 去掉状态、统计、时间等与接口定义无关的字段
+func (info *ServiceInfo) ClearRuntimeFields() {
+	info.ApplyNum, info.PreviewNum = 0, 0
+	info.Status, info.PublishStatus = "", ""
+	info.AuditType, info.AuditStatus, info.AuditAdvice, info.OnlineAuditAdvice = "", "", "", ""
+	info.SyncFlag, info.SyncMsg, info.UpdateFlag, info.UpdateMsg = "", "", "", ""
+	info.PublishTime, info.OnlineTime, info.CreateTime, info.UpdateTime = "", "", "", ""
+	info.ChangedServiceId, info.IsChanged = "", ""
+	info.IsFavored, info.FavorID, info.CanAuth = false, 0, false
+	info.GatewayUrl = ""
+}
+
+// RequestMapping 注册接口转发到后台服务的请求映射
+type RequestMapping struct {
+	Headers HeaderMapping `json:"headers"`
+	Query   QueryMapping  `json:"query"`
+	Body    BodyMapping   `json:"body"`
+}
+
+// HeaderMapping 请求头映射，请求头名称不区分大小写
+type HeaderMapping struct {
+	// 转发的请求头，为空时转发 deny 以外的请求头。Authorization 和 Cookie 默认不转发，需要在这里显式允许
+	Allow []string `json:"allow" binding:"omitempty,dive,required,max=128"`
+	// 不转发的请求头
+	Deny []string `json:"deny" binding:"omitempty,dive,required,max=128"`
+	// 转发时重命名请求头，key 为调用方的请求头，value 为后台服务的请求头
+	Rename map[string]string `json:"rename" binding:"omitempty,dive,keys,required,max=128,endkeys,required,max=128"`
+	// 固定添加的请求头，覆盖调用方的同名请求头
+	Inject []StaticHeader `json:"inject" binding:"omitempty,dive"`
+}
+
+// StaticHeader 固定添加的请求头
+type StaticHeader struct {
+	Name string `json:"name" binding:"required,max=128" example:"X-Api-Key"`
+	// 请求头的值，secret 为 true 时保存时加密，查询时返回密文
+	Value string `json:"value" binding:"required,max=4096"`
+	// 是否为密钥，如后台服务的 API Key
+	Secret bool `json:"secret"`
+}
+
+// QueryMapping query 参数映射
+type QueryMapping struct {
+	// 转发 query 参数的所有值，否则只转发只有一个值的参数
+	MultiValue bool `json:"multi_value"`
+}
+
+// BodyMapping 请求体映射
+type BodyMapping struct {
+	// json 按请求参数重新编码为 JSON，默认；raw 原样转发调用方的请求体和 Content-Type
+	Mode string `json:"mode" binding:"omitempty,oneof=json raw"`
+}
+
 type CategoryInfo struct {
 	CategoryId       string `json:"category_id" binding:"omitempty,uuid" example:"019407b6-c67b-7a4d-ad2a-dac2791d23b6"`
 	CategoryName     string `json:"category_name" binding:"omitempty" example:"类目名称"`
@@ -605,7 +659,7 @@ type MonitorListReq struct {
 	CallDepartmentID    string `json:"call_department_id" form:"call_department_id" binding:"omitempty,uuid" example:"019407b2-bf43-7eab-a9a6-277c5fb5f56c"`       // 调用部门ID
 	CallInfoSystemID    string `json:"call_system_id" form:"call_system_id" binding:"omitempty,uuid" example:"019407b2-bf43-7eab-a9a6-277c5fb5f56c"`               // 调用信息系统ID
 	CallAppID           string `json:"call_app_id" form:"call_app_id" binding:"omitempty,uuid" example:"019407b2-bf43-7eab-a9a6-277c5fb5f56c"`                     // 调用应用ID
-	Status              string `json:"status" form:"status" binding:"omitempty,oneof=success fail" example:"success"`                                              // 调用状态 success 成功 fail
+	Status              string `json:"status" form:"status" binding:"omitempty,oneof=success fail circuit_open" example:"success"`                                 // 调用状态 success 成功 fail 失败(包括熔断) circuit_open 后端服务熔断
 	StartTime           string `json:"start_time" form:"start_time" binding:"omitempty,datetime=2006-01-02 15:04:05" example:"2006-01-02 15:04:05"`                // 调用开始时间
 	EndTime             string `json:"end_time" form:"end_time" binding:"omitempty,datetime=2006-01-02 15:04:05" example:"2006-01-02 15:04:05"`                    // 调用结束时间
 	Offset              int    `json:"offset" form:"offset" binding:"omitempty,min=0" default:"0" example:"0"`                                                     // 偏移量
@@ -633,7 +687,7 @@ type MonitorRecord struct {
 	CallDuration            string `json:"call_duration"`              // 调用时长
 	CallNum                 int    `json:"call_num"`                   // 调用次数
 	CallAverageCallDuration int    `json:"call_average_call_duration"` // 平均调用时长
-	Status                  string `json:"status"`                     // 状态 (success, fail)
+	Status                  string `json:"status"`                     // 状态 (success, fail, circuit_open, unknown)
 }
 
 // MonitorListRes 监控列表响应结构体
@@ -11,7 +11,7 @@ type ServiceApply struct {
 	OwnerName     string `json:"owner_name"`     // 数据Owner用户名称
 	ApplyDays     uint32 `json:"apply_days"`     // 申请时长 0表示长期
 	ApplyReason   string `json:"apply_reason"`   // 申请理由
-	AuditStatus   string `json:"audit_status"`   // 审核状态 auditing 审核中 pass 通过 reject 驳回
+	AuditStatus   string `json:"audit_status"`   // 审核状态 auditing 审核中 pass 通过 reject 驳回 expired 已过期
 	AuthTime      string `json:"auth_time"`      // 授权时间
 	ExpiredTime   string `json:"expired_time"`   // 过期时间 空字符串表示长期
 	CreateTime    string `json:"create_time"`    // 申请时间
@@ -21,7 +21,7 @@ type ServiceApply struct {
 type ServiceApplyListReq struct {
 	PageInfo
 	Keyword     string `json:"keyword" form:"keyword" binding:"omitempty"`                                    // 搜索关键词 接口ID/接口名称
-	AuditStatus string `json:"audit_status" form:"audit_status" binding:"omitempty"`                          // 审核状态 auditing 审核中 pass 通过 reject 驳回, 多个以逗号分隔
+	AuditStatus string `json:"audit_status" form:"audit_status" binding:"omitempty"`                          // 审核状态 auditing 审核中 pass 通过 reject 驳回 expired 已过期, 多个以逗号分隔
 	StartTime   string `json:"start_time" form:"start_time" binding:"omitempty,datetime=2006-01-02 15:04:05"` // 开始时间 示例: 2006-01-02 15:04:05
 	EndTime     string `json:"end_time" form:"end_time" binding:"omitempty,datetime=2006-01-02 15:04:05"`     // 结束时间 示例: 2006-01-02 15:04:05
 }
@@ -42,10 +42,35 @@ type ServiceApplyGetRes struct {
 
 type ServiceApplyCreateReq struct {
 	ServiceID   string  `json:"service_id" form:"service_id" binding:"required,VerifyNameEn"`        // 接口ID
-	ApplyDays   *uint32 `json:"apply_days" binding:"required,oneof=0"`                               // 申请时长 0表示长期
+	ApplyDays   *uint32 `json:"apply_days" binding:"required,min=0,max=365"`                         // 申请时长 0表示长期, 最长365天
 	ApplyReason string  `json:"apply_reason" binding:"required,TrimSpace,max=800,VerifyDescription"` // 申请理由
 }
 
+type ServiceApplyRenewReq struct {
+	ServiceApplyRenewUriReq
+	ServiceApplyRenewBodyReq
+}
+
+type ServiceApplyRenewUriReq struct {
+	ApplyId string `json:"apply_id" uri:"apply_id" binding:"required,VerifyNameEn"` // 续期的申请id
+}
+
+type ServiceApplyRenewBodyReq struct {
+	ApplyDays   *uint32 `json:"apply_days" binding:"required,min=1,max=365"`                         // 续期时长, 从当前授权的过期时间起算
+	ApplyReason string  `json:"apply_reason" binding:"required,TrimSpace,max=800,VerifyDescription"` // 申请理由
+}
+
+// ServiceApplyExpiringMsg 接口授权即将过期的提醒消息，由消息中心通知接口的数据 owner 和申请人
+type ServiceApplyExpiringMsg struct {
+	ApplyId     string   `json:"apply_id"`     // 申请id
+	ServiceID   string   `json:"service_id"`   // 接口ID
+	ServiceName string   `json:"service_name"` // 接口名称
+	ApplicantId string   `json:"applicant_id"` // 申请人用户ID
+	OwnerId     string   `json:"owner_id"`     // 数据Owner用户ID
+	ExpiredTime string   `json:"expired_time"` // 过期时间
+	Receivers   []string `json:"receivers"`    // 接收提醒的用户ID
+}
+
 type ServiceAuthInfoReq struct {
 	ServiceID string `json:"service_id" uri:"service_id" binding:"required,VerifyNameEn"` // 接口ID
 }
@@ -54,7 +79,7 @@ type ServiceAuthInfoRes struct {
 	ServiceAddress string `json:"service_address"` // 接口地址
 	AppId          string `json:"app_id"`          // AppId
 	AppSecret      string `json:"app_secret"`      // AppSecret
-	AuditStatus    string `json:"audit_status"`    // 审核状态 auditing 审核中 pass 通过 reject 驳回
+	AuditStatus    string `json:"audit_status"`    // 审核状态 auditing 审核中 pass 通过 reject 驳回 expired 已过期
 	ExpiredTime    string `json:"expired_time"`    // 过期时间 空字符串表示长期
 }
 
@@ -0,0 +1,120 @@
+package dto
+
+import "mime/multipart"
+
+// ServiceBundleVersion 当前的接口导出包版本，导入时只接受该版本
+const ServiceBundleVersion = "v1"
+
+// 导入接口时名称、路径冲突的处理方式
+const (
+	ServiceBundleConflictFail   = "fail"   // 导入失败
+	ServiceBundleConflictSkip   = "skip"   // 跳过该接口
+	ServiceBundleConflictRename = "rename" // 名称、路径加上序号后导入
+)
+
+// 导入接口的结果
+const (
+	ServiceBundleImportValid   = "valid"   // 校验通过，仅预检查时返回
+	ServiceBundleImportCreated = "created" // 已创建
+	ServiceBundleImportSkipped = "skipped" // 名称或路径冲突，已跳过
+	ServiceBundleImportFailed  = "failed"  // 校验或创建失败
+)
+
+// ServiceBundle 接口导出包，用于在不同环境之间迁移接口定义。
+// 环境相关的ID（接口ID、部门ID、数据视图ID等）和密钥请求头的值不会导出，部门、数据视图、主题域和开发商以可解析的键表示，导入时在目标环境中解析。
+// 信息系统、应用、数据owner 和类目只导出名称，导入后需要重新选择
+type ServiceBundle struct {
+	BundleVersion string                 `json:"bundle_version"` // 导出包版本
+	ExportTime    string                 `json:"export_time"`    // 导出时间
+	Services      []ServiceBundleService `json:"services"`       // 接口列表
+}
+
+// ServiceBundleService 导出包中的一个接口
+type ServiceBundleService struct {
+	ServiceInfo     ServiceInfo               `json:"service_info"`               // 基本信息
+	CategoryInfo    []CategoryInfo            `json:"category_info"`              // 类目信息集合
+	ServiceParam    ServiceParamWrite         `json:"service_param"`              // 参数配置
+	ServiceResponse *ServiceResponse          `json:"service_response,omitempty"` // 返回结果
+	ServiceTest     ServiceTest               `json:"service_test"`               // 接口测试
+	SubServices     []ServiceBundleSubService `json:"sub_services"`               // 子接口（行列规则）
+	References      ServiceBundleReferences   `json:"references"`                 // 环境相关的引用
+}
+
+// ServiceBundleReferences 接口引用的部门、数据视图、主题域和开发商，导入时解析为目标环境中的ID
+type ServiceBundleReferences struct {
+	Department    *ServiceBundleDepartmentKey    `json:"department,omitempty"`     // 所属部门
+	DataView      *ServiceBundleDataViewKey      `json:"data_view,omitempty"`      // 数据视图，只有接口生成的向导模式有
+	SubjectDomain *ServiceBundleSubjectDomainKey `json:"subject_domain,omitempty"` // 所属主题域
+	Developer     *ServiceBundleDeveloperKey     `json:"developer,omitempty"`      // 开发商
+}
+
+// ServiceBundleDepartmentKey 部门的键
+type ServiceBundleDepartmentKey struct {
+	Path string `json:"path"` // 部门路径，如 组织/部门/子部门
+}
+
+// ServiceBundleDataViewKey 数据视图的键
+type ServiceBundleDataViewKey struct {
+	DatasourceName string `json:"datasource_name"` // 数据源名称
+	TechnicalName  string `json:"technical_name"`  // 数据视图技术名称
+}
+
+// ServiceBundleSubjectDomainKey 主题域的键
+type ServiceBundleSubjectDomainKey struct {
+	Path string `json:"path"` // 主题域路径，如 主题域分组/主题域/业务对象
+}
+
+// ServiceBundleDeveloperKey 开发商的键
+type ServiceBundleDeveloperKey struct {
+	Name string `json:"name"` // 开发商名称
+}
+
+// ServiceBundleSubService 导出包中的子接口
+type ServiceBundleSubService struct {
+	Name      string `json:"name"`       // 子接口名称
+	AuthScope string `json:"auth_scope"` // 授权范围，为空表示接口本身，否则为同一接口下另一个子接口的名称
+	Detail    string `json:"detail"`     // 行列规则，导入时根据行列规则生成行过滤器子句
+}
+
+type ServiceBundleExportReq struct {
+	ServiceIDs []string `json:"service_ids" binding:"required,min=1,max=100,dive,uuid" example:"019407b3-d158-7177-a0c8-0da2f2683c50"` // 导出的接口ID列表
+	Format     string   `json:"format" binding:"omitempty,oneof=json yaml" default:"json"`                                             // 导出格式 json yaml，默认 json
+}
+
+// ServiceBundleExportRes 导出包文件，Buffer 为文件内容
+type ServiceBundleExportRes struct {
+	Buffer      []byte
+	FileName    string
+	ContentType string
+}
+
+type ServiceBundleImportReq struct {
+	File             *multipart.FileHeader `form:"file" binding:"required"`                                                                  // 导出包文件，支持 .json .yaml .yml
+	DryRun           bool                  `form:"dry_run"`                                                                                  // 是否只做预检查，不创建接口
+	ConflictStrategy string                `form:"conflict_strategy,default=fail" binding:"omitempty,oneof=fail skip rename" default:"fail"` // 名称、路径冲突的处理方式 fail 失败 skip 跳过 rename 重命名
+	AutoPublish      bool                  `form:"auto_publish"`                                                                             // 导入后是否自动提交发布审核
+}
+
+type ServiceBundleImportRes struct {
+	DryRun      bool                        `json:"dry_run"`      // 是否为预检查
+	TotalCount  int                         `json:"total_count"`  // 导出包中的接口数量
+	ValidCount  int                         `json:"valid_count"`  // 校验通过的接口数量，仅预检查时有值
+	CreateCount int                         `json:"create_count"` // 创建的接口数量
+	SkipCount   int                         `json:"skip_count"`   // 跳过的接口数量
+	FailCount   int                         `json:"fail_count"`   // 失败的接口数量
+	Entries     []*ServiceBundleImportEntry `json:"entries"`      // 每个接口的导入结果，顺序与导出包相同
+}
+
+// ServiceBundleImportEntry 一个接口的导入结果
+type ServiceBundleImportEntry struct {
+	Index         int      `json:"index"`                    // 在导出包中的序号，从0开始
+	ServiceName   string   `json:"service_name"`             // 导出包中的接口名称
+	ServicePath   string   `json:"service_path"`             // 导出包中的接口路径
+	Result        string   `json:"result"`                   // 导入结果 valid 校验通过 created 已创建 skipped 已跳过 failed 失败
+	NewName       string   `json:"new_name,omitempty"`       // 重命名后的接口名称
+	NewPath       string   `json:"new_path,omitempty"`       // 重命名后的接口路径
+	ServiceID     string   `json:"service_id,omitempty"`     // 创建的接口ID
+	SecretHeaders []string `json:"secret_headers,omitempty"` // 需要重新填写值的密钥请求头，导出包中不含密钥的值
+	References    []string `json:"references,omitempty"`     // 需要重新选择的信息系统、应用、数据owner 和类目，导出包中只有名称
+	Message       string   `json:"message,omitempty"`        // 失败、跳过的原因，或提交发布审核失败的原因
+}
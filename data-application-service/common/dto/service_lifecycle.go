@@ -0,0 +1,23 @@
+package dto
+
+// ServiceLifecycleRes 接口的生命周期时间线
+type ServiceLifecycleRes struct {
+	ServiceID     string                    `json:"service_id"`     // 接口ID
+	PublishStatus string                    `json:"publish_status"` // 当前的发布状态，变更审核中、变更审核未通过时为变更版本的发布状态
+	Status        string                    `json:"status"`         // 当前的上线状态
+	Entries       []*ServiceLifecycleRecord `json:"entries"`        // 按时间顺序的迁移记录
+}
+
+// ServiceLifecycleRecord 接口生命周期的一次迁移
+type ServiceLifecycleRecord struct {
+	Trigger           string `json:"trigger"`             // 触发动作 publish-submit 提交发布审核 publish-pass 发布审核通过 online-submit 提交上线审核 等
+	FromPublishStatus string `json:"from_publish_status"` // 迁移前的发布状态
+	FromStatus        string `json:"from_status"`         // 迁移前的上线状态
+	ToPublishStatus   string `json:"to_publish_status"`   // 迁移后的发布状态
+	ToStatus          string `json:"to_status"`           // 迁移后的上线状态
+	ApplyID           string `json:"apply_id"`            // 审核申请id
+	FlowID            string `json:"flow_id"`             // 审批流程实例id，只有审核结果触发的迁移才有
+	OperatorID        string `json:"operator_id"`         // 操作人用户ID，审核结果触发时为空
+	OperatorName      string `json:"operator_name"`       // 操作人用户名称
+	CreateTime        string `json:"create_time"`         // 迁移时间
+}
@@ -0,0 +1,77 @@
+package dto
+
+// ServiceVersionContent 接口版本的快照，发布审核、变更审核通过或子服务变更时记录，记录后不再修改
+type ServiceVersionContent struct {
+	ServiceGetRes
+	// 记录版本时接口的子服务（行列规则）
+	SubServices []ServiceVersionSubService `json:"sub_services"`
+}
+
+// ServiceVersionSubService 版本快照中的子服务
+type ServiceVersionSubService struct {
+	ID              string `json:"id"`                // 子服务ID
+	Name            string `json:"name"`              // 子服务名称
+	AuthScopeID     string `json:"auth_scope_id"`     // 授权范围ID
+	Detail          string `json:"detail"`            // 行列规则
+	RowFilterClause string `json:"row_filter_clause"` // 行过滤器子句
+}
+
+type ServiceVersionUriReq struct {
+	ServiceID string `json:"service_id" uri:"service_id" binding:"required,VerifyNameEn"` // 接口ID
+	Version   int    `json:"version" uri:"version" binding:"required,min=1"`              // 版本号
+}
+
+type ServiceVersionListReq struct {
+	ServiceIdReq
+	ServiceVersionListQueryReq
+}
+
+type ServiceVersionListQueryReq struct {
+	Offset int `json:"offset" form:"offset,default=1" binding:"omitempty,min=1" default:"1"`         // 页码 默认 1
+	Limit  int `json:"limit" form:"limit,default=10" binding:"omitempty,min=1,max=100" default:"10"` // 每页大小 默认 10
+}
+
+type ServiceVersionDiffReq struct {
+	ServiceIdReq
+	ServiceVersionDiffQueryReq
+}
+
+type ServiceVersionDiffQueryReq struct {
+	From int `json:"from" form:"from" binding:"required,min=1"` // 比较的旧版本号
+	To   int `json:"to" form:"to" binding:"required,min=1"`     // 比较的新版本号
+}
+
+// ServiceVersion 接口版本
+type ServiceVersion struct {
+	ServiceID  string `json:"service_id"`  // 接口ID
+	Version    int    `json:"version"`     // 版本号，从1开始递增
+	AuditType  string `json:"audit_type"`  // 产生版本的审核类型 af-data-application-publish 发布 af-data-application-change 变更 sub-service 子服务变更
+	ApplyID    string `json:"apply_id"`    // 审核申请id
+	FlowID     string `json:"flow_id"`     // 审批流程实例id，无需审核时为空
+	AuthorID   string `json:"author_id"`   // 提交人用户ID
+	AuthorName string `json:"author_name"` // 提交人用户名称
+	CreateTime string `json:"create_time"` // 版本记录时间
+}
+
+type ServiceVersionListRes struct {
+	PageResult[ServiceVersion]
+}
+
+type ServiceVersionGetRes struct {
+	ServiceVersion
+	Content ServiceVersionContent `json:"content"` // 版本快照
+}
+
+type ServiceVersionDiffRes struct {
+	From    int                    `json:"from"`    // 旧版本号
+	To      int                    `json:"to"`      // 新版本号
+	Changes []ServiceVersionChange `json:"changes"` // 差异，按路径排序
+}
+
+// ServiceVersionChange 两个版本快照中一个字段的差异
+type ServiceVersionChange struct {
+	Path string `json:"path"`           // 字段路径，如 service_param.data_table_request_params[0].en_name
+	Type string `json:"type"`           // 差异类型 added 新增 removed 删除 modified 修改
+	From any    `json:"from,omitempty"` // 旧版本的值
+	To   any    `json:"to,omitempty"`   // 新版本的值
+}
@@ -85,6 +85,7 @@ const (
 	AuditStatusPass        = "pass"
 	AuditStatusReject      = "reject"
 	AuditStatusUndone      = "undone"
+	AuditStatusExpired     = "expired" // 接口调用申请的授权已过期
 )
 
 // 系统类目的分类，定义的三个固定的cate_id
@@ -116,6 +117,7 @@ var (
 		AuditStatusPass:        {},
 		AuditStatusReject:      {},
 		AuditStatusUndone:      {},
+		AuditStatusExpired:     {},
 	}
 )
 
@@ -133,41 +135,7 @@ var (
 	}
 )
 
-/* 可上线的状态
-| 序号 | 发布状态 | 上线状态 |
-|------|----------|----------|
-| 1    | 已发布     | 未上线   |
-| 2    | 已发布     | 已下线   |
-| 3    | 变更审核中     | 未上线     |
-| 4    | 变更审核中     | 已下线     |
-| 5    | 变更审核未通过     | 未上线     |
-| 6    | 变更审核未通过     | 已下线     |
-*/
-
-var (
-	ServiceAllowedUpStatus = map[string]struct{}{
-		PublishStatusPublished + LineStatusNotLine:       {}, //1
-		PublishStatusPublished + LineStatusOffLine:       {}, //2
-		PublishStatusPublished + LineStatusUpReject:      {},
-		PublishStatusChangeAuditing + LineStatusNotLine:  {},
-		PublishStatusChangeAuditing + LineStatusOffLine:  {},
-		PublishStatusChangeAuditing + LineStatusUpReject: {},
-		PublishStatusChangeReject + LineStatusNotLine:    {},
-		PublishStatusChangeReject + LineStatusOffLine:    {},
-		PublishStatusChangeReject + LineStatusUpReject:   {},
-	}
-)
-
-var (
-	ServiceAllowedDownStatus = map[string]struct{}{
-		PublishStatusPublished + LineStatusOnLine:          {}, //1
-		PublishStatusChangeAuditing + LineStatusOnLine:     {},
-		PublishStatusChangeReject + LineStatusOnLine:       {},
-		PublishStatusPublished + LineStatusDownReject:      {}, //1
-		PublishStatusChangeAuditing + LineStatusDownReject: {},
-		PublishStatusChangeReject + LineStatusDownReject:   {},
-	}
-)
+// 可上线、可下线的状态由接口生命周期状态机（service_lifecycle.go）中的 online、offline 迁移决定
 
 /* 可删除的状态
 | 序号 | 发布状态 | 上线状态 |
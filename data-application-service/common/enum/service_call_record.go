@@ -0,0 +1,42 @@
+package enum
+
+// 接口调用状态，对应 service_call_record.call_status，与网关记录的一致
+const (
+	CallStatusFailed      = 0 //失败
+	CallStatusSuccess     = 1 //成功
+	CallStatusCircuitOpen = 2 //后端服务熔断，未请求后端服务
+)
+
+// 调用记录列表返回和筛选的调用状态
+const (
+	CallStatusNameSuccess     = "success"
+	CallStatusNameFail        = "fail"
+	CallStatusNameCircuitOpen = "circuit_open"
+	CallStatusNameUnknown     = "unknown"
+)
+
+// CallStatusName 返回 call_status 对应的调用状态
+func CallStatusName(callStatus int) string {
+	switch callStatus {
+	case CallStatusSuccess:
+		return CallStatusNameSuccess
+	case CallStatusFailed:
+		return CallStatusNameFail
+	case CallStatusCircuitOpen:
+		return CallStatusNameCircuitOpen
+	}
+	return CallStatusNameUnknown
+}
+
+// CallStatusFilter 返回按调用状态筛选时匹配的 call_status，fail 包括后端服务熔断的调用
+func CallStatusFilter(name string) []int {
+	switch name {
+	case CallStatusNameSuccess:
+		return []int{CallStatusSuccess}
+	case CallStatusNameFail:
+		return []int{CallStatusFailed, CallStatusCircuitOpen}
+	case CallStatusNameCircuitOpen:
+		return []int{CallStatusCircuitOpen}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package enum
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallStatusName(t *testing.T) {
+	tests := []struct {
+		callStatus int
+		want       string
+	}{
+		{callStatus: CallStatusFailed, want: CallStatusNameFail},
+		{callStatus: CallStatusSuccess, want: CallStatusNameSuccess},
+		{callStatus: CallStatusCircuitOpen, want: CallStatusNameCircuitOpen},
+		{callStatus: 9, want: CallStatusNameUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, CallStatusName(tt.callStatus))
+		})
+	}
+}
+
+func TestCallStatusFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		want []int
+	}{
+		{name: CallStatusNameSuccess, want: []int{CallStatusSuccess}},
+		{name: CallStatusNameFail, want: []int{CallStatusFailed, CallStatusCircuitOpen}},
+		{name: CallStatusNameCircuitOpen, want: []int{CallStatusCircuitOpen}},
+		{name: "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CallStatusFilter(tt.name))
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package enum
+
+import (
+	"fmt"
+	"slices"
+)
+
+// 接口生命周期的触发动作
+const (
+	LifecycleTriggerPublishSubmit = "publish-submit" // 提交发布审核
+	LifecycleTriggerPublishPass   = "publish-pass"   // 发布审核通过
+	LifecycleTriggerPublishReject = "publish-reject" // 发布审核未通过
+	LifecycleTriggerPublishUndo   = "publish-undo"   // 发布审核撤回
+	LifecycleTriggerPublish       = "publish"        // 未绑定审核流程，直接发布
+
+	LifecycleTriggerChangeSubmit  = "change-submit"  // 提交变更审核
+	LifecycleTriggerChangePass    = "change-pass"    // 变更审核通过，或未绑定审核流程时直接变更
+	LifecycleTriggerChangeReject  = "change-reject"  // 变更审核未通过
+	LifecycleTriggerChangeUndo    = "change-undo"    // 变更审核撤回，变更的内容作为已发布版本的草稿
+	LifecycleTriggerChangeAbandon = "change-abandon" // 放弃未通过审核的变更，恢复到已发布的版本
+
+	LifecycleTriggerOnlineSubmit = "online-submit" // 提交上线审核
+	LifecycleTriggerOnlinePass   = "online-pass"   // 上线审核通过
+	LifecycleTriggerOnlineReject = "online-reject" // 上线审核未通过
+	LifecycleTriggerOnlineUndo   = "online-undo"   // 上线审核撤回
+	LifecycleTriggerOnline       = "online"        // 未绑定审核流程，直接上线
+
+	LifecycleTriggerOfflineSubmit = "offline-submit" // 提交下线审核
+	LifecycleTriggerOfflinePass   = "offline-pass"   // 下线审核通过
+	LifecycleTriggerOfflineReject = "offline-reject" // 下线审核未通过
+	LifecycleTriggerOfflineUndo   = "offline-undo"   // 下线审核撤回
+	LifecycleTriggerOffline       = "offline"        // 未绑定审核流程，直接下线
+
+	LifecycleTriggerBatchPublishOnline = "batch-publish-online" // 批量发布并上线，不经过审核
+)
+
+// PublishStatuses 所有的发布状态
+var PublishStatuses = []string{
+	PublishStatusUnPublished,
+	PublishStatusPubAuditing,
+	PublishStatusPubReject,
+	PublishStatusPublished,
+	PublishStatusChangeAuditing,
+	PublishStatusChangeReject,
+}
+
+// LineStatuses 所有的上线状态
+var LineStatuses = []string{
+	LineStatusNotLine,
+	LineStatusUpAuditing,
+	LineStatusUpReject,
+	LineStatusOnLine,
+	LineStatusDownAuditing,
+	LineStatusDownReject,
+	LineStatusOffLine,
+}
+
+// LifecycleState 接口的生命周期状态，由发布状态和上线状态组成。
+// 变更审核中、变更审核未通过时，发布状态为变更版本的发布状态，上线状态为已发布版本的上线状态
+type LifecycleState struct {
+	PublishStatus string `json:"publish_status"` // 发布状态
+	Status        string `json:"status"`         // 上线状态
+}
+
+// NewLifecycleState 上线状态为空的旧数据视为未上线
+func NewLifecycleState(publishStatus, status string) LifecycleState {
+	if status == "" {
+		status = LineStatusNotLine
+	}
+	return LifecycleState{PublishStatus: publishStatus, Status: status}
+}
+
+func (s LifecycleState) String() string {
+	return s.PublishStatus + "/" + s.Status
+}
+
+// lifecycleTransition 生命周期中的一个迁移，起始状态为空时不限制，目标状态为空时不变
+type lifecycleTransition struct {
+	trigger     string
+	fromPublish []string
+	fromStatus  []string
+	toPublish   string
+	toStatus    string
+}
+
+var (
+	// 发布前的发布状态，此时接口只能是未上线
+	unpublishedStatuses = []string{PublishStatusUnPublished, PublishStatusPubReject}
+	// 可以上线的上线状态
+	upAllowedStatuses = []string{LineStatusNotLine, LineStatusOffLine, LineStatusUpReject}
+	// 可以下线的上线状态
+	downAllowedStatuses = []string{LineStatusOnLine, LineStatusDownReject}
+)
+
+// serviceLifecycle 接口生命周期的全部迁移，不在其中的迁移都是非法的
+var serviceLifecycle = []lifecycleTransition{
+	{trigger: LifecycleTriggerPublishSubmit, fromPublish: unpublishedStatuses, fromStatus: []string{LineStatusNotLine}, toPublish: PublishStatusPubAuditing},
+	{trigger: LifecycleTriggerPublishPass, fromPublish: []string{PublishStatusPubAuditing}, fromStatus: []string{LineStatusNotLine}, toPublish: PublishStatusPublished},
+	{trigger: LifecycleTriggerPublishReject, fromPublish: []string{PublishStatusPubAuditing}, fromStatus: []string{LineStatusNotLine}, toPublish: PublishStatusPubReject},
+	{trigger: LifecycleTriggerPublishUndo, fromPublish: []string{PublishStatusPubAuditing}, fromStatus: []string{LineStatusNotLine}, toPublish: PublishStatusUnPublished},
+	{trigger: LifecycleTriggerPublish, fromPublish: unpublishedStatuses, fromStatus: []string{LineStatusNotLine}, toPublish: PublishStatusPublished},
+
+	{trigger: LifecycleTriggerChangeSubmit, fromPublish: []string{PublishStatusPublished, PublishStatusChangeReject}, toPublish: PublishStatusChangeAuditing},
+	{trigger: LifecycleTriggerChangePass, fromPublish: []string{PublishStatusChangeAuditing}, toPublish: PublishStatusPublished},
+	{trigger: LifecycleTriggerChangeReject, fromPublish: []string{PublishStatusChangeAuditing}, toPublish: PublishStatusChangeReject},
+	{trigger: LifecycleTriggerChangeUndo, fromPublish: []string{PublishStatusChangeAuditing}, toPublish: PublishStatusPublished},
+	{trigger: LifecycleTriggerChangeAbandon, fromPublish: []string{PublishStatusChangeReject}, toPublish: PublishStatusPublished},
+
+	{trigger: LifecycleTriggerOnlineSubmit, fromPublish: ConsideredAsPublishedStatuses, fromStatus: upAllowedStatuses, toStatus: LineStatusUpAuditing},
+	{trigger: LifecycleTriggerOnlinePass, fromPublish: ConsideredAsPublishedStatuses, fromStatus: []string{LineStatusUpAuditing}, toStatus: LineStatusOnLine},
+	{trigger: LifecycleTriggerOnlineReject, fromPublish: ConsideredAsPublishedStatuses, fromStatus: []string{LineStatusUpAuditing}, toStatus: LineStatusUpReject},
+	{trigger: LifecycleTriggerOnlineUndo, fromPublish: ConsideredAsPublishedStatuses, fromStatus: []string{LineStatusUpAuditing}, toStatus: LineStatusNotLine},
+	{trigger: LifecycleTriggerOnline, fromPublish: ConsideredAsPublishedStatuses, fromStatus: upAllowedStatuses, toStatus: LineStatusOnLine},
+
+	{trigger: LifecycleTriggerOfflineSubmit, fromPublish: ConsideredAsPublishedStatuses, fromStatus: downAllowedStatuses, toStatus: LineStatusDownAuditing},
+	{trigger: LifecycleTriggerOfflinePass, fromPublish: ConsideredAsPublishedStatuses, fromStatus: []string{LineStatusDownAuditing}, toStatus: LineStatusOffLine},
+	{trigger: LifecycleTriggerOfflineReject, fromPublish: ConsideredAsPublishedStatuses, fromStatus: []string{LineStatusDownAuditing}, toStatus: LineStatusDownReject},
+	{trigger: LifecycleTriggerOfflineUndo, fromPublish: ConsideredAsPublishedStatuses, fromStatus: []string{LineStatusDownAuditing}, toStatus: LineStatusOnLine},
+	{trigger: LifecycleTriggerOffline, fromPublish: ConsideredAsPublishedStatuses, fromStatus: downAllowedStatuses, toStatus: LineStatusOffLine},
+
+	{
+		trigger:     LifecycleTriggerBatchPublishOnline,
+		fromPublish: []string{PublishStatusUnPublished, PublishStatusPubReject, PublishStatusPublished, PublishStatusChangeReject},
+		fromStatus:  []string{LineStatusNotLine, LineStatusUpReject, LineStatusOnLine, LineStatusDownReject, LineStatusOffLine},
+		toPublish:   PublishStatusPublished,
+		toStatus:    LineStatusOnLine,
+	},
+}
+
+// LifecycleTriggers 所有的触发动作
+func LifecycleTriggers() []string {
+	triggers := make([]string, 0, len(serviceLifecycle))
+	for _, t := range serviceLifecycle {
+		triggers = append(triggers, t.trigger)
+	}
+	return triggers
+}
+
+// CanFireLifecycle 接口在状态 from 时是否可以执行触发动作 trigger
+func CanFireLifecycle(from LifecycleState, trigger string) bool {
+	_, err := FireLifecycle(from, trigger)
+	return err == nil
+}
+
+// FireLifecycle 返回接口在状态 from 时执行触发动作 trigger 后的状态，迁移非法时返回错误
+func FireLifecycle(from LifecycleState, trigger string) (LifecycleState, error) {
+	from = NewLifecycleState(from.PublishStatus, from.Status)
+	if !slices.Contains(PublishStatuses, from.PublishStatus) || !slices.Contains(LineStatuses, from.Status) {
+		return from, fmt.Errorf("unknown lifecycle state %s", from)
+	}
+	for _, t := range serviceLifecycle {
+		if t.trigger != trigger {
+			continue
+		}
+		if len(t.fromPublish) > 0 && !slices.Contains(t.fromPublish, from.PublishStatus) {
+			continue
+		}
+		if len(t.fromStatus) > 0 && !slices.Contains(t.fromStatus, from.Status) {
+			continue
+		}
+		to := from
+		if t.toPublish != "" {
+			to.PublishStatus = t.toPublish
+		}
+		if t.toStatus != "" {
+			to.Status = t.toStatus
+		}
+		return to, nil
+	}
+	return from, fmt.Errorf("illegal lifecycle transition %q from %s", trigger, from)
+}
+
+// AuditLifecycleTrigger 审核结果对应的触发动作，审核类型或审核结果不是生命周期的迁移时返回空
+func AuditLifecycleTrigger(auditType, result string) string {
+	triggers := map[string][3]string{
+		AuditTypePublish: {LifecycleTriggerPublishPass, LifecycleTriggerPublishReject, LifecycleTriggerPublishUndo},
+		AuditTypeChange:  {LifecycleTriggerChangePass, LifecycleTriggerChangeReject, LifecycleTriggerChangeUndo},
+		AuditTypeOnline:  {LifecycleTriggerOnlinePass, LifecycleTriggerOnlineReject, LifecycleTriggerOnlineUndo},
+		AuditTypeOffline: {LifecycleTriggerOfflinePass, LifecycleTriggerOfflineReject, LifecycleTriggerOfflineUndo},
+	}[auditType]
+	switch result {
+	case AuditStatusPass:
+		return triggers[0]
+	case AuditStatusReject:
+		return triggers[1]
+	case AuditStatusUndone:
+		return triggers[2]
+	}
+	return ""
+}
@@ -0,0 +1,250 @@
+package enum
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// lifecycleInitState 新建接口的状态
+var lifecycleInitState = NewLifecycleState(PublishStatusUnPublished, LineStatusNotLine)
+
+// reachableLifecycleStates 从新建接口出发可以到达的全部状态
+func reachableLifecycleStates() map[LifecycleState]bool {
+	reached := map[LifecycleState]bool{lifecycleInitState: true}
+	queue := []LifecycleState{lifecycleInitState}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		for _, trigger := range LifecycleTriggers() {
+			to, err := FireLifecycle(s, trigger)
+			if err != nil || reached[to] {
+				continue
+			}
+			reached[to] = true
+			queue = append(queue, to)
+		}
+	}
+	return reached
+}
+
+// checkLifecycleInvariant 检查状态是否合法：状态取值已定义，发布之前只能是未上线
+func checkLifecycleInvariant(t *testing.T, s LifecycleState) bool {
+	ok := assert.Contains(t, PublishStatuses, s.PublishStatus, s.String())
+	ok = assert.Contains(t, LineStatuses, s.Status, s.String()) && ok
+	if !IsConsideredAsPublished(s.PublishStatus) {
+		ok = assert.Equal(t, LineStatusNotLine, s.Status, "未发布的接口不能上线: %s", s) && ok
+	}
+	return ok
+}
+
+func TestFireLifecycle(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    LifecycleState
+		trigger string
+		want    LifecycleState
+		wantErr bool
+	}{
+		{
+			name:    "提交发布审核",
+			from:    NewLifecycleState(PublishStatusUnPublished, ""),
+			trigger: LifecycleTriggerPublishSubmit,
+			want:    NewLifecycleState(PublishStatusPubAuditing, LineStatusNotLine),
+		},
+		{
+			name:    "发布审核未通过后重新提交",
+			from:    NewLifecycleState(PublishStatusPubReject, LineStatusNotLine),
+			trigger: LifecycleTriggerPublishSubmit,
+			want:    NewLifecycleState(PublishStatusPubAuditing, LineStatusNotLine),
+		},
+		{
+			name:    "已发布的接口不能再次发布",
+			from:    NewLifecycleState(PublishStatusPublished, LineStatusNotLine),
+			trigger: LifecycleTriggerPublishSubmit,
+			wantErr: true,
+		},
+		{
+			name:    "变更审核通过不改变上线状态",
+			from:    NewLifecycleState(PublishStatusChangeAuditing, LineStatusOnLine),
+			trigger: LifecycleTriggerChangePass,
+			want:    NewLifecycleState(PublishStatusPublished, LineStatusOnLine),
+		},
+		{
+			name:    "未发布的接口不能上线",
+			from:    NewLifecycleState(PublishStatusPubAuditing, LineStatusNotLine),
+			trigger: LifecycleTriggerOnlineSubmit,
+			wantErr: true,
+		},
+		{
+			name:    "上线审核中不能再次提交上线",
+			from:    NewLifecycleState(PublishStatusPublished, LineStatusUpAuditing),
+			trigger: LifecycleTriggerOnlineSubmit,
+			wantErr: true,
+		},
+		{
+			name:    "下线审核撤回后恢复为已上线",
+			from:    NewLifecycleState(PublishStatusChangeReject, LineStatusDownAuditing),
+			trigger: LifecycleTriggerOfflineUndo,
+			want:    NewLifecycleState(PublishStatusChangeReject, LineStatusOnLine),
+		},
+		{
+			name:    "审核中的接口不能批量发布上线",
+			from:    NewLifecycleState(PublishStatusPublished, LineStatusUpAuditing),
+			trigger: LifecycleTriggerBatchPublishOnline,
+			wantErr: true,
+		},
+		{
+			name:    "批量发布上线",
+			from:    NewLifecycleState(PublishStatusUnPublished, LineStatusNotLine),
+			trigger: LifecycleTriggerBatchPublishOnline,
+			want:    NewLifecycleState(PublishStatusPublished, LineStatusOnLine),
+		},
+		{
+			name:    "未定义的触发动作",
+			from:    NewLifecycleState(PublishStatusPublished, LineStatusOnLine),
+			trigger: "unknown",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FireLifecycle(tt.from, tt.trigger)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.False(t, CanFireLifecycle(tt.from, tt.trigger))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.True(t, CanFireLifecycle(tt.from, tt.trigger))
+		})
+	}
+}
+
+// TestLifecycleReachableStates 穷举从新建接口可以到达的状态，所有状态都满足不变量
+func TestLifecycleReachableStates(t *testing.T) {
+	reached := reachableLifecycleStates()
+	for s := range reached {
+		checkLifecycleInvariant(t, s)
+	}
+	// 发布前 3 个状态只能未上线，发布后 3 个发布状态与 7 个上线状态任意组合
+	assert.Len(t, reached, 3+3*7)
+}
+
+// TestLifecycleRandomWalk 从新建接口出发随机执行触发动作，非法的迁移不改变状态，任何时候都不会到达非法状态
+func TestLifecycleRandomWalk(t *testing.T) {
+	triggers := append(LifecycleTriggers(), "unknown")
+	walk := func(seed int64, steps uint8) bool {
+		r := rand.New(rand.NewSource(seed))
+		s := lifecycleInitState
+		for i := 0; i < int(steps); i++ {
+			trigger := triggers[r.Intn(len(triggers))]
+			to, err := FireLifecycle(s, trigger)
+			if err != nil {
+				if to != s {
+					return false
+				}
+				continue
+			}
+			if !checkLifecycleInvariant(t, to) {
+				return false
+			}
+			s = to
+		}
+		return true
+	}
+	assert.NoError(t, quick.Check(walk, &quick.Config{MaxCount: 1000}))
+}
+
+// TestLifecycleIllegalStates 从非法状态出发的迁移都会被拒绝，不会把非法状态带入生命周期
+func TestLifecycleIllegalStates(t *testing.T) {
+	reached := reachableLifecycleStates()
+	for _, p := range append(PublishStatuses, "", "unknown") {
+		for _, s := range append(LineStatuses, "unknown") {
+			from := NewLifecycleState(p, s)
+			if reached[from] {
+				continue
+			}
+			for _, trigger := range LifecycleTriggers() {
+				if to, err := FireLifecycle(from, trigger); err == nil {
+					// 只允许把非法状态恢复为合法状态
+					assert.True(t, reached[to], "%s --%s--> %s", from, trigger, to)
+				}
+			}
+		}
+	}
+}
+
+// TestLifecycleDeterministic 每个状态下同一个触发动作最多只有一个迁移
+func TestLifecycleDeterministic(t *testing.T) {
+	for s := range reachableLifecycleStates() {
+		for _, trigger := range LifecycleTriggers() {
+			var matched int
+			for _, tr := range serviceLifecycle {
+				if tr.trigger == trigger &&
+					(len(tr.fromPublish) == 0 || slices.Contains(tr.fromPublish, s.PublishStatus)) &&
+					(len(tr.fromStatus) == 0 || slices.Contains(tr.fromStatus, s.Status)) {
+					matched++
+				}
+			}
+			assert.LessOrEqual(t, matched, 1, "%s --%s-->", s, trigger)
+		}
+	}
+}
+
+// TestLifecycleOnlineOfflineGuard 上线、下线的前置状态与原来的可上线、可下线状态表一致
+func TestLifecycleOnlineOfflineGuard(t *testing.T) {
+	allowedUp := []string{
+		PublishStatusPublished + LineStatusNotLine,
+		PublishStatusPublished + LineStatusOffLine,
+		PublishStatusPublished + LineStatusUpReject,
+		PublishStatusChangeAuditing + LineStatusNotLine,
+		PublishStatusChangeAuditing + LineStatusOffLine,
+		PublishStatusChangeAuditing + LineStatusUpReject,
+		PublishStatusChangeReject + LineStatusNotLine,
+		PublishStatusChangeReject + LineStatusOffLine,
+		PublishStatusChangeReject + LineStatusUpReject,
+	}
+	allowedDown := []string{
+		PublishStatusPublished + LineStatusOnLine,
+		PublishStatusChangeAuditing + LineStatusOnLine,
+		PublishStatusChangeReject + LineStatusOnLine,
+		PublishStatusPublished + LineStatusDownReject,
+		PublishStatusChangeAuditing + LineStatusDownReject,
+		PublishStatusChangeReject + LineStatusDownReject,
+	}
+	for _, p := range PublishStatuses {
+		for _, s := range LineStatuses {
+			from := NewLifecycleState(p, s)
+			assert.Equal(t, slices.Contains(allowedUp, p+s), CanFireLifecycle(from, LifecycleTriggerOnlineSubmit), from.String())
+			assert.Equal(t, slices.Contains(allowedUp, p+s), CanFireLifecycle(from, LifecycleTriggerOnline), from.String())
+			assert.Equal(t, slices.Contains(allowedDown, p+s), CanFireLifecycle(from, LifecycleTriggerOfflineSubmit), from.String())
+			assert.Equal(t, slices.Contains(allowedDown, p+s), CanFireLifecycle(from, LifecycleTriggerOffline), from.String())
+		}
+	}
+}
+
+func TestAuditLifecycleTrigger(t *testing.T) {
+	tests := []struct {
+		name      string
+		auditType string
+		result    string
+		want      string
+	}{
+		{name: "发布审核通过", auditType: AuditTypePublish, result: AuditStatusPass, want: LifecycleTriggerPublishPass},
+		{name: "变更审核未通过", auditType: AuditTypeChange, result: AuditStatusReject, want: LifecycleTriggerChangeReject},
+		{name: "上线审核撤回", auditType: AuditTypeOnline, result: AuditStatusUndone, want: LifecycleTriggerOnlineUndo},
+		{name: "下线审核通过", auditType: AuditTypeOffline, result: AuditStatusPass, want: LifecycleTriggerOfflinePass},
+		{name: "审核中", auditType: AuditTypeOffline, result: AuditStatusAuditing, want: ""},
+		{name: "调用申请", auditType: AuditTypeRequest, result: AuditStatusPass, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, AuditLifecycleTrigger(tt.auditType, tt.result))
+		})
+	}
+}
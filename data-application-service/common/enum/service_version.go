@@ -0,0 +1,5 @@
+package enum
+
+// ServiceVersionTypeSubService 子服务（行列规则）新建、修改、删除或回滚后记录的版本，不经过审核。
+// 与 AuditTypePublish、AuditTypeChange 一起作为版本的 audit_type
+const ServiceVersionTypeSubService = "sub-service"
@@ -13,15 +13,16 @@ const (
 const (
 	serviceApplyPreCoder = constant.ServiceName + "." + serviceApplyModelName + "."
 
-	ServiceApplyAuditingExist  = serviceApplyPreCoder + "ServiceApplyAuditingExist"
-	ServiceApplyAvailableExist = serviceApplyPreCoder + "ServiceApplyAvailableExist"
-	GetOwnerAuditorsNotAllowed = serviceApplyPreCoder + "GetOwnerAuditorsNotAllowed"
-	ServiceNoOwner             = serviceApplyPreCoder + "ServiceNoOwner"
-	ServiceApplyIdNotExist     = serviceApplyPreCoder + "ServiceApplyIdNotExist"
-	ServiceApplyNotExist       = serviceApplyPreCoder + "ServiceApplyNotExist"
-	ServiceApplyNotPass        = serviceApplyPreCoder + "ServiceApplyNotPass"
-	OrgCodeNotExist            = serviceApplyPreCoder + "OrgCodeNotExist"
-	SubjectDomainNotExist      = serviceApplyPreCoder + "SubjectDomainNotExist"
+	ServiceApplyAuditingExist   = serviceApplyPreCoder + "ServiceApplyAuditingExist"
+	ServiceApplyAvailableExist  = serviceApplyPreCoder + "ServiceApplyAvailableExist"
+	GetOwnerAuditorsNotAllowed  = serviceApplyPreCoder + "GetOwnerAuditorsNotAllowed"
+	ServiceNoOwner              = serviceApplyPreCoder + "ServiceNoOwner"
+	ServiceApplyIdNotExist      = serviceApplyPreCoder + "ServiceApplyIdNotExist"
+	ServiceApplyNotExist        = serviceApplyPreCoder + "ServiceApplyNotExist"
+	ServiceApplyNotPass         = serviceApplyPreCoder + "ServiceApplyNotPass"
+	OrgCodeNotExist             = serviceApplyPreCoder + "OrgCodeNotExist"
+	SubjectDomainNotExist       = serviceApplyPreCoder + "SubjectDomainNotExist"
+	ServiceApplyRenewNotAllowed = serviceApplyPreCoder + "ServiceApplyRenewNotAllowed"
 )
 
 var serviceApplyErrorMap = errorCode{
@@ -69,4 +70,9 @@ var serviceApplyErrorMap = errorCode{
 		description: "主题域id不存在",
 		solution:    "请重试",
 	},
+	ServiceApplyRenewNotAllowed: {
+		description: "只能续期本人已通过或已过期的限时申请",
+		cause:       "",
+		solution:    "请重新申请",
+	},
 }
@@ -16,6 +16,7 @@ const (
 
 	ServiceNameExist              = servicePreCoder + "ServiceNameExist"
 	ServicePathExist              = servicePreCoder + "ServicePathExist"
+	ServicePathConflict           = servicePreCoder + "ServicePathConflict"
 	ServiceIDNotExist             = servicePreCoder + "ServiceIDNotExist"
 	ServiceNameNotExist           = servicePreCoder + "ServiceNameNotExist"
 	ServiceStatusPublish          = servicePreCoder + "ServiceStatusPublish"
@@ -47,6 +48,18 @@ const (
 	InfoSystemIdNotExist = servicePreCoder + "InfoSystemIdNotExist"
 	// 应用ID不存在
 	AppsIdNotExist = servicePreCoder + "AppsIdNotExist"
+	// 接口版本不存在
+	ServiceVersionNotExist = servicePreCoder + "ServiceVersionNotExist"
+	// 接口生命周期状态迁移不合法
+	ServiceLifecycleTransitionError = servicePreCoder + "ServiceLifecycleTransitionError"
+	// 接口导出包格式错误
+	ServiceBundleInvalid = servicePreCoder + "ServiceBundleInvalid"
+	// 接口导出包版本不支持
+	ServiceBundleVersionNotSupported = servicePreCoder + "ServiceBundleVersionNotSupported"
+	// 接口未发布，不能生成接口文档
+	ServiceOpenAPIUnpublished = servicePreCoder + "ServiceOpenAPIUnpublished"
+	// 接口文档生成失败
+	ServiceOpenAPIGenerateError = servicePreCoder + "ServiceOpenAPIGenerateError"
 )
 
 var serviceErrorMap = errorCode{
@@ -60,6 +73,11 @@ var serviceErrorMap = errorCode{
 		cause:       "",
 		solution:    "请重新输入接口路径",
 	},
+	ServicePathConflict: {
+		description: "接口路径与已有的接口路径[service_path]可能匹配同一请求",
+		cause:       "",
+		solution:    "请修改接口路径中的固定部分",
+	},
 	ServiceIDNotExist: {
 		description: "接口ID不存在",
 		cause:       "",
@@ -199,4 +217,34 @@ var serviceErrorMap = errorCode{
 		cause:       "",
 		solution:    "请检查接口名称是否正确",
 	},
+	ServiceVersionNotExist: {
+		description: "接口版本不存在",
+		cause:       "",
+		solution:    "请检查版本号是否正确",
+	},
+	ServiceLifecycleTransitionError: {
+		description: "接口当前状态不符合要求，不能进行该操作",
+		cause:       "",
+		solution:    "请检查接口的发布状态和上线状态",
+	},
+	ServiceBundleInvalid: {
+		description: "接口导出包格式错误",
+		cause:       "",
+		solution:    "请上传由接口导出生成的 json 或 yaml 文件",
+	},
+	ServiceBundleVersionNotSupported: {
+		description: "接口导出包版本不支持",
+		cause:       "",
+		solution:    "请使用当前版本重新导出接口",
+	},
+	ServiceOpenAPIUnpublished: {
+		description: "接口未发布，不能生成接口文档",
+		cause:       "",
+		solution:    "请发布接口后再获取接口文档",
+	},
+	ServiceOpenAPIGenerateError: {
+		description: "接口文档生成失败",
+		cause:       "",
+		solution:    "请检查接口的路径和请求方式",
+	},
 }
@@ -3,6 +3,7 @@ package settings
 import (
 	"fmt"
 	"net"
+	"strings"
 
 	"github.com/IBM/sarama"
 	"github.com/kweaver-ai/idrm-go-frame/core/options"
@@ -29,6 +30,10 @@ type Settings struct {
 	Workflow Workflow
 	// 回调配置
 	Callback Callback `json:"callback,omitempty" yaml:"callback"`
+	// 加密配置
+	Crypto Crypto `json:"crypto,omitempty" yaml:"crypto"`
+	// 网关的认证方式
+	GatewayAuth GatewayAuth `json:"gateway_auth,omitempty" yaml:"gateway_auth"`
 }
 
 type Server struct {
@@ -132,3 +137,83 @@ type Callback struct {
 	// 回调接口前缀
 	PrePath string `json:"pre_path,omitempty" yaml:"pre_path"`
 }
+
+// Crypto 加密注册接口请求映射中的后台服务密钥，需要与网关的配置一致
+type Crypto struct {
+	// base64 编码的 32 字节 AES 密钥
+	Key string `json:"key,omitempty" yaml:"key"`
+}
+
+// GatewayAuth 网关数据查询接口的认证方式，用于生成接口文档的认证方案，需要与网关的 auth 配置一致
+type GatewayAuth struct {
+	// 默认认证方案启用的认证方式，oauth 令牌认证，sign HMAC 签名认证，为空时只启用令牌认证
+	Modes []string `json:"modes,omitempty" yaml:"modes"`
+	// 认证方案，key 为方案名称，value 为依次执行的认证方式，可覆盖内置的 default 和 cssjj 方案
+	Schemes map[string][]string `json:"schemes,omitempty" yaml:"schemes"`
+	// 接口单独使用的认证方案，key 为接口路径，value 为认证方案名称
+	Services map[string]string `json:"services,omitempty" yaml:"services"`
+}
+
+// 网关的认证方式
+const (
+	AuthModeOAuth     = "oauth"      // 令牌认证
+	AuthModeSign      = "sign"       // HMAC 签名认证
+	AuthModeCssjj     = "cssjj"      // 长沙 x-tif-* 签名认证
+	AuthModeEnforce   = "enforce"    // 向 auth-service 鉴权
+	AuthModeNoEnforce = "no-enforce" // 不向 auth-service 鉴权
+)
+
+// 网关内置的认证方案
+const (
+	AuthSchemeDefault = "default" // 默认的认证方案，modes 中的认证方式加上 auth-service 鉴权
+	AuthSchemeCssjj   = "cssjj"   // 长沙的认证方案，配置中心 cssjj 为 true 时使用
+)
+
+// AuthModes 返回网关调用接口时使用的认证方式，与网关选择认证方案的规则一致：接口单独配置的方案优先，其次为 scheme。
+// 方案不存在或配置无效时网关拒绝调用，返回空
+func (a GatewayAuth) AuthModes(scheme, servicePath string) []string {
+	if name, ok := a.Services[servicePath]; ok {
+		scheme = name
+	}
+	if modes, ok := a.Schemes[scheme]; ok {
+		return validAuthModes(modes)
+	}
+	switch scheme {
+	case AuthSchemeDefault:
+		var modes []string
+		for _, mode := range a.Modes {
+			if mode = strings.ToLower(mode); mode == AuthModeOAuth || mode == AuthModeSign {
+				modes = append(modes, mode)
+			}
+		}
+		if len(a.Modes) == 0 {
+			modes = []string{AuthModeOAuth}
+		}
+		return modes
+	case AuthSchemeCssjj:
+		return []string{AuthModeCssjj}
+	}
+	return nil
+}
+
+// validAuthModes 返回配置的认证方案中的认证方式，包含未知认证方式，或者 enforce 与 no-enforce 不是恰好有一个时方案无效
+func validAuthModes(modes []string) []string {
+	var res []string
+	var enforce, noEnforce bool
+	for _, mode := range modes {
+		switch mode = strings.ToLower(mode); mode {
+		case AuthModeOAuth, AuthModeSign, AuthModeCssjj:
+			res = append(res, mode)
+		case AuthModeEnforce:
+			enforce = true
+		case AuthModeNoEnforce:
+			noEnforce = true
+		default:
+			return nil
+		}
+	}
+	if enforce == noEnforce {
+		return nil
+	}
+	return res
+}
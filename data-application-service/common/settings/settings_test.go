@@ -0,0 +1,67 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGatewayAuth_AuthModes(t *testing.T) {
+	tests := []struct {
+		name        string
+		auth        GatewayAuth
+		scheme      string
+		servicePath string
+		want        []string
+	}{
+		{
+			name:   "未配置时默认方案只启用令牌认证",
+			scheme: AuthSchemeDefault,
+			want:   []string{AuthModeOAuth},
+		},
+		{
+			name:   "默认方案使用 modes 中的令牌认证和签名认证",
+			auth:   GatewayAuth{Modes: []string{"OAuth", "sign", "cssjj"}},
+			scheme: AuthSchemeDefault,
+			want:   []string{AuthModeOAuth, AuthModeSign},
+		},
+		{
+			name:   "长沙方案",
+			scheme: AuthSchemeCssjj,
+			want:   []string{AuthModeCssjj},
+		},
+		{
+			name:   "配置的方案覆盖内置方案",
+			auth:   GatewayAuth{Schemes: map[string][]string{AuthSchemeDefault: {AuthModeSign, AuthModeEnforce}}},
+			scheme: AuthSchemeDefault,
+			want:   []string{AuthModeSign},
+		},
+		{
+			name:        "接口单独配置的方案优先",
+			auth:        GatewayAuth{Schemes: map[string][]string{"partner": {AuthModeCssjj, AuthModeNoEnforce}}, Services: map[string]string{"/api/a": "partner"}},
+			scheme:      AuthSchemeDefault,
+			servicePath: "/api/a",
+			want:        []string{AuthModeCssjj},
+		},
+		{
+			name:        "没有 enforce 或 no-enforce 的方案无效",
+			auth:        GatewayAuth{Schemes: map[string][]string{"partner": {AuthModeSign}}, Services: map[string]string{"/api/a": "partner"}},
+			scheme:      AuthSchemeDefault,
+			servicePath: "/api/a",
+		},
+		{
+			name:   "包含未知认证方式的方案无效",
+			auth:   GatewayAuth{Schemes: map[string][]string{AuthSchemeDefault: {"basic", AuthModeEnforce}}},
+			scheme: AuthSchemeDefault,
+		},
+		{
+			name:   "方案不存在",
+			scheme: "partner",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.auth.AuthModes(tt.scheme, tt.servicePath))
+		})
+	}
+}
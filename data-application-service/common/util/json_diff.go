@@ -0,0 +1,83 @@
+package util
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// JSON 差异的类型
+const (
+	JSONChangeAdded    = "added"
+	JSONChangeRemoved  = "removed"
+	JSONChangeModified = "modified"
+)
+
+// JSONChange 两个 JSON 文档中一个叶子节点的差异，Path 如 service_param.data_table_request_params[0].en_name
+type JSONChange struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+	From any    `json:"from,omitempty"`
+	To   any    `json:"to,omitempty"`
+}
+
+// DiffJSON 比较两个 JSON 文档，返回按路径排序的叶子节点差异。空对象和空数组视为叶子节点
+func DiffJSON(from, to []byte) ([]JSONChange, error) {
+	var a, b any
+	if err := json.Unmarshal(from, &a); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(to, &b); err != nil {
+		return nil, err
+	}
+
+	fa, fb := make(map[string]any), make(map[string]any)
+	flattenJSON("", a, fa)
+	flattenJSON("", b, fb)
+
+	changes := make([]JSONChange, 0)
+	for path, va := range fa {
+		vb, ok := fb[path]
+		switch {
+		case !ok:
+			changes = append(changes, JSONChange{Path: path, Type: JSONChangeRemoved, From: va})
+		case !reflect.DeepEqual(va, vb):
+			changes = append(changes, JSONChange{Path: path, Type: JSONChangeModified, From: va, To: vb})
+		}
+	}
+	for path, vb := range fb {
+		if _, ok := fa[path]; !ok {
+			changes = append(changes, JSONChange{Path: path, Type: JSONChangeAdded, To: vb})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+func flattenJSON(prefix string, v any, out map[string]any) {
+	switch v := v.(type) {
+	case map[string]any:
+		if len(v) == 0 && prefix != "" {
+			out[prefix] = v
+			return
+		}
+		for k, child := range v {
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+			flattenJSON(path, child, out)
+		}
+	case []any:
+		if len(v) == 0 {
+			out[prefix] = v
+			return
+		}
+		for i, child := range v {
+			flattenJSON(prefix+"["+strconv.Itoa(i)+"]", child, out)
+		}
+	default:
+		out[prefix] = v
+	}
+}
@@ -0,0 +1,62 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		want    []JSONChange
+		wantErr bool
+	}{
+		{
+			name: "相同",
+			from: `{"a":1,"b":{"c":[1,2]}}`,
+			to:   `{"b":{"c":[1,2]},"a":1}`,
+			want: []JSONChange{},
+		},
+		{
+			name: "修改、新增和删除，按路径排序",
+			from: `{"name":"a","param":{"script":"select 1","rules":[{"param":"id"}]}}`,
+			to:   `{"name":"b","param":{"rules":[{"param":"id"},{"param":"age"}]},"page":"yes"}`,
+			want: []JSONChange{
+				{Path: "name", Type: JSONChangeModified, From: "a", To: "b"},
+				{Path: "page", Type: JSONChangeAdded, To: "yes"},
+				{Path: "param.rules[1].param", Type: JSONChangeAdded, To: "age"},
+				{Path: "param.script", Type: JSONChangeRemoved, From: "select 1"},
+			},
+		},
+		{
+			name: "空数组变为非空",
+			from: `{"rules":[]}`,
+			to:   `{"rules":[1]}`,
+			want: []JSONChange{
+				{Path: "rules", Type: JSONChangeRemoved, From: []any{}},
+				{Path: "rules[0]", Type: JSONChangeAdded, To: float64(1)},
+			},
+		},
+		{
+			name:    "不是 JSON",
+			from:    `{`,
+			to:      `{}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DiffJSON([]byte(tt.from), []byte(tt.to))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/settings"
+)
+
+// 接口生成的分页参数的取值范围，与网关的校验一致
+const (
+	openAPIOffsetMinimum int64 = 1
+	openAPILimitMinimum  int64 = 1
+	openAPILimitMaximum  int64 = 1000
+)
+
+// NewOpenAPI 返回不包含接口的 OpenAPI 文档，modes 为网关对文档中的接口启用的认证方式，满足其中一种即可
+func NewOpenAPI(info dto.OpenAPIInfo, server dto.OpenAPIServer, modes []string) *dto.OpenAPI {
+	doc := &dto.OpenAPI{
+		OpenAPI: dto.OpenAPIVersion,
+		Info:    info,
+		Servers: []dto.OpenAPIServer{server},
+		Paths:   make(map[string]*dto.OpenAPIPathItem),
+	}
+	doc.Security = addOpenAPISecurity(doc, modes)
+	return doc
+}
+
+// addOpenAPISecurity 把认证方式对应的认证方案加入文档，返回满足其中一种即可的认证要求。
+// 长沙 x-tif-* 签名认证需要同时提供签名、时间戳和随机数
+func addOpenAPISecurity(doc *dto.OpenAPI, modes []string) []map[string][]string {
+	if doc.Components.SecuritySchemes == nil {
+		doc.Components.SecuritySchemes = make(map[string]*dto.OpenAPISecurityScheme)
+	}
+	schemes := doc.Components.SecuritySchemes
+	var security []map[string][]string
+	for _, mode := range modes {
+		switch mode {
+		case settings.AuthModeOAuth:
+			schemes[dto.OpenAPISecurityOAuth] = &dto.OpenAPISecurityScheme{Type: "http", Scheme: "bearer", Description: "令牌认证，使用应用获取的访问令牌"}
+			security = append(security, map[string][]string{dto.OpenAPISecurityOAuth: {}})
+		case settings.AuthModeSign:
+			schemes[dto.OpenAPISecuritySign] = &dto.OpenAPISecurityScheme{Type: "apiKey", In: "header", Name: "Authorization", Description: "HMAC 签名认证，格式为 " +
+				"ANYFABRIC-HMAC-SHA256 appid={应用ID},timestamp={时间戳},nonce={随机数},signature={签名}，" +
+				"签名为使用应用密钥对请求方法、时间戳、随机数、路径、按名称排序的查询参数和请求体计算的 HMAC-SHA256"}
+			security = append(security, map[string][]string{dto.OpenAPISecuritySign: {}})
+		case settings.AuthModeCssjj:
+			schemes[dto.OpenAPISecurityCssjjSignature] = &dto.OpenAPISecurityScheme{Type: "apiKey", In: "header", Name: "x-tif-signature", Description: "签名，使用应用的密钥对时间戳、随机数计算"}
+			schemes[dto.OpenAPISecurityCssjjTimestamp] = &dto.OpenAPISecurityScheme{Type: "apiKey", In: "header", Name: "x-tif-timestamp", Description: "时间戳，单位秒"}
+			schemes[dto.OpenAPISecurityCssjjNonce] = &dto.OpenAPISecurityScheme{Type: "apiKey", In: "header", Name: "x-tif-nonce", Description: "随机数"}
+			security = append(security, map[string][]string{
+				dto.OpenAPISecurityCssjjSignature: {},
+				dto.OpenAPISecurityCssjjTimestamp: {},
+				dto.OpenAPISecurityCssjjNonce:     {},
+			})
+		}
+	}
+	return security
+}
+
+// AddServiceToOpenAPI 把接口加入 OpenAPI 文档。路径变量作为 path 参数，
+// get、delete 接口的其他请求参数作为 query 参数，post、put 接口的其他请求参数作为 JSON 请求体。
+// modes 为网关对该接口启用的认证方式，与文档的认证方式不同时单独声明
+func AddServiceToOpenAPI(doc *dto.OpenAPI, service *dto.ServiceGetRes, modes []string) error {
+	info := &service.ServiceInfo
+
+	var pathVars []string
+	if IsPathTemplate(info.ServicePath) {
+		t, err := ParsePathTemplate(info.ServicePath)
+		if err != nil {
+			return err
+		}
+		pathVars = t.Vars()
+	}
+
+	op := &dto.OpenAPIOperation{
+		Summary:     info.ServiceName,
+		Description: info.Description,
+		OperationID: info.ServiceID,
+		Responses:   map[string]*dto.OpenAPIResponse{"200": openAPIResponse(service)},
+	}
+	if info.Department.Name != "" {
+		op.Tags = []string{info.Department.Name}
+	}
+	if security := addOpenAPISecurity(doc, modes); !reflect.DeepEqual(security, doc.Security) {
+		// 网关拒绝调用的接口声明空的认证要求
+		security = append([]map[string][]string{}, security...)
+		op.Security = &security
+	}
+
+	params := service.ServiceParam.DataTableRequestParams
+	// 路径变量的类型、描述取同名的请求参数，没有配置时为字符串
+	for _, name := range pathVars {
+		p := &dto.OpenAPIParameter{Name: name, In: "path", Required: true, Schema: &dto.OpenAPISchema{Type: "string"}}
+		for i := range params {
+			if params[i].EnName == name {
+				p.Description, p.Schema = params[i].Description, openAPIRequestParamSchema(&params[i])
+				break
+			}
+		}
+		op.Parameters = append(op.Parameters, p)
+	}
+
+	body := &dto.OpenAPISchema{Type: "object", Properties: make(map[string]*dto.OpenAPISchema)}
+	inBody := info.HTTPMethod == "post" || info.HTTPMethod == "put"
+	for i := range params {
+		p := &params[i]
+		if p.EnName == "" || slices.Contains(pathVars, p.EnName) {
+			continue
+		}
+		if inBody {
+			body.Properties[p.EnName] = openAPIRequestParamSchema(p)
+			if p.Required == "yes" {
+				body.Required = append(body.Required, p.EnName)
+			}
+			continue
+		}
+		op.Parameters = append(op.Parameters, &dto.OpenAPIParameter{
+			Name:        p.EnName,
+			In:          "query",
+			Description: p.Description,
+			Required:    p.Required == "yes",
+			Schema:      openAPIRequestParamSchema(p),
+		})
+	}
+
+	// 接口生成的分页参数，未传时网关使用 offset 1 和返回结果配置的分页大小
+	if info.ServiceType == "service_generate" {
+		limit := openAPILimitMaximum
+		if service.ServiceResponse != nil && service.ServiceResponse.PageSize > 0 {
+			limit = service.ServiceResponse.PageSize
+		}
+		offset := &dto.OpenAPISchema{Type: "integer", Format: "int32", Description: "页码", Default: openAPIOffsetMinimum, Minimum: ValueToPtr(openAPIOffsetMinimum)}
+		size := &dto.OpenAPISchema{Type: "integer", Format: "int32", Description: "每页大小", Default: limit, Minimum: ValueToPtr(openAPILimitMinimum), Maximum: ValueToPtr(openAPILimitMaximum)}
+		if inBody {
+			body.Properties["offset"], body.Properties["limit"] = offset, size
+		} else {
+			op.Parameters = append(op.Parameters,
+				&dto.OpenAPIParameter{Name: "offset", In: "query", Description: offset.Description, Schema: offset},
+				&dto.OpenAPIParameter{Name: "limit", In: "query", Description: size.Description, Schema: size})
+		}
+	}
+
+	if inBody {
+		op.RequestBody = &dto.OpenAPIRequestBody{
+			Required: len(body.Required) > 0,
+			Content: map[string]*dto.OpenAPIMediaType{
+				"application/json": {Schema: body, Example: openAPIExample(service.ServiceTest.RequestExample)},
+			},
+		}
+	}
+
+	item, ok := doc.Paths[info.ServicePath]
+	if !ok {
+		item = &dto.OpenAPIPathItem{}
+		doc.Paths[info.ServicePath] = item
+	}
+	switch strings.ToUpper(info.HTTPMethod) {
+	case http.MethodGet:
+		item.Get = op
+	case http.MethodPost:
+		item.Post = op
+	case http.MethodPut:
+		item.Put = op
+	case http.MethodDelete:
+		item.Delete = op
+	default:
+		return fmt.Errorf("unsupported http method %q of service %q", info.HTTPMethod, info.ServicePath)
+	}
+	return nil
+}
+
+// openAPIResponse 返回接口的成功响应。接口生成返回 total_count 和 data，接口注册按返回参数描述后台服务的响应
+func openAPIResponse(service *dto.ServiceGetRes) *dto.OpenAPIResponse {
+	res := &dto.OpenAPIResponse{Description: "成功"}
+	switch service.ServiceInfo.ReturnType {
+	case "", "json":
+	case "csv":
+		res.Content = map[string]*dto.OpenAPIMediaType{"text/csv": {}}
+		return res
+	case "xml":
+		res.Content = map[string]*dto.OpenAPIMediaType{"application/xml": {}}
+		return res
+	default:
+		res.Content = map[string]*dto.OpenAPIMediaType{"application/octet-stream": {}}
+		return res
+	}
+
+	record := &dto.OpenAPISchema{Type: "object", Properties: make(map[string]*dto.OpenAPISchema)}
+	for _, p := range service.ServiceParam.DataTableResponseParams {
+		if p.EnName == "" {
+			continue
+		}
+		s := openAPIDataTypeSchema(p.DataType)
+		s.Title, s.Description = p.CNName, p.Description
+		record.Properties[p.EnName] = s
+	}
+
+	schema := record
+	if service.ServiceInfo.ServiceType == "service_generate" {
+		schema = &dto.OpenAPISchema{
+			Type: "object",
+			Properties: map[string]*dto.OpenAPISchema{
+				"total_count": {Type: "integer", Format: "int64", Description: "总数"},
+				"data":        {Type: "array", Description: "数据", Items: record},
+			},
+		}
+	}
+	res.Content = map[string]*dto.OpenAPIMediaType{
+		"application/json": {Schema: schema, Example: openAPIExample(service.ServiceTest.ResponseExample)},
+	}
+	return res
+}
+
+func openAPIRequestParamSchema(p *dto.DataTableRequestParam) *dto.OpenAPISchema {
+	s := openAPIDataTypeSchema(p.DataType)
+	s.Title, s.Description = p.CNName, p.Description
+	if p.DefaultValue != "" {
+		s.Default = openAPIDefault(p.DataType, p.DefaultValue)
+	}
+	return s
+}
+
+// openAPIDataTypeSchema 返回参数的字段类型对应的 schema
+func openAPIDataTypeSchema(dataType string) *dto.OpenAPISchema {
+	switch dataType {
+	case "int":
+		return &dto.OpenAPISchema{Type: "integer", Format: "int32"}
+	case "long":
+		return &dto.OpenAPISchema{Type: "integer", Format: "int64"}
+	case "float":
+		return &dto.OpenAPISchema{Type: "number", Format: "float"}
+	case "double":
+		return &dto.OpenAPISchema{Type: "number", Format: "double"}
+	case "boolean":
+		return &dto.OpenAPISchema{Type: "boolean"}
+	default:
+		return &dto.OpenAPISchema{Type: "string"}
+	}
+}
+
+// openAPIDefault 把默认值转换为字段类型对应的值，无法转换时返回 nil，不输出默认值
+func openAPIDefault(dataType, value string) any {
+	switch dataType {
+	case "int", "long":
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return v
+		}
+	case "float", "double":
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+	case "boolean":
+		if v, err := strconv.ParseBool(value); err == nil {
+			return v
+		}
+	default:
+		return value
+	}
+	return nil
+}
+
+// openAPIExample 返回接口测试中保存的示例，不是合法的 JSON 时不输出示例
+func openAPIExample(example string) json.RawMessage {
+	if !json.Valid([]byte(example)) {
+		return nil
+	}
+	return json.RawMessage(example)
+}
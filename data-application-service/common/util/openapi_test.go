@@ -0,0 +1,210 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/settings"
+)
+
+func TestNewOpenAPI(t *testing.T) {
+	tests := []struct {
+		name         string
+		modes        []string
+		wantSchemes  []string
+		wantSecurity []map[string][]string
+	}{
+		{
+			name:         "令牌认证或签名认证",
+			modes:        []string{settings.AuthModeOAuth, settings.AuthModeSign},
+			wantSchemes:  []string{dto.OpenAPISecurityOAuth, dto.OpenAPISecuritySign},
+			wantSecurity: []map[string][]string{{dto.OpenAPISecurityOAuth: {}}, {dto.OpenAPISecuritySign: {}}},
+		},
+		{
+			name:         "只启用令牌认证",
+			modes:        []string{settings.AuthModeOAuth},
+			wantSchemes:  []string{dto.OpenAPISecurityOAuth},
+			wantSecurity: []map[string][]string{{dto.OpenAPISecurityOAuth: {}}},
+		},
+		{
+			name:        "长沙 x-tif-* 签名认证",
+			modes:       []string{settings.AuthModeCssjj},
+			wantSchemes: []string{dto.OpenAPISecurityCssjjSignature, dto.OpenAPISecurityCssjjTimestamp, dto.OpenAPISecurityCssjjNonce},
+			wantSecurity: []map[string][]string{{
+				dto.OpenAPISecurityCssjjSignature: {},
+				dto.OpenAPISecurityCssjjTimestamp: {},
+				dto.OpenAPISecurityCssjjNonce:     {},
+			}},
+		},
+		{
+			name: "网关不接受调用",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := NewOpenAPI(dto.OpenAPIInfo{Title: "接口", Version: "1"}, dto.OpenAPIServer{URL: "https://example.com/data-application-gateway"}, tt.modes)
+			assert.Equal(t, dto.OpenAPIVersion, doc.OpenAPI)
+			assert.Len(t, doc.Components.SecuritySchemes, len(tt.wantSchemes))
+			for _, name := range tt.wantSchemes {
+				assert.Contains(t, doc.Components.SecuritySchemes, name)
+			}
+			assert.Equal(t, tt.wantSecurity, doc.Security)
+		})
+	}
+}
+
+// defaultModes 网关默认认证方案启用的认证方式
+var defaultModes = []string{settings.AuthModeOAuth, settings.AuthModeSign}
+
+func TestAddServiceToOpenAPI(t *testing.T) {
+	tests := []struct {
+		name    string
+		service *dto.ServiceGetRes
+		wantErr bool
+		check   func(t *testing.T, doc *dto.OpenAPI)
+	}{
+		{
+			name: "接口生成 get 请求参数为 query 参数，返回 total_count 和 data",
+			service: &dto.ServiceGetRes{
+				ServiceInfo: dto.ServiceInfo{ServiceID: "s1", ServiceName: "用户", ServiceType: "service_generate", ServicePath: "/api/users", HTTPMethod: "get"},
+				ServiceParam: dto.ServiceParamRead{
+					DataTableRequestParams: []dto.DataTableRequestParam{
+						{EnName: "age", CNName: "年龄", DataType: "int", Required: "yes", DefaultValue: "18"},
+						{EnName: "name", DataType: "string", Required: "no"},
+					},
+					DataTableResponseParams: []dto.DataTableResponseParam{{EnName: "age", DataType: "long"}},
+				},
+				ServiceResponse: &dto.ServiceResponse{Page: "yes", PageSize: 20},
+				ServiceTest:     dto.ServiceTest{ResponseExample: `{"total_count":1,"data":[{"age":18}]}`},
+			},
+			check: func(t *testing.T, doc *dto.OpenAPI) {
+				op := doc.Paths["/api/users"].Get
+				require.NotNil(t, op)
+				assert.Nil(t, op.RequestBody)
+				require.Len(t, op.Parameters, 4)
+				assert.Equal(t, &dto.OpenAPIParameter{Name: "age", In: "query", Required: true,
+					Schema: &dto.OpenAPISchema{Type: "integer", Format: "int32", Title: "年龄", Default: int64(18)}}, op.Parameters[0])
+				assert.Equal(t, "name", op.Parameters[1].Name)
+				assert.False(t, op.Parameters[1].Required)
+				assert.Equal(t, "offset", op.Parameters[2].Name)
+				assert.Equal(t, "limit", op.Parameters[3].Name)
+				assert.Equal(t, int64(20), op.Parameters[3].Schema.Default)
+
+				media := op.Responses["200"].Content["application/json"]
+				require.NotNil(t, media)
+				assert.JSONEq(t, `{"total_count":1,"data":[{"age":18}]}`, string(media.Example))
+				assert.Equal(t, "array", media.Schema.Properties["data"].Type)
+				assert.Equal(t, "int64", media.Schema.Properties["data"].Items.Properties["age"].Format)
+			},
+		},
+		{
+			name: "接口注册 post 路径变量为 path 参数，其他参数为请求体",
+			service: &dto.ServiceGetRes{
+				ServiceInfo: dto.ServiceInfo{ServiceID: "s2", ServiceType: "service_register", ServicePath: "/api/orders/{id}", HTTPMethod: "post"},
+				ServiceParam: dto.ServiceParamRead{
+					DataTableRequestParams: []dto.DataTableRequestParam{
+						{EnName: "id", DataType: "long", Required: "yes"},
+						{EnName: "paid", DataType: "boolean", Required: "yes", DefaultValue: "abc"},
+						{EnName: "amount", DataType: "double", Required: "no"},
+					},
+					DataTableResponseParams: []dto.DataTableResponseParam{{EnName: "status", DataType: "string"}},
+				},
+				ServiceTest: dto.ServiceTest{RequestExample: `{"paid":true}`, ResponseExample: "not json"},
+			},
+			check: func(t *testing.T, doc *dto.OpenAPI) {
+				op := doc.Paths["/api/orders/{id}"].Post
+				require.NotNil(t, op)
+				require.Len(t, op.Parameters, 1)
+				assert.Equal(t, &dto.OpenAPIParameter{Name: "id", In: "path", Required: true,
+					Schema: &dto.OpenAPISchema{Type: "integer", Format: "int64"}}, op.Parameters[0])
+
+				require.NotNil(t, op.RequestBody)
+				assert.True(t, op.RequestBody.Required)
+				body := op.RequestBody.Content["application/json"]
+				assert.Equal(t, []string{"paid"}, body.Schema.Required)
+				assert.Len(t, body.Schema.Properties, 2)
+				assert.Nil(t, body.Schema.Properties["paid"].Default, "无法转换的默认值不输出")
+				assert.JSONEq(t, `{"paid":true}`, string(body.Example))
+
+				media := op.Responses["200"].Content["application/json"]
+				assert.Nil(t, media.Example, "不是 JSON 的示例不输出")
+				assert.Equal(t, "string", media.Schema.Properties["status"].Type)
+			},
+		},
+		{
+			name: "非 JSON 返回类型",
+			service: &dto.ServiceGetRes{
+				ServiceInfo: dto.ServiceInfo{ServiceType: "service_generate", ServicePath: "/api/export", HTTPMethod: "get", ReturnType: "csv"},
+			},
+			check: func(t *testing.T, doc *dto.OpenAPI) {
+				content := doc.Paths["/api/export"].Get.Responses["200"].Content
+				assert.Contains(t, content, "text/csv")
+				assert.Nil(t, content["text/csv"].Schema)
+			},
+		},
+		{
+			name: "不支持的请求方式",
+			service: &dto.ServiceGetRes{
+				ServiceInfo: dto.ServiceInfo{ServicePath: "/api/a", HTTPMethod: "patch"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "路径变量不合法",
+			service: &dto.ServiceGetRes{
+				ServiceInfo: dto.ServiceInfo{ServicePath: "/api/a{id}", HTTPMethod: "get"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := NewOpenAPI(dto.OpenAPIInfo{Title: "接口", Version: "1"}, dto.OpenAPIServer{URL: "https://example.com"}, defaultModes)
+			err := AddServiceToOpenAPI(doc, tt.service, defaultModes)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, doc)
+
+			_, err = json.Marshal(doc)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAddServiceToOpenAPISamePath(t *testing.T) {
+	doc := NewOpenAPI(dto.OpenAPIInfo{Title: "接口", Version: "1"}, dto.OpenAPIServer{URL: "https://example.com"}, defaultModes)
+	require.NoError(t, AddServiceToOpenAPI(doc, &dto.ServiceGetRes{ServiceInfo: dto.ServiceInfo{ServicePath: "/api/a", HTTPMethod: "get"}}, defaultModes))
+	require.NoError(t, AddServiceToOpenAPI(doc, &dto.ServiceGetRes{ServiceInfo: dto.ServiceInfo{ServicePath: "/api/a", HTTPMethod: "delete"}}, defaultModes))
+	require.Len(t, doc.Paths, 1)
+	assert.NotNil(t, doc.Paths["/api/a"].Get)
+	assert.NotNil(t, doc.Paths["/api/a"].Delete)
+}
+
+func TestAddServiceToOpenAPISecurity(t *testing.T) {
+	doc := NewOpenAPI(dto.OpenAPIInfo{Title: "接口", Version: "1"}, dto.OpenAPIServer{URL: "https://example.com"}, defaultModes)
+	require.NoError(t, AddServiceToOpenAPI(doc, &dto.ServiceGetRes{ServiceInfo: dto.ServiceInfo{ServicePath: "/api/a", HTTPMethod: "get"}}, defaultModes))
+	require.NoError(t, AddServiceToOpenAPI(doc, &dto.ServiceGetRes{ServiceInfo: dto.ServiceInfo{ServicePath: "/api/b", HTTPMethod: "get"}}, []string{settings.AuthModeCssjj}))
+	require.NoError(t, AddServiceToOpenAPI(doc, &dto.ServiceGetRes{ServiceInfo: dto.ServiceInfo{ServicePath: "/api/c", HTTPMethod: "get"}}, nil))
+
+	// 与文档相同的认证方式不单独声明
+	assert.Nil(t, doc.Paths["/api/a"].Get.Security)
+	require.NotNil(t, doc.Paths["/api/b"].Get.Security)
+	assert.Equal(t, []map[string][]string{{
+		dto.OpenAPISecurityCssjjSignature: {},
+		dto.OpenAPISecurityCssjjTimestamp: {},
+		dto.OpenAPISecurityCssjjNonce:     {},
+	}}, *doc.Paths["/api/b"].Get.Security)
+	assert.Contains(t, doc.Components.SecuritySchemes, dto.OpenAPISecurityCssjjSignature)
+
+	// 网关不接受调用的接口声明空的认证要求
+	content, err := json.Marshal(doc.Paths["/api/c"].Get)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"security":[]`)
+}
@@ -0,0 +1,72 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 路径模板在接口服务和网关各有一份：接口服务在保存接口时校验模板和冲突，网关在请求时匹配和展开，
+// 两个服务是独立的 go module，没有共享的代码库。解析规则修改时需要同时修改两份
+
+// pathVarRegexp 路径变量名，变量必须占一整段路径，如 /orders/{id}。与网关的匹配规则保持一致
+var pathVarRegexp = regexp.MustCompile(`^\{([A-Za-z_][A-Za-z0-9_-]*)\}$`)
+
+// PathTemplate 带路径变量的接口路径，如 /orders/{id}/items/{item_id}
+type PathTemplate struct {
+	segments []string
+	// 与 segments 一一对应，不是变量的段为空
+	vars []string
+}
+
+// IsPathTemplate 路径中是否包含路径变量
+func IsPathTemplate(path string) bool {
+	return strings.ContainsAny(path, "{}")
+}
+
+// ParsePathTemplate 解析路径模板，变量不是一整段路径、变量名不合法或重复时返回错误
+func ParsePathTemplate(path string) (*PathTemplate, error) {
+	t := &PathTemplate{segments: strings.Split(path, "/")}
+	t.vars = make([]string, len(t.segments))
+	seen := make(map[string]bool)
+	for i, seg := range t.segments {
+		if !strings.ContainsAny(seg, "{}") {
+			continue
+		}
+		m := pathVarRegexp.FindStringSubmatch(seg)
+		if m == nil {
+			return nil, fmt.Errorf("路径变量 %s 不合法，变量需要占一整段路径，如 /orders/{id}", seg)
+		}
+		if seen[m[1]] {
+			return nil, fmt.Errorf("路径变量 %s 重复", m[1])
+		}
+		seen[m[1]] = true
+		t.vars[i] = m[1]
+	}
+	return t, nil
+}
+
+// Vars 返回路径变量名，按出现的顺序
+func (t *PathTemplate) Vars() []string {
+	var vars []string
+	for _, v := range t.vars {
+		if v != "" {
+			vars = append(vars, v)
+		}
+	}
+	return vars
+}
+
+// Conflicts 两个带路径变量的接口路径是否可能匹配同一个请求路径，如 /orders/{id} 和 /orders/{order_id}、
+// /{a}/items 和 /orders/{b}。网关优先匹配路径完全相同的接口，所以不带变量的路径不会与模板冲突
+func (t *PathTemplate) Conflicts(o *PathTemplate) bool {
+	if len(t.segments) != len(o.segments) {
+		return false
+	}
+	for i := range t.segments {
+		if t.vars[i] == "" && o.vars[i] == "" && t.segments[i] != o.segments[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePathTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		vars    []string
+		wantErr bool
+	}{
+		{name: "没有变量", path: "/orders"},
+		{name: "多个变量", path: "/orders/{order_id}/items/{item-id}", vars: []string{"order_id", "item-id"}},
+		{name: "变量不是一整段", path: "/orders/id-{id}", wantErr: true},
+		{name: "括号不匹配", path: "/orders/{id", wantErr: true},
+		{name: "变量名重复", path: "/{id}/{id}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePathTemplate(tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.vars, got.Vars())
+		})
+	}
+}
+
+func TestPathTemplate_Conflicts(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "/orders/{id}", b: "/orders/{order_id}", want: true},
+		{a: "/{a}/items", b: "/orders/{b}", want: true},
+		{a: "/orders/{id}", b: "/users/{id}"},
+		{a: "/orders/{id}", b: "/orders/{id}/items"},
+		{a: "/orders/{id}/items", b: "/orders/{id}/logs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			a, err := ParsePathTemplate(tt.a)
+			require.NoError(t, err)
+			b, err := ParsePathTemplate(tt.b)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, a.Conflicts(b))
+			assert.Equal(t, tt.want, b.Conflicts(a))
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package service_bundle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/kweaver-ai/idrm-go-common/util/sets"
+	"github.com/samber/lo"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/util"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/infrastructure/repository/db/model"
+)
+
+// MaxRename 重命名冲突的接口名称、路径时最多尝试的次数
+const MaxRename = 100
+
+// NewService 生成导出包中的接口，去掉状态和环境相关的ID，密钥请求头的值不导出
+func NewService(detail *dto.ServiceGetRes, subServices []model.SubService) *dto.ServiceBundleService {
+	info := detail.ServiceInfo
+	info.ClearRuntimeFields()
+	info.ServiceID, info.ServiceCode = "", ""
+	info.CreatedBy, info.UpdateBy = "", ""
+	info.Department.ID = ""
+	// 主题域、开发商以可解析的键导出
+	info.SubjectDomainId, info.SubjectDomainName = "", ""
+	info.Developer = dto.Developer{}
+	// 信息系统、应用、数据owner 和类目在其他环境中无法按名称解析，只导出名称，导入时需要重新选择
+	info.InfoSystemId, info.AppsId = "", ""
+	info.Owners = make([]dto.Owners, len(detail.ServiceInfo.Owners))
+	for i, o := range detail.ServiceInfo.Owners {
+		info.Owners[i] = dto.Owners{OwnerName: o.OwnerName}
+	}
+	info.OwnerId, info.OwnerName = "", ""
+	// 附件只存在于当前环境
+	info.File = dto.File{}
+	// 类目信息单独导出
+	info.CategoryInfo = nil
+	categories := make([]dto.CategoryInfo, len(detail.CategoryInfo))
+	for i, c := range detail.CategoryInfo {
+		categories[i] = dto.CategoryInfo{CategoryName: c.CategoryName, CategoryNodeName: c.CategoryNodeName}
+	}
+	// 密钥以当前环境的密钥加密，在其他环境中无法解密
+	info.RequestMapping = clearSecretHeaders(info.RequestMapping)
+
+	param := dto.ServiceParamWrite(detail.ServiceParam)
+	param.DatasourceId, param.DataViewId = "", ""
+
+	return &dto.ServiceBundleService{
+		ServiceInfo:     info,
+		CategoryInfo:    categories,
+		ServiceParam:    param,
+		ServiceResponse: detail.ServiceResponse,
+		ServiceTest:     detail.ServiceTest,
+		SubServices:     NewSubServices(detail.ServiceInfo.ServiceID, subServices),
+	}
+}
+
+// clearSecretHeaders 返回去掉密钥请求头的值的请求映射，不修改 m
+func clearSecretHeaders(m *dto.RequestMapping) *dto.RequestMapping {
+	if m == nil {
+		return nil
+	}
+	mapping := *m
+	mapping.Headers.Inject = make([]dto.StaticHeader, len(m.Headers.Inject))
+	for i, h := range m.Headers.Inject {
+		if h.Secret {
+			h.Value = ""
+		}
+		mapping.Headers.Inject[i] = h
+	}
+	return &mapping
+}
+
+// DropUnsetSecretHeaders 去掉导出包中没有值的密钥请求头，返回需要重新填写的请求头名称。
+// 其他环境加密的值在当前环境中无法解密，同样需要重新填写
+func DropUnsetSecretHeaders(m *dto.RequestMapping) []string {
+	if m == nil {
+		return nil
+	}
+	var names []string
+	inject := make([]dto.StaticHeader, 0, len(m.Headers.Inject))
+	for _, h := range m.Headers.Inject {
+		if h.Secret && (h.Value == "" || util.IsEncryptedSecret(h.Value)) {
+			names = append(names, h.Name)
+			continue
+		}
+		inject = append(inject, h)
+	}
+	m.Headers.Inject = inject
+	return names
+}
+
+// DropUnresolvedReferences 去掉导出包中无法在当前环境解析的信息系统、应用、数据owner 和类目，
+// 返回需要重新选择的引用。早期导出包中的ID来自其他环境，同样去掉
+func DropUnresolvedReferences(item *dto.ServiceBundleService) []string {
+	var refs []string
+	info := &item.ServiceInfo
+	if info.InfoSystemId != "" || info.InfoSystemName != "" {
+		refs = append(refs, "信息系统："+lo.CoalesceOrEmpty(info.InfoSystemName, info.InfoSystemId))
+	}
+	info.InfoSystemId, info.InfoSystemName = "", ""
+	if info.AppsId != "" || info.AppsName != "" {
+		refs = append(refs, "应用："+lo.CoalesceOrEmpty(info.AppsName, info.AppsId))
+	}
+	info.AppsId, info.AppsName = "", ""
+	for _, o := range info.Owners {
+		refs = append(refs, "数据owner："+lo.CoalesceOrEmpty(o.OwnerName, o.OwnerId))
+	}
+	info.Owners, info.OwnerId, info.OwnerName = nil, "", ""
+	for _, c := range append(item.CategoryInfo, info.CategoryInfo...) {
+		name := lo.CoalesceOrEmpty(c.CategoryName, c.CategoryId)
+		if node := lo.CoalesceOrEmpty(c.CategoryNodeName, c.CategoryNodeID); node != "" {
+			name += "/" + node
+		}
+		refs = append(refs, "类目："+name)
+	}
+	item.CategoryInfo, info.CategoryInfo = nil, nil
+	return refs
+}
+
+// NewSubServices 子接口的授权范围以名称表示，并排序使授权范围在前，导入时可以按顺序创建。
+// 行过滤器子句不导出，导入时根据行列规则重新生成
+func NewSubServices(serviceID string, subServices []model.SubService) []dto.ServiceBundleSubService {
+	names := make(map[uuid.UUID]string, len(subServices))
+	for _, s := range subServices {
+		names[s.ID] = s.Name
+	}
+
+	res := make([]dto.ServiceBundleSubService, 0, len(subServices))
+	added := sets.New[string]()
+	for len(res) < len(subServices) {
+		progressed := false
+		for _, s := range subServices {
+			if added.Has(s.Name) {
+				continue
+			}
+			scope, ok := names[s.AuthScopeID]
+			// 授权范围是接口本身，或者授权范围的子接口已不存在
+			if s.AuthScopeID.String() == serviceID || !ok {
+				scope = ""
+			}
+			if scope != "" && !added.Has(scope) {
+				continue
+			}
+			res = append(res, dto.ServiceBundleSubService{
+				Name:      s.Name,
+				AuthScope: scope,
+				Detail:    s.Detail,
+			})
+			added.Insert(s.Name)
+			progressed = true
+		}
+		// 授权范围循环引用的数据不导出
+		if !progressed {
+			break
+		}
+	}
+	return res
+}
+
+// Parse 根据扩展名解析 json 或 yaml 格式的导出包
+func Parse(ext string, content []byte) (*dto.ServiceBundle, error) {
+	var err error
+	switch strings.ToLower(ext) {
+	case ".json":
+	case ".yaml", ".yml":
+		if content, err = util.YAMLToJSON(content); err != nil {
+			return nil, errorcode.Detail(errorcode.ServiceBundleInvalid, err)
+		}
+	default:
+		return nil, errorcode.Desc(errorcode.ServiceBundleInvalid)
+	}
+
+	bundle := &dto.ServiceBundle{}
+	if err = json.Unmarshal(content, bundle); err != nil {
+		return nil, errorcode.Detail(errorcode.ServiceBundleInvalid, err)
+	}
+	if bundle.BundleVersion != dto.ServiceBundleVersion {
+		return nil, errorcode.Detail(errorcode.ServiceBundleVersionNotSupported, bundle.BundleVersion)
+	}
+	return bundle, nil
+}
+
+// CheckSubServices 子接口名称不能重复，授权范围必须是接口本身或前面的子接口
+func CheckSubServices(subServices []dto.ServiceBundleSubService) error {
+	names := sets.New[string]()
+	for _, s := range subServices {
+		if names.Has(s.Name) {
+			return fmt.Errorf("子接口%s重复", s.Name)
+		}
+		if s.AuthScope != "" && !names.Has(s.AuthScope) {
+			return fmt.Errorf("子接口%s的授权范围%s不存在", s.Name, s.AuthScope)
+		}
+		names.Insert(s.Name)
+	}
+	return nil
+}
+
+// ConflictFunc 返回 value 与已有接口冲突的原因，不冲突时返回空
+type ConflictFunc func(ctx context.Context, value string) (string, error)
+
+// ResolveConflict 按 strategy 处理接口名称、路径的冲突。重命名时修改 info 并在 entry 中记录新的名称、路径；
+// 跳过时设置 entry 的结果并返回 true；strategy 为 fail 时返回冲突的原因
+func ResolveConflict(ctx context.Context, strategy string, info *dto.ServiceInfo, entry *dto.ServiceBundleImportEntry, nameConflict, pathConflict ConflictFunc) (skipped bool, err error) {
+	nameReason, err := nameConflict(ctx, info.ServiceName)
+	if err != nil {
+		return false, err
+	}
+	pathReason, err := pathConflict(ctx, info.ServicePath)
+	if err != nil {
+		return false, err
+	}
+	if nameReason == "" && pathReason == "" {
+		return false, nil
+	}
+
+	reason := nameReason
+	if reason == "" {
+		reason = pathReason
+	}
+	switch strategy {
+	case dto.ServiceBundleConflictSkip:
+		entry.Result, entry.Message = dto.ServiceBundleImportSkipped, reason
+		return true, nil
+	case dto.ServiceBundleConflictRename:
+		if nameReason != "" {
+			if info.ServiceName, err = Rename(ctx, info.ServiceName, RenameServiceName, nameConflict); err != nil {
+				return false, err
+			}
+			entry.NewName = info.ServiceName
+		}
+		if pathReason != "" {
+			if info.ServicePath, err = Rename(ctx, info.ServicePath, RenameServicePath, pathConflict); err != nil {
+				return false, err
+			}
+			entry.NewPath = info.ServicePath
+		}
+		return false, nil
+	default:
+		return false, errors.New(reason)
+	}
+}
+
+// Rename 依次尝试加上序号，返回第一个不冲突的值
+func Rename(ctx context.Context, value string, renameFunc func(string, int) string, conflictFunc ConflictFunc) (string, error) {
+	for n := 1; n <= MaxRename; n++ {
+		renamed := renameFunc(value, n)
+		conflict, err := conflictFunc(ctx, renamed)
+		if err != nil {
+			return "", err
+		}
+		if conflict == "" {
+			return renamed, nil
+		}
+	}
+	return "", fmt.Errorf("%s重命名%d次后仍然冲突", value, MaxRename)
+}
+
+// RenameServiceName 接口名称加上序号，如 接口 -> 接口_1
+func RenameServiceName(name string, n int) string {
+	return fmt.Sprintf("%s_%d", name, n)
+}
+
+// RenameServicePath 接口路径的最后一个非变量段加上序号，如 /api/users/{id} -> /api/users_1/{id}
+func RenameServicePath(servicePath string, n int) string {
+	segments := strings.Split(servicePath, "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if segments[i] != "" && !strings.HasPrefix(segments[i], "{") {
+			segments[i] = fmt.Sprintf("%s_%d", segments[i], n)
+			return strings.Join(segments, "/")
+		}
+	}
+	return fmt.Sprintf("%s/_%d", strings.TrimSuffix(servicePath, "/"), n)
+}
@@ -0,0 +1,407 @@
+package service_bundle
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kweaver-ai/idrm-go-frame/core/errorx/agerrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/infrastructure/repository/db/model"
+)
+
+func TestNewService(t *testing.T) {
+	serviceID := uuid.New()
+	detail := &dto.ServiceGetRes{
+		ServiceInfo: dto.ServiceInfo{
+			ServiceID:         serviceID.String(),
+			ServiceCode:       "code",
+			ServiceName:       "接口",
+			ServicePath:       "/api/users",
+			ServiceType:       "service_register",
+			Status:            "online",
+			PublishStatus:     "published",
+			CreatedBy:         "user",
+			Department:        dto.Department{ID: "department", Name: "部门"},
+			CategoryInfo:      []dto.CategoryInfo{{CategoryId: "category"}},
+			SubjectDomainId:   "subject-domain",
+			SubjectDomainName: "分组/主题域",
+			InfoSystemId:      "info-system",
+			InfoSystemName:    "信息系统",
+			AppsId:            "apps",
+			AppsName:          "应用",
+			Owners:            []dto.Owners{{OwnerId: "owner", OwnerName: "用户"}},
+			Developer:         dto.Developer{ID: "developer", Name: "开发商"},
+			RequestMapping: &dto.RequestMapping{Headers: dto.HeaderMapping{Inject: []dto.StaticHeader{
+				{Name: "X-Api-Key", Value: "enc:v1:ciphertext", Secret: true},
+				{Name: "X-Tenant", Value: "tenant"},
+			}}},
+		},
+		CategoryInfo: []dto.CategoryInfo{{CategoryId: "category", CategoryName: "类目", CategoryNodeID: "node", CategoryNodeName: "节点"}},
+		ServiceParam: dto.ServiceParamRead{CreateModel: "wizard", DatasourceId: "datasource", DataViewId: "view"},
+	}
+
+	got := NewService(detail, []model.SubService{{ID: uuid.New(), Name: "子接口", ServiceID: serviceID, AuthScopeID: serviceID, Detail: "{}", RowFilterClause: "(a = 1)"}})
+
+	info := got.ServiceInfo
+	assert.Equal(t, "接口", info.ServiceName)
+	assert.Equal(t, "/api/users", info.ServicePath)
+	assert.Empty(t, info.ServiceID)
+	assert.Empty(t, info.ServiceCode)
+	assert.Empty(t, info.Status)
+	assert.Empty(t, info.PublishStatus)
+	assert.Empty(t, info.CreatedBy)
+	assert.Empty(t, info.Department.ID)
+	assert.Equal(t, "部门", info.Department.Name)
+	assert.Nil(t, info.CategoryInfo)
+	assert.Equal(t, []dto.CategoryInfo{{CategoryName: "类目", CategoryNodeName: "节点"}}, got.CategoryInfo)
+	assert.Empty(t, info.SubjectDomainId)
+	assert.Empty(t, info.SubjectDomainName)
+	assert.Equal(t, dto.Developer{}, info.Developer)
+	assert.Empty(t, info.InfoSystemId)
+	assert.Equal(t, "信息系统", info.InfoSystemName)
+	assert.Empty(t, info.AppsId)
+	assert.Equal(t, "应用", info.AppsName)
+	assert.Equal(t, []dto.Owners{{OwnerName: "用户"}}, info.Owners)
+	assert.Equal(t, "owner", detail.ServiceInfo.Owners[0].OwnerId)
+	assert.Equal(t, []dto.StaticHeader{
+		{Name: "X-Api-Key", Value: "", Secret: true},
+		{Name: "X-Tenant", Value: "tenant"},
+	}, info.RequestMapping.Headers.Inject)
+	// 不修改查询到的接口详情
+	assert.Equal(t, "enc:v1:ciphertext", detail.ServiceInfo.RequestMapping.Headers.Inject[0].Value)
+	assert.Empty(t, got.ServiceParam.DatasourceId)
+	assert.Empty(t, got.ServiceParam.DataViewId)
+	assert.Equal(t, []dto.ServiceBundleSubService{{Name: "子接口", Detail: "{}"}}, got.SubServices)
+}
+
+func TestNewSubServices(t *testing.T) {
+	serviceID := uuid.New()
+	a, b, c, d := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name        string
+		subServices []model.SubService
+		want        []dto.ServiceBundleSubService
+	}{
+		{
+			name: "没有子接口",
+			want: []dto.ServiceBundleSubService{},
+		},
+		{
+			name: "授权范围在前",
+			subServices: []model.SubService{
+				{ID: b, Name: "b", AuthScopeID: a, Detail: "b"},
+				{ID: a, Name: "a", AuthScopeID: serviceID, Detail: "a"},
+			},
+			want: []dto.ServiceBundleSubService{
+				{Name: "a", Detail: "a"},
+				{Name: "b", AuthScope: "a", Detail: "b"},
+			},
+		},
+		{
+			name: "授权范围的子接口不存在时为接口本身",
+			subServices: []model.SubService{
+				{ID: a, Name: "a", AuthScopeID: uuid.New(), Detail: "a"},
+			},
+			want: []dto.ServiceBundleSubService{
+				{Name: "a", Detail: "a"},
+			},
+		},
+		{
+			name: "循环引用不导出",
+			subServices: []model.SubService{
+				{ID: a, Name: "a", AuthScopeID: serviceID},
+				{ID: c, Name: "c", AuthScopeID: d},
+				{ID: d, Name: "d", AuthScopeID: c},
+			},
+			want: []dto.ServiceBundleSubService{
+				{Name: "a"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewSubServices(serviceID.String(), tt.subServices))
+		})
+	}
+}
+
+func TestDropUnsetSecretHeaders(t *testing.T) {
+	tests := []struct {
+		name       string
+		mapping    *dto.RequestMapping
+		want       []string
+		wantInject []dto.StaticHeader
+	}{
+		{
+			name: "没有请求映射",
+		},
+		{
+			name: "去掉没有值和其他环境加密的密钥",
+			mapping: &dto.RequestMapping{Headers: dto.HeaderMapping{Inject: []dto.StaticHeader{
+				{Name: "X-Api-Key", Secret: true},
+				{Name: "X-Token", Value: "enc:v1:ciphertext", Secret: true},
+				{Name: "X-Secret", Value: "plaintext", Secret: true},
+				{Name: "X-Tenant", Value: "tenant"},
+			}}},
+			want: []string{"X-Api-Key", "X-Token"},
+			wantInject: []dto.StaticHeader{
+				{Name: "X-Secret", Value: "plaintext", Secret: true},
+				{Name: "X-Tenant", Value: "tenant"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DropUnsetSecretHeaders(tt.mapping))
+			if tt.mapping != nil {
+				assert.Equal(t, tt.wantInject, tt.mapping.Headers.Inject)
+			}
+		})
+	}
+}
+
+func TestDropUnresolvedReferences(t *testing.T) {
+	tests := []struct {
+		name string
+		item *dto.ServiceBundleService
+		want []string
+	}{
+		{
+			name: "没有引用",
+			item: &dto.ServiceBundleService{},
+		},
+		{
+			name: "导出的名称",
+			item: &dto.ServiceBundleService{
+				ServiceInfo: dto.ServiceInfo{
+					InfoSystemName: "信息系统",
+					AppsName:       "应用",
+					Owners:         []dto.Owners{{OwnerName: "用户1"}, {OwnerName: "用户2"}},
+				},
+				CategoryInfo: []dto.CategoryInfo{{CategoryName: "类目", CategoryNodeName: "节点"}},
+			},
+			want: []string{"信息系统：信息系统", "应用：应用", "数据owner：用户1", "数据owner：用户2", "类目：类目/节点"},
+		},
+		{
+			name: "早期导出包中的ID",
+			item: &dto.ServiceBundleService{
+				ServiceInfo: dto.ServiceInfo{
+					InfoSystemId: "info-system",
+					AppsId:       "apps",
+					CategoryInfo: []dto.CategoryInfo{{CategoryId: "category"}},
+				},
+			},
+			want: []string{"信息系统：info-system", "应用：apps", "类目：category"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DropUnresolvedReferences(tt.item))
+			info := tt.item.ServiceInfo
+			assert.Empty(t, info.InfoSystemId)
+			assert.Empty(t, info.InfoSystemName)
+			assert.Empty(t, info.AppsId)
+			assert.Empty(t, info.AppsName)
+			assert.Empty(t, info.Owners)
+			assert.Empty(t, info.CategoryInfo)
+			assert.Empty(t, tt.item.CategoryInfo)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		ext      string
+		content  string
+		want     *dto.ServiceBundle
+		wantCode string
+	}{
+		{
+			name:    "json",
+			ext:     ".json",
+			content: `{"bundle_version":"v1","services":[{"service_info":{"service_name":"接口"}}]}`,
+			want:    &dto.ServiceBundle{BundleVersion: "v1", Services: []dto.ServiceBundleService{{ServiceInfo: dto.ServiceInfo{ServiceName: "接口"}}}},
+		},
+		{
+			name:    "yaml 扩展名不区分大小写",
+			ext:     ".YML",
+			content: "bundle_version: v1\nservices:\n  - service_info:\n      service_name: 接口\n",
+			want:    &dto.ServiceBundle{BundleVersion: "v1", Services: []dto.ServiceBundleService{{ServiceInfo: dto.ServiceInfo{ServiceName: "接口"}}}},
+		},
+		{
+			name:     "不支持的扩展名",
+			ext:      ".xml",
+			content:  `<bundle/>`,
+			wantCode: errorcode.ServiceBundleInvalid,
+		},
+		{
+			name:     "格式错误",
+			ext:      ".json",
+			content:  `{"bundle_version":`,
+			wantCode: errorcode.ServiceBundleInvalid,
+		},
+		{
+			name:     "不支持的版本",
+			ext:      ".json",
+			content:  `{"bundle_version":"v2"}`,
+			wantCode: errorcode.ServiceBundleVersionNotSupported,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.ext, []byte(tt.content))
+			if tt.wantCode != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantCode, agerrors.Code(err).GetErrorCode())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCheckSubServices(t *testing.T) {
+	tests := []struct {
+		name        string
+		subServices []dto.ServiceBundleSubService
+		wantErr     bool
+	}{
+		{name: "没有子接口"},
+		{name: "授权范围在前", subServices: []dto.ServiceBundleSubService{{Name: "a"}, {Name: "b", AuthScope: "a"}}},
+		{name: "名称重复", subServices: []dto.ServiceBundleSubService{{Name: "a"}, {Name: "a"}}, wantErr: true},
+		{name: "授权范围在后", subServices: []dto.ServiceBundleSubService{{Name: "b", AuthScope: "a"}, {Name: "a"}}, wantErr: true},
+		{name: "授权范围不存在", subServices: []dto.ServiceBundleSubService{{Name: "a", AuthScope: "c"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckSubServices(tt.subServices)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+		})
+	}
+}
+
+func TestRenameServicePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/api/users", want: "/api/users_2"},
+		{path: "/api/users/{id}", want: "/api/users_2/{id}"},
+		{path: "/api/users/", want: "/api/users_2/"},
+		{path: "/{id}", want: "/{id}/_2"},
+		{path: "/", want: "/_2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, RenameServicePath(tt.path, 2))
+		})
+	}
+}
+
+// conflicts 返回已有值冲突的 ConflictFunc
+func conflicts(values ...string) ConflictFunc {
+	return func(_ context.Context, value string) (string, error) {
+		for _, v := range values {
+			if v == value {
+				return value + "已存在", nil
+			}
+		}
+		return "", nil
+	}
+}
+
+func TestResolveConflict(t *testing.T) {
+	errQuery := errors.New("query failed")
+	failed := func(context.Context, string) (string, error) { return "", errQuery }
+
+	tests := []struct {
+		name         string
+		strategy     string
+		nameConflict ConflictFunc
+		pathConflict ConflictFunc
+		wantSkipped  bool
+		wantErr      error
+		wantInfo     dto.ServiceInfo
+		wantEntry    dto.ServiceBundleImportEntry
+	}{
+		{
+			name:         "不冲突",
+			strategy:     dto.ServiceBundleConflictFail,
+			nameConflict: conflicts(),
+			pathConflict: conflicts(),
+			wantInfo:     dto.ServiceInfo{ServiceName: "接口", ServicePath: "/api/users"},
+		},
+		{
+			name:         "冲突时失败",
+			strategy:     dto.ServiceBundleConflictFail,
+			nameConflict: conflicts(),
+			pathConflict: conflicts("/api/users"),
+			wantErr:      errors.New("/api/users已存在"),
+			wantInfo:     dto.ServiceInfo{ServiceName: "接口", ServicePath: "/api/users"},
+		},
+		{
+			name:         "冲突时跳过，原因为名称冲突",
+			strategy:     dto.ServiceBundleConflictSkip,
+			nameConflict: conflicts("接口"),
+			pathConflict: conflicts("/api/users"),
+			wantSkipped:  true,
+			wantInfo:     dto.ServiceInfo{ServiceName: "接口", ServicePath: "/api/users"},
+			wantEntry:    dto.ServiceBundleImportEntry{Result: dto.ServiceBundleImportSkipped, Message: "接口已存在"},
+		},
+		{
+			name:         "冲突时重命名",
+			strategy:     dto.ServiceBundleConflictRename,
+			nameConflict: conflicts("接口", "接口_1"),
+			pathConflict: conflicts("/api/users"),
+			wantInfo:     dto.ServiceInfo{ServiceName: "接口_2", ServicePath: "/api/users_1"},
+			wantEntry:    dto.ServiceBundleImportEntry{NewName: "接口_2", NewPath: "/api/users_1"},
+		},
+		{
+			name:         "只重命名冲突的路径",
+			strategy:     dto.ServiceBundleConflictRename,
+			nameConflict: conflicts(),
+			pathConflict: conflicts("/api/users"),
+			wantInfo:     dto.ServiceInfo{ServiceName: "接口", ServicePath: "/api/users_1"},
+			wantEntry:    dto.ServiceBundleImportEntry{NewPath: "/api/users_1"},
+		},
+		{
+			name:         "查询冲突失败",
+			strategy:     dto.ServiceBundleConflictRename,
+			nameConflict: failed,
+			pathConflict: conflicts(),
+			wantErr:      errQuery,
+			wantInfo:     dto.ServiceInfo{ServiceName: "接口", ServicePath: "/api/users"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &dto.ServiceInfo{ServiceName: "接口", ServicePath: "/api/users"}
+			entry := &dto.ServiceBundleImportEntry{}
+			skipped, err := ResolveConflict(context.Background(), tt.strategy, info, entry, tt.nameConflict, tt.pathConflict)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantSkipped, skipped)
+			assert.Equal(t, tt.wantInfo, *info)
+			assert.Equal(t, tt.wantEntry, *entry)
+		})
+	}
+}
+
+func TestRename(t *testing.T) {
+	// 所有序号都冲突
+	always := func(context.Context, string) (string, error) { return "已存在", nil }
+	_, err := Rename(context.Background(), "接口", RenameServiceName, always)
+	assert.Error(t, err)
+
+	got, err := Rename(context.Background(), "接口", RenameServiceName, conflicts("接口_1", "接口_2"))
+	require.NoError(t, err)
+	assert.Equal(t, "接口_3", got)
+}
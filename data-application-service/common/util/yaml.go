@@ -0,0 +1,35 @@
+package util
+
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v3"
+)
+
+// JSONToYAML 把 JSON 文档转换为 YAML，保持对象中字段的顺序。JSON 是 YAML 的子集，
+// 解析为 yaml.Node 后去掉 JSON 的 flow 风格，即可输出块风格的 YAML
+func JSONToYAML(data []byte) ([]byte, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	resetYAMLStyle(&node)
+	return yaml.Marshal(&node)
+}
+
+// YAMLToJSON 把 YAML 文档转换为 JSON，用于复用结构体的 json tag 解析 YAML
+func YAMLToJSON(data []byte) ([]byte, error) {
+	var v any
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// resetYAMLStyle 清除节点的风格，由 encoder 决定输出的风格，字符串标量在需要时仍会加引号
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, n := range node.Content {
+		resetYAMLStyle(n)
+	}
+}
@@ -0,0 +1,79 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONToYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{
+			name: "保持字段顺序",
+			json: `{"b":1,"a":{"d":[1,2],"c":true}}`,
+			want: "b: 1\na:\n    d:\n        - 1\n        - 2\n    c: true\n",
+		},
+		{
+			name: "类似数字、布尔值的字符串保持为字符串",
+			json: `{"code":"001","flag":"true","empty":""}`,
+			want: "code: \"001\"\nflag: \"true\"\nempty: \"\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONToYAML([]byte(tt.json))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestYAMLToJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "对象和数组",
+			yaml: "name: a\nparams:\n  - en_name: id\n    required: \"yes\"\n",
+			want: `{"name":"a","params":[{"en_name":"id","required":"yes"}]}`,
+		},
+		{
+			name: "JSON 也是合法的 YAML",
+			yaml: `{"name":"a","page_size":10}`,
+			want: `{"name":"a","page_size":10}`,
+		},
+		{
+			name:    "格式错误",
+			yaml:    "name: [a",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := YAMLToJSON([]byte(tt.yaml))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestJSONToYAMLRoundTrip(t *testing.T) {
+	doc := `{"service_info":{"service_name":"接口","timeout":60},"service_test":{"request_example":"{\n  \"id\": 1\n}"},"sub_services":[]}`
+	y, err := JSONToYAML([]byte(doc))
+	require.NoError(t, err)
+	got, err := YAMLToJSON(y)
+	require.NoError(t, err)
+	assert.JSONEq(t, doc, string(got))
+}
@@ -15,6 +15,10 @@ var ProviderSet = wire.NewSet(
 	NewServiceApplyDomain,
 	NewSubjectDomain,
 	NewServiceDailyRecordDomain,
+	NewServiceApplyExpireDomain,
+	NewServiceVersionDomain,
+	NewServiceBundleDomain,
+	NewServiceOpenAPIDomain,
 	sub_service.NewSubServiceUseCase,
 	NewServiceCallRecordDomain,
 )
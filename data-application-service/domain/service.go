@@ -842,9 +842,67 @@ func (u *ServiceDomain) CheckServicePath(ctx context.Context, req *dto.ServiceCh
 		return errorcode.Desc(errorcode.ServicePathExist)
 	}
 
+	// 带路径变量的接口路径，如 /orders/{id}，不能与其他接口匹配同一请求路径
+	conflict, err := u.serviceRepo.ServicePathConflict(ctx, req.ServicePath, req.ServiceID)
+	if err != nil {
+		return err
+	}
+	if conflict != "" {
+		return errorcode.Desc(errorcode.ServicePathConflict, conflict)
+	}
+
 	return nil
 }
 
+// checkPathParams 检查接口路径和后台服务路径中的变量。接口路径中的变量需要声明为请求参数，
+// 后台服务路径中的变量只能使用接口路径中的变量
+func checkPathParams(serviceInfo dto.ServiceInfo, serviceParam dto.ServiceParamWrite) (validErrors form_validator.ValidErrors) {
+	pathVars := make(map[string]bool)
+	if util.IsPathTemplate(serviceInfo.ServicePath) {
+		t, err := util.ParsePathTemplate(serviceInfo.ServicePath)
+		if err != nil {
+			return append(validErrors, &form_validator.ValidError{Key: "service_info.service_path", Message: err.Error()})
+		}
+		declared := make(map[string]bool)
+		for _, p := range serviceParam.DataTableRequestParams {
+			declared[p.EnName] = true
+		}
+		for _, v := range t.Vars() {
+			pathVars[v] = true
+			if !declared[v] {
+				validErrors = append(validErrors, &form_validator.ValidError{Key: "service_param.data_table_request_params", Message: "路径参数" + v + "需要声明为请求参数"})
+			}
+		}
+	}
+
+	if serviceInfo.ServiceType == "service_register" && util.IsPathTemplate(serviceInfo.BackendServicePath) {
+		t, err := util.ParsePathTemplate(serviceInfo.BackendServicePath)
+		if err != nil {
+			return append(validErrors, &form_validator.ValidError{Key: "service_info.backend_service_path", Message: err.Error()})
+		}
+		for _, v := range t.Vars() {
+			if !pathVars[v] {
+				validErrors = append(validErrors, &form_validator.ValidError{Key: "service_info.backend_service_path", Message: "后台服务路径中的变量" + v + "不是接口路径中的变量"})
+			}
+		}
+	}
+	return validErrors
+}
+
+// checkReturnTypeMasking 网关只能对 JSON 的返回数据脱敏，其他返回类型的注册接口不允许配置脱敏规则。
+// 接口生成的返回类型总是 json
+func checkReturnTypeMasking(serviceInfo dto.ServiceInfo, serviceParam dto.ServiceParamWrite) (validErrors form_validator.ValidErrors) {
+	if serviceInfo.ServiceType != "service_register" || serviceInfo.ReturnType == "" || serviceInfo.ReturnType == "json" {
+		return nil
+	}
+	for _, param := range serviceParam.DataTableResponseParams {
+		if param.Masking != "" && param.Masking != "plaintext" {
+			validErrors = append(validErrors, &form_validator.ValidError{Key: "service_param.data_table_response_params.masking", Message: "返回类型为" + serviceInfo.ReturnType + "的接口不支持脱敏，字段" + param.EnName + "不能配置脱敏规则"})
+		}
+	}
+	return validErrors
+}
+
 func (u *ServiceDomain) serviceCheckParam(ctx context.Context, serviceInfo dto.ServiceInfo, serviceParam dto.ServiceParamWrite) (err error) {
 	// 根据字段值进行不同的必填项校验
 	// https://pkg.go.dev/github.com/go-playground/validator/v10#hdr-Required_If
@@ -861,6 +919,9 @@ func (u *ServiceDomain) serviceCheckParam(ctx context.Context, serviceInfo dto.S
 		validErrors = append(validErrors, &form_validator.ValidError{Key: "service_info.service_path", Message: "service_path为必填字段"})
 	}
 
+	validErrors = append(validErrors, checkPathParams(serviceInfo, serviceParam)...)
+	validErrors = append(validErrors, checkReturnTypeMasking(serviceInfo, serviceParam)...)
+
 	if serviceInfo.ServiceType == "service_register" {
 		if serviceInfo.BackendServiceHost == "" {
 			validErrors = append(validErrors, &form_validator.ValidError{Key: "service_info.backend_service_host", Message: "backend_service_host为必填字段"})
@@ -999,6 +1060,22 @@ func (u *ServiceDomain) AuditProcessInstanceCreate(c context.Context, req *dto.A
 		isAuditProcessExist = true
 	}
 
+	//由生命周期状态机确定接口的目标状态
+	trigger := auditLifecycleTrigger(req.AuditType, isAuditProcessExist)
+	from := pendingSubmitLifecycleState(service.ServiceInfo.PublishStatus, service.ServiceInfo.Status, service.ServiceInfo.AuditStatus)
+	to := from
+	if trigger != "" {
+		if to, err = enum.FireLifecycle(from, trigger); err != nil {
+			log.WithContext(c).Error("AuditProcessInstanceCreate", zap.Error(err), zap.String("serviceID", req.ServiceID))
+			return errorcode.Detail(errorcode.ServiceLifecycleTransitionError, err)
+		}
+	}
+	// 变更审核通过后变更版本的接口ID会被替换，先查询已发布版本的接口ID
+	fields, err := u.serviceRepo.ServiceGetFields(c, req.ServiceID, []string{"changed_service_id"})
+	if err != nil {
+		return err
+	}
+
 	//生成审核实例
 	t := time.Now()
 	audit := &model.Service{
@@ -1008,16 +1085,20 @@ func (u *ServiceDomain) AuditProcessInstanceCreate(c context.Context, req *dto.A
 	}
 
 	//标记接口状态和审核状态
-	switch req.AuditType {
-	case enum.AuditTypePublish: //发布
-		switch isAuditProcessExist {
-		case true: // 绑定了审核流程，发布待审核
-			audit.AuditStatus = enum.AuditStatusAuditing
-			audit.ProcDefKey = process.ProcDefKey
-			audit.PublishStatus = enum.PublishStatusPubAuditing
-		case false: // 没绑定审核流程，改为已发布
-			audit.AuditStatus = enum.AuditStatusPass
-			audit.PublishStatus = enum.PublishStatusPublished
+	if to.PublishStatus != from.PublishStatus {
+		audit.PublishStatus = to.PublishStatus
+	}
+	if to.Status != from.Status {
+		audit.Status = to.Status
+	}
+	switch isAuditProcessExist {
+	case true: // 绑定了审核流程，待审核
+		audit.AuditStatus = enum.AuditStatusAuditing
+		audit.ProcDefKey = process.ProcDefKey
+	case false: // 没绑定审核流程，直接通过
+		audit.AuditStatus = enum.AuditStatusPass
+		switch req.AuditType {
+		case enum.AuditTypePublish, enum.AuditTypeChange:
 			audit.PublishTime = &t
 			// 如果没有绑定审核流程，调用回调事件
 			if callbackErr := u.serviceRepo.HandleCallbackEvent(c, req.ServiceID); callbackErr != nil {
@@ -1025,45 +1106,9 @@ func (u *ServiceDomain) AuditProcessInstanceCreate(c context.Context, req *dto.A
 					zap.String("serviceID", req.ServiceID), zap.Error(callbackErr))
 				// 回调失败不影响主流程，只记录日志
 			}
-		}
-	case enum.AuditTypeChange: //变更
-		switch isAuditProcessExist {
-		case true: // 变更审核中
-			audit.AuditStatus = enum.AuditStatusAuditing
-			audit.ProcDefKey = process.ProcDefKey
-			audit.PublishStatus = enum.PublishStatusChangeAuditing
-		case false: // 已发布
-			audit.AuditStatus = enum.AuditStatusPass
-			audit.PublishStatus = enum.PublishStatusPublished
-			audit.PublishTime = &t
-			// 如果没有绑定审核流程，调用回调事件
-			if callbackErr := u.serviceRepo.HandleCallbackEvent(c, req.ServiceID); callbackErr != nil {
-				log.WithContext(c).Error("AuditProcessInstanceCreate handle callback event failed",
-					zap.String("serviceID", req.ServiceID), zap.Error(callbackErr))
-				// 回调失败不影响主流程，只记录日志
-			}
-		}
-	case enum.AuditTypeOnline: //上线
-		switch isAuditProcessExist {
-		case true:
-			audit.AuditStatus = enum.AuditStatusAuditing
-			audit.ProcDefKey = process.ProcDefKey
-			audit.Status = enum.LineStatusUpAuditing
-		case false:
-			audit.AuditStatus = enum.AuditStatusPass
-			audit.Status = enum.LineStatusOnLine
+		case enum.AuditTypeOnline:
 			audit.OnlineTime = &t
 		}
-	case enum.AuditTypeOffline: //下线
-		switch isAuditProcessExist {
-		case true:
-			audit.AuditStatus = enum.AuditStatusAuditing
-			audit.ProcDefKey = process.ProcDefKey
-			audit.Status = enum.LineStatusDownAuditing
-		case false:
-			audit.AuditStatus = enum.AuditStatusPass
-			audit.Status = enum.LineStatusOffLine
-		}
 	}
 	audit.UpdateTime = t
 
@@ -1073,7 +1118,18 @@ func (u *ServiceDomain) AuditProcessInstanceCreate(c context.Context, req *dto.A
 		return err
 	}
 
-	err = u.serviceRepo.AuditProcessInstanceCreate(c, req.ServiceID, audit)
+	// 生命周期的迁移与审核状态在同一个事务中记录
+	var transitions []*gorm.LifecycleTransition
+	if trigger != "" {
+		transitions = append(transitions, &gorm.LifecycleTransition{
+			ServiceID: gorm.LifecycleServiceID(req.ServiceID, fields.ChangedServiceId),
+			Trigger:   trigger,
+			From:      from,
+			To:        to,
+			ApplyID:   audit.ApplyID,
+		})
+	}
+	err = u.serviceRepo.AuditProcessInstanceCreate(c, req.ServiceID, audit, transitions...)
 	if err != nil {
 		return err
 	}
@@ -1084,6 +1140,22 @@ func (u *ServiceDomain) AuditProcessInstanceCreate(c context.Context, req *dto.A
 	return nil
 }
 
+// auditLifecycleTrigger 提交审核对应的生命周期触发动作，未绑定审核流程时直接通过。
+// 提交变更审核时，变更版本在 ServiceChange 中已经迁移为变更审核中，此时返回空
+func auditLifecycleTrigger(auditType string, isAuditProcessExist bool) string {
+	switch auditType {
+	case enum.AuditTypePublish:
+		return lo.Ternary(isAuditProcessExist, enum.LifecycleTriggerPublishSubmit, enum.LifecycleTriggerPublish)
+	case enum.AuditTypeChange:
+		return lo.Ternary(isAuditProcessExist, "", enum.LifecycleTriggerChangePass)
+	case enum.AuditTypeOnline:
+		return lo.Ternary(isAuditProcessExist, enum.LifecycleTriggerOnlineSubmit, enum.LifecycleTriggerOnline)
+	case enum.AuditTypeOffline:
+		return lo.Ternary(isAuditProcessExist, enum.LifecycleTriggerOfflineSubmit, enum.LifecycleTriggerOffline)
+	}
+	return ""
+}
+
 func (u *ServiceDomain) ServiceSearch(c context.Context, req *dto.ServiceSearchReq) (res *dto.ServiceSearchRes, err error) {
 	interfaceSvcSearchReq := &microservice.InterfaceSvcSearchReq{
 		Keyword:  req.Keyword,
@@ -1744,11 +1816,13 @@ func (u *ServiceDomain) UndoAuditByType(ctx context.Context, serviceID string, o
 
 func (u *ServiceDomain) UndoPublishAudit(ctx context.Context, serviceID string) (resp *dto.ServiceIdRes, err error) {
 	//状态校验
-	service, err := u.serviceRepo.ServiceGetFields(ctx, serviceID, []string{"apply_id", "audit_type", "publish_status"})
+	service, err := u.serviceRepo.ServiceGetFields(ctx, serviceID, []string{"apply_id", "audit_type", "publish_status", "status"})
 	if err != nil {
 		return nil, err
 	}
-	if service.PublishStatus != enum.PublishStatusPubAuditing || service.AuditType != enum.AuditTypePublish {
+	from := enum.NewLifecycleState(service.PublishStatus, service.Status)
+	to, err := enum.FireLifecycle(from, enum.LifecycleTriggerPublishUndo)
+	if err != nil || service.AuditType != enum.AuditTypePublish {
 		return nil, errorcode.Desc(errorcode.ServiceAuditUndoError)
 	}
 	//给wf发审核撤回的消息
@@ -1764,7 +1838,11 @@ func (u *ServiceDomain) UndoPublishAudit(ctx context.Context, serviceID string)
 	}
 	log.Info("producer workflow msg", zap.String("topic", mq.TopicWorkflowAuditCancel), zap.Any("msg", msg))
 	//入队成功就改库中的状态
-	resp, err = u.serviceRepo.UpdateServicePublishStatus(ctx, serviceID, enum.PublishStatusUnPublished)
+	resp, err = u.serviceRepo.UpdateServicePublishStatus(ctx, serviceID, to.PublishStatus,
+		&gorm.LifecycleTransition{ServiceID: serviceID, Trigger: enum.LifecycleTriggerPublishUndo, From: from, To: to, ApplyID: service.ApplyID})
+	if err != nil {
+		return nil, err
+	}
 	return
 }
 
@@ -1775,11 +1853,12 @@ func (u *ServiceDomain) UndoChangeAudit(ctx context.Context, serviceID string) (
 		return nil, err
 	}
 	//状态校验
-	service, err := u.serviceRepo.ServiceGetFields(ctx, sid, []string{"apply_id", "audit_type", "publish_status"})
+	service, err := u.serviceRepo.ServiceGetFields(ctx, sid, []string{"apply_id", "audit_type", "publish_status", "status"})
 	if err != nil {
 		return nil, err
 	}
-	if service.PublishStatus != enum.PublishStatusChangeAuditing || service.AuditType != enum.AuditTypeChange {
+	// 变更版本的发布状态在收到审核撤回的结果时迁移，由 ServiceRepo 记录
+	if !enum.CanFireLifecycle(enum.NewLifecycleState(service.PublishStatus, service.Status), enum.LifecycleTriggerChangeUndo) || service.AuditType != enum.AuditTypeChange {
 		return nil, errorcode.Desc(errorcode.ServiceAuditUndoError)
 	}
 	//给wf发审核撤回的消息
@@ -1805,7 +1884,9 @@ func (u *ServiceDomain) UndoUpAudit(ctx context.Context, serviceID string) (resp
 	if err != nil {
 		return nil, err
 	}
-	if service.AuditType != enum.AuditTypeOnline || service.Status != enum.LineStatusUpAuditing {
+	from := enum.NewLifecycleState(service.PublishStatus, service.Status)
+	to, err := enum.FireLifecycle(from, enum.LifecycleTriggerOnlineUndo)
+	if err != nil || service.AuditType != enum.AuditTypeOnline {
 		return nil, errorcode.Desc(errorcode.ServiceAuditUndoError)
 	}
 	//给wf发审核撤回的消息
@@ -1821,7 +1902,11 @@ func (u *ServiceDomain) UndoUpAudit(ctx context.Context, serviceID string) (resp
 	}
 	log.Info("producer workflow msg", zap.String("topic", mq.TopicWorkflowAuditCancel), zap.Any("msg", msg))
 	//入队成功就改库中的状态
-	resp, err = u.serviceRepo.ServiceUpdateStatus(ctx, serviceID, enum.LineStatusNotLine)
+	resp, err = u.serviceRepo.ServiceUpdateStatus(ctx, serviceID, to.Status,
+		&gorm.LifecycleTransition{ServiceID: serviceID, Trigger: enum.LifecycleTriggerOnlineUndo, From: from, To: to, ApplyID: service.ApplyID})
+	if err != nil {
+		return nil, err
+	}
 	return
 }
 
@@ -1831,7 +1916,9 @@ func (u *ServiceDomain) UndoDownAudit(ctx context.Context, serviceID string) (re
 	if err != nil {
 		return nil, err
 	}
-	if service.AuditType != enum.AuditTypeOffline || service.Status != enum.LineStatusDownAuditing {
+	from := enum.NewLifecycleState(service.PublishStatus, service.Status)
+	to, err := enum.FireLifecycle(from, enum.LifecycleTriggerOfflineUndo)
+	if err != nil || service.AuditType != enum.AuditTypeOffline {
 		return nil, errorcode.Desc(errorcode.ServiceAuditUndoError)
 	}
 	//给wf发审核撤回的消息
@@ -1847,7 +1934,11 @@ func (u *ServiceDomain) UndoDownAudit(ctx context.Context, serviceID string) (re
 	}
 	log.Info("producer workflow msg", zap.String("topic", mq.TopicWorkflowAuditCancel), zap.Any("msg", msg))
 	//入队成功就改库中的状态
-	resp, err = u.serviceRepo.ServiceUpdateStatus(ctx, serviceID, enum.LineStatusOnLine)
+	resp, err = u.serviceRepo.ServiceUpdateStatus(ctx, serviceID, to.Status,
+		&gorm.LifecycleTransition{ServiceID: serviceID, Trigger: enum.LifecycleTriggerOfflineUndo, From: from, To: to, ApplyID: service.ApplyID})
+	if err != nil {
+		return nil, err
+	}
 	return
 }
 
@@ -1894,8 +1985,29 @@ func (u *ServiceDomain) AbandonServiceChange(ctx context.Context, serviceID stri
 		serviceID = sid
 	}
 
+	//放弃变更审核未通过的版本时记录生命周期的迁移，只删除草稿时接口状态不变
+	changed, err := u.serviceRepo.ServiceGetFields(ctx, serviceID, []string{"publish_status", "status", "changed_service_id"})
+	if err != nil {
+		return nil, err
+	}
+	from := enum.NewLifecycleState(changed.PublishStatus, changed.Status)
+	to, fireErr := enum.FireLifecycle(from, enum.LifecycleTriggerChangeAbandon)
+
+	var transitions []*gorm.LifecycleTransition
+	if fireErr == nil {
+		transitions = append(transitions, &gorm.LifecycleTransition{
+			ServiceID: gorm.LifecycleServiceID(serviceID, changed.ChangedServiceId),
+			Trigger:   enum.LifecycleTriggerChangeAbandon,
+			From:      from,
+			To:        to,
+		})
+	}
+
 	//恢复到已发布的内容
-	resp, err = u.serviceRepo.RecoverToPublished(ctx, serviceID)
+	resp, err = u.serviceRepo.RecoverToPublished(ctx, serviceID, transitions...)
+	if err != nil {
+		return nil, err
+	}
 	return
 }
 
@@ -1972,18 +2084,16 @@ func (u *ServiceDomain) ServiceOnlineOperate(ctx context.Context, serviceID stri
 	auditInst := dto.AuditProcessInstanceCreateReq{
 		AuditType: enum.AuditTypeOnline,
 	}
-	var ok bool = true
-	if "up" == operate {
-		auditInst.AuditType = enum.AuditTypeOnline
-		_, ok = enum.ServiceAllowedUpStatus[service.PublishStatus+service.Status]
-	} else {
+	if "up" != operate {
 		auditInst.AuditType = enum.AuditTypeOffline
-		_, ok = enum.ServiceAllowedDownStatus[service.PublishStatus+service.Status]
 	}
-
-	if !ok {
-		log.WithContext(ctx).Error("ServiceAllowedUpStatus", zap.Error(errorcode.Desc(errorcode.ServiceUpStatusError)))
-		return nil, errorcode.Desc(errorcode.ServiceUpStatusError)
+	//状态校验：变更审核中、变更审核未通过时，按变更版本的发布状态和已发布版本的上线状态校验
+	from := enum.NewLifecycleState(service.PublishStatus, service.Status)
+	to, err := enum.FireLifecycle(from, auditLifecycleTrigger(auditInst.AuditType, isBindProcess))
+	if err != nil {
+		statusErr := lo.Ternary("up" == operate, errorcode.ServiceUpStatusError, errorcode.ServiceDownStatusError)
+		log.WithContext(ctx).Error("ServiceOnlineOperate", zap.Error(err))
+		return nil, errorcode.Desc(statusErr)
 	}
 
 	switch service.PublishStatus {
@@ -1992,20 +2102,9 @@ func (u *ServiceDomain) ServiceOnlineOperate(ctx context.Context, serviceID stri
 	case enum.PublishStatusChangeAuditing, enum.PublishStatusChangeReject: //变更审核中上线，把已发布版本上线（变更的时候，Vn版本不会下线），因此前提是已发布的版本未上线或者已下线
 		//送已发布的版本去审核
 		auditInst.ServiceID = service.ChangedServiceId
-		// 将Vn+1 版本也上线，但不用送去审核
-		if !isBindProcess {
-			if "up" == operate {
-				_, err = u.serviceRepo.ServiceUpdateStatus(ctx, service.ServiceID, enum.LineStatusOnLine)
-			} else {
-				_, err = u.serviceRepo.ServiceUpdateStatus(ctx, service.ServiceID, enum.LineStatusOffLine)
-			}
-		} else {
-			if "up" == operate {
-				_, err = u.serviceRepo.ServiceUpdateStatus(ctx, service.ServiceID, enum.LineStatusUpAuditing)
-			} else {
-				_, err = u.serviceRepo.ServiceUpdateStatus(ctx, service.ServiceID, enum.LineStatusDownAuditing)
-			}
-
+		// 将Vn+1 版本也上线或改为审核中，但不用送去审核
+		if _, err = u.serviceRepo.ServiceUpdateStatus(ctx, service.ServiceID, to.Status); err != nil {
+			return nil, err
 		}
 	}
 	//未绑定上线审核流程，直接更新库并更新ES；绑定，发wf消息，并更新库为上线审核中的状态
@@ -2038,6 +2137,17 @@ func (u *ServiceDomain) ServiceChange(ctx context.Context, req *dto.ServiceChang
 	if !(service.PublishStatus == enum.PublishStatusPublished || service.PublishStatus == enum.PublishStatusChangeReject) {
 		return nil, errorcode.Desc(errorcode.ServiceChangeStatusError)
 	}
+	//提交变更时由生命周期状态机校验，变更审核中的接口不能再次提交变更
+	publishedID := req.ServiceID
+	from, err := u.lifecycleState(ctx, publishedID)
+	if err != nil {
+		return nil, err
+	}
+	to, fireErr := enum.FireLifecycle(from, enum.LifecycleTriggerChangeSubmit)
+	if !req.IsTemp && fireErr != nil {
+		log.WithContext(ctx).Error("ServiceChange", zap.Error(fireErr))
+		return nil, errorcode.Desc(errorcode.ServiceChangeStatusError)
+	}
 	//暂存状态下不检查必填项
 	if !req.IsTemp {
 		err = u.serviceCheckParam(ctx, req.ServiceInfo, req.ServiceParam)
@@ -2057,8 +2167,14 @@ func (u *ServiceDomain) ServiceChange(ctx context.Context, req *dto.ServiceChang
 		req.ServiceParam.Script = ""
 	}
 
+	//暂存时不记录生命周期的迁移
+	var transitions []*gorm.LifecycleTransition
+	if !req.IsTemp {
+		transitions = append(transitions, &gorm.LifecycleTransition{ServiceID: publishedID, Trigger: enum.LifecycleTriggerChangeSubmit, From: from, To: to})
+	}
+
 	if service.PublishStatus == enum.PublishStatusPublished && service.IsChanged != "1" {
-		resp, err = u.serviceRepo.ServiceChangeInPublished(ctx, req) //已发布状态下进行变更和暂存
+		resp, err = u.serviceRepo.ServiceChangeInPublished(ctx, req, transitions...) //已发布状态下进行变更和暂存
 		if err != nil {
 			log.WithContext(ctx).Error("ServiceChangeInPublished  --> 更新数据失败：", zap.Error(err))
 			return nil, err
@@ -2109,7 +2225,7 @@ func (u *ServiceDomain) ServiceChange(ctx context.Context, req *dto.ServiceChang
 			return nil, err
 		}
 		req.ServiceID = sid
-		resp, err = u.serviceRepo.ServiceChangeInChangeAuditReject(ctx, req) //变更审核未通过时进行变更和暂存
+		resp, err = u.serviceRepo.ServiceChangeInChangeAuditReject(ctx, req, transitions...) //变更审核未通过时进行变更和暂存
 		if err != nil {
 			log.WithContext(ctx).Error("ServiceChangeInChangeAuditReject  --> 更新数据失败：", zap.Error(err))
 			return nil, err
@@ -2203,8 +2319,28 @@ func (u *ServiceDomain) GetServicesMaxResponse(ctx context.Context, req *dto.Get
 
 // BatchPublishAndOnline 批量上线和发布接口服务，不经过审核
 func (u *ServiceDomain) BatchPublishAndOnline(ctx context.Context, ids []string) error {
+	// 所有接口都可以发布并上线时才更新，审核中的接口不能批量发布上线
+	var transitions []*gorm.LifecycleTransition
+	for _, id := range ids {
+		service, err := u.serviceRepo.ServiceGetFields(ctx, id, []string{"service_id", "publish_status", "status"})
+		if err != nil {
+			return err
+		}
+		// 不存在的接口忽略
+		if service.ServiceID == "" {
+			continue
+		}
+		from := enum.NewLifecycleState(service.PublishStatus, service.Status)
+		to, err := enum.FireLifecycle(from, enum.LifecycleTriggerBatchPublishOnline)
+		if err != nil {
+			log.WithContext(ctx).Error("BatchPublishAndOnline", zap.Error(err), zap.String("serviceID", id))
+			return errorcode.Detail(errorcode.ServiceLifecycleTransitionError, err)
+		}
+		transitions = append(transitions, &gorm.LifecycleTransition{ServiceID: id, Trigger: enum.LifecycleTriggerBatchPublishOnline, From: from, To: to})
+	}
+
 	// 更新指定接口的发布、上线状态
-	return u.serviceRepo.ServiceUpdateStatusAndPublishStatus(ctx, enum.LineStatusOnLine, enum.PublishStatusPublished, gorm.ServiceUpdateOptions{Filter: gorm.ServiceIDs(ids)})
+	return u.serviceRepo.ServiceUpdateStatusAndPublishStatus(ctx, enum.LineStatusOnLine, enum.PublishStatusPublished, gorm.ServiceUpdateOptions{Filter: gorm.ServiceIDs(ids)}, transitions...)
 }
 
 // start GetStatusStatistics
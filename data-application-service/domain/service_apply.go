@@ -151,42 +151,80 @@ func (d *ServiceApplyDomain) ServiceApplyCreate(c context.Context, req *dto.Serv
 		return errorcode.Desc(errorcode.ServiceApplyAvailableExist)
 	}
 
-	//检查是否有绑定的审核流程
-	process, err := d.auditProcessBindRepo.GetByAuditType(c, enum.AuditTypeRequest)
+	procDefKey, err := d.requestProcDefKey(c)
+	if err != nil {
+		return err
+	}
+
+	apply := &model.ServiceApply{
+		ID:          uint64(util.GetUniqueID()),
+		UID:         util.GetUser(c).Id,
+		ServiceID:   req.ServiceID,
+		ApplyDays:   *req.ApplyDays,
+		ApplyReason: req.ApplyReason,
+		ApplyID:     util.GetUniqueString(),
+		AuditType:   enum.AuditTypeRequest,
+		AuditStatus: enum.AuditStatusAuditing,
+		ProcDefKey:  procDefKey,
+	}
+
+	err = d.applyRepo.Create(c, apply)
+	if err != nil {
+		return errorcode.Desc(errorcode.PublicDatabaseError)
+	}
+
+	//增加申请量
+	d.statsRepo.IncrApplyNum(c, req.ServiceID)
+
+	return nil
+}
+
+// ServiceApplyRenew 续期已通过或已过期的限时申请，创建新的申请并走申请的审核流程，
+// 审核通过后重新授权，过期时间从当前授权的过期时间起算
+func (d *ServiceApplyDomain) ServiceApplyRenew(c context.Context, req *dto.ServiceApplyRenewReq) (err error) {
+	exist, err := d.applyRepo.IsExist(c, req.ApplyId)
 	if err != nil {
 		return errorcode.Detail(errorcode.PublicDatabaseError, err)
 	}
+	if !exist {
+		return errorcode.Desc(errorcode.ServiceApplyIdNotExist)
+	}
 
-	if process.ProcDefKey == "" {
-		return errorcode.Desc(errorcode.AuditProcessNotExist)
+	origin, err := d.applyRepo.Get(c, req.ApplyId)
+	if err != nil {
+		return errorcode.Detail(errorcode.PublicDatabaseError, err)
 	}
 
-	if process.ProcDefKey != "" {
-		//检查 ProcDefKey 是否正确
-		res, err := d.workflowRestRepo.ProcessDefinitionGet(c, process.ProcDefKey)
-		if err != nil {
-			return errorcode.Desc(errorcode.AuditProcessNotExist)
-		}
+	uid := util.GetUser(c).Id
+	if origin.UID != uid || origin.ApplyDays == 0 ||
+		(origin.AuditStatus != enum.AuditStatusPass && origin.AuditStatus != enum.AuditStatusExpired) {
+		return errorcode.Desc(errorcode.ServiceApplyRenewNotAllowed)
+	}
 
-		if res.Type != process.AuditType {
-			return errorcode.Desc(errorcode.AuditProcessNotExist)
-		}
+	//检查接口是否正在审核中
+	exist, err = d.applyRepo.IsAuditing(c, origin.ServiceID, uid)
+	if err != nil {
+		return errorcode.Desc(errorcode.PublicDatabaseError)
+	}
+	if exist {
+		return errorcode.Desc(errorcode.ServiceApplyAuditingExist)
+	}
 
-		if res.Key != process.ProcDefKey {
-			return errorcode.Desc(errorcode.AuditProcessNotExist)
-		}
+	procDefKey, err := d.requestProcDefKey(c)
+	if err != nil {
+		return err
 	}
 
 	apply := &model.ServiceApply{
 		ID:          uint64(util.GetUniqueID()),
-		UID:         util.GetUser(c).Id,
-		ServiceID:   req.ServiceID,
+		UID:         uid,
+		ServiceID:   origin.ServiceID,
 		ApplyDays:   *req.ApplyDays,
 		ApplyReason: req.ApplyReason,
 		ApplyID:     util.GetUniqueString(),
 		AuditType:   enum.AuditTypeRequest,
 		AuditStatus: enum.AuditStatusAuditing,
-		ProcDefKey:  process.ProcDefKey,
+		ProcDefKey:  procDefKey,
 	}
 
 	err = d.applyRepo.Create(c, apply)
@@ -194,12 +232,38 @@ func (d *ServiceApplyDomain) ServiceApplyCreate(c context.Context, req *dto.Serv
 		return errorcode.Desc(errorcode.PublicDatabaseError)
 	}
 
-	//增加申请量
-	d.statsRepo.IncrApplyNum(c, req.ServiceID)
-
 	return nil
 }
 
+// requestProcDefKey 返回接口调用申请绑定的审核流程
+func (d *ServiceApplyDomain) requestProcDefKey(c context.Context) (string, error) {
+	//检查是否有绑定的审核流程
+	process, err := d.auditProcessBindRepo.GetByAuditType(c, enum.AuditTypeRequest)
+	if err != nil {
+		return "", errorcode.Detail(errorcode.PublicDatabaseError, err)
+	}
+
+	if process.ProcDefKey == "" {
+		return "", errorcode.Desc(errorcode.AuditProcessNotExist)
+	}
+
+	//检查 ProcDefKey 是否正确
+	res, err := d.workflowRestRepo.ProcessDefinitionGet(c, process.ProcDefKey)
+	if err != nil {
+		return "", errorcode.Desc(errorcode.AuditProcessNotExist)
+	}
+
+	if res.Type != process.AuditType {
+		return "", errorcode.Desc(errorcode.AuditProcessNotExist)
+	}
+
+	if res.Key != process.ProcDefKey {
+		return "", errorcode.Desc(errorcode.AuditProcessNotExist)
+	}
+
+	return process.ProcDefKey, nil
+}
+
 func (d *ServiceApplyDomain) GetOwnerAuditors(c context.Context, req *dto.GetOwnerAuditorsReq) (res *dto.GetOwnerAuditorsRes, err error) {
 	serviceApplyAssociations, err := d.applyRepo.Get(c, req.ApplyId)
 	if err != nil {
@@ -0,0 +1,175 @@
+package domain
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driven/gorm"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/util"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+const (
+	// applyExpireInterval 检查过期申请的间隔
+	applyExpireInterval = time.Hour
+	// applyExpireNotifyBefore 过期前多久提醒接口的数据 owner 和申请人
+	applyExpireNotifyBefore = 7 * 24 * time.Hour
+)
+
+// ServiceApplyExpireDomain 限时授权的过期处理：标记已过期的申请，提醒即将过期的授权
+type ServiceApplyExpireDomain struct {
+	applyRepo gorm.ServiceApplyRepo
+	stopChan  chan struct{} // 停止信号
+	isRunning bool          // 运行状态
+	mu        sync.RWMutex  // 保护状态变量
+}
+
+func NewServiceApplyExpireDomain(applyRepo gorm.ServiceApplyRepo) *ServiceApplyExpireDomain {
+	return &ServiceApplyExpireDomain{
+		applyRepo: applyRepo,
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// StartApplyExpireJob 启动定时任务，启动时执行一次，之后每小时执行一次
+func (d *ServiceApplyExpireDomain) StartApplyExpireJob() {
+	d.mu.Lock()
+	if d.isRunning {
+		d.mu.Unlock()
+		log.Warn("StartApplyExpireJob 已经在运行中")
+		return
+	}
+	d.isRunning = true
+	d.stopChan = make(chan struct{})
+	d.mu.Unlock()
+
+	log.Info("StartApplyExpireJob 定时任务已启动")
+	d.execute()
+
+	go d.runScheduledJob()
+}
+
+// runScheduledJob 运行定时任务的核心逻辑
+func (d *ServiceApplyExpireDomain) runScheduledJob() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("StartApplyExpireJob panic recovered", zap.Any("panic", r))
+		}
+
+		d.mu.Lock()
+		d.isRunning = false
+		d.mu.Unlock()
+
+		log.Info("StartApplyExpireJob goroutine已退出")
+	}()
+
+	ticker := time.NewTicker(applyExpireInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			d.execute()
+		case <-d.stopChan:
+			log.Info("StartApplyExpireJob 收到停止信号，退出循环")
+			return
+		}
+	}
+}
+
+// StopApplyExpireJob 停止定时任务
+func (d *ServiceApplyExpireDomain) StopApplyExpireJob() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isRunning {
+		close(d.stopChan)
+		d.isRunning = false
+		log.Info("StartApplyExpireJob 已停止")
+	} else {
+		log.Info("StartApplyExpireJob 当前未在运行")
+	}
+}
+
+// IsRunning 检查定时任务是否正在运行
+func (d *ServiceApplyExpireDomain) IsRunning() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.isRunning
+}
+
+func (d *ServiceApplyExpireDomain) execute() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	now := time.Now()
+	if err := d.expire(ctx, now); err != nil {
+		log.WithContext(ctx).Error("StartApplyExpireJob 处理过期申请失败", zap.Error(err))
+	}
+	if err := d.notify(ctx, now); err != nil {
+		log.WithContext(ctx).Error("StartApplyExpireJob 提醒即将过期的申请失败", zap.Error(err))
+	}
+}
+
+// expire 将已过期的申请标记为已过期。授权策略带有过期时间，由 auth-service 在过期后拒绝访问，这里不撤销策略，
+// 避免撤销同一用户对接口续期后仍然有效的授权。多个实例同时执行时只有一个能标记成功
+func (d *ServiceApplyExpireDomain) expire(ctx context.Context, now time.Time) error {
+	applies, err := d.applyRepo.ListExpired(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, apply := range applies {
+		marked, err := d.applyRepo.MarkExpired(ctx, apply.ApplyID)
+		if err != nil {
+			return err
+		}
+		if marked {
+			log.WithContext(ctx).Info("StartApplyExpireJob 申请已过期", zap.String("apply_id", apply.ApplyID))
+		}
+	}
+
+	return nil
+}
+
+// notify 提醒接口的数据 owner 和申请人授权即将过期，每个申请只提醒一次，发送失败时下次执行时重试
+func (d *ServiceApplyExpireDomain) notify(ctx context.Context, now time.Time) error {
+	applies, err := d.applyRepo.ListExpiring(ctx, now, now.Add(applyExpireNotifyBefore))
+	if err != nil {
+		return err
+	}
+
+	for _, apply := range applies {
+		msg := &dto.ServiceApplyExpiringMsg{
+			ApplyId:     apply.ApplyID,
+			ServiceID:   apply.ServiceID,
+			ServiceName: apply.Service.ServiceName,
+			ApplicantId: apply.UID,
+			OwnerId:     apply.Service.OwnerID,
+			ExpiredTime: util.TimeFormat(apply.ExpiredTime),
+			Receivers:   lo.Uniq(lo.Compact([]string{apply.UID, apply.Service.OwnerID})),
+		}
+		// 先标记再发送，多个实例同时执行时只有标记成功的实例发送提醒
+		marked, err := d.applyRepo.MarkNotified(ctx, apply.ApplyID, now)
+		if err != nil {
+			return err
+		}
+		if !marked {
+			continue
+		}
+
+		if err := d.applyRepo.ProduceApplyExpiring(ctx, msg); err != nil {
+			log.WithContext(ctx).Error("StartApplyExpireJob 发送过期提醒失败", zap.Error(err), zap.String("apply_id", apply.ApplyID))
+			if err := d.applyRepo.UnmarkNotified(ctx, apply.ApplyID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,504 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driven/gorm"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driven/microservice"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/enum"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/form_validator"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/util"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/util/service_bundle"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/domain/sub_service"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/domain/sub_service/validation"
+	"github.com/kweaver-ai/idrm-go-common/util/sets"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+// ServiceBundleDomain 接口的导出、导入，用于在不同环境之间迁移接口定义
+type ServiceBundleDomain struct {
+	serviceDomain           *ServiceDomain
+	serviceRepo             gorm.ServiceRepo
+	subServiceRepo          gorm.SubServiceRepo
+	subServiceUseCase       sub_service.UseCase
+	dataViewRepo            microservice.DataViewRepo
+	configurationCenterRepo microservice.ConfigurationCenterRepo
+}
+
+func NewServiceBundleDomain(
+	serviceDomain *ServiceDomain,
+	serviceRepo gorm.ServiceRepo,
+	subServiceRepo gorm.SubServiceRepo,
+	subServiceUseCase sub_service.UseCase,
+	dataViewRepo microservice.DataViewRepo,
+	configurationCenterRepo microservice.ConfigurationCenterRepo,
+) *ServiceBundleDomain {
+	return &ServiceBundleDomain{
+		serviceDomain:           serviceDomain,
+		serviceRepo:             serviceRepo,
+		subServiceRepo:          subServiceRepo,
+		subServiceUseCase:       subServiceUseCase,
+		dataViewRepo:            dataViewRepo,
+		configurationCenterRepo: configurationCenterRepo,
+	}
+}
+
+// Export 导出接口为导出包，ServiceIDs 为列表返回的接口ID，导出的是已发布或未发布的当前版本
+func (d *ServiceBundleDomain) Export(ctx context.Context, req *dto.ServiceBundleExportReq) (*dto.ServiceBundleExportRes, error) {
+	now := time.Now()
+	bundle := &dto.ServiceBundle{
+		BundleVersion: dto.ServiceBundleVersion,
+		ExportTime:    util.TimeFormat(&now),
+	}
+
+	departmentPaths := make(map[string]string)
+	subjectDomainPaths := make(map[string]string)
+	var firms map[string]string
+	dataViewIDs := make(map[int]string)
+	for _, serviceID := range lo.Uniq(req.ServiceIDs) {
+		exist, err := d.serviceRepo.IsServiceIDExist(ctx, serviceID)
+		if err != nil {
+			return nil, err
+		}
+		if !exist {
+			return nil, errorcode.Desc(errorcode.ServiceIDNotExist)
+		}
+
+		detail, err := d.serviceRepo.ServiceGet(ctx, serviceID)
+		if err != nil {
+			return nil, err
+		}
+		subServices, _, err := d.subServiceRepo.List(ctx, gorm.ListOptions{ServiceID: uuid.MustParse(serviceID)})
+		if err != nil {
+			return nil, err
+		}
+		item := service_bundle.NewService(detail, subServices)
+
+		if departmentID := detail.ServiceInfo.Department.ID; departmentID != "" {
+			if _, ok := departmentPaths[departmentID]; !ok {
+				department, err := d.configurationCenterRepo.DepartmentGet(ctx, departmentID)
+				if err != nil {
+					return nil, err
+				}
+				departmentPaths[departmentID] = department.Path
+			}
+			item.References.Department = &dto.ServiceBundleDepartmentKey{Path: departmentPaths[departmentID]}
+		}
+		if subjectDomainID := detail.ServiceInfo.SubjectDomainId; subjectDomainID != "" {
+			if _, ok := subjectDomainPaths[subjectDomainID]; !ok {
+				subjectDomain, err := d.serviceDomain.DataSubjectRepo.DataSubjectGet(ctx, subjectDomainID)
+				if err != nil {
+					return nil, err
+				}
+				subjectDomainPaths[subjectDomainID] = subjectDomain.PathName
+			}
+			item.References.SubjectDomain = &dto.ServiceBundleSubjectDomainKey{Path: subjectDomainPaths[subjectDomainID]}
+		}
+		if developerID := detail.ServiceInfo.Developer.ID; developerID != "" {
+			if firms == nil {
+				res, err := d.configurationCenterRepo.FirmList(ctx)
+				if err != nil {
+					return nil, err
+				}
+				firms = make(map[string]string, len(res.Entries))
+				for _, f := range res.Entries {
+					firms[f.ID] = f.Name
+				}
+			}
+			if name, ok := firms[developerID]; ok {
+				item.References.Developer = &dto.ServiceBundleDeveloperKey{Name: name}
+			}
+		}
+		if dataViewID := detail.ServiceParam.DataViewId; dataViewID != "" {
+			dataViewIDs[len(bundle.Services)] = dataViewID
+		}
+		bundle.Services = append(bundle.Services, *item)
+	}
+
+	if len(dataViewIDs) > 0 {
+		dataViews, err := d.dataViewRepo.DataViewList(ctx, lo.Uniq(lo.Values(dataViewIDs)))
+		if err != nil {
+			return nil, err
+		}
+		for i, id := range dataViewIDs {
+			for _, v := range dataViews.Entries {
+				if v.Id == id {
+					bundle.Services[i].References.DataView = &dto.ServiceBundleDataViewKey{DatasourceName: v.Datasource, TechnicalName: v.TechnicalName}
+					break
+				}
+			}
+		}
+	}
+
+	content, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, errorcode.Detail(errorcode.PublicInternalError, err)
+	}
+	res := &dto.ServiceBundleExportRes{
+		Buffer:      content,
+		FileName:    "services_" + now.Format("20060102150405") + ".json",
+		ContentType: "application/json",
+	}
+	if req.Format == "yaml" {
+		if res.Buffer, err = util.JSONToYAML(content); err != nil {
+			return nil, errorcode.Detail(errorcode.PublicInternalError, err)
+		}
+		res.FileName = strings.TrimSuffix(res.FileName, ".json") + ".yaml"
+		res.ContentType = "application/yaml"
+	}
+	return res, nil
+}
+
+// Import 导入导出包中的接口。每个接口单独校验和创建，一个接口失败不影响其他接口。
+// 预检查时只校验，不创建接口；名称、路径冲突按 ConflictStrategy 处理
+func (d *ServiceBundleDomain) Import(ctx context.Context, req *dto.ServiceBundleImportReq) (*dto.ServiceBundleImportRes, error) {
+	bundle, err := readServiceBundle(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &dto.ServiceBundleImportRes{
+		DryRun:     req.DryRun,
+		TotalCount: len(bundle.Services),
+		Entries:    make([]*dto.ServiceBundleImportEntry, 0, len(bundle.Services)),
+	}
+	im := &serviceBundleImporter{
+		ServiceBundleDomain: d,
+		req:                 req,
+		names:               sets.New[string](),
+		paths:               sets.New[string](),
+	}
+	for i := range bundle.Services {
+		entry := im.importService(ctx, i, &bundle.Services[i])
+		switch entry.Result {
+		case dto.ServiceBundleImportValid:
+			res.ValidCount++
+		case dto.ServiceBundleImportCreated:
+			res.CreateCount++
+		case dto.ServiceBundleImportSkipped:
+			res.SkipCount++
+		case dto.ServiceBundleImportFailed:
+			res.FailCount++
+		}
+		res.Entries = append(res.Entries, entry)
+	}
+	return res, nil
+}
+
+// readServiceBundle 读取导出包文件，根据扩展名解析 json 或 yaml
+func readServiceBundle(req *dto.ServiceBundleImportReq) (*dto.ServiceBundle, error) {
+	f, err := req.File.Open()
+	if err != nil {
+		return nil, errorcode.Detail(errorcode.ServiceBundleInvalid, err)
+	}
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, errorcode.Detail(errorcode.ServiceBundleInvalid, err)
+	}
+	return service_bundle.Parse(path.Ext(req.File.Filename), content)
+}
+
+// serviceBundleImporter 一次导入的状态，names、paths 记录本次导入中前面的接口已使用的名称和路径，
+// departments 等记录已查询的目标环境中的引用
+type serviceBundleImporter struct {
+	*ServiceBundleDomain
+	req         *dto.ServiceBundleImportReq
+	names       sets.Set[string]
+	paths       sets.Set[string]
+	departments map[string]string
+	// 主题域路径、开发商名称到ID的映射，第一次解析时查询
+	subjectDomains map[string]string
+	developers     map[string]string
+}
+
+func (im *serviceBundleImporter) importService(ctx context.Context, index int, item *dto.ServiceBundleService) *dto.ServiceBundleImportEntry {
+	entry := &dto.ServiceBundleImportEntry{
+		Index:       index,
+		ServiceName: item.ServiceInfo.ServiceName,
+		ServicePath: item.ServiceInfo.ServicePath,
+	}
+	fail := func(err error) *dto.ServiceBundleImportEntry {
+		entry.Result, entry.Message = dto.ServiceBundleImportFailed, err.Error()
+		return entry
+	}
+
+	// 导出包中没有密钥的值，信息系统等引用也无法解析，需要导入后重新填写，此时不能提交发布审核
+	entry.SecretHeaders = service_bundle.DropUnsetSecretHeaders(item.ServiceInfo.RequestMapping)
+	entry.References = service_bundle.DropUnresolvedReferences(item)
+	publish := im.req.AutoPublish && len(entry.SecretHeaders) == 0 && len(entry.References) == 0
+
+	createReq, err := im.createReq(ctx, item, publish)
+	if err != nil {
+		return fail(err)
+	}
+	if err = service_bundle.CheckSubServices(item.SubServices); err != nil {
+		return fail(err)
+	}
+	if err = validateServiceBundleSubServices(item.SubServices); err != nil {
+		return fail(err)
+	}
+
+	// 名称、路径冲突
+	info := &createReq.ServiceInfo
+	skipped, err := service_bundle.ResolveConflict(ctx, im.req.ConflictStrategy, info, entry, im.nameConflict, im.pathConflict)
+	if err != nil {
+		return fail(err)
+	}
+	if skipped {
+		return entry
+	}
+	im.names.Insert(info.ServiceName)
+	im.paths.Insert(info.ServicePath)
+
+	if im.req.DryRun {
+		entry.Result = dto.ServiceBundleImportValid
+		return entry
+	}
+
+	created, err := im.serviceDomain.ServiceCreate(ctx, createReq)
+	if err != nil {
+		return fail(err)
+	}
+	if err = im.createSubServices(ctx, created.ServiceID, item.SubServices); err != nil {
+		// 子接口创建失败时删除已创建的接口，避免留下不完整的接口
+		if delErr := im.serviceRepo.ServiceDelete(ctx, created.ServiceID); delErr != nil {
+			log.WithContext(ctx).Error("ServiceBundle Import ServiceDelete", zap.String("serviceID", created.ServiceID), zap.Error(delErr))
+		}
+		return fail(err)
+	}
+	entry.Result, entry.ServiceID = dto.ServiceBundleImportCreated, created.ServiceID
+
+	if im.req.AutoPublish && !publish {
+		var unset []string
+		if len(entry.SecretHeaders) > 0 {
+			unset = append(unset, "密钥请求头")
+		}
+		if len(entry.References) > 0 {
+			unset = append(unset, "关联的信息系统、应用、数据owner 或类目")
+		}
+		entry.Message = strings.Join(unset, "和") + "需要重新填写，未提交发布审核"
+	}
+	if publish {
+		err = im.serviceDomain.AuditProcessInstanceCreate(ctx, &dto.AuditProcessInstanceCreateReq{
+			ServiceID: created.ServiceID,
+			AuditType: enum.AuditTypePublish,
+		})
+		if err != nil {
+			entry.Message = "提交发布审核失败：" + err.Error()
+		}
+	}
+	return entry
+}
+
+// createReq 把导出包中的接口转换为创建请求，解析部门、数据视图、主题域和开发商的引用，并做与创建接口相同的参数校验
+func (im *serviceBundleImporter) createReq(ctx context.Context, item *dto.ServiceBundleService, publish bool) (*dto.ServiceCreateOrTempReq, error) {
+	req := &dto.ServiceCreateOrTempReq{
+		// 不提交发布审核时暂存，与页面上的暂存一致
+		IsTemp:       !publish,
+		ServiceInfo:  item.ServiceInfo,
+		CategoryInfo: item.CategoryInfo,
+		ServiceParam: item.ServiceParam,
+		ServiceTest:  item.ServiceTest,
+	}
+	if item.ServiceResponse != nil {
+		req.ServiceResponse = *item.ServiceResponse
+	}
+	req.ServiceInfo.CategoryInfo = item.CategoryInfo
+
+	if ref := item.References.Department; ref != nil && ref.Path != "" {
+		id, err := im.departmentID(ctx, ref.Path)
+		if err != nil {
+			return nil, err
+		}
+		req.ServiceInfo.Department.ID = id
+	}
+	if ref := item.References.DataView; ref != nil && ref.TechnicalName != "" {
+		id, err := im.dataViewID(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		req.ServiceParam.DataViewId = id
+	}
+	// 导出包中的ID来自其他环境，只使用解析出的ID
+	req.ServiceInfo.SubjectDomainId, req.ServiceInfo.SubjectDomainName = "", ""
+	if ref := item.References.SubjectDomain; ref != nil && ref.Path != "" {
+		id, err := im.subjectDomainID(ctx, ref.Path)
+		if err != nil {
+			return nil, err
+		}
+		req.ServiceInfo.SubjectDomainId = id
+	}
+	req.ServiceInfo.Developer = dto.Developer{}
+	if ref := item.References.Developer; ref != nil && ref.Name != "" {
+		id, err := im.developerID(ctx, ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		req.ServiceInfo.Developer = dto.Developer{ID: id, Name: ref.Name}
+	}
+
+	if _, err := form_validator.BindStructAndValid(req); err != nil {
+		return nil, err
+	}
+	if !req.IsTemp {
+		if err := im.serviceDomain.serviceCheckParam(ctx, req.ServiceInfo, req.ServiceParam); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// departmentID 根据部门路径查找目标环境中的部门ID
+func (im *serviceBundleImporter) departmentID(ctx context.Context, departmentPath string) (string, error) {
+	if im.departments == nil {
+		res, err := im.configurationCenterRepo.SubDepartmentGet(ctx, "")
+		if err != nil {
+			return "", err
+		}
+		im.departments = make(map[string]string, len(res.Entries))
+		for _, e := range res.Entries {
+			im.departments[e.Path] = e.Id
+		}
+	}
+	id, ok := im.departments[departmentPath]
+	if !ok {
+		return "", fmt.Errorf("部门%s不存在", departmentPath)
+	}
+	return id, nil
+}
+
+// subjectDomainID 根据主题域路径查找目标环境中的主题域ID
+func (im *serviceBundleImporter) subjectDomainID(ctx context.Context, subjectDomainPath string) (string, error) {
+	if im.subjectDomains == nil {
+		res, err := im.serviceDomain.DataSubjectRepo.DataSubjectList(ctx, "", "")
+		if err != nil {
+			return "", err
+		}
+		im.subjectDomains = make(map[string]string, len(res.Entries))
+		for _, e := range res.Entries {
+			im.subjectDomains[e.PathName] = e.Id
+		}
+	}
+	id, ok := im.subjectDomains[subjectDomainPath]
+	if !ok {
+		return "", fmt.Errorf("主题域%s不存在", subjectDomainPath)
+	}
+	return id, nil
+}
+
+// developerID 根据开发商名称查找目标环境中的开发商ID
+func (im *serviceBundleImporter) developerID(ctx context.Context, name string) (string, error) {
+	if im.developers == nil {
+		res, err := im.configurationCenterRepo.FirmList(ctx)
+		if err != nil {
+			return "", err
+		}
+		im.developers = make(map[string]string, len(res.Entries))
+		for _, f := range res.Entries {
+			im.developers[f.Name] = f.ID
+		}
+	}
+	id, ok := im.developers[name]
+	if !ok {
+		return "", fmt.Errorf("开发商%s不存在", name)
+	}
+	return id, nil
+}
+
+// dataViewID 根据数据源名称和技术名称查找目标环境中的数据视图ID
+func (im *serviceBundleImporter) dataViewID(ctx context.Context, key *dto.ServiceBundleDataViewKey) (string, error) {
+	res, err := im.dataViewRepo.DataViewSearch(ctx, key.TechnicalName)
+	if err != nil {
+		return "", err
+	}
+	for _, v := range res.Entries {
+		if v.TechnicalName == key.TechnicalName && v.Datasource == key.DatasourceName {
+			return v.Id, nil
+		}
+	}
+	return "", fmt.Errorf("数据源%s中的数据视图%s不存在", key.DatasourceName, key.TechnicalName)
+}
+
+// nameConflict 返回接口名称与已有接口或本次导入中前面的接口冲突的原因，不冲突时返回空
+func (im *serviceBundleImporter) nameConflict(ctx context.Context, name string) (string, error) {
+	if im.names.Has(name) {
+		return errorcode.Desc(errorcode.ServiceNameExist).Error(), nil
+	}
+	exist, err := im.serviceRepo.IsServiceNameExist(ctx, name, "")
+	if err != nil || !exist {
+		return "", err
+	}
+	return errorcode.Desc(errorcode.ServiceNameExist).Error(), nil
+}
+
+// pathConflict 返回接口路径与已有接口或本次导入中前面的接口冲突的原因，包括带路径变量的路径冲突，不冲突时返回空
+func (im *serviceBundleImporter) pathConflict(ctx context.Context, servicePath string) (string, error) {
+	if im.paths.Has(servicePath) {
+		return errorcode.Desc(errorcode.ServicePathExist).Error(), nil
+	}
+	exist, err := im.serviceRepo.IsServicePathExist(ctx, servicePath, "")
+	if err != nil {
+		return "", err
+	}
+	if exist {
+		return errorcode.Desc(errorcode.ServicePathExist).Error(), nil
+	}
+	conflict, err := im.serviceRepo.ServicePathConflict(ctx, servicePath, "")
+	if err != nil || conflict == "" {
+		return "", err
+	}
+	return errorcode.Desc(errorcode.ServicePathConflict, conflict).Error(), nil
+}
+
+// validateServiceBundleSubServices 按创建子接口的规则校验导出包中的子接口，行列规则必须能解析，
+// 否则生成的行过滤器子句为空，子接口不再限定数据范围。预检查时接口还未创建，使用临时的接口ID校验
+func validateServiceBundleSubServices(subServices []dto.ServiceBundleSubService) error {
+	serviceID := uuid.New()
+	for _, s := range subServices {
+		subService := &sub_service.SubService{Name: s.Name, ServiceID: serviceID, AuthScopeID: serviceID, Detail: s.Detail}
+		if allErrs := validation.ValidateSubServiceCreate(subService); allErrs != nil {
+			return errorcode.Detail(errorcode.PublicInvalidParameter, form_validator.CreateValidErrorsFromFieldErrorList(allErrs))
+		}
+		if err := json.Unmarshal([]byte(s.Detail), &sub_service.SubServiceDetail{}); err != nil {
+			return fmt.Errorf("子接口%s的行列规则无效：%w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// createSubServices 按顺序创建子接口，授权范围的子接口已在前面创建。
+// 通过子接口的用例创建，与页面上创建子接口一样校验并根据行列规则生成行过滤器子句
+func (im *serviceBundleImporter) createSubServices(ctx context.Context, serviceID string, subServices []dto.ServiceBundleSubService) error {
+	id := uuid.MustParse(serviceID)
+	scopes := make(map[string]uuid.UUID, len(subServices))
+	for _, s := range subServices {
+		scope := id
+		if s.AuthScope != "" {
+			scope = scopes[s.AuthScope]
+		}
+		created, err := im.subServiceUseCase.Create(ctx, &sub_service.SubService{
+			Name:        s.Name,
+			ServiceID:   id,
+			AuthScopeID: scope,
+			Detail:      s.Detail,
+		}, true)
+		if err != nil {
+			return err
+		}
+		scopes[s.Name] = created.ID
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/samber/lo"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/enum"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/util"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/infrastructure/repository/db/model"
+)
+
+// ServiceLifecycleTimeline 接口的生命周期时间线，serviceID 为列表返回的接口ID
+func (u *ServiceDomain) ServiceLifecycleTimeline(ctx context.Context, serviceID string) (*dto.ServiceLifecycleRes, error) {
+	exist, err := u.serviceRepo.IsServiceIDExist(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, errorcode.Desc(errorcode.ServiceIDNotExist)
+	}
+
+	state, err := u.lifecycleState(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	histories, err := u.serviceRepo.ListLifecycleHistory(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ServiceLifecycleRes{
+		ServiceID:     serviceID,
+		PublishStatus: state.PublishStatus,
+		Status:        state.Status,
+		Entries: lo.Map(histories, func(h *model.ServiceLifecycleHistory, _ int) *dto.ServiceLifecycleRecord {
+			return &dto.ServiceLifecycleRecord{
+				Trigger:           h.TriggerType,
+				FromPublishStatus: h.FromPublishStatus,
+				FromStatus:        h.FromStatus,
+				ToPublishStatus:   h.ToPublishStatus,
+				ToStatus:          h.ToStatus,
+				ApplyID:           h.ApplyID,
+				FlowID:            h.FlowID,
+				OperatorID:        h.OperatorID,
+				OperatorName:      h.OperatorName,
+				CreateTime:        util.TimeFormat(&h.CreateTime),
+			}
+		}),
+	}, nil
+}
+
+// lifecycleState 返回接口的生命周期状态，serviceID 为列表返回的接口ID。
+// 变更审核中、变更审核未通过时列表返回已发布版本的ID，此时发布状态取变更版本的发布状态
+func (u *ServiceDomain) lifecycleState(ctx context.Context, serviceID string) (enum.LifecycleState, error) {
+	service, err := u.serviceRepo.ServiceGetFields(ctx, serviceID, []string{"publish_status", "status", "is_changed"})
+	if err != nil {
+		return enum.LifecycleState{}, err
+	}
+	state := enum.NewLifecycleState(service.PublishStatus, service.Status)
+	if service.PublishStatus != enum.PublishStatusPublished || service.IsChanged != "1" {
+		return state, nil
+	}
+
+	sid, err := u.serviceRepo.GetIdByPublishedId(ctx, serviceID)
+	if err != nil || sid == "" {
+		return state, err
+	}
+	changed, err := u.serviceRepo.ServiceGetFields(ctx, sid, []string{"publish_status"})
+	if err != nil {
+		return enum.LifecycleState{}, err
+	}
+	// 暂存的草稿不是生命周期中的状态
+	if changed.PublishStatus == enum.PublishStatusChangeAuditing || changed.PublishStatus == enum.PublishStatusChangeReject {
+		state.PublishStatus = changed.PublishStatus
+	}
+	return state, nil
+}
+
+// pendingSubmitLifecycleState 返回提交审核前接口的生命周期状态。保存并提交的接口在创建、编辑时已标记为发布审核中，
+// 但还没有发起审核，此时视为未发布
+func pendingSubmitLifecycleState(publishStatus, status, auditStatus string) enum.LifecycleState {
+	state := enum.NewLifecycleState(publishStatus, status)
+	if state.PublishStatus == enum.PublishStatusPubAuditing && auditStatus != enum.AuditStatusAuditing {
+		state.PublishStatus = enum.PublishStatusUnPublished
+	}
+	return state
+}
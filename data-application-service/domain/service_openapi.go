@@ -0,0 +1,194 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driven/gorm"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driven/microservice"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/enum"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/settings"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/util"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+// 汇总文档分页查询接口时每页的数量
+const serviceOpenAPIPageSize = 100
+
+// ServiceOpenAPIDomain 生成已发布接口的 OpenAPI 3.0 文档，供调用方通过网关调用接口
+type ServiceOpenAPIDomain struct {
+	serviceDomain           *ServiceDomain
+	serviceRepo             gorm.ServiceRepo
+	configurationCenterRepo microservice.ConfigurationCenterRepo
+	dataSubjectRepo         microservice.DataSubjectRepo
+	deployMgmRepo           microservice.DrivenDeployMgm
+	versionRepo             gorm.ServiceVersionRepo
+}
+
+func NewServiceOpenAPIDomain(
+	serviceDomain *ServiceDomain,
+	serviceRepo gorm.ServiceRepo,
+	configurationCenterRepo microservice.ConfigurationCenterRepo,
+	dataSubjectRepo microservice.DataSubjectRepo,
+	deployMgmRepo microservice.DrivenDeployMgm,
+	versionRepo gorm.ServiceVersionRepo,
+) *ServiceOpenAPIDomain {
+	return &ServiceOpenAPIDomain{
+		serviceDomain:           serviceDomain,
+		serviceRepo:             serviceRepo,
+		configurationCenterRepo: configurationCenterRepo,
+		dataSubjectRepo:         dataSubjectRepo,
+		deployMgmRepo:           deployMgmRepo,
+		versionRepo:             versionRepo,
+	}
+}
+
+// ServiceOpenAPI 返回一个已发布接口的 OpenAPI 文档
+func (d *ServiceOpenAPIDomain) ServiceOpenAPI(ctx context.Context, serviceID string) (*dto.OpenAPI, error) {
+	exist, err := d.serviceRepo.IsServiceIDExist(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, errorcode.Desc(errorcode.ServiceIDNotExist)
+	}
+
+	service, err := d.serviceRepo.ServiceGet(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if !enum.IsConsideredAsPublished(service.ServiceInfo.PublishStatus) {
+		return nil, errorcode.Desc(errorcode.ServiceOpenAPIUnpublished)
+	}
+
+	version, err := d.serviceVersion(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	info := dto.OpenAPIInfo{
+		Title:       service.ServiceInfo.ServiceName,
+		Description: service.ServiceInfo.Description,
+		Version:     strconv.Itoa(version),
+	}
+	return d.newOpenAPI(ctx, info, service.ServiceInfo.AppsId, service)
+}
+
+// ServiceOpenAPIList 返回部门或主题域下所有已发布接口的 OpenAPI 文档，包括子部门、子主题域的接口
+func (d *ServiceOpenAPIDomain) ServiceOpenAPIList(ctx context.Context, req *dto.ServiceOpenAPIListReq) (*dto.OpenAPI, error) {
+	info := dto.OpenAPIInfo{}
+	if req.DepartmentID != "" {
+		department, err := d.configurationCenterRepo.DepartmentGet(ctx, req.DepartmentID)
+		if err != nil {
+			return nil, err
+		}
+		info.Title, info.Description = department.Name, fmt.Sprintf("部门 %s 的接口", department.Path)
+	} else {
+		subject, err := d.dataSubjectRepo.DataSubjectGet(ctx, req.SubjectDomainId)
+		if err != nil {
+			return nil, err
+		}
+		info.Title, info.Description = subject.Name, fmt.Sprintf("主题域 %s 的接口", subject.PathName)
+	}
+
+	listReq := &dto.ServiceListReq{
+		Offset:                   1,
+		Limit:                    serviceOpenAPIPageSize,
+		Sort:                     "name",
+		Direction:                "asc",
+		DepartmentID:             req.DepartmentID,
+		SubjectDomainId:          req.SubjectDomainId,
+		IsAll:                    "true",
+		PublishAndOnlineStatuses: strings.Join(enum.ConsideredAsPublishedStatuses, ","),
+	}
+	var services []*dto.ServiceGetRes
+	// 文档版本为接口版本号之和，任一接口发布新版本后文档版本随之变化
+	var revision int
+	for {
+		entries, count, err := d.serviceRepo.ServiceList(ctx, listReq)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			service, err := d.serviceRepo.ServiceGet(ctx, e.ServiceID)
+			if err != nil {
+				return nil, err
+			}
+			services = append(services, service)
+			version, err := d.serviceVersion(ctx, e.ServiceID)
+			if err != nil {
+				return nil, err
+			}
+			revision += version
+		}
+		if len(entries) < serviceOpenAPIPageSize || int64(listReq.Offset*serviceOpenAPIPageSize) >= count {
+			break
+		}
+		listReq.Offset++
+	}
+	info.Version = strconv.Itoa(revision)
+
+	return d.newOpenAPI(ctx, info, "", services...)
+}
+
+// newOpenAPI 生成包含指定接口的文档，appsID 为长沙数据局项目中网关地址的应用ID的默认值
+func (d *ServiceOpenAPIDomain) newOpenAPI(ctx context.Context, info dto.OpenAPIInfo, appsID string, services ...*dto.ServiceGetRes) (*dto.OpenAPI, error) {
+	cssjj, err := d.serviceDomain.IsCSSJJ(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var server dto.OpenAPIServer
+	if cssjj {
+		if appsID == "" {
+			appsID = "x-tif-paasid"
+		}
+		// https://smartgate.changsha.gov.cn/ebus/{发布服务的应用ID}/data-application-gateway/{AF接口路径}
+		server = dto.OpenAPIServer{
+			URL:       "https://smartgate.changsha.gov.cn/ebus/{paasid}/data-application-gateway",
+			Variables: map[string]dto.OpenAPIServerVariable{"paasid": {Default: appsID, Description: "发布接口的应用ID"}},
+		}
+	} else {
+		getHostRes, err := d.deployMgmRepo.GetHost(ctx)
+		if err != nil {
+			log.Info("deployMgm GetHost error", zap.Error(err))
+			return nil, err
+		}
+		server = dto.OpenAPIServer{URL: fmt.Sprintf("%s://%s:%s/data-application-gateway", getHostRes.Scheme, getHostRes.Host, getHostRes.Port)}
+	}
+
+	// 文档的认证方式与网关一致，只有一个接口时使用该接口的认证方式
+	scheme, docPath := settings.AuthSchemeDefault, ""
+	if cssjj {
+		scheme = settings.AuthSchemeCssjj
+	}
+	if len(services) == 1 {
+		docPath = services[0].ServiceInfo.ServicePath
+	}
+	auth := settings.Instance.GatewayAuth
+	doc := util.NewOpenAPI(info, server, auth.AuthModes(scheme, docPath))
+	for _, service := range services {
+		if err := util.AddServiceToOpenAPI(doc, service, auth.AuthModes(scheme, service.ServiceInfo.ServicePath)); err != nil {
+			log.WithContext(ctx).Error("AddServiceToOpenAPI failed", zap.String("service_id", service.ServiceInfo.ServiceID), zap.Error(err))
+			return nil, errorcode.Detail(errorcode.ServiceOpenAPIGenerateError, err.Error())
+		}
+	}
+	return doc, nil
+}
+
+// serviceVersion 返回已发布接口的最新版本号，版本历史记录之前发布的接口没有版本，为 1
+func (d *ServiceOpenAPIDomain) serviceVersion(ctx context.Context, serviceID string) (int, error) {
+	versions, _, err := d.versionRepo.List(ctx, serviceID, 1, 1)
+	if err != nil {
+		return 0, err
+	}
+	if len(versions) == 0 {
+		return 1, nil
+	}
+	return versions[0].Version, nil
+}
@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/adapter/driven/gorm"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/dto"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/errorcode"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/util"
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/infrastructure/repository/db/model"
+	"github.com/kweaver-ai/idrm-go-frame/core/telemetry/log"
+)
+
+// ServiceVersionDomain 接口的版本历史：查看、比较版本，回滚到历史版本
+type ServiceVersionDomain struct {
+	serviceDomain *ServiceDomain
+	serviceRepo   gorm.ServiceRepo
+	versionRepo   gorm.ServiceVersionRepo
+}
+
+func NewServiceVersionDomain(serviceDomain *ServiceDomain, serviceRepo gorm.ServiceRepo, versionRepo gorm.ServiceVersionRepo) *ServiceVersionDomain {
+	return &ServiceVersionDomain{
+		serviceDomain: serviceDomain,
+		serviceRepo:   serviceRepo,
+		versionRepo:   versionRepo,
+	}
+}
+
+// List 接口的版本列表，按版本号倒序
+func (d *ServiceVersionDomain) List(ctx context.Context, req *dto.ServiceVersionListReq) (*dto.ServiceVersionListRes, error) {
+	if err := d.checkServiceExist(ctx, req.ServiceID); err != nil {
+		return nil, err
+	}
+
+	versions, count, err := d.versionRepo.List(ctx, req.ServiceID, req.Offset, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &dto.ServiceVersionListRes{}
+	res.TotalCount = count
+	res.Entries = lo.Map(versions, func(v *model.ServiceVersion, _ int) *dto.ServiceVersion {
+		return newServiceVersion(v)
+	})
+	return res, nil
+}
+
+// Get 接口版本详情，包括版本快照
+func (d *ServiceVersionDomain) Get(ctx context.Context, req *dto.ServiceVersionUriReq) (*dto.ServiceVersionGetRes, error) {
+	v, err := d.versionRepo.Get(ctx, req.ServiceID, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &dto.ServiceVersionGetRes{ServiceVersion: *newServiceVersion(v)}
+	if err = json.Unmarshal([]byte(v.Content), &res.Content); err != nil {
+		log.WithContext(ctx).Error("ServiceVersion Get", zap.Error(err), zap.String("service_id", v.ServiceID), zap.Int("version", v.Version))
+		return nil, errorcode.Detail(errorcode.PublicInternalError, err)
+	}
+	return res, nil
+}
+
+// Diff 比较接口的两个版本，from 和 to 可以是任意两个版本
+func (d *ServiceVersionDomain) Diff(ctx context.Context, req *dto.ServiceVersionDiffReq) (*dto.ServiceVersionDiffRes, error) {
+	from, err := d.versionRepo.Get(ctx, req.ServiceID, req.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := d.versionRepo.Get(ctx, req.ServiceID, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := util.DiffJSON([]byte(from.Content), []byte(to.Content))
+	if err != nil {
+		log.WithContext(ctx).Error("ServiceVersion Diff", zap.Error(err), zap.String("service_id", req.ServiceID))
+		return nil, errorcode.Detail(errorcode.PublicInternalError, err)
+	}
+
+	return &dto.ServiceVersionDiffRes{
+		From: req.From,
+		To:   req.To,
+		Changes: lo.Map(changes, func(c util.JSONChange, _ int) dto.ServiceVersionChange {
+			return dto.ServiceVersionChange{Path: c.Path, Type: c.Type, From: c.From, To: c.To}
+		}),
+	}, nil
+}
+
+// Rollback 以历史版本的内容提交变更审核，审核通过后产生新的版本。
+// 子服务（行列规则）不经过变更审核，提交变更审核后直接恢复为历史版本的子服务，并记录新的版本
+func (d *ServiceVersionDomain) Rollback(ctx context.Context, req *dto.ServiceVersionUriReq) (*dto.ServiceIdRes, error) {
+	v, err := d.Get(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	content := v.Content
+	info := content.ServiceInfo
+	// 类目信息在快照中单独保存
+	info.CategoryInfo = content.CategoryInfo
+
+	change := &dto.ServiceChangeReq{
+		ServiceUpdateUriReq: dto.ServiceUpdateUriReq{ServiceID: req.ServiceID},
+		ServiceChangeBodyReq: dto.ServiceChangeBodyReq{
+			IsTemp:       false,
+			ServiceInfo:  info,
+			ServiceParam: dto.ServiceParamWrite(content.ServiceParam),
+			ServiceTest:  content.ServiceTest,
+		},
+	}
+	if content.ServiceResponse != nil {
+		change.ServiceResponse = *content.ServiceResponse
+	}
+
+	res, err := d.serviceDomain.ServiceChange(ctx, change)
+	if err != nil {
+		return nil, err
+	}
+	if err = d.serviceRepo.RestoreSubServices(ctx, req.ServiceID, content.SubServices); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (d *ServiceVersionDomain) checkServiceExist(ctx context.Context, serviceID string) error {
+	exist, err := d.serviceRepo.IsServiceIDExist(ctx, serviceID)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return errorcode.Desc(errorcode.ServiceIDNotExist)
+	}
+	return nil
+}
+
+func newServiceVersion(v *model.ServiceVersion) *dto.ServiceVersion {
+	return &dto.ServiceVersion{
+		ServiceID:  v.ServiceID,
+		Version:    v.Version,
+		AuditType:  v.AuditType,
+		ApplyID:    v.ApplyID,
+		FlowID:     v.FlowID,
+		AuthorID:   v.AuthorID,
+		AuthorName: v.AuthorName,
+		CreateTime: util.TimeFormat(&v.CreateTime),
+	}
+}
@@ -41,5 +41,9 @@ func (s *subServiceUseCase) Create(ctx context.Context, subService *sub_service.
 	if err != nil {
 		return nil, err
 	}
+	// 子服务变更后记录接口的版本
+	if err := s.serviceRepo.RecordSubServiceVersion(ctx, m.ServiceID.String()); err != nil {
+		return nil, err
+	}
 	return sub_service.GenSubServiceByModel(m), nil
 }
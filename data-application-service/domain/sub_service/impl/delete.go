@@ -14,10 +14,10 @@ func (s *subServiceUseCase) Delete(ctx context.Context, id uuid.UUID) error {
 	defer span.End()
 
 	// 获取指定子视图
-	//subService, err := s.subServiceRepo.Get(ctx, id)
-	//if err != nil {
-	//	return err
-	//}
+	subService, err := s.subServiceRepo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
 
 	////检查当前用户是否有权限
 	//if err = s.checkPermission(ctx, subService.ID.String(), authServiceV1.ObjectSubService, AllocateAction, AuthAction); err != nil {
@@ -29,5 +29,6 @@ func (s *subServiceUseCase) Delete(ctx context.Context, id uuid.UUID) error {
 		return err
 	}
 
-	return nil
+	// 子服务变更后记录接口的版本
+	return s.serviceRepo.RecordSubServiceVersion(ctx, subService.ServiceID.String())
 }
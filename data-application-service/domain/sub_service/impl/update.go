@@ -44,6 +44,10 @@ func (s *subServiceUseCase) Update(ctx context.Context, subService *sub_service.
 	if err != nil {
 		return nil, err
 	}
+	// 子服务变更后记录接口的版本
+	if err := s.serviceRepo.RecordSubServiceVersion(ctx, mOld.ServiceID.String()); err != nil {
+		return nil, err
+	}
 
 	return sub_service.GenSubServiceByModel(mNew), nil
 }
@@ -52,6 +52,7 @@ type Service struct {
 	HTTPMethod         string     `gorm:"column:http_method;type:varchar(10);not null;comment:请求方式 post get" json:"http_method"`                                      // 请求方式 post get
 	ReturnType         string     `gorm:"column:return_type;type:varchar(10);not null;comment:返回类型 json" json:"return_type"`                                          // 返回类型 json
 	Protocol           string     `gorm:"column:protocol;type:varchar(10);not null;comment:协议 http" json:"protocol"`                                                  // 协议 http
+	RequestMapping     *string    `gorm:"column:request_mapping;type:text;comment:请求映射 JSON" json:"request_mapping"`                                                 // 请求映射 JSON
 	FileID             string     `gorm:"column:file_id;type:varchar(255);not null;comment:接口文档id" json:"file_id"`                                                    // 接口文档id
 	Description        *string    `gorm:"column:description;type:text;comment:接口说明" json:"description"`                                                               // 接口说明
 	DeveloperID        string     `gorm:"column:developer_id;type:varchar(255);not null;comment:开发商id" json:"developer_id"`                                           // 开发商id
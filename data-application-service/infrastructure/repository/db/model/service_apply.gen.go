@@ -27,6 +27,7 @@ type ServiceApply struct {
 	ProcDefKey  string     `gorm:"column:proc_def_key;not null;comment:审核流程key" json:"proc_def_key"`                             // 审核流程key
 	AuthTime    *time.Time `gorm:"column:auth_time;comment:授权时间" json:"auth_time"`                                               // 授权时间
 	ExpiredTime *time.Time `gorm:"column:expired_time;comment:过期时间" json:"expired_time"`                                         // 过期时间
+	NotifyTime  *time.Time `gorm:"column:notify_time;comment:到期提醒时间" json:"notify_time"`                                         // 到期提醒时间
 	CreateTime  time.Time  `gorm:"column:create_time;not null;default:current_timestamp();comment:创建时间" json:"create_time"`      // 创建时间
 	UpdateTime  time.Time  `gorm:"column:update_time;not null;autoUpdateTime;comment:更新时间" json:"update_time"`      // 更新时间
 }
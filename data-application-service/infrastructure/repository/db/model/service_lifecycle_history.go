@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/util"
+	"gorm.io/gorm"
+)
+
+const TableNameServiceLifecycleHistory = "service_lifecycle_history"
+
+// ServiceLifecycleHistory mapped from table <service_lifecycle_history>
+type ServiceLifecycleHistory struct {
+	ID                int64     `gorm:"column:id;comment:主键" json:"id"`                                                           // 主键
+	ServiceID         string    `gorm:"column:service_id;not null;comment:接口ID，已发布接口为已发布版本的接口ID" json:"service_id"`               // 接口ID，已发布接口为已发布版本的接口ID
+	TriggerType       string    `gorm:"column:trigger_type;not null;comment:触发动作" json:"trigger_type"`                            // 触发动作
+	FromPublishStatus string    `gorm:"column:from_publish_status;not null;comment:迁移前的发布状态" json:"from_publish_status"`          // 迁移前的发布状态
+	FromStatus        string    `gorm:"column:from_status;not null;comment:迁移前的上线状态" json:"from_status"`                          // 迁移前的上线状态
+	ToPublishStatus   string    `gorm:"column:to_publish_status;not null;comment:迁移后的发布状态" json:"to_publish_status"`              // 迁移后的发布状态
+	ToStatus          string    `gorm:"column:to_status;not null;comment:迁移后的上线状态" json:"to_status"`                              // 迁移后的上线状态
+	ApplyID           string    `gorm:"column:apply_id;not null;comment:审核申请id" json:"apply_id"`                                  // 审核申请id
+	FlowID            string    `gorm:"column:flow_id;not null;comment:审批流程实例id，无需审核时为空" json:"flow_id"`                          // 审批流程实例id，无需审核时为空
+	OperatorID        string    `gorm:"column:operator_id;not null;comment:操作人用户ID，审核结果触发时为空" json:"operator_id"`                 // 操作人用户ID，审核结果触发时为空
+	OperatorName      string    `gorm:"column:operator_name;not null;comment:操作人用户名称" json:"operator_name"`                       // 操作人用户名称
+	CreateTime        time.Time `gorm:"column:create_time;not null;default:current_timestamp(3);comment:迁移时间" json:"create_time"` // 迁移时间
+}
+
+// TableName ServiceLifecycleHistory's table name
+func (*ServiceLifecycleHistory) TableName() string {
+	return TableNameServiceLifecycleHistory
+}
+
+func (m *ServiceLifecycleHistory) UniqueKey() string {
+	return "id"
+}
+
+func (m *ServiceLifecycleHistory) BeforeCreate(_ *gorm.DB) error {
+	if m == nil {
+		return nil
+	}
+	if m.ID == 0 {
+		m.ID = util.GetUniqueID()
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"github.com/kweaver-ai/dsg/services/apps/data-application-service/common/util"
+	"gorm.io/gorm"
+)
+
+const TableNameServiceVersion = "service_version"
+
+// ServiceVersion mapped from table <service_version>
+type ServiceVersion struct {
+	ID         int64     `gorm:"column:id;comment:主键" json:"id"`                                                          // 主键
+	ServiceID  string    `gorm:"column:service_id;not null;comment:接口ID，已发布版本的接口ID" json:"service_id"`                    // 接口ID，已发布版本的接口ID
+	Version    int       `gorm:"column:version;not null;comment:版本号，从1开始递增" json:"version"`                               // 版本号，从1开始递增
+	AuditType  string    `gorm:"column:audit_type;not null;comment:产生版本的审核类型" json:"audit_type"`                          // 产生版本的审核类型
+	ApplyID    string    `gorm:"column:apply_id;not null;comment:审核申请id" json:"apply_id"`                                 // 审核申请id
+	FlowID     string    `gorm:"column:flow_id;not null;comment:审批流程实例id，无需审核时为空" json:"flow_id"`                         // 审批流程实例id，无需审核时为空
+	AuthorID   string    `gorm:"column:author_id;not null;comment:提交人用户ID" json:"author_id"`                              // 提交人用户ID
+	AuthorName string    `gorm:"column:author_name;not null;comment:提交人用户名称" json:"author_name"`                          // 提交人用户名称
+	Content    string    `gorm:"column:content;not null;comment:版本快照 JSON" json:"content"`                                // 版本快照 JSON
+	CreateTime time.Time `gorm:"column:create_time;not null;default:current_timestamp();comment:创建时间" json:"create_time"` // 创建时间
+}
+
+// TableName ServiceVersion's table name
+func (*ServiceVersion) TableName() string {
+	return TableNameServiceVersion
+}
+
+func (m *ServiceVersion) UniqueKey() string {
+	return "id"
+}
+
+func (m *ServiceVersion) BeforeCreate(_ *gorm.DB) error {
+	if m == nil {
+		return nil
+	}
+	if m.ID == 0 {
+		m.ID = util.GetUniqueID()
+	}
+	return nil
+}
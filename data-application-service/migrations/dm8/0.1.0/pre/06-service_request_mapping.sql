@@ -0,0 +1,4 @@
+SET SCHEMA data_application_service;
+
+-- 为接口服务表(service)添加请求映射字段
+ALTER TABLE "service" ADD COLUMN IF NOT EXISTS "request_mapping" TEXT DEFAULT NULL;
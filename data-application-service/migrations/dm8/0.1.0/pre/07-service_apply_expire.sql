@@ -0,0 +1,7 @@
+SET SCHEMA data_application_service;
+
+-- 为接口调用申请表(service_apply)添加到期提醒时间字段
+ALTER TABLE "service_apply" ADD COLUMN IF NOT EXISTS "notify_time" datetime(0) DEFAULT NULL;
+
+-- 定时任务按过期时间查询已过期和即将过期的申请
+CREATE INDEX IF NOT EXISTS service_apply_expired_time ON service_apply("expired_time");
@@ -0,0 +1,19 @@
+SET SCHEMA data_application_service;
+
+-- 接口版本历史表，发布审核、变更审核通过时记录接口的版本快照
+
+CREATE TABLE IF NOT EXISTS "service_version" (
+    "id"          BIGINT NOT NULL,
+    "service_id"  VARCHAR(255 char) NOT NULL,
+    "version"     INT NOT NULL,
+    "audit_type"  VARCHAR(100 char) NOT NULL DEFAULT '',
+    "apply_id"    VARCHAR(255 char) NOT NULL DEFAULT '',
+    "flow_id"     VARCHAR(50 char) NOT NULL DEFAULT '',
+    "author_id"   VARCHAR(50 char) NOT NULL DEFAULT '',
+    "author_name" VARCHAR(255 char) NOT NULL DEFAULT '',
+    "content"     CLOB NOT NULL,
+    "create_time" datetime(0) NOT NULL DEFAULT current_timestamp(),
+    CLUSTER PRIMARY KEY ("id")
+    );
+
+CREATE UNIQUE INDEX IF NOT EXISTS service_version_uniq_service_version ON service_version("service_id", "version");
@@ -0,0 +1,21 @@
+SET SCHEMA data_application_service;
+
+-- 接口生命周期迁移记录表，记录发布状态、上线状态的每一次迁移
+
+CREATE TABLE IF NOT EXISTS "service_lifecycle_history" (
+    "id"                  BIGINT NOT NULL,
+    "service_id"          VARCHAR(255 char) NOT NULL,
+    "trigger_type"        VARCHAR(50 char) NOT NULL,
+    "from_publish_status" VARCHAR(20 char) NOT NULL DEFAULT '',
+    "from_status"         VARCHAR(20 char) NOT NULL DEFAULT '',
+    "to_publish_status"   VARCHAR(20 char) NOT NULL DEFAULT '',
+    "to_status"           VARCHAR(20 char) NOT NULL DEFAULT '',
+    "apply_id"            VARCHAR(255 char) NOT NULL DEFAULT '',
+    "flow_id"             VARCHAR(50 char) NOT NULL DEFAULT '',
+    "operator_id"         VARCHAR(50 char) NOT NULL DEFAULT '',
+    "operator_name"       VARCHAR(255 char) NOT NULL DEFAULT '',
+    "create_time"         datetime(3) NOT NULL DEFAULT current_timestamp(3),
+    CLUSTER PRIMARY KEY ("id")
+    );
+
+CREATE INDEX IF NOT EXISTS service_lifecycle_history_idx_service_id ON service_lifecycle_history("service_id", "create_time");
@@ -90,6 +90,7 @@ CREATE TABLE IF NOT EXISTS "service"
     "http_method"          VARCHAR(10 char)         NOT NULL DEFAULT '',
     "return_type"          VARCHAR(10 char)         NOT NULL DEFAULT '',
     "protocol"             VARCHAR(10 char)         NOT NULL DEFAULT '',
+    "request_mapping"      TEXT                     DEFAULT NULL,
     "file_id"              VARCHAR(255 char)        NOT NULL DEFAULT '',
     "description"         text  ,
     "developer_id"         VARCHAR(255 char)        NOT NULL DEFAULT '',
@@ -157,6 +158,7 @@ CREATE TABLE IF NOT EXISTS "service_apply"
     "proc_def_key" VARCHAR(128 char)        NOT NULL DEFAULT '',
     "auth_time"    datetime(0) DEFAULT NULL,
     "expired_time" datetime(0) DEFAULT NULL,
+    "notify_time"  datetime(0) DEFAULT NULL,
     "create_time"  datetime(0) NOT NULL DEFAULT current_timestamp(),
     "update_time"  datetime(0) NOT NULL DEFAULT current_timestamp(),
     CLUSTER PRIMARY KEY ("id")
@@ -168,6 +170,8 @@ CREATE INDEX IF NOT EXISTS service_apply_audit_status ON service_apply("audit_st
 
 CREATE INDEX IF NOT EXISTS service_apply_create_time ON service_apply("create_time");
 
+CREATE INDEX IF NOT EXISTS service_apply_expired_time ON service_apply("expired_time");
+
 CREATE TABLE IF NOT EXISTS "service_data_source"
 (
     "id"               BIGINT  NOT NULL,
@@ -377,4 +381,38 @@ CREATE TABLE IF NOT EXISTS gateway_collection_log (
     "invoke_num" INT,
     "invoke_average_call_duration" INT,
     CLUSTER PRIMARY KEY ("id")
-    ) ;
\ No newline at end of file
+    ) ;
+
+CREATE TABLE IF NOT EXISTS "service_version" (
+    "id"          BIGINT NOT NULL,
+    "service_id"  VARCHAR(255 char) NOT NULL,
+    "version"     INT NOT NULL,
+    "audit_type"  VARCHAR(100 char) NOT NULL DEFAULT '',
+    "apply_id"    VARCHAR(255 char) NOT NULL DEFAULT '',
+    "flow_id"     VARCHAR(50 char) NOT NULL DEFAULT '',
+    "author_id"   VARCHAR(50 char) NOT NULL DEFAULT '',
+    "author_name" VARCHAR(255 char) NOT NULL DEFAULT '',
+    "content"     CLOB NOT NULL,
+    "create_time" datetime(0) NOT NULL DEFAULT current_timestamp(),
+    CLUSTER PRIMARY KEY ("id")
+    );
+
+CREATE UNIQUE INDEX IF NOT EXISTS service_version_uniq_service_version ON service_version("service_id", "version");
+
+CREATE TABLE IF NOT EXISTS "service_lifecycle_history" (
+    "id"                  BIGINT NOT NULL,
+    "service_id"          VARCHAR(255 char) NOT NULL,
+    "trigger_type"        VARCHAR(50 char) NOT NULL,
+    "from_publish_status" VARCHAR(20 char) NOT NULL DEFAULT '',
+    "from_status"         VARCHAR(20 char) NOT NULL DEFAULT '',
+    "to_publish_status"   VARCHAR(20 char) NOT NULL DEFAULT '',
+    "to_status"           VARCHAR(20 char) NOT NULL DEFAULT '',
+    "apply_id"            VARCHAR(255 char) NOT NULL DEFAULT '',
+    "flow_id"             VARCHAR(50 char) NOT NULL DEFAULT '',
+    "operator_id"         VARCHAR(50 char) NOT NULL DEFAULT '',
+    "operator_name"       VARCHAR(255 char) NOT NULL DEFAULT '',
+    "create_time"         datetime(3) NOT NULL DEFAULT current_timestamp(3),
+    CLUSTER PRIMARY KEY ("id")
+    );
+
+CREATE INDEX IF NOT EXISTS service_lifecycle_history_idx_service_id ON service_lifecycle_history("service_id", "create_time");
@@ -0,0 +1,4 @@
+USE data_application_service;
+
+-- 为接口服务表(service)添加请求映射字段
+ALTER TABLE `service` ADD COLUMN IF NOT EXISTS `request_mapping` text DEFAULT NULL COMMENT '请求映射 JSON' AFTER `protocol`;
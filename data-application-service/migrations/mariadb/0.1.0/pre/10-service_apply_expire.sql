@@ -0,0 +1,7 @@
+USE data_application_service;
+
+-- 为接口调用申请表(service_apply)添加到期提醒时间字段
+ALTER TABLE `service_apply` ADD COLUMN IF NOT EXISTS `notify_time` datetime DEFAULT NULL COMMENT '到期提醒时间' AFTER `expired_time`;
+
+-- 定时任务按过期时间查询已过期和即将过期的申请
+CREATE INDEX IF NOT EXISTS `expired_time` ON `service_apply` (`expired_time`);
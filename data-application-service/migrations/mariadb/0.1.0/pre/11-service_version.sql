@@ -0,0 +1,18 @@
+USE data_application_service;
+
+-- 接口版本历史表，发布审核、变更审核通过时记录接口的版本快照
+
+CREATE TABLE IF NOT EXISTS `service_version` (
+    `id`          BIGINT(20)   NOT NULL COMMENT '主键',
+    `service_id`  VARCHAR(255) NOT NULL COMMENT '接口ID，已发布版本的接口ID',
+    `version`     INT(10)      NOT NULL COMMENT '版本号，从1开始递增',
+    `audit_type`  VARCHAR(100) NOT NULL DEFAULT '' COMMENT '产生版本的审核类型 af-data-application-publish 发布 af-data-application-change 变更',
+    `apply_id`    VARCHAR(255) NOT NULL DEFAULT '' COMMENT '审核申请id',
+    `flow_id`     VARCHAR(50)  NOT NULL DEFAULT '' COMMENT '审批流程实例id，无需审核时为空',
+    `author_id`   VARCHAR(50)  NOT NULL DEFAULT '' COMMENT '提交人用户ID',
+    `author_name` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '提交人用户名称',
+    `content`     LONGTEXT     NOT NULL COMMENT '版本快照 JSON',
+    `create_time` DATETIME     NOT NULL DEFAULT current_timestamp() COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uniq_service_version` (`service_id`, `version`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='接口版本历史表';
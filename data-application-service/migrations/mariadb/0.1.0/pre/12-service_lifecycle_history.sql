@@ -0,0 +1,20 @@
+USE data_application_service;
+
+-- 接口生命周期迁移记录表，记录发布状态、上线状态的每一次迁移
+
+CREATE TABLE IF NOT EXISTS `service_lifecycle_history` (
+    `id`                  BIGINT(20)   NOT NULL COMMENT '主键',
+    `service_id`          VARCHAR(255) NOT NULL COMMENT '接口ID，已发布接口为已发布版本的接口ID',
+    `trigger_type`        VARCHAR(50)  NOT NULL COMMENT '触发动作 publish-submit 提交发布审核 publish-pass 发布审核通过 online-submit 提交上线审核 等',
+    `from_publish_status` VARCHAR(20)  NOT NULL DEFAULT '' COMMENT '迁移前的发布状态',
+    `from_status`         VARCHAR(20)  NOT NULL DEFAULT '' COMMENT '迁移前的上线状态',
+    `to_publish_status`   VARCHAR(20)  NOT NULL DEFAULT '' COMMENT '迁移后的发布状态',
+    `to_status`           VARCHAR(20)  NOT NULL DEFAULT '' COMMENT '迁移后的上线状态',
+    `apply_id`            VARCHAR(255) NOT NULL DEFAULT '' COMMENT '审核申请id',
+    `flow_id`             VARCHAR(50)  NOT NULL DEFAULT '' COMMENT '审批流程实例id，无需审核时为空',
+    `operator_id`         VARCHAR(50)  NOT NULL DEFAULT '' COMMENT '操作人用户ID，审核结果触发时为空',
+    `operator_name`       VARCHAR(255) NOT NULL DEFAULT '' COMMENT '操作人用户名称',
+    `create_time`         DATETIME(3)  NOT NULL DEFAULT current_timestamp(3) COMMENT '迁移时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_service_id` (`service_id`, `create_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='接口生命周期迁移记录表';
@@ -86,6 +86,7 @@ CREATE TABLE IF NOT EXISTS `service`
     `http_method`          varchar(10)         NOT NULL DEFAULT '' COMMENT '请求方式 post get',
     `return_type`          varchar(10)         NOT NULL DEFAULT '' COMMENT '返回类型 json',
     `protocol`             varchar(10)         NOT NULL DEFAULT '' COMMENT '协议 http',
+    `request_mapping`      text                         DEFAULT NULL COMMENT '请求映射 JSON',
     `file_id`              varchar(255)        NOT NULL DEFAULT '' COMMENT '接口文档id',
     `description`         text   COMMENT '接口说明',
     `developer_id`         varchar(255)        NOT NULL DEFAULT '' COMMENT '开发商id',
@@ -143,12 +144,14 @@ CREATE TABLE IF NOT EXISTS `service_apply`
     `proc_def_key` varchar(128)        NOT NULL DEFAULT '' COMMENT '审核流程key',
     `auth_time`    datetime                     DEFAULT NULL COMMENT '授权时间',
     `expired_time` datetime                     DEFAULT NULL COMMENT '过期时间',
+    `notify_time`  datetime                     DEFAULT NULL COMMENT '到期提醒时间',
     `create_time`  datetime            NOT NULL DEFAULT current_timestamp() COMMENT '创建时间',
     `update_time`  datetime            NOT NULL DEFAULT current_timestamp()  COMMENT '更新时间',
 
     UNIQUE KEY `apply` (`uid`, `service_id`, `apply_id`),
     KEY `audit_status` (`audit_status`),
     KEY `create_time` (`create_time`),
+    KEY `expired_time` (`expired_time`),
     PRIMARY KEY (`id`)
 ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4  COLLATE utf8mb4_unicode_ci  COMMENT ='接口调用申请记录';
 
@@ -360,4 +363,36 @@ CREATE TABLE if not exists `service_authed_users` (
     PRIMARY KEY (`id`),
     KEY `service_authed_users_user_id_IDX` (`user_id`,`service_id`) USING BTREE,
     KEY `service_authed_users_service_id_IDX` (`service_id`,`user_id`) USING BTREE
-    ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='接口服务授权用户关系表';
\ No newline at end of file
+    ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='接口服务授权用户关系表';
+
+CREATE TABLE IF NOT EXISTS `service_version` (
+    `id`          BIGINT(20)   NOT NULL COMMENT '主键',
+    `service_id`  VARCHAR(255) NOT NULL COMMENT '接口ID，已发布版本的接口ID',
+    `version`     INT(10)      NOT NULL COMMENT '版本号，从1开始递增',
+    `audit_type`  VARCHAR(100) NOT NULL DEFAULT '' COMMENT '产生版本的审核类型 af-data-application-publish 发布 af-data-application-change 变更',
+    `apply_id`    VARCHAR(255) NOT NULL DEFAULT '' COMMENT '审核申请id',
+    `flow_id`     VARCHAR(50)  NOT NULL DEFAULT '' COMMENT '审批流程实例id，无需审核时为空',
+    `author_id`   VARCHAR(50)  NOT NULL DEFAULT '' COMMENT '提交人用户ID',
+    `author_name` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '提交人用户名称',
+    `content`     LONGTEXT     NOT NULL COMMENT '版本快照 JSON',
+    `create_time` DATETIME     NOT NULL DEFAULT current_timestamp() COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uniq_service_version` (`service_id`, `version`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='接口版本历史表';
+
+CREATE TABLE IF NOT EXISTS `service_lifecycle_history` (
+    `id`                  BIGINT(20)   NOT NULL COMMENT '主键',
+    `service_id`          VARCHAR(255) NOT NULL COMMENT '接口ID，已发布接口为已发布版本的接口ID',
+    `trigger_type`        VARCHAR(50)  NOT NULL COMMENT '触发动作 publish-submit 提交发布审核 publish-pass 发布审核通过 online-submit 提交上线审核 等',
+    `from_publish_status` VARCHAR(20)  NOT NULL DEFAULT '' COMMENT '迁移前的发布状态',
+    `from_status`         VARCHAR(20)  NOT NULL DEFAULT '' COMMENT '迁移前的上线状态',
+    `to_publish_status`   VARCHAR(20)  NOT NULL DEFAULT '' COMMENT '迁移后的发布状态',
+    `to_status`           VARCHAR(20)  NOT NULL DEFAULT '' COMMENT '迁移后的上线状态',
+    `apply_id`            VARCHAR(255) NOT NULL DEFAULT '' COMMENT '审核申请id',
+    `flow_id`             VARCHAR(50)  NOT NULL DEFAULT '' COMMENT '审批流程实例id，无需审核时为空',
+    `operator_id`         VARCHAR(50)  NOT NULL DEFAULT '' COMMENT '操作人用户ID，审核结果触发时为空',
+    `operator_name`       VARCHAR(255) NOT NULL DEFAULT '' COMMENT '操作人用户名称',
+    `create_time`         DATETIME(3)  NOT NULL DEFAULT current_timestamp(3) COMMENT '迁移时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_service_id` (`service_id`, `create_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='接口生命周期迁移记录表';
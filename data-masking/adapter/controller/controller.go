@@ -2,6 +2,8 @@ package controller
 
 import (
 	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/adapter/controller/demo/v1"
+	masking_rule "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/adapter/controller/masking_rule/v1"
+	record_masking "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/adapter/controller/record_masking/v1"
 	"github.com/google/wire"
 )
 
@@ -10,4 +12,6 @@ var HttpProviderSet = wire.NewSet(NewHttpServer)
 
 var ServiceProviderSet = wire.NewSet(
 	demo.NewService,
+	masking_rule.NewService,
+	record_masking.NewService,
 )
@@ -1,7 +1,6 @@
 package demo
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"net/http"
@@ -45,8 +44,6 @@ func (s *Service) Create(c *gin.Context) {
 		}
 	}
 
-	output := bytes.Buffer{}
-	output.WriteString("SELECT ")
 	for i := 0; i < len(req.CreateReqBodyParam.Fields); i++ {
 
 		field := req.CreateReqBodyParam.Fields[i].Field //chinese_name string, sensitive int, classified
@@ -70,28 +67,21 @@ func (s *Service) Create(c *gin.Context) {
 		if !check_int_input(sensitive, c, "sensitive") {
 			return
 		}
-
-		field_str := str_concat(field_type, field, chinese_name, sensitive, classified)
-		output.WriteString(field_str)
-
-		if i < len(req.CreateReqBodyParam.Fields)-1 {
-			output.WriteString(",")
-		}
 	}
 	if !check_string_input(req.CreateReqBodyParam.TableName, c, "table_name") {
 		return
 	}
-	output.WriteString(" ")
-	output.WriteString("FROM ")
-	output.WriteString(req.CreateReqBodyParam.TableName)
-	// output.WriteString(";")
 
-	sql_masking := map[string]string{ //初始化返回值，类型为map
-		"masked_sql": string(output.String()),
+	// 按启用的脱敏规则生成每个字段的查询列
+	resp, err := s.uc.Create(c, req)
+	if err != nil {
+		c.Writer.WriteHeader(http.StatusInternalServerError)
+		ginx.ResErrJson(c, err)
+		return
 	}
 	log.Info("new sql:")
-	log.Info(output.String())
-	ginx.ResOKJson(c, sql_masking)
+	log.Info(resp.MaskedSQL)
+	ginx.ResOKJson(c, resp)
 
 }
 func Containss(slice []any, element string) bool {
@@ -164,44 +154,3 @@ func check_string_input(check_string string, c *gin.Context, field string) (resu
 		return true
 	}
 }
-
-var user_defined_masking_rule = map[string]string{
-	"姓名":   "MASK_LAST_1",
-	"电话号码": "MASK_MID_5,8",
-	"身份证":  "MASK_MID_7,14",
-	"护照":   "MASK_LAST_4",
-}
-
-func str_concat(field_type string, field string, chinese_name string, sensitive int, classified int) string {
-	if strings.ToLower(field_type) != "string" {
-		return fmt.Sprintf(`"` + field + `"`)
-	}
-	_, ok := user_defined_masking_rule[chinese_name]
-	if ok == true {
-		if chinese_name == "姓名" { //CONCAT(SUBSTR(%s,1,1),'*')
-			return fmt.Sprintf("CONCAT(SUBSTR(" + `"` + field + `"` + ",1,1),'**')  AS " + `"` + field + `"`)
-			// return fmt.Sprintf("CONCAT(SUBSTR(%s,1,1),'**')  AS %s", field, field)
-		} else if chinese_name == "电话号码" { //中间4位用*代替
-			return fmt.Sprintf("CONCAT(SUBSTR(" + `"` + field + `"` + ",1,3),'****',SUBSTR(" + `"` + field + `"` + ",8,12)) AS " + `"` + field + `"`)
-			// return fmt.Sprintf("CONCAT(SUBSTR(%s,1,3),'****',SUBSTR(%s,8,12)) AS %s", field, field, field)
-		} else if chinese_name == "身份证" { // 中间的生日8位用*代替
-			return fmt.Sprintf("CONCAT(SUBSTR(" + `"` + field + `"` + ",1,6),'********',SUBSTR(" + `"` + field + `"` + ",15,18)) AS " + `"` + field + `"`)
-			//return fmt.Sprintf("CONCAT(SUBSTR(%s,1,6),'********',SUBSTR(%s,15,18)) AS %s", field, field, field)
-		} else if chinese_name == "护照" {
-			return fmt.Sprintf("CONCAT(SUBSTR(" + `"` + field + `"` + ",1,5),'****')  AS " + `"` + field + `"`)
-		} else {
-			return ""
-		}
-	} else {
-		base_result := sensitive + classified
-		if base_result == 0 { //不敏感、不涉密，不处理
-			return field
-		} else if base_result == 1 { //敏感、涉密，字段全部进行脱敏
-			return fmt.Sprintf("rpad('',6,'*') as " + `"` + field + `"`)
-			// return fmt.Sprintf("CASE WHEN LENGTH(%s) > 2 THEN  RPAD(SUBSTR(%s,1,LENGTH(%s)/2),LENGTH(%s),'*') ELSE '*' END AS %s", field, field, field, field, field)
-		} else { //敏感、涉密，字段全部进行脱敏
-			return fmt.Sprintf("rpad('',6,'*') as " + `"` + field + `"`)
-		}
-	}
-	return ""
-}
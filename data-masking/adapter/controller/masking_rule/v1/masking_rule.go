@@ -0,0 +1,128 @@
+package masking_rule
+
+import (
+	"errors"
+	"net/http"
+
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/common/errorcode"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/common/form_validator"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/common/log"
+	domain "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/domain/masking_rule"
+	"github.com/gin-gonic/gin"
+	"github.com/jinguoxing/af-go-frame/core/errorx/agerrors"
+	"github.com/jinguoxing/af-go-frame/core/transport/rest/ginx"
+)
+
+type Service struct {
+	uc domain.UseCase
+}
+
+func NewService(uc domain.UseCase) *Service {
+	return &Service{uc: uc}
+}
+
+// Create 新建脱敏规则
+func (s *Service) Create(c *gin.Context) {
+	req := &domain.CreateReqParam{}
+	if _, err := form_validator.BindJsonAndValid(c, &req.RuleBodyParam); err != nil {
+		resInvalidParameter(c, err)
+		return
+	}
+
+	resp, err := s.uc.Create(c, req)
+	if err != nil {
+		resErr(c, err)
+		return
+	}
+	ginx.ResOKJson(c, resp)
+}
+
+// Update 修改脱敏规则
+func (s *Service) Update(c *gin.Context) {
+	req := &domain.UpdateReqParam{}
+	if _, err := form_validator.BindUriAndValid(c, &req.IDReqPathParam); err != nil {
+		resInvalidParameter(c, err)
+		return
+	}
+	if _, err := form_validator.BindJsonAndValid(c, &req.RuleBodyParam); err != nil {
+		resInvalidParameter(c, err)
+		return
+	}
+
+	resp, err := s.uc.Update(c, req)
+	if err != nil {
+		resErr(c, err)
+		return
+	}
+	ginx.ResOKJson(c, resp)
+}
+
+// Delete 删除脱敏规则
+func (s *Service) Delete(c *gin.Context) {
+	req := &domain.DeleteReqParam{}
+	if _, err := form_validator.BindUriAndValid(c, &req.IDReqPathParam); err != nil {
+		resInvalidParameter(c, err)
+		return
+	}
+
+	resp, err := s.uc.Delete(c, req)
+	if err != nil {
+		resErr(c, err)
+		return
+	}
+	ginx.ResOKJson(c, resp)
+}
+
+// Get 查看脱敏规则
+func (s *Service) Get(c *gin.Context) {
+	req := &domain.GetReqParam{}
+	if _, err := form_validator.BindUriAndValid(c, &req.IDReqPathParam); err != nil {
+		resInvalidParameter(c, err)
+		return
+	}
+
+	resp, err := s.uc.Get(c, req)
+	if err != nil {
+		resErr(c, err)
+		return
+	}
+	ginx.ResOKJson(c, resp)
+}
+
+// List 脱敏规则列表
+func (s *Service) List(c *gin.Context) {
+	req := &domain.ListReqParam{}
+	if _, err := form_validator.BindQueryAndValid(c, &req.PageInfo); err != nil {
+		resInvalidParameter(c, err)
+		return
+	}
+
+	resp, err := s.uc.List(c, req)
+	if err != nil {
+		resErr(c, err)
+		return
+	}
+	ginx.ResOKJson(c, resp)
+}
+
+func resInvalidParameter(c *gin.Context, err error) {
+	c.Writer.WriteHeader(http.StatusBadRequest)
+	if errors.As(err, &form_validator.ValidErrors{}) {
+		ginx.ResErrJson(c, errorcode.Detail(errorcode.PublicInvalidParameter, err))
+		return
+	}
+	ginx.ResErrJson(c, errorcode.Detail(errorcode.PublicInvalidParameterJson, err.Error()))
+}
+
+func resErr(c *gin.Context, err error) {
+	switch agerrors.Code(err).GetErrorCode() {
+	case errorcode.MaskingRuleNotExist:
+		c.Writer.WriteHeader(http.StatusNotFound)
+	case errorcode.MaskingRuleInvalid:
+		c.Writer.WriteHeader(http.StatusBadRequest)
+	default:
+		log.Errorf("masking rule request failed, err: %v", err)
+		c.Writer.WriteHeader(http.StatusInternalServerError)
+	}
+	ginx.ResErrJson(c, err)
+}
@@ -0,0 +1,50 @@
+package record_masking
+
+import (
+	"errors"
+	"net/http"
+
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/common/errorcode"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/common/form_validator"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/common/log"
+	domain "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/domain/record_masking"
+	"github.com/gin-gonic/gin"
+	"github.com/jinguoxing/af-go-frame/core/errorx/agerrors"
+	"github.com/jinguoxing/af-go-frame/core/transport/rest/ginx"
+)
+
+type Service struct {
+	uc domain.UseCase
+}
+
+func NewService(uc domain.UseCase) *Service {
+	return &Service{uc: uc}
+}
+
+// Mask 脱敏 JSON 记录
+func (s *Service) Mask(c *gin.Context) {
+	req := &domain.MaskReqParam{}
+	if _, err := form_validator.BindJsonAndValid(c, &req.MaskReqBodyParam); err != nil {
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		if errors.As(err, &form_validator.ValidErrors{}) {
+			ginx.ResErrJson(c, errorcode.Detail(errorcode.PublicInvalidParameter, err))
+			return
+		}
+		ginx.ResErrJson(c, errorcode.Detail(errorcode.PublicInvalidParameterJson, err.Error()))
+		return
+	}
+
+	resp, err := s.uc.Mask(c, req)
+	if err != nil {
+		switch agerrors.Code(err).GetErrorCode() {
+		case errorcode.PublicInvalidParameter, errorcode.PublicInvalidParameterJson:
+			c.Writer.WriteHeader(http.StatusBadRequest)
+		default:
+			log.Errorf("record masking failed, err: %v", err)
+			c.Writer.WriteHeader(http.StatusInternalServerError)
+		}
+		ginx.ResErrJson(c, err)
+		return
+	}
+	ginx.ResOKJson(c, resp)
+}
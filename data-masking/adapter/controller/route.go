@@ -2,6 +2,8 @@ package controller
 
 import (
 	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/adapter/controller/demo/v1"
+	masking_rule "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/adapter/controller/masking_rule/v1"
+	record_masking "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/adapter/controller/record_masking/v1"
 	"github.com/gin-gonic/gin"
 	"github.com/google/wire"
 )
@@ -15,7 +17,9 @@ type IRouter interface {
 }
 
 type Router struct {
-	DemoApi *demo.Service
+	DemoApi          *demo.Service
+	MaskingRuleApi   *masking_rule.Service
+	RecordMaskingApi *record_masking.Service
 }
 
 func (r *Router) Register(engine *gin.Engine) error {
@@ -31,6 +35,17 @@ func (r *Router) RegisterApi(engine *gin.Engine) {
 			demoRouter := dataMaskingRouter.Group("/data-masking")
 
 			demoRouter.POST("/sql-masking", r.DemoApi.Create)
+			demoRouter.POST("/record-masking", r.RecordMaskingApi.Mask)
+		}
+
+		{
+			maskingRuleRouter := dataMaskingRouter.Group("/data-masking/rules")
+
+			maskingRuleRouter.POST("", r.MaskingRuleApi.Create)
+			maskingRuleRouter.GET("", r.MaskingRuleApi.List)
+			maskingRuleRouter.GET("/:id", r.MaskingRuleApi.Get)
+			maskingRuleRouter.PUT("/:id", r.MaskingRuleApi.Update)
+			maskingRuleRouter.DELETE("/:id", r.MaskingRuleApi.Delete)
 		}
 	}
 }
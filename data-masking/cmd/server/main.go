@@ -80,6 +80,9 @@ func main() {
 	if err := c.Scan(&settings.SwagConfig); err != nil {
 		panic(err)
 	}
+	if err := c.Scan(&settings.MaskingConf); err != nil {
+		panic(err)
+	}
 	// if settings.SwagConfig.Doc.Host == "" {
 	// 	settings.SwagConfig.Doc.Host = "127.0.0.1:8153"
 	// }
@@ -87,6 +90,9 @@ func main() {
 	// 初始化日志
 	log.InitProjectLogger()
 	defer log.Flush()
+	if settings.MaskingConf.Masking.HashKey == "" {
+		log.Warn("masking.hash_key is not configured, hash masking outputs NULL")
+	}
 
 	// 初始化验证器
 	// err := form_validator.SetupValidator()
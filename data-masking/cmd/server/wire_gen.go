@@ -9,10 +9,14 @@ package main
 import (
 	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/adapter/controller"
 	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/adapter/controller/demo/v1"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/adapter/controller/masking_rule/v1"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/adapter/controller/record_masking/v1"
 	impl2 "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/domain/demo/impl"
+	impl3 "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/domain/masking_rule/impl"
+	impl4 "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/domain/record_masking/impl"
 	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/infrastructure/conf"
 	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/infrastructure/repository/db"
-	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/infrastructure/repository/db/gorm/demo/impl"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/infrastructure/repository/db/gorm/masking_rule/impl"
 	"github.com/google/wire"
 )
 
@@ -26,8 +30,14 @@ func InitApp(server *conf.Server, database *db.Database) (*AppRunner, func(), er
 	repo := impl.NewRepo(data)
 	useCase := impl2.NewUseCase(repo)
 	service := demo.NewService(useCase)
+	masking_ruleUseCase := impl3.NewUseCase(repo)
+	masking_ruleService := masking_rule.NewService(masking_ruleUseCase)
+	record_maskingUseCase := impl4.NewUseCase(repo)
+	record_maskingService := record_masking.NewService(record_maskingUseCase)
 	router := &controller.Router{
-		DemoApi: service,
+		DemoApi:          service,
+		MaskingRuleApi:   masking_ruleService,
+		RecordMaskingApi: record_maskingService,
 	}
 	restServer := controller.NewHttpServer(server, router)
 	app := newApp(restServer)
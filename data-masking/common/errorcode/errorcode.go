@@ -13,7 +13,8 @@ import (
 const (
 	publicModelName = "Public"
 
-	demoModelName = "Demo"
+	demoModelName        = "Demo"
+	maskingRuleModelName = "MaskingRule"
 )
 
 // Public error
@@ -0,0 +1,28 @@
+package errorcode
+
+import "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/common/constant"
+
+func init() {
+	registerErrorCode(maskingRuleErrorMap)
+}
+
+// MaskingRule error
+const (
+	maskingRulePreCoder = constant.ServiceName + "." + maskingRuleModelName + "."
+
+	MaskingRuleNotExist = maskingRulePreCoder + "MaskingRuleNotExist"
+	MaskingRuleInvalid  = maskingRulePreCoder + "MaskingRuleInvalid"
+)
+
+var maskingRuleErrorMap = errorCode{
+	MaskingRuleNotExist: {
+		description: "脱敏规则不存在",
+		cause:       "",
+		solution:    "请重新选择脱敏规则",
+	},
+	MaskingRuleInvalid: {
+		description: "脱敏规则配置不合法",
+		cause:       "",
+		solution:    "请检查规则的匹配条件和脱敏算法参数",
+	},
+}
@@ -21,6 +21,19 @@ type SwagConf struct {
 	Doc SwagInfo `yaml:"doc"`
 }
 
+// MaskingConf 脱敏算法的配置
+var MaskingConf MaskingConfig
+
+type MaskingConfig struct {
+	Masking Masking `yaml:"masking" json:"masking"`
+}
+
+// Masking hash 算法的配置
+type Masking struct {
+	// HashKey HMAC-SHA256 的密钥，为空时 hash 算法脱敏后为 NULL
+	HashKey string `yaml:"hash_key" json:"hash_key"`
+}
+
 var MQConf MQConfig
 
 type MQConfig struct {
@@ -3,10 +3,32 @@ package impl
 import (
 	"context"
 
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/common/errorcode"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/common/settings"
 	domain "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/domain/demo"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/domain/masking"
+	masking_rule "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/domain/masking_rule"
 )
 
+// Create 按启用的脱敏规则和指定的方言生成查询字段的 SQL
 func (u *useCase) Create(ctx context.Context, req *domain.CreateReqParam) (*domain.CreateRespParam, error) {
-	_, _ = u.repoDemo.Get(ctx, "demo id")
-	panic("impl me")
+	dialect, err := masking.GetDialect(req.Dialect)
+	if err != nil {
+		return nil, errorcode.Detail(errorcode.PublicInvalidParameter, err.Error())
+	}
+
+	models, err := u.repoMaskingRule.ListEnabled(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]*masking.Field, 0, len(req.Fields))
+	for _, f := range req.Fields {
+		fields = append(fields, f.ToField())
+	}
+	return &domain.CreateRespParam{
+		ID:        req.PId,
+		Name:      req.TableName,
+		MaskedSQL: masking.CompileSelect(dialect, req.TableName, fields, masking_rule.ToRuleSet(models, []byte(settings.MaskingConf.Masking.HashKey))),
+	}, nil
 }
@@ -2,13 +2,13 @@ package impl
 
 import (
 	domain "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/domain/demo"
-	repo "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/infrastructure/repository/db/gorm/demo"
+	repo "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/infrastructure/repository/db/gorm/masking_rule"
 )
 
 type useCase struct {
-	repoDemo repo.Repo
+	repoMaskingRule repo.Repo
 }
 
-func NewUseCase(repoDemo repo.Repo) domain.UseCase {
-	return &useCase{repoDemo: repoDemo}
+func NewUseCase(repoMaskingRule repo.Repo) domain.UseCase {
+	return &useCase{repoMaskingRule: repoMaskingRule}
 }
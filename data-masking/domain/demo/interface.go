@@ -2,6 +2,8 @@ package demo
 
 import (
 	"context"
+
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/domain/masking"
 )
 
 type UseCase interface {
@@ -28,6 +30,7 @@ type CreateReqQueryParam struct {
 type CreateReqBodyParam struct {
 	Fields    []*FieldInfo `json:"fields"`
 	TableName string       `json:"table_name" binding:"required"`
+	Dialect   string       `json:"dialect" binding:"omitempty,oneof=trino presto mysql mariadb dm8" example:"trino"` // 生成 SQL 的方言，默认为 trino
 }
 type FieldInfo struct {
 	Field       string `json:"field" bindging:"required,fl VerifyReq"`
@@ -35,6 +38,18 @@ type FieldInfo struct {
 	Sensitive   int    `json:"sensitive" bindging:"required"`
 	Classified  int    `json:"classified" bindging:"required"`
 	FieldType   string `json:"field_type" bindging:"required"`
+	GradeLabel  string `json:"grade_label"` // 分级标签，用于匹配脱敏规则
+}
+
+func (f *FieldInfo) ToField() *masking.Field {
+	return &masking.Field{
+		Name:        f.Field,
+		ChineseName: f.ChineseName,
+		DataType:    f.FieldType,
+		GradeLabel:  f.GradeLabel,
+		Sensitive:   f.Sensitive,
+		Classified:  f.Classified,
+	}
 }
 
 // type CreateReqBodyParam struct {
@@ -58,4 +73,6 @@ type FieldInfo struct {
 type CreateRespParam struct {
 	ID   string `json:"id" binding:"required,uuid" example:"4a5a3cc0-0169-4d62-9442-62214d8fcd8d"` // DemoID
 	Name string `json:"name" binding:"required,max=128" example:"demo_name"`                       // Demo名称
+
+	MaskedSQL string `json:"masked_sql" example:"SELECT CONCAT(SUBSTR(\"name\",1,1),RPAD('',GREATEST(LENGTH(\"name\")-1,0),'*')) AS \"name\" FROM t"` // 脱敏后的 SQL
 }
@@ -2,10 +2,14 @@ package domain
 
 import (
 	demo "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/domain/demo/impl"
+	masking_rule "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/domain/masking_rule/impl"
+	record_masking "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/domain/record_masking/impl"
 	"github.com/google/wire"
 )
 
 // ProviderSet is biz providers.
 var ProviderSet = wire.NewSet(
 	demo.NewUseCase,
+	masking_rule.NewUseCase,
+	record_masking.NewUseCase,
 )
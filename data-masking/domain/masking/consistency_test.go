@@ -0,0 +1,290 @@
+package masking
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMask_SQLMatchesRecords 同一个值分别按 SQL 脱敏表达式和记录脱敏处理，结果应该一致。
+// SQL 脱敏表达式由 trinoEval 按 Trino 的函数语义求值
+func TestMask_SQLMatchesRecords(t *testing.T) {
+	key := []byte("test-key")
+	rules := []*Rule{
+		{Algorithm: AlgorithmKeep, FirstN: 1},
+		{Algorithm: AlgorithmKeep, LastN: 4},
+		{Algorithm: AlgorithmKeep, FirstN: 3, LastN: 4, MaskChar: "#"},
+		{Algorithm: AlgorithmKeep},
+		{Algorithm: AlgorithmMaskRange, StartPos: 7, EndPos: 14},
+		{Algorithm: AlgorithmMaskRange, StartPos: 1, EndPos: 2},
+		{Algorithm: AlgorithmMaskRange, StartPos: 6, EndPos: 10},
+		{Algorithm: AlgorithmHash, HashKey: key},
+		{Algorithm: AlgorithmHash},
+		{Algorithm: AlgorithmReplace, Replacement: "it's"},
+		{Algorithm: AlgorithmNullify},
+	}
+	values := []struct {
+		dataType string
+		value    interface{}
+	}{
+		{dataType: "string", value: "张三丰"},
+		{dataType: "string", value: "13812345678"},
+		{dataType: "string", value: "110101199001011234"},
+		{dataType: "string", value: "ab"},
+		{dataType: "string", value: ""},
+		{dataType: "string", value: nil},
+		{dataType: "double", value: json.Number("1.0")},
+		{dataType: "int", value: json.Number("12")},
+		{dataType: "boolean", value: true},
+		{dataType: "int", value: nil},
+	}
+	for _, rule := range rules {
+		for _, v := range values {
+			name := fmt.Sprintf("%s %+v %s %v", rule.Algorithm, *rule, v.dataType, v.value)
+			field := &Field{Name: "c", DataType: v.dataType}
+			expr := maskExpr(DefaultDialect, DefaultDialect.QuoteIdentifier(field.Name), field, rule)
+			got, err := trinoEval(expr, v.value)
+			require.NoError(t, err, name+": "+expr)
+			assert.Equal(t, MaskValue(rule, v.value), got, name+": "+expr)
+		}
+	}
+}
+
+// trinoEval 按 Trino 的语义计算脱敏表达式，只支持脱敏表达式中用到的函数，column 为唯一的列的值
+func trinoEval(expr string, column interface{}) (interface{}, error) {
+	e := &evaluator{s: expr, column: column}
+	v, err := e.expr()
+	if err != nil {
+		return nil, err
+	}
+	if e.skipSpace(); e.pos != len(e.s) {
+		return nil, fmt.Errorf("unexpected %q", e.s[e.pos:])
+	}
+	return v, nil
+}
+
+type evaluator struct {
+	s      string
+	pos    int
+	column interface{}
+}
+
+func (e *evaluator) skipSpace() {
+	for e.pos < len(e.s) && e.s[e.pos] == ' ' {
+		e.pos++
+	}
+}
+
+// keyword 跳过关键字 kw，不存在时返回 false
+func (e *evaluator) keyword(kw string) bool {
+	e.skipSpace()
+	if strings.HasPrefix(e.s[e.pos:], kw) {
+		e.pos += len(kw)
+		return true
+	}
+	return false
+}
+
+func (e *evaluator) expect(kw string) error {
+	if !e.keyword(kw) {
+		return fmt.Errorf("expect %q at %q", kw, e.s[e.pos:])
+	}
+	return nil
+}
+
+// expr 加减法，NULL 参与运算结果为 NULL
+func (e *evaluator) expr() (interface{}, error) {
+	left, err := e.term()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		e.skipSpace()
+		if e.pos >= len(e.s) || (e.s[e.pos] != '+' && e.s[e.pos] != '-') {
+			return left, nil
+		}
+		op := e.s[e.pos]
+		e.pos++
+		right, err := e.term()
+		if err != nil {
+			return nil, err
+		}
+		if left == nil || right == nil {
+			left = nil
+			continue
+		}
+		if op == '+' {
+			left = left.(int) + right.(int)
+		} else {
+			left = left.(int) - right.(int)
+		}
+	}
+}
+
+func (e *evaluator) term() (interface{}, error) {
+	e.skipSpace()
+	switch {
+	case e.pos >= len(e.s):
+		return nil, fmt.Errorf("unexpected end")
+	case e.s[e.pos] == '\'':
+		value, end, ok := scanQuoted(e.s, e.pos, '\'')
+		if !ok {
+			return nil, fmt.Errorf("unterminated string")
+		}
+		e.pos = end
+		return value, nil
+	case e.s[e.pos] == '"':
+		_, end, ok := scanQuoted(e.s, e.pos, '"')
+		if !ok {
+			return nil, fmt.Errorf("unterminated identifier")
+		}
+		e.pos = end
+		return e.column, nil
+	case e.s[e.pos] >= '0' && e.s[e.pos] <= '9':
+		end := e.pos
+		for end < len(e.s) && e.s[end] >= '0' && e.s[end] <= '9' {
+			end++
+		}
+		n, _ := strconv.Atoi(e.s[e.pos:end])
+		e.pos = end
+		return n, nil
+	case e.keyword("NULL"):
+		return nil, nil
+	case e.keyword("CASE WHEN"):
+		return e.caseWhen()
+	}
+
+	end := strings.IndexByte(e.s[e.pos:], '(')
+	if end < 0 {
+		return nil, fmt.Errorf("unexpected %q", e.s[e.pos:])
+	}
+	name := e.s[e.pos : e.pos+end]
+	e.pos += end + 1
+	var args []interface{}
+	for !e.keyword(")") {
+		if len(args) > 0 {
+			if err := e.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := e.expr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return call(name, args)
+}
+
+// caseWhen CASE WHEN x IS NULL THEN a ELSE b END
+func (e *evaluator) caseWhen() (interface{}, error) {
+	cond, err := e.expr()
+	if err != nil {
+		return nil, err
+	}
+	if err = e.expect("IS NULL THEN"); err != nil {
+		return nil, err
+	}
+	then, err := e.expr()
+	if err != nil {
+		return nil, err
+	}
+	if err = e.expect("ELSE"); err != nil {
+		return nil, err
+	}
+	otherwise, err := e.expr()
+	if err != nil {
+		return nil, err
+	}
+	if err = e.expect("END"); err != nil {
+		return nil, err
+	}
+	if cond == nil {
+		return then, nil
+	}
+	return otherwise, nil
+}
+
+// scanQuoted 读取 s[i] 开始的引号内的内容，两个连续的引号表示一个引号
+func scanQuoted(s string, i int, quote byte) (string, int, bool) {
+	var b strings.Builder
+	for j := i + 1; j < len(s); j++ {
+		if s[j] != quote {
+			b.WriteByte(s[j])
+			continue
+		}
+		if j+1 < len(s) && s[j+1] == quote {
+			b.WriteByte(quote)
+			j++
+			continue
+		}
+		return b.String(), j + 1, true
+	}
+	return "", 0, false
+}
+
+func call(name string, args []interface{}) (interface{}, error) {
+	for _, arg := range args {
+		if arg == nil {
+			return nil, nil
+		}
+	}
+	switch name {
+	case "CONCAT":
+		var b strings.Builder
+		for _, arg := range args {
+			b.WriteString(arg.(string))
+		}
+		return b.String(), nil
+	case "SUBSTR":
+		runes := []rune(args[0].(string))
+		start := args[1].(int) - 1
+		if start >= len(runes) {
+			return "", nil
+		}
+		end := len(runes)
+		if len(args) == 3 && start+args[2].(int) < end {
+			end = start + args[2].(int)
+		}
+		return string(runes[start:end]), nil
+	case "RPAD":
+		s, size, pad := args[0].(string), args[1].(int), args[2].(string)
+		runes := []rune(s)
+		if size <= len(runes) {
+			return string(runes[:size]), nil
+		}
+		return s + strings.Repeat(pad, size-len(runes)), nil
+	case "LENGTH":
+		return utf8.RuneCountInString(args[0].(string)), nil
+	case "GREATEST", "LEAST":
+		n := args[0].(int)
+		for _, arg := range args[1:] {
+			if (name == "GREATEST") == (arg.(int) > n) {
+				n = arg.(int)
+			}
+		}
+		return n, nil
+	case "LOWER":
+		return strings.ToLower(args[0].(string)), nil
+	case "TO_UTF8":
+		return []byte(args[0].(string)), nil
+	case "FROM_HEX":
+		return hex.DecodeString(args[0].(string))
+	case "TO_HEX":
+		return strings.ToUpper(hex.EncodeToString(args[0].([]byte))), nil
+	case "HMAC_SHA256":
+		mac := hmac.New(sha256.New, args[1].([]byte))
+		mac.Write(args[0].([]byte))
+		return mac.Sum(nil), nil
+	}
+	return nil, fmt.Errorf("unsupported function %s", name)
+}
@@ -0,0 +1,201 @@
+package masking
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Dialect 生成脱敏表达式用到的 SQL 函数。各数据库的字符串函数都按字符计算长度和位置
+type Dialect interface {
+	// Name 方言名称
+	Name() string
+	// QuoteIdentifier 转义标识符
+	QuoteIdentifier(name string) string
+	// QuoteString 转义字符串字面量
+	QuoteString(s string) string
+	// Length 字符串的字符数
+	Length(expr string) string
+	// Substr 从第 start 个字符开始截取 length 个字符，length 为空时截取到结尾
+	Substr(expr, start, length string) string
+	// Repeat 将字符串字面量 s 重复 count 次，count 不大于 0 时为空字符串
+	Repeat(s, count string) string
+	// Concat 拼接字符串
+	Concat(exprs ...string) string
+	// Hash 以 key 为密钥的 HMAC-SHA256 摘要的十六进制小写字符串
+	Hash(expr string, key []byte) string
+}
+
+// 支持的方言名称
+const (
+	DialectTrino   = "trino"
+	DialectPresto  = "presto"
+	DialectMySQL   = "mysql"
+	DialectMariaDB = "mariadb"
+	DialectDM8     = "dm8"
+)
+
+// DefaultDialect 未指定方言时使用虚拟化引擎的语法
+var DefaultDialect Dialect = trino{}
+
+var dialects = map[string]Dialect{
+	DialectTrino:   trino{},
+	DialectPresto:  trino{},
+	DialectMySQL:   mysql{},
+	DialectMariaDB: mysql{},
+	DialectDM8:     dm8{},
+}
+
+// GetDialect 按名称获取方言，名称不区分大小写，为空时返回 DefaultDialect
+func GetDialect(name string) (Dialect, error) {
+	if name == "" {
+		return DefaultDialect, nil
+	}
+	d, ok := dialects[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("不支持的 SQL 方言 %q", name)
+	}
+	return d, nil
+}
+
+// trino Trino/Presto，虚拟化引擎使用的语法
+type trino struct{}
+
+func (trino) Name() string { return DialectTrino }
+
+func (trino) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (trino) QuoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func (trino) Length(expr string) string {
+	return fmt.Sprintf("LENGTH(%s)", expr)
+}
+
+func (trino) Substr(expr, start, length string) string {
+	if length == "" {
+		return fmt.Sprintf("SUBSTR(%s,%s)", expr, start)
+	}
+	return fmt.Sprintf("SUBSTR(%s,%s,%s)", expr, start, length)
+}
+
+// Repeat Trino 的 repeat 返回数组，使用 rpad 补齐空字符串
+func (trino) Repeat(s, count string) string {
+	return fmt.Sprintf("RPAD('',GREATEST(%s,0),%s)", count, s)
+}
+
+func (trino) Concat(exprs ...string) string {
+	return concat(exprs)
+}
+
+func (trino) Hash(expr string, key []byte) string {
+	return fmt.Sprintf("LOWER(TO_HEX(HMAC_SHA256(TO_UTF8(%s),FROM_HEX('%s'))))", expr, hex.EncodeToString(key))
+}
+
+// mysql MySQL/MariaDB
+type mysql struct{}
+
+func (mysql) Name() string { return DialectMySQL }
+
+func (mysql) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// QuoteString 默认的 sql_mode 中反斜杠是转义字符，同样需要转义
+func (mysql) QuoteString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// Length LENGTH 返回字节数，按字符计算使用 CHAR_LENGTH
+func (mysql) Length(expr string) string {
+	return fmt.Sprintf("CHAR_LENGTH(%s)", expr)
+}
+
+func (mysql) Substr(expr, start, length string) string {
+	if length == "" {
+		return fmt.Sprintf("SUBSTRING(%s,%s)", expr, start)
+	}
+	return fmt.Sprintf("SUBSTRING(%s,%s,%s)", expr, start, length)
+}
+
+func (mysql) Repeat(s, count string) string {
+	return fmt.Sprintf("REPEAT(%s,GREATEST(%s,0))", s, count)
+}
+
+func (mysql) Concat(exprs ...string) string {
+	return concat(exprs)
+}
+
+// Hash MySQL 没有 HMAC 函数，按 HMAC 的定义由两次 SHA2 计算
+func (mysql) Hash(expr string, key []byte) string {
+	ipad, opad := hmacPads(key)
+	return fmt.Sprintf("SHA2(CONCAT(UNHEX('%s'),UNHEX(SHA2(CONCAT(UNHEX('%s'),%s),256))),256)", opad, ipad, expr)
+}
+
+// dm8 达梦 DM8
+type dm8 struct{}
+
+func (dm8) Name() string { return DialectDM8 }
+
+func (dm8) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (dm8) QuoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func (dm8) Length(expr string) string {
+	return fmt.Sprintf("LENGTH(%s)", expr)
+}
+
+func (dm8) Substr(expr, start, length string) string {
+	if length == "" {
+		return fmt.Sprintf("SUBSTR(%s,%s)", expr, start)
+	}
+	return fmt.Sprintf("SUBSTR(%s,%s,%s)", expr, start, length)
+}
+
+func (dm8) Repeat(s, count string) string {
+	return fmt.Sprintf("REPEAT(%s,GREATEST(%s,0))", s, count)
+}
+
+func (dm8) Concat(exprs ...string) string {
+	return concat(exprs)
+}
+
+// Hash 使用 DBMS_CRYPTO 系统包，需要数据库已创建系统包。按 HMAC 的定义由两次 SHA-256 计算
+func (dm8) Hash(expr string, key []byte) string {
+	ipad, opad := hmacPads(key)
+	inner := fmt.Sprintf("DBMS_CRYPTO.HASH(UTL_RAW.CONCAT(HEXTORAW('%s'),UTL_RAW.CAST_TO_RAW(%s)),DBMS_CRYPTO.HASH_SH256)", ipad, expr)
+	return fmt.Sprintf("LOWER(RAWTOHEX(DBMS_CRYPTO.HASH(UTL_RAW.CONCAT(HEXTORAW('%s'),%s),DBMS_CRYPTO.HASH_SH256)))", opad, inner)
+}
+
+// hmacPads 返回 HMAC-SHA256 的内层、外层填充后的密钥的十六进制字符串，即 H((K^opad)||H((K^ipad)||m)) 中的 K^ipad 和 K^opad
+func hmacPads(key []byte) (ipad, opad string) {
+	if len(key) > sha256.BlockSize {
+		sum := sha256.Sum256(key)
+		key = sum[:]
+	}
+	inner := make([]byte, sha256.BlockSize)
+	outer := make([]byte, sha256.BlockSize)
+	copy(inner, key)
+	copy(outer, key)
+	for i := range inner {
+		inner[i] ^= 0x36
+		outer[i] ^= 0x5c
+	}
+	return hex.EncodeToString(inner), hex.EncodeToString(outer)
+}
+
+func concat(exprs []string) string {
+	if len(exprs) == 1 {
+		return exprs[0]
+	}
+	return "CONCAT(" + strings.Join(exprs, ",") + ")"
+}
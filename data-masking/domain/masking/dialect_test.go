@@ -0,0 +1,110 @@
+package masking
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test ./domain/masking -run Golden -update 更新 testdata 中的 golden 文件
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGetDialect(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "", want: DialectTrino},
+		{name: "trino", want: DialectTrino},
+		{name: "Presto", want: DialectTrino},
+		{name: "mysql", want: DialectMySQL},
+		{name: "mariadb", want: DialectMySQL},
+		{name: "DM8", want: DialectDM8},
+		{name: "oracle", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := GetDialect(tt.name)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, d.Name())
+		})
+	}
+}
+
+// TestProjection_Golden 每个方言下每个脱敏函数生成的查询列
+func TestProjection_Golden(t *testing.T) {
+	cases := []struct {
+		name  string
+		field Field
+		rule  *Rule
+	}{
+		{name: "none", field: Field{Name: "age", DataType: "int"}},
+		{name: "keep_first", field: Field{Name: "name", DataType: "string"}, rule: &Rule{Algorithm: AlgorithmKeep, FirstN: 1}},
+		{name: "keep_last", field: Field{Name: "passport", DataType: "string"}, rule: &Rule{Algorithm: AlgorithmKeep, LastN: 4}},
+		{name: "keep_first_last", field: Field{Name: "phone", DataType: "string"}, rule: &Rule{Algorithm: AlgorithmKeep, FirstN: 3, LastN: 4, MaskChar: "#"}},
+		{name: "mask_range", field: Field{Name: "id_card", DataType: "string"}, rule: &Rule{Algorithm: AlgorithmMaskRange, StartPos: 7, EndPos: 14}},
+		{name: "mask_range_from_start", field: Field{Name: "code", DataType: "string"}, rule: &Rule{Algorithm: AlgorithmMaskRange, StartPos: 1, EndPos: 2}},
+		{name: "hash", field: Field{Name: "email", DataType: "string"}, rule: &Rule{Algorithm: AlgorithmHash, HashKey: []byte("test-key")}},
+		{name: "hash_without_key", field: Field{Name: "email", DataType: "string"}, rule: &Rule{Algorithm: AlgorithmHash}},
+		{name: "keep_non_string", field: Field{Name: "amount", DataType: "int"}, rule: &Rule{Algorithm: AlgorithmKeep, FirstN: 1}},
+		{name: "replace", field: Field{Name: "remark", DataType: "string"}, rule: &Rule{Algorithm: AlgorithmReplace, Replacement: `it's \N`}},
+		{name: "nullify", field: Field{Name: "salary", DataType: "double"}, rule: &Rule{Algorithm: AlgorithmNullify}},
+		{name: "quote_identifier", field: Field{Name: "a\"b`c", DataType: "string"}, rule: &Rule{Algorithm: AlgorithmKeep, FirstN: 1}},
+	}
+	for _, dialect := range []string{DialectTrino, DialectMySQL, DialectDM8} {
+		d, err := GetDialect(dialect)
+		require.NoError(t, err)
+
+		t.Run(dialect, func(t *testing.T) {
+			var b strings.Builder
+			for _, c := range cases {
+				b.WriteString(c.name + ": " + Projection(d, &c.field, c.rule) + "\n")
+			}
+			assertGolden(t, dialect, b.String())
+		})
+	}
+}
+
+// TestHMACPads MySQL、DM8 按填充后的密钥计算的 HMAC 与标准库的结果一致
+func TestHMACPads(t *testing.T) {
+	for _, key := range [][]byte{[]byte("test-key"), bytes.Repeat([]byte("k"), sha256.BlockSize+1)} {
+		ipad, opad := hmacPads(key)
+		inner, err := hex.DecodeString(ipad)
+		require.NoError(t, err)
+		outer, err := hex.DecodeString(opad)
+		require.NoError(t, err)
+
+		innerSum := sha256.Sum256(append(inner, "abc"...))
+		got := sha256.Sum256(append(outer, innerSum[:]...))
+
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte("abc"))
+		assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), hex.EncodeToString(got[:]))
+	}
+}
+
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), got)
+}
@@ -0,0 +1,164 @@
+package masking
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Path 解析后的 JSONPath，支持 JSONPath 的以下子集：
+//
+//	$             根节点，即一条记录
+//	.name ['name'] 对象的成员
+//	[0]           数组的元素
+//	.* [*]        对象的所有成员或数组的所有元素
+type Path struct {
+	raw   string
+	steps []step
+}
+
+type step struct {
+	name     string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// ParsePath 解析 JSONPath，必须以 $ 开头
+func ParsePath(s string) (*Path, error) {
+	p := &Path{raw: s}
+	if !strings.HasPrefix(s, "$") {
+		return nil, fmt.Errorf("JSONPath %q 应以 $ 开头", s)
+	}
+	rest := s[1:]
+	for rest != "" {
+		var st step
+		var err error
+		switch rest[0] {
+		case '.':
+			st, rest, err = parseDotStep(rest[1:])
+		case '[':
+			st, rest, err = parseBracketStep(rest[1:])
+		default:
+			err = fmt.Errorf("不支持的语法 %q", rest)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("JSONPath %q 不合法: %w", s, err)
+		}
+		p.steps = append(p.steps, st)
+	}
+	return p, nil
+}
+
+func parseDotStep(s string) (step, string, error) {
+	if strings.HasPrefix(s, ".") {
+		return step{}, "", fmt.Errorf("不支持递归查找 ..")
+	}
+	end := strings.IndexAny(s, ".[")
+	if end < 0 {
+		end = len(s)
+	}
+	name := s[:end]
+	if name == "" {
+		return step{}, "", fmt.Errorf("成员名称为空")
+	}
+	if name == "*" {
+		return step{wildcard: true}, s[end:], nil
+	}
+	return step{name: name}, s[end:], nil
+}
+
+func parseBracketStep(s string) (step, string, error) {
+	if s != "" && (s[0] == '\'' || s[0] == '"') {
+		quote := s[0]
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			switch {
+			case s[i] == '\\' && i+1 < len(s):
+				i++
+				b.WriteByte(s[i])
+			case s[i] == quote:
+				if i+1 >= len(s) || s[i+1] != ']' {
+					return step{}, "", fmt.Errorf("缺少 ]")
+				}
+				return step{name: b.String()}, s[i+2:], nil
+			default:
+				b.WriteByte(s[i])
+			}
+		}
+		return step{}, "", fmt.Errorf("缺少引号")
+	}
+
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return step{}, "", fmt.Errorf("缺少 ]")
+	}
+	content := strings.TrimSpace(s[:end])
+	if content == "*" {
+		return step{wildcard: true}, s[end+1:], nil
+	}
+	index, err := strconv.Atoi(content)
+	if err != nil || index < 0 {
+		return step{}, "", fmt.Errorf("数组下标 %q 不合法", content)
+	}
+	return step{index: index, isIndex: true}, s[end+1:], nil
+}
+
+func (p *Path) String() string {
+	return p.raw
+}
+
+// Name 最后一个成员的名称，用于按字段名称匹配规则
+func (p *Path) Name() string {
+	for i := len(p.steps) - 1; i >= 0; i-- {
+		if p.steps[i].name != "" {
+			return p.steps[i].name
+		}
+	}
+	return ""
+}
+
+// Replace 将 doc 中所有匹配的值替换为 fn 的返回值，对象和数组在原处修改，返回替换后的 doc。
+// 不存在的成员和越界的下标会被忽略
+func (p *Path) Replace(doc interface{}, fn func(v interface{}) interface{}) interface{} {
+	return p.replaceAt(doc, func(_ string, v interface{}) interface{} { return fn(v) })
+}
+
+// replaceAt 同 Replace，fn 的 loc 为匹配的值在 doc 中的位置，不同 JSONPath 匹配同一个值时 loc 相同
+func (p *Path) replaceAt(doc interface{}, fn func(loc string, v interface{}) interface{}) interface{} {
+	return replace(doc, "$", p.steps, fn)
+}
+
+func replace(v interface{}, loc string, steps []step, fn func(loc string, v interface{}) interface{}) interface{} {
+	if len(steps) == 0 {
+		return fn(loc, v)
+	}
+	st, rest := steps[0], steps[1:]
+	switch node := v.(type) {
+	case map[string]interface{}:
+		if st.wildcard {
+			for k, child := range node {
+				node[k] = replace(child, memberLoc(loc, k), rest, fn)
+			}
+		} else if child, ok := node[st.name]; ok && !st.isIndex {
+			node[st.name] = replace(child, memberLoc(loc, st.name), rest, fn)
+		}
+	case []interface{}:
+		if st.wildcard {
+			for i, child := range node {
+				node[i] = replace(child, indexLoc(loc, i), rest, fn)
+			}
+		} else if st.isIndex && st.index < len(node) {
+			node[st.index] = replace(node[st.index], indexLoc(loc, st.index), rest, fn)
+		}
+	}
+	return v
+}
+
+func memberLoc(loc, name string) string {
+	return loc + "[" + strconv.Quote(name) + "]"
+}
+
+func indexLoc(loc string, index int) string {
+	return loc + "[" + strconv.Itoa(index) + "]"
+}
@@ -0,0 +1,155 @@
+package masking
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRule_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr bool
+	}{
+		{name: "keep", rule: Rule{FieldPattern: "*phone*", Algorithm: AlgorithmKeep, FirstN: 3, LastN: 4}},
+		{name: "mask range", rule: Rule{DataType: "string", Algorithm: AlgorithmMaskRange, StartPos: 4, EndPos: 7}},
+		{name: "hash", rule: Rule{GradeLabel: "敏感", Algorithm: AlgorithmHash}},
+		{name: "replace", rule: Rule{FieldPattern: "姓名", Algorithm: AlgorithmReplace, Replacement: "***"}},
+		{name: "nullify", rule: Rule{FieldPattern: "id_?", Algorithm: AlgorithmNullify}},
+		{name: "no condition", rule: Rule{Algorithm: AlgorithmHash}, wantErr: true},
+		{name: "unknown algorithm", rule: Rule{DataType: "string", Algorithm: "shuffle"}, wantErr: true},
+		{name: "negative keep", rule: Rule{DataType: "string", Algorithm: AlgorithmKeep, FirstN: -1}, wantErr: true},
+		{name: "range start", rule: Rule{DataType: "string", Algorithm: AlgorithmMaskRange, StartPos: 0, EndPos: 3}, wantErr: true},
+		{name: "range end", rule: Rule{DataType: "string", Algorithm: AlgorithmMaskRange, StartPos: 4, EndPos: 3}, wantErr: true},
+		{name: "mask char", rule: Rule{DataType: "string", Algorithm: AlgorithmKeep, MaskChar: "**"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			assert.Equal(t, tt.wantErr, err != nil, "err: %v", err)
+		})
+	}
+}
+
+func TestRule_Match(t *testing.T) {
+	field := &Field{Name: "mobile_phone", ChineseName: "电话号码", DataType: "string", GradeLabel: "敏感"}
+	tests := []struct {
+		name string
+		rule Rule
+		want bool
+	}{
+		{name: "name pattern", rule: Rule{FieldPattern: "*PHONE"}, want: true},
+		{name: "chinese name", rule: Rule{FieldPattern: "电话号码"}, want: true},
+		{name: "single char wildcard", rule: Rule{FieldPattern: "mobile?phone"}, want: true},
+		{name: "pattern is not regexp", rule: Rule{FieldPattern: "mobile.phone"}},
+		{name: "partial name", rule: Rule{FieldPattern: "phone"}},
+		{name: "grade label", rule: Rule{GradeLabel: "敏感"}, want: true},
+		{name: "other grade label", rule: Rule{GradeLabel: "公开"}},
+		{name: "data type", rule: Rule{DataType: "STRING"}, want: true},
+		{name: "all conditions", rule: Rule{FieldPattern: "*phone*", GradeLabel: "敏感", DataType: "string"}, want: true},
+		{name: "one condition not met", rule: Rule{FieldPattern: "*phone*", DataType: "int"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.rule.Match(field))
+		})
+	}
+}
+
+func TestRuleSet_Match(t *testing.T) {
+	low := &Rule{Name: "low", DataType: "string", Algorithm: AlgorithmHash, Priority: 10}
+	high := &Rule{Name: "high", FieldPattern: "*phone*", Algorithm: AlgorithmKeep, Priority: 1}
+	rules := NewRuleSet([]*Rule{low, high})
+
+	assert.Same(t, high, rules.Match(&Field{Name: "phone", DataType: "string"}))
+	assert.Same(t, low, rules.Match(&Field{Name: "name", DataType: "string"}))
+	assert.Nil(t, rules.Match(&Field{Name: "age", DataType: "int"}))
+	// 没有匹配的规则时，敏感或涉密的字符串字段整体替换
+	assert.Same(t, sensitiveRule, NewRuleSet(nil).Match(&Field{Name: "name", DataType: "string", Sensitive: 1}))
+	assert.Nil(t, NewRuleSet(nil).Match(&Field{Name: "age", DataType: "int", Classified: 1}))
+}
+
+func TestProjection(t *testing.T) {
+	tests := []struct {
+		name  string
+		field Field
+		rule  *Rule
+		want  string
+	}{
+		{
+			name:  "no rule",
+			field: Field{Name: "age", DataType: "int"},
+			want:  `"age"`,
+		},
+		{
+			name:  "keep first",
+			field: Field{Name: "name", DataType: "string"},
+			rule:  &Rule{Algorithm: AlgorithmKeep, FirstN: 1},
+			want:  `CONCAT(SUBSTR("name",1,1),RPAD('',GREATEST(LENGTH("name")-1,0),'*')) AS "name"`,
+		},
+		{
+			name:  "keep first and last",
+			field: Field{Name: "phone", DataType: "string"},
+			rule:  &Rule{Algorithm: AlgorithmKeep, FirstN: 3, LastN: 4, MaskChar: "#"},
+			want:  `CONCAT(SUBSTR("phone",1,3),RPAD('',GREATEST(LENGTH("phone")-7,0),'#'),SUBSTR("phone",GREATEST(LENGTH("phone")-3,4))) AS "phone"`,
+		},
+		{
+			name:  "keep nothing",
+			field: Field{Name: "code", DataType: "string"},
+			rule:  &Rule{Algorithm: AlgorithmKeep},
+			want:  `RPAD('',GREATEST(LENGTH("code")-0,0),'*') AS "code"`,
+		},
+		{
+			name:  "mask range",
+			field: Field{Name: "id_card", DataType: "string"},
+			rule:  &Rule{Algorithm: AlgorithmMaskRange, StartPos: 7, EndPos: 14},
+			want:  `CONCAT(SUBSTR("id_card",1,6),RPAD('',GREATEST(LEAST(LENGTH("id_card"),14)-6,0),'*'),SUBSTR("id_card",15)) AS "id_card"`,
+		},
+		{
+			name:  "mask range from start",
+			field: Field{Name: "code", DataType: "string"},
+			rule:  &Rule{Algorithm: AlgorithmMaskRange, StartPos: 1, EndPos: 2},
+			want:  `CONCAT(RPAD('',GREATEST(LEAST(LENGTH("code"),2)-0,0),'*'),SUBSTR("code",3)) AS "code"`,
+		},
+		{
+			name:  "hash non string",
+			field: Field{Name: "amount", DataType: "int"},
+			rule:  &Rule{Algorithm: AlgorithmHash, HashKey: []byte("k")},
+			want:  `NULL AS "amount"`,
+		},
+		{
+			name:  "replace",
+			field: Field{Name: `a"b`, DataType: "string"},
+			rule:  &Rule{Algorithm: AlgorithmReplace, Replacement: "it's"},
+			want:  `CASE WHEN "a""b" IS NULL THEN NULL ELSE 'it''s' END AS "a""b"`,
+		},
+		{
+			name:  "nullify",
+			field: Field{Name: "email", DataType: "string"},
+			rule:  &Rule{Algorithm: AlgorithmNullify},
+			want:  `NULL AS "email"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Projection(DefaultDialect, &tt.field, tt.rule))
+		})
+	}
+}
+
+func TestCompileSelect(t *testing.T) {
+	rules := NewRuleSet([]*Rule{
+		{FieldPattern: "姓名", Algorithm: AlgorithmKeep, FirstN: 1},
+		{GradeLabel: "机密", Algorithm: AlgorithmNullify},
+	})
+	fields := []*Field{
+		{Name: "name", ChineseName: "姓名", DataType: "string"},
+		{Name: "salary", ChineseName: "薪资", DataType: "double", GradeLabel: "机密"},
+		{Name: "remark", ChineseName: "备注", DataType: "string", Sensitive: 1},
+		{Name: "age", ChineseName: "年龄", DataType: "int"},
+	}
+	assert.Equal(t,
+		`SELECT CONCAT(SUBSTR("name",1,1),RPAD('',GREATEST(LENGTH("name")-1,0),'*')) AS "name",NULL AS "salary",CASE WHEN "remark" IS NULL THEN NULL ELSE '******' END AS "remark","age" FROM t`,
+		CompileSelect(DefaultDialect, "t", fields, rules))
+}
@@ -0,0 +1,68 @@
+package masking
+
+// FieldRule 记录中需要脱敏的字段，Path 为相对于每条记录的 JSONPath。
+// Field 用于匹配规则，未指定字段名称时使用 JSONPath 最后一个成员的名称
+type FieldRule struct {
+	Path  string
+	Field Field
+}
+
+// RecordMasker 按字段匹配的规则脱敏 JSON 记录
+type RecordMasker struct {
+	fields []maskedField
+}
+
+type maskedField struct {
+	path *Path
+	rule *Rule
+}
+
+// NewRecordMasker 解析 JSONPath 并为每个字段匹配规则，与 SQL 脱敏使用同样的匹配逻辑
+func NewRecordMasker(fieldRules []FieldRule, rules RuleSet) (*RecordMasker, error) {
+	m := &RecordMasker{}
+	for _, fr := range fieldRules {
+		path, err := ParsePath(fr.Path)
+		if err != nil {
+			return nil, err
+		}
+		field := fr.Field
+		if field.Name == "" {
+			field.Name = path.Name()
+		}
+		rule := rules.Match(&field)
+		if rule == nil {
+			continue
+		}
+		// 与 SQL 脱敏一致，按字符处理的算法不适用于非字符串类型的字段
+		if field.DataType != "" && !isStringType(field.DataType) && rule.masksText() {
+			rule = nonStringRule
+		}
+		m.fields = append(m.fields, maskedField{path: path, rule: rule})
+	}
+	return m, nil
+}
+
+// Mask 在原处脱敏一条记录，返回脱敏后的记录。
+// 多个 JSONPath 匹配同一个值时，只按排在前面的字段的规则脱敏一次
+func (m *RecordMasker) Mask(record interface{}) interface{} {
+	masked := make(map[string]struct{})
+	for _, f := range m.fields {
+		rule := f.rule
+		record = f.path.replaceAt(record, func(loc string, v interface{}) interface{} {
+			if _, ok := masked[loc]; ok {
+				return v
+			}
+			masked[loc] = struct{}{}
+			return MaskValue(rule, v)
+		})
+	}
+	return record
+}
+
+// MaskRecords 脱敏所有记录
+func (m *RecordMasker) MaskRecords(records []interface{}) []interface{} {
+	for i := range records {
+		records[i] = m.Mask(records[i])
+	}
+	return records
+}
@@ -0,0 +1,151 @@
+package masking
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path     string
+		wantName string
+		wantErr  bool
+	}{
+		{path: "$", wantName: ""},
+		{path: "$.phone", wantName: "phone"},
+		{path: "$.user.phone", wantName: "phone"},
+		{path: "$['user']['电话号码']", wantName: "电话号码"},
+		{path: `$["a.b"]`, wantName: "a.b"},
+		{path: "$.orders[*].amount", wantName: "amount"},
+		{path: "$.tags[0]", wantName: "tags"},
+		{path: "$.*", wantName: ""},
+		{path: "phone", wantErr: true},
+		{path: "$..phone", wantErr: true},
+		{path: "$.", wantErr: true},
+		{path: "$[-1]", wantErr: true},
+		{path: "$[a]", wantErr: true},
+		{path: "$['a'", wantErr: true},
+		{path: "$.a b", wantName: "a b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			p, err := ParsePath(tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantName, p.Name())
+			assert.Equal(t, tt.path, p.String())
+		})
+	}
+}
+
+// TestMaskValue 与 TestProjection 中 SQL 表达式的结果一致
+func TestMaskValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  Rule
+		value interface{}
+		want  interface{}
+	}{
+		{name: "keep first", rule: Rule{Algorithm: AlgorithmKeep, FirstN: 1}, value: "张三丰", want: "张**"},
+		{name: "keep first and last", rule: Rule{Algorithm: AlgorithmKeep, FirstN: 3, LastN: 4, MaskChar: "#"}, value: "13812345678", want: "138####5678"},
+		{name: "keep short value", rule: Rule{Algorithm: AlgorithmKeep, FirstN: 3, LastN: 4}, value: "12345", want: "12345"},
+		{name: "keep nothing", rule: Rule{Algorithm: AlgorithmKeep}, value: "abc", want: "***"},
+		{name: "mask range", rule: Rule{Algorithm: AlgorithmMaskRange, StartPos: 7, EndPos: 14}, value: "110101199001011234", want: "110101********1234"},
+		{name: "mask range beyond value", rule: Rule{Algorithm: AlgorithmMaskRange, StartPos: 3, EndPos: 10}, value: "abcd", want: "ab**"},
+		{name: "mask range after value", rule: Rule{Algorithm: AlgorithmMaskRange, StartPos: 6, EndPos: 10}, value: "abcd", want: "abcd"},
+		{name: "hash", rule: Rule{Algorithm: AlgorithmHash, HashKey: []byte("test-key")}, value: "abc", want: "5d0ea494ece26078f3d279ea524a2dd1c6525fa4719127a4e72fac5fb0e3a9be"},
+		{name: "hash without key", rule: Rule{Algorithm: AlgorithmHash}, value: "abc", want: nil},
+		{name: "number", rule: Rule{Algorithm: AlgorithmKeep, FirstN: 1}, value: json.Number("12.50"), want: nil},
+		{name: "bool", rule: Rule{Algorithm: AlgorithmKeep, LastN: 1}, value: true, want: nil},
+		{name: "object", rule: Rule{Algorithm: AlgorithmHash, HashKey: []byte("test-key")}, value: map[string]interface{}{"a": "b"}, want: nil},
+		{name: "null", rule: Rule{Algorithm: AlgorithmKeep, FirstN: 1}, value: nil, want: nil},
+		{name: "replace", rule: Rule{Algorithm: AlgorithmReplace, Replacement: "***"}, value: json.Number("1"), want: "***"},
+		{name: "replace null", rule: Rule{Algorithm: AlgorithmReplace, Replacement: "***"}, value: nil, want: nil},
+		{name: "nullify", rule: Rule{Algorithm: AlgorithmNullify}, value: "abc", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MaskValue(&tt.rule, tt.value))
+		})
+	}
+}
+
+func TestRecordMasker(t *testing.T) {
+	rules := NewRuleSet([]*Rule{
+		{FieldPattern: "电话号码", Algorithm: AlgorithmKeep, FirstN: 3, LastN: 4},
+		{FieldPattern: "name", Algorithm: AlgorithmKeep, FirstN: 1},
+		{GradeLabel: "机密", Algorithm: AlgorithmNullify},
+	})
+	masker, err := NewRecordMasker([]FieldRule{
+		{Path: "$.user.name"},
+		{Path: "$.user.contact['mobile']", Field: Field{ChineseName: "电话号码"}},
+		{Path: "$.orders[*].amount", Field: Field{GradeLabel: "机密"}},
+		{Path: "$.remark", Field: Field{DataType: "string", Sensitive: 1}},
+		{Path: "$.age"},
+	}, rules)
+	require.NoError(t, err)
+
+	records := decodeRecords(t, `[
+		{"user":{"name":"张三","contact":{"mobile":"13812345678"}},"orders":[{"amount":10},{"amount":20.5}],"remark":"x","age":30},
+		{"user":{"name":null},"orders":[],"age":18},
+		{"other":1}
+	]`)
+	got, err := json.Marshal(masker.MaskRecords(records))
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"user":{"name":"张*","contact":{"mobile":"138****5678"}},"orders":[{"amount":null},{"amount":null}],"remark":"******","age":30},
+		{"user":{"name":null},"orders":[],"age":18},
+		{"other":1}
+	]`, string(got))
+
+	_, err = NewRecordMasker([]FieldRule{{Path: "user.name"}}, rules)
+	assert.Error(t, err)
+}
+
+// TestRecordMasker_overlappingPaths 多个 JSONPath 匹配同一个值时只脱敏一次，使用排在前面的字段的规则
+func TestRecordMasker_overlappingPaths(t *testing.T) {
+	hash := &Rule{FieldPattern: "name", Algorithm: AlgorithmHash, HashKey: []byte("test-key")}
+	rules := NewRuleSet([]*Rule{hash, {FieldPattern: "nick", Algorithm: AlgorithmKeep, FirstN: 1}})
+	masker, err := NewRecordMasker([]FieldRule{
+		{Path: "$.user.name"},
+		{Path: "$.user['name']"},
+		{Path: "$.user.*", Field: Field{Name: "nick"}},
+		{Path: "$.users[*].name"},
+		{Path: "$.users[0].name"},
+	}, rules)
+	require.NoError(t, err)
+
+	records := decodeRecords(t, `[{"user":{"name":"张三","alias":"李四"},"users":[{"name":"王五"}]}]`)
+	got := masker.MaskRecords(records)[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"name": MaskValue(hash, "张三"), "alias": "李*"}, got["user"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": MaskValue(hash, "王五")}}, got["users"])
+}
+
+// TestRecordMasker_nonStringField 字段的类型不是字符串时，按字符处理的算法与 SQL 脱敏一样置为 null
+func TestRecordMasker_nonStringField(t *testing.T) {
+	rules := NewRuleSet([]*Rule{{FieldPattern: "*", Algorithm: AlgorithmKeep, FirstN: 1}})
+	masker, err := NewRecordMasker([]FieldRule{
+		{Path: "$.code", Field: Field{DataType: "int"}},
+		{Path: "$.name", Field: Field{DataType: "string"}},
+	}, rules)
+	require.NoError(t, err)
+
+	got := masker.Mask(map[string]interface{}{"code": "123", "name": "张三"})
+	assert.Equal(t, map[string]interface{}{"code": nil, "name": "张*"}, got)
+}
+
+func decodeRecords(t *testing.T, s string) []interface{} {
+	t.Helper()
+	decoder := json.NewDecoder(bytes.NewReader([]byte(s)))
+	decoder.UseNumber()
+	var records []interface{}
+	require.NoError(t, decoder.Decode(&records))
+	return records
+}
@@ -0,0 +1,210 @@
+package masking
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Algorithm 脱敏算法
+type Algorithm string
+
+const (
+	// AlgorithmKeep 保留前 FirstN 位和后 LastN 位，其余字符替换为掩码字符
+	AlgorithmKeep Algorithm = "keep"
+	// AlgorithmMaskRange 将第 StartPos 位到第 EndPos 位（从 1 开始，包含两端）替换为掩码字符
+	AlgorithmMaskRange Algorithm = "mask_range"
+	// AlgorithmHash 替换为以 HashKey 为密钥的 HMAC-SHA256 摘要的十六进制小写字符串
+	AlgorithmHash Algorithm = "hash"
+	// AlgorithmReplace 替换为固定的字符串 Replacement
+	AlgorithmReplace Algorithm = "replace"
+	// AlgorithmNullify 置为 NULL
+	AlgorithmNullify Algorithm = "nullify"
+)
+
+// Algorithms 支持的脱敏算法
+var Algorithms = []Algorithm{AlgorithmKeep, AlgorithmMaskRange, AlgorithmHash, AlgorithmReplace, AlgorithmNullify}
+
+// DefaultMaskChar 未配置掩码字符时使用的掩码字符
+const DefaultMaskChar = "*"
+
+// Rule 脱敏规则。FieldPattern、GradeLabel、DataType 为匹配条件，配置了的条件需要同时满足
+type Rule struct {
+	ID   string
+	Name string
+
+	// FieldPattern 字段名称的通配符，匹配字段的英文名或中文名，* 匹配任意个字符，? 匹配一个字符，不区分大小写
+	FieldPattern string
+	// GradeLabel 字段的分级标签
+	GradeLabel string
+	// DataType 字段的数据类型，不区分大小写
+	DataType string
+
+	Algorithm   Algorithm
+	FirstN      int
+	LastN       int
+	StartPos    int
+	EndPos      int
+	MaskChar    string
+	Replacement string
+
+	// Priority 多个规则匹配同一字段时，取值小的优先
+	Priority int
+
+	// HashKey AlgorithmHash 的 HMAC 密钥，来自服务配置。为空时脱敏后为 NULL，不输出可被枚举还原的无密钥摘要
+	HashKey []byte
+
+	pattern *regexp.Regexp
+}
+
+// Field 需要脱敏的字段
+type Field struct {
+	// Name 字段的英文名
+	Name string
+	// ChineseName 字段的中文名
+	ChineseName string
+	// DataType 字段的数据类型
+	DataType string
+	// GradeLabel 字段的分级标签
+	GradeLabel string
+	// Sensitive 是否敏感，1 为敏感
+	Sensitive int
+	// Classified 是否涉密，1 为涉密
+	Classified int
+}
+
+// Validate 检查规则的匹配条件和算法参数
+func (r *Rule) Validate() error {
+	if r.FieldPattern == "" && r.GradeLabel == "" && r.DataType == "" {
+		return errors.New("至少需要配置字段名称、分级标签、数据类型中的一个匹配条件")
+	}
+	if _, err := r.compilePattern(); err != nil {
+		return err
+	}
+	if r.MaskChar != "" && utf8.RuneCountInString(r.MaskChar) != 1 {
+		return errors.New("掩码字符只能为一个字符")
+	}
+
+	switch r.Algorithm {
+	case AlgorithmKeep:
+		if r.FirstN < 0 || r.LastN < 0 {
+			return errors.New("保留的位数不能小于 0")
+		}
+	case AlgorithmMaskRange:
+		if r.StartPos < 1 || r.EndPos < r.StartPos {
+			return errors.New("脱敏的起始位置应大于 0 且不大于结束位置")
+		}
+	case AlgorithmHash, AlgorithmReplace, AlgorithmNullify:
+	default:
+		return fmt.Errorf("不支持的脱敏算法 %q", r.Algorithm)
+	}
+	return nil
+}
+
+// Match 字段是否满足规则的匹配条件
+func (r *Rule) Match(f *Field) bool {
+	if r.DataType != "" && !strings.EqualFold(r.DataType, f.DataType) {
+		return false
+	}
+	if r.GradeLabel != "" && r.GradeLabel != f.GradeLabel {
+		return false
+	}
+	if r.FieldPattern != "" {
+		pattern, err := r.compilePattern()
+		if err != nil {
+			return false
+		}
+		if !pattern.MatchString(f.Name) && !pattern.MatchString(f.ChineseName) {
+			return false
+		}
+	}
+	return true
+}
+
+// masksText 算法是否按字符处理字段的值，这些算法只适用于字符串类型的字段
+func (r *Rule) masksText() bool {
+	switch r.Algorithm {
+	case AlgorithmKeep, AlgorithmMaskRange, AlgorithmHash:
+		return true
+	}
+	return false
+}
+
+func (r *Rule) maskChar() string {
+	if r.MaskChar == "" {
+		return DefaultMaskChar
+	}
+	return r.MaskChar
+}
+
+// compilePattern 将通配符转换为正则表达式
+func (r *Rule) compilePattern() (*regexp.Regexp, error) {
+	if r.pattern != nil {
+		return r.pattern, nil
+	}
+	var b strings.Builder
+	b.WriteString("(?is)^")
+	for _, c := range r.FieldPattern {
+		switch c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	pattern, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("字段名称的通配符不合法: %w", err)
+	}
+	r.pattern = pattern
+	return pattern, nil
+}
+
+// RuleSet 按优先级排序的规则集合
+type RuleSet []*Rule
+
+// NewRuleSet 按优先级排序规则，优先级相同时保持原有顺序。通配符在这里预先编译，之后可以并发匹配
+func NewRuleSet(rules []*Rule) RuleSet {
+	set := make(RuleSet, len(rules))
+	copy(set, rules)
+	for _, r := range set {
+		_, _ = r.compilePattern()
+	}
+	sort.SliceStable(set, func(i, j int) bool { return set[i].Priority < set[j].Priority })
+	return set
+}
+
+// sensitiveRule 没有匹配的规则时，敏感或涉密的字符串字段整体替换
+var sensitiveRule = &Rule{Name: "sensitive", Algorithm: AlgorithmReplace, Replacement: "******"}
+
+// nonStringRule 按字符处理的算法匹配到非字符串类型的字段时置为 NULL。
+// 数值、布尔值转换为字符串的格式因数据库而异，脱敏结果无法保持一致
+var nonStringRule = &Rule{Name: "non string", Algorithm: AlgorithmNullify}
+
+// Match 返回字段匹配的第一个规则，没有匹配的规则且字段不需要脱敏时返回 nil
+func (s RuleSet) Match(f *Field) *Rule {
+	for _, r := range s {
+		if r.Match(f) {
+			return r
+		}
+	}
+	if isStringType(f.DataType) && f.Sensitive+f.Classified > 0 {
+		return sensitiveRule
+	}
+	return nil
+}
+
+// isStringType 是否字符串类型的字段
+func isStringType(dataType string) bool {
+	switch strings.ToLower(dataType) {
+	case "string", "varchar", "char", "text":
+		return true
+	}
+	return false
+}
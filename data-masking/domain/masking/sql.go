@@ -0,0 +1,70 @@
+package masking
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CompileSelect 按规则集合为每个字段生成查询列，拼接为查询 tableName 的 SELECT 语句
+func CompileSelect(d Dialect, tableName string, fields []*Field, rules RuleSet) string {
+	columns := make([]string, 0, len(fields))
+	for _, f := range fields {
+		columns = append(columns, Projection(d, f, rules.Match(f)))
+	}
+	return "SELECT " + strings.Join(columns, ",") + " FROM " + tableName
+}
+
+// Projection 生成字段的查询列，rule 为 nil 时不脱敏
+func Projection(d Dialect, f *Field, rule *Rule) string {
+	column := d.QuoteIdentifier(f.Name)
+	if rule == nil {
+		return column
+	}
+	return maskExpr(d, column, f, rule) + " AS " + column
+}
+
+// maskExpr 生成脱敏表达式，结果与 SQL 的字符串函数一致：按字符计算位置，NULL 脱敏后仍为 NULL。
+// 按字符处理的算法只适用于字符串字段，其他类型的字段转换为字符串的格式与记录脱敏不一致，脱敏后为 NULL
+func maskExpr(d Dialect, column string, f *Field, rule *Rule) string {
+	if rule.masksText() && !isStringType(f.DataType) {
+		return "NULL"
+	}
+	value := column
+	mask := d.QuoteString(rule.maskChar())
+	length := d.Length(value)
+
+	switch rule.Algorithm {
+	case AlgorithmKeep:
+		// 保留前后的字符，字符数不超过 FirstN+LastN 时原样返回
+		parts := make([]string, 0, 3)
+		if rule.FirstN > 0 {
+			parts = append(parts, d.Substr(value, "1", strconv.Itoa(rule.FirstN)))
+		}
+		parts = append(parts, d.Repeat(mask, fmt.Sprintf("%s-%d", length, rule.FirstN+rule.LastN)))
+		if rule.LastN > 0 {
+			parts = append(parts, d.Substr(value, fmt.Sprintf("GREATEST(%s-%d,%d)", length, rule.LastN-1, rule.FirstN+1), ""))
+		}
+		return d.Concat(parts...)
+	case AlgorithmMaskRange:
+		parts := make([]string, 0, 3)
+		if rule.StartPos > 1 {
+			parts = append(parts, d.Substr(value, "1", strconv.Itoa(rule.StartPos-1)))
+		}
+		parts = append(parts,
+			d.Repeat(mask, fmt.Sprintf("LEAST(%s,%d)-%d", length, rule.EndPos, rule.StartPos-1)),
+			d.Substr(value, strconv.Itoa(rule.EndPos+1), ""),
+		)
+		return d.Concat(parts...)
+	case AlgorithmHash:
+		if len(rule.HashKey) == 0 {
+			return "NULL"
+		}
+		return d.Hash(value, rule.HashKey)
+	case AlgorithmReplace:
+		return "CASE WHEN " + column + " IS NULL THEN NULL ELSE " + d.QuoteString(rule.Replacement) + " END"
+	case AlgorithmNullify:
+		return "NULL"
+	}
+	return column
+}
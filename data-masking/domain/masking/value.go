@@ -0,0 +1,65 @@
+package masking
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// MaskValue 按规则脱敏 JSON 中的值，结果与 SQL 脱敏表达式的结果一致：null 脱敏后仍为 null；
+// 按字符处理的算法只适用于字符串，数值、布尔值、对象和数组脱敏后为 null
+func MaskValue(rule *Rule, v interface{}) interface{} {
+	if v == nil || rule.Algorithm == AlgorithmNullify {
+		return nil
+	}
+	if rule.Algorithm == AlgorithmReplace {
+		return rule.Replacement
+	}
+	s, ok := v.(string)
+	if !ok {
+		return nil
+	}
+
+	switch rule.Algorithm {
+	case AlgorithmKeep:
+		return keep([]rune(s), rule.FirstN, rule.LastN, rule.maskChar())
+	case AlgorithmMaskRange:
+		return maskRange([]rune(s), rule.StartPos, rule.EndPos, rule.maskChar())
+	case AlgorithmHash:
+		if len(rule.HashKey) == 0 {
+			return nil
+		}
+		mac := hmac.New(sha256.New, rule.HashKey)
+		mac.Write([]byte(s))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	return v
+}
+
+// keep 保留前 firstN 个和后 lastN 个字符
+func keep(runes []rune, firstN, lastN int, mask string) string {
+	n := len(runes)
+	if n <= firstN+lastN {
+		return string(runes)
+	}
+	return string(runes[:firstN]) + strings.Repeat(mask, n-firstN-lastN) + string(runes[n-lastN:])
+}
+
+// maskRange 替换第 start 个到第 end 个字符
+func maskRange(runes []rune, start, end int, mask string) string {
+	n := len(runes)
+	prefix := start - 1
+	if prefix > n {
+		prefix = n
+	}
+	suffix := end
+	if suffix > n {
+		suffix = n
+	}
+	count := suffix - prefix
+	if count < 0 {
+		count = 0
+	}
+	return string(runes[:prefix]) + strings.Repeat(mask, count) + string(runes[suffix:])
+}
@@ -0,0 +1,81 @@
+package impl
+
+import (
+	"context"
+
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/common/errorcode"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/common/models/response"
+	domain "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/domain/masking_rule"
+	repo "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/infrastructure/repository/db/gorm/masking_rule"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/infrastructure/repository/db/model"
+)
+
+type useCase struct {
+	repoMaskingRule repo.Repo
+}
+
+func NewUseCase(repoMaskingRule repo.Repo) domain.UseCase {
+	return &useCase{repoMaskingRule: repoMaskingRule}
+}
+
+func (u *useCase) Create(ctx context.Context, req *domain.CreateReqParam) (*response.NameIDResp, error) {
+	rule := req.ToModel()
+	if err := validate(rule); err != nil {
+		return nil, err
+	}
+	if err := u.repoMaskingRule.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+	return &response.NameIDResp{ID: rule.ID, Name: rule.Name}, nil
+}
+
+func (u *useCase) Update(ctx context.Context, req *domain.UpdateReqParam) (*response.NameIDResp, error) {
+	rule := req.ToModel()
+	rule.ID = req.ID
+	if err := validate(rule); err != nil {
+		return nil, err
+	}
+	if err := u.repoMaskingRule.Update(ctx, rule); err != nil {
+		return nil, err
+	}
+	return &response.NameIDResp{ID: rule.ID, Name: rule.Name}, nil
+}
+
+func (u *useCase) Delete(ctx context.Context, req *domain.DeleteReqParam) (*response.NameIDResp, error) {
+	rule, err := u.repoMaskingRule.Get(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err = u.repoMaskingRule.Delete(ctx, req.ID); err != nil {
+		return nil, err
+	}
+	return &response.NameIDResp{ID: rule.ID, Name: rule.Name}, nil
+}
+
+func (u *useCase) Get(ctx context.Context, req *domain.GetReqParam) (*domain.MaskingRuleResp, error) {
+	rule, err := u.repoMaskingRule.Get(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewMaskingRuleResp(rule), nil
+}
+
+func (u *useCase) List(ctx context.Context, req *domain.ListReqParam) (*response.PageResult, error) {
+	rules, total, err := u.repoMaskingRule.List(ctx, &req.PageInfo)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*domain.MaskingRuleResp, 0, len(rules))
+	for _, rule := range rules {
+		entries = append(entries, domain.NewMaskingRuleResp(rule))
+	}
+	return &response.PageResult{Entries: entries, TotalCount: total}, nil
+}
+
+// validate 检查规则的匹配条件和算法参数的组合
+func validate(rule *model.MaskingRule) error {
+	if err := domain.ToRule(rule).Validate(); err != nil {
+		return errorcode.Detail(errorcode.MaskingRuleInvalid, err.Error())
+	}
+	return nil
+}
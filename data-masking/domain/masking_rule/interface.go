@@ -0,0 +1,170 @@
+package masking_rule
+
+import (
+	"context"
+
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/common/models/request"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/common/models/response"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/domain/masking"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/infrastructure/repository/db/model"
+)
+
+type UseCase interface {
+	Create(ctx context.Context, req *CreateReqParam) (*response.NameIDResp, error)
+	Update(ctx context.Context, req *UpdateReqParam) (*response.NameIDResp, error)
+	Delete(ctx context.Context, req *DeleteReqParam) (*response.NameIDResp, error)
+	Get(ctx context.Context, req *GetReqParam) (*MaskingRuleResp, error)
+	List(ctx context.Context, req *ListReqParam) (*response.PageResult, error)
+}
+
+type IDReqPathParam struct {
+	ID string `json:"id" uri:"id" binding:"required,uuid" example:"4a5a3cc0-0169-4d62-9442-62214d8fcd8d"` // 规则ID，uuid
+}
+
+// RuleBodyParam 规则的匹配条件和脱敏算法，匹配条件至少配置一个
+type RuleBodyParam struct {
+	Name         string `json:"name" binding:"required,min=1,max=128" example:"手机号"`                                          // 规则名称
+	Description  string `json:"description" binding:"omitempty,max=300" example:"手机号中间 4 位脱敏"`                                // 规则描述
+	FieldPattern string `json:"field_pattern" binding:"omitempty,max=255" example:"*phone*"`                                  // 字段名称的通配符，匹配字段的英文名或中文名，* 匹配任意个字符，? 匹配一个字符
+	GradeLabel   string `json:"grade_label" binding:"omitempty,max=128" example:"敏感"`                                         // 分级标签
+	DataType     string `json:"data_type" binding:"omitempty,max=64" example:"string"`                                        // 数据类型
+	Algorithm    string `json:"algorithm" binding:"required,oneof=keep mask_range hash replace nullify" example:"mask_range"` // 脱敏算法：keep 保留前后几位，mask_range 区间脱敏，hash 哈希，replace 固定值替换，nullify 置空
+	FirstN       int    `json:"first_n" binding:"omitempty,min=0" example:"3"`                                                // keep 保留的前几位
+	LastN        int    `json:"last_n" binding:"omitempty,min=0" example:"4"`                                                 // keep 保留的后几位
+	StartPos     int    `json:"start_pos" binding:"omitempty,min=1" example:"4"`                                              // mask_range 脱敏的起始位置，从 1 开始
+	EndPos       int    `json:"end_pos" binding:"omitempty,min=1" example:"7"`                                                // mask_range 脱敏的结束位置，包含
+	MaskChar     string `json:"mask_char" binding:"omitempty,max=1" example:"*"`                                              // 掩码字符，默认为 *
+	Replacement  string `json:"replacement" binding:"omitempty,max=255" example:"******"`                                     // replace 替换的固定字符串
+	Priority     int    `json:"priority" example:"100"`                                                                       // 优先级，多个规则匹配同一字段时取值小的优先
+	Enabled      *bool  `json:"enabled" example:"true"`                                                                       // 是否启用，默认启用
+}
+
+func (p *RuleBodyParam) ToModel() *model.MaskingRule {
+	if p == nil {
+		return nil
+	}
+
+	enabled := true
+	if p.Enabled != nil {
+		enabled = *p.Enabled
+	}
+	return &model.MaskingRule{
+		Name:         p.Name,
+		Description:  p.Description,
+		FieldPattern: p.FieldPattern,
+		GradeLabel:   p.GradeLabel,
+		DataType:     p.DataType,
+		Algorithm:    p.Algorithm,
+		FirstN:       int32(p.FirstN),
+		LastN:        int32(p.LastN),
+		StartPos:     int32(p.StartPos),
+		EndPos:       int32(p.EndPos),
+		MaskChar:     p.MaskChar,
+		Replacement:  p.Replacement,
+		Priority:     int32(p.Priority),
+		Enabled:      enabled,
+	}
+}
+
+// ToRule 将存储的规则转换为脱敏引擎的规则
+func ToRule(m *model.MaskingRule) *masking.Rule {
+	return &masking.Rule{
+		ID:           m.ID,
+		Name:         m.Name,
+		FieldPattern: m.FieldPattern,
+		GradeLabel:   m.GradeLabel,
+		DataType:     m.DataType,
+		Algorithm:    masking.Algorithm(m.Algorithm),
+		FirstN:       int(m.FirstN),
+		LastN:        int(m.LastN),
+		StartPos:     int(m.StartPos),
+		EndPos:       int(m.EndPos),
+		MaskChar:     m.MaskChar,
+		Replacement:  m.Replacement,
+		Priority:     int(m.Priority),
+	}
+}
+
+// ToRuleSet 将存储的规则转换为按优先级排序的规则集合，hashKey 为 hash 算法的 HMAC 密钥
+func ToRuleSet(models []*model.MaskingRule, hashKey []byte) masking.RuleSet {
+	rules := make([]*masking.Rule, 0, len(models))
+	for _, m := range models {
+		rule := ToRule(m)
+		rule.HashKey = hashKey
+		rules = append(rules, rule)
+	}
+	return masking.NewRuleSet(rules)
+}
+
+/////////////////// Create ///////////////////
+
+type CreateReqParam struct {
+	RuleBodyParam
+}
+
+/////////////////// Update ///////////////////
+
+type UpdateReqParam struct {
+	IDReqPathParam
+	RuleBodyParam
+}
+
+/////////////////// Delete ///////////////////
+
+type DeleteReqParam struct {
+	IDReqPathParam
+}
+
+/////////////////// Get ///////////////////
+
+type GetReqParam struct {
+	IDReqPathParam
+}
+
+type MaskingRuleResp struct {
+	ID           string `json:"id" example:"4a5a3cc0-0169-4d62-9442-62214d8fcd8d"` // 规则ID
+	Name         string `json:"name" example:"手机号"`                                // 规则名称
+	Description  string `json:"description"`                                       // 规则描述
+	FieldPattern string `json:"field_pattern"`                                     // 字段名称的通配符
+	GradeLabel   string `json:"grade_label"`                                       // 分级标签
+	DataType     string `json:"data_type"`                                         // 数据类型
+	Algorithm    string `json:"algorithm"`                                         // 脱敏算法
+	FirstN       int    `json:"first_n"`                                           // 保留的前几位
+	LastN        int    `json:"last_n"`                                            // 保留的后几位
+	StartPos     int    `json:"start_pos"`                                         // 脱敏的起始位置
+	EndPos       int    `json:"end_pos"`                                           // 脱敏的结束位置
+	MaskChar     string `json:"mask_char"`                                         // 掩码字符
+	Replacement  string `json:"replacement"`                                       // 替换的固定字符串
+	Priority     int    `json:"priority"`                                          // 优先级
+	Enabled      bool   `json:"enabled"`                                           // 是否启用
+	CreatedAt    int64  `json:"created_at"`                                        // 创建时间，毫秒时间戳
+	UpdatedAt    int64  `json:"updated_at"`                                        // 更新时间，毫秒时间戳
+}
+
+func NewMaskingRuleResp(m *model.MaskingRule) *MaskingRuleResp {
+	return &MaskingRuleResp{
+		ID:           m.ID,
+		Name:         m.Name,
+		Description:  m.Description,
+		FieldPattern: m.FieldPattern,
+		GradeLabel:   m.GradeLabel,
+		DataType:     m.DataType,
+		Algorithm:    m.Algorithm,
+		FirstN:       int(m.FirstN),
+		LastN:        int(m.LastN),
+		StartPos:     int(m.StartPos),
+		EndPos:       int(m.EndPos),
+		MaskChar:     m.MaskChar,
+		Replacement:  m.Replacement,
+		Priority:     int(m.Priority),
+		Enabled:      m.Enabled,
+		CreatedAt:    m.CreatedAt.UnixMilli(),
+		UpdatedAt:    m.UpdatedAt.UnixMilli(),
+	}
+}
+
+/////////////////// List ///////////////////
+
+type ListReqParam struct {
+	request.PageInfo
+}
@@ -0,0 +1,52 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/common/errorcode"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/common/settings"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/domain/masking"
+	masking_rule "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/domain/masking_rule"
+	domain "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/domain/record_masking"
+	repo "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/infrastructure/repository/db/gorm/masking_rule"
+)
+
+type useCase struct {
+	repoMaskingRule repo.Repo
+}
+
+func NewUseCase(repoMaskingRule repo.Repo) domain.UseCase {
+	return &useCase{repoMaskingRule: repoMaskingRule}
+}
+
+// Mask 按启用的脱敏规则脱敏 JSON 记录，与 SQL 脱敏使用同样的规则匹配和脱敏算法
+func (u *useCase) Mask(ctx context.Context, req *domain.MaskReqParam) (*domain.MaskRespParam, error) {
+	// 数值保持为 json.Number，不需要脱敏的数值原样返回，不损失精度
+	decoder := json.NewDecoder(bytes.NewReader(req.Records))
+	decoder.UseNumber()
+	var records []interface{}
+	if err := decoder.Decode(&records); err != nil {
+		return nil, errorcode.Detail(errorcode.PublicInvalidParameterJson, err.Error())
+	}
+
+	fieldRules := make([]masking.FieldRule, 0, len(req.Fields))
+	for _, f := range req.Fields {
+		fieldRules = append(fieldRules, f.ToFieldRule())
+	}
+
+	models, err := u.repoMaskingRule.ListEnabled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	masker, err := masking.NewRecordMasker(fieldRules, masking_rule.ToRuleSet(models, []byte(settings.MaskingConf.Masking.HashKey)))
+	if err != nil {
+		return nil, errorcode.Detail(errorcode.PublicInvalidParameter, err.Error())
+	}
+
+	if records == nil {
+		records = []interface{}{}
+	}
+	return &domain.MaskRespParam{Records: masker.MaskRecords(records)}, nil
+}
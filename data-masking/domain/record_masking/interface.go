@@ -0,0 +1,52 @@
+package record_masking
+
+import (
+	"context"
+	"encoding/json"
+
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/domain/masking"
+)
+
+type UseCase interface {
+	Mask(ctx context.Context, req *MaskReqParam) (*MaskRespParam, error)
+}
+
+/////////////////// Mask ///////////////////
+
+type MaskReqParam struct {
+	MaskReqBodyParam
+}
+
+type MaskReqBodyParam struct {
+	Records json.RawMessage `json:"records" binding:"required" swaggertype:"array,object"` // 需要脱敏的记录，JSON 数组
+	Fields  []*FieldInfo    `json:"fields" binding:"required,dive"`                        // 需要脱敏的字段
+}
+
+// FieldInfo 按 JSONPath 选择记录中的值，并用字段信息匹配脱敏规则
+type FieldInfo struct {
+	Path        string `json:"path" binding:"required,max=1024" example:"$.user.phone"` // 相对于每条记录的 JSONPath，支持 .name、['name']、[0]、[*]
+	Field       string `json:"field" binding:"omitempty,max=255" example:"phone"`       // 字段名称，默认为 JSONPath 最后一个成员的名称
+	ChineseName string `json:"chinese_name" binding:"omitempty,max=255" example:"电话号码"` // 字段中文名称
+	FieldType   string `json:"field_type" binding:"omitempty,max=64" example:"string"`  // 字段的数据类型
+	GradeLabel  string `json:"grade_label" binding:"omitempty,max=128" example:"敏感"`    // 分级标签
+	Sensitive   int    `json:"sensitive" binding:"omitempty,oneof=0 1" example:"1"`     // 是否敏感
+	Classified  int    `json:"classified" binding:"omitempty,oneof=0 1" example:"0"`    // 是否涉密
+}
+
+func (f *FieldInfo) ToFieldRule() masking.FieldRule {
+	return masking.FieldRule{
+		Path: f.Path,
+		Field: masking.Field{
+			Name:        f.Field,
+			ChineseName: f.ChineseName,
+			DataType:    f.FieldType,
+			GradeLabel:  f.GradeLabel,
+			Sensitive:   f.Sensitive,
+			Classified:  f.Classified,
+		},
+	}
+}
+
+type MaskRespParam struct {
+	Records []interface{} `json:"records" swaggertype:"array,object"` // 脱敏后的记录
+}
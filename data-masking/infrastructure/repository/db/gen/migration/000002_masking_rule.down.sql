@@ -0,0 +1 @@
+DROP TABLE `masking_rule`;
@@ -0,0 +1,32 @@
+CREATE TABLE
+    IF NOT EXISTS `masking_rule` (
+                                  `id` VARCHAR (36) NOT NULL COMMENT '主键，uuid',
+                                  `name` VARCHAR (128) NOT NULL COMMENT '规则名称',
+                                  `description` VARCHAR (300) NOT NULL DEFAULT '' COMMENT '规则描述',
+                                  `field_pattern` VARCHAR (255) NOT NULL DEFAULT '' COMMENT '字段名称的通配符，匹配字段的英文名或中文名',
+                                  `grade_label` VARCHAR (128) NOT NULL DEFAULT '' COMMENT '分级标签',
+                                  `data_type` VARCHAR (64) NOT NULL DEFAULT '' COMMENT '数据类型',
+                                  `algorithm` VARCHAR (32) NOT NULL COMMENT '脱敏算法：keep 保留前后几位，mask_range 区间脱敏，hash 哈希，replace 固定值替换，nullify 置空',
+                                  `first_n` INT NOT NULL DEFAULT 0 COMMENT '保留的前几位',
+                                  `last_n` INT NOT NULL DEFAULT 0 COMMENT '保留的后几位',
+                                  `start_pos` INT NOT NULL DEFAULT 0 COMMENT '脱敏的起始位置，从 1 开始',
+                                  `end_pos` INT NOT NULL DEFAULT 0 COMMENT '脱敏的结束位置，包含',
+                                  `mask_char` VARCHAR (4) NOT NULL DEFAULT '' COMMENT '掩码字符，默认为 *',
+                                  `replacement` VARCHAR (255) NOT NULL DEFAULT '' COMMENT '替换的固定字符串',
+                                  `priority` INT NOT NULL DEFAULT 0 COMMENT '优先级，取值小的优先',
+                                  `enabled` TINYINT (1) NOT NULL DEFAULT 1 COMMENT '是否启用',
+                                  `created_at` DATETIME (3) NULL COMMENT '创建时间',
+                                  `created_by_uid` VARCHAR (36) NOT NULL DEFAULT '' COMMENT '创建用户ID',
+                                  `updated_at` DATETIME (3) NULL COMMENT '更新时间',
+                                  `updated_by_uid` VARCHAR (36) NOT NULL DEFAULT '' COMMENT '更新用户ID',
+                                  `deleted_at` DATETIME (3) COMMENT '删除时间(逻辑删除)',
+                                  INDEX `idx_deleted_at` (`deleted_at`),
+                                  PRIMARY KEY (`id`) USING BTREE
+) ENGINE = INNODB CHARACTER
+    SET utf8mb4 COLLATE utf8mb4_general_ci COMMENT '脱敏规则';
+
+INSERT INTO `masking_rule` (`id`, `name`, `description`, `field_pattern`, `data_type`, `algorithm`, `first_n`, `last_n`, `start_pos`, `end_pos`, `priority`, `created_at`, `updated_at`)
+VALUES ('9b0f3c52-6a57-4c1e-9a55-1f0a7e3d2c01', '姓名', '保留姓名的第一个字', '姓名', 'string', 'keep', 1, 0, 0, 0, 100, NOW(3), NOW(3)),
+       ('9b0f3c52-6a57-4c1e-9a55-1f0a7e3d2c02', '电话号码', '第 4 到 7 位脱敏', '电话号码', 'string', 'mask_range', 0, 0, 4, 7, 100, NOW(3), NOW(3)),
+       ('9b0f3c52-6a57-4c1e-9a55-1f0a7e3d2c03', '身份证', '出生日期的 8 位脱敏', '身份证', 'string', 'mask_range', 0, 0, 7, 14, 100, NOW(3), NOW(3)),
+       ('9b0f3c52-6a57-4c1e-9a55-1f0a7e3d2c04', '护照', '保留前 5 位', '护照', 'string', 'keep', 5, 0, 0, 0, 100, NOW(3), NOW(3));
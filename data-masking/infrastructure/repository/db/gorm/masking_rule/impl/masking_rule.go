@@ -0,0 +1,94 @@
+package impl
+
+import (
+	"context"
+	"errors"
+
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/common/errorcode"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/common/models/request"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/infrastructure/repository/db"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/infrastructure/repository/db/gorm/masking_rule"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/infrastructure/repository/db/model"
+	"gorm.io/gorm"
+)
+
+type repo struct {
+	data *db.Data
+}
+
+func NewRepo(data *db.Data) masking_rule.Repo {
+	return &repo{data: data}
+}
+
+func (r *repo) Create(ctx context.Context, rule *model.MaskingRule) error {
+	if err := r.data.DB.WithContext(ctx).Create(rule).Error; err != nil {
+		return errorcode.Detail(errorcode.PublicDatabaseError, err)
+	}
+	return nil
+}
+
+func (r *repo) Update(ctx context.Context, rule *model.MaskingRule) error {
+	// 零值的字段同样需要更新，创建信息保持不变
+	result := r.data.DB.WithContext(ctx).
+		Select("*").
+		Omit("id", "created_at", "created_by_uid", "deleted_at").
+		Updates(rule)
+	if result.Error != nil {
+		return errorcode.Detail(errorcode.PublicDatabaseError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errorcode.Desc(errorcode.MaskingRuleNotExist)
+	}
+	return nil
+}
+
+func (r *repo) Delete(ctx context.Context, id string) error {
+	result := r.data.DB.WithContext(ctx).Where("id = ?", id).Delete(&model.MaskingRule{})
+	if result.Error != nil {
+		return errorcode.Detail(errorcode.PublicDatabaseError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errorcode.Desc(errorcode.MaskingRuleNotExist)
+	}
+	return nil
+}
+
+func (r *repo) Get(ctx context.Context, id string) (*model.MaskingRule, error) {
+	rule := &model.MaskingRule{}
+	err := r.data.DB.WithContext(ctx).Where("id = ?", id).Take(rule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errorcode.Desc(errorcode.MaskingRuleNotExist)
+	}
+	if err != nil {
+		return nil, errorcode.Detail(errorcode.PublicDatabaseError, err)
+	}
+	return rule, nil
+}
+
+func (r *repo) List(ctx context.Context, page *request.PageInfo) ([]*model.MaskingRule, int64, error) {
+	tx := r.data.DB.WithContext(ctx).Model(&model.MaskingRule{})
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, errorcode.Detail(errorcode.PublicDatabaseError, err)
+	}
+
+	var rules []*model.MaskingRule
+	err := tx.Order(page.Sort + " " + page.Direction).
+		Offset((page.Offset - 1) * page.Limit).
+		Limit(page.Limit).
+		Find(&rules).Error
+	if err != nil {
+		return nil, 0, errorcode.Detail(errorcode.PublicDatabaseError, err)
+	}
+	return rules, total, nil
+}
+
+func (r *repo) ListEnabled(ctx context.Context) ([]*model.MaskingRule, error) {
+	var rules []*model.MaskingRule
+	err := r.data.DB.WithContext(ctx).Where("enabled = ?", true).Order("priority asc, created_at asc").Find(&rules).Error
+	if err != nil {
+		return nil, errorcode.Detail(errorcode.PublicDatabaseError, err)
+	}
+	return rules, nil
+}
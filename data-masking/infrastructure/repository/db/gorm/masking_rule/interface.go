@@ -0,0 +1,18 @@
+package masking_rule
+
+import (
+	"context"
+
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/common/models/request"
+	"devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/infrastructure/repository/db/model"
+)
+
+type Repo interface {
+	Create(ctx context.Context, rule *model.MaskingRule) error
+	Update(ctx context.Context, rule *model.MaskingRule) error
+	Delete(ctx context.Context, id string) error
+	Get(ctx context.Context, id string) (*model.MaskingRule, error)
+	List(ctx context.Context, page *request.PageInfo) ([]*model.MaskingRule, int64, error)
+	// ListEnabled 返回所有启用的规则
+	ListEnabled(ctx context.Context) ([]*model.MaskingRule, error)
+}
@@ -2,9 +2,11 @@ package gorm
 
 import (
 	demo "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/infrastructure/repository/db/gorm/demo/impl"
+	masking_rule "devops.aishu.cn/AISHUDevOps/AnyFabric/_git/data-masking/infrastructure/repository/db/gorm/masking_rule/impl"
 	"github.com/google/wire"
 )
 
 var RepositoryProviderSet = wire.NewSet(
 	demo.NewRepo,
+	masking_rule.NewRepo,
 )
@@ -3,6 +3,7 @@ package db
 import (
 	"bytes"
 	"fmt"
+	"os"
 	"os/exec"
 	"sync"
 
@@ -23,31 +24,29 @@ type Data struct {
 
 // NewData .
 func NewData(database *Database) (*Data, func(), error) {
-	log.Info("暂时不需要使用数据库")
-	return nil, nil, nil
-	// var err error
-	// var client *gorm.DB
-	// once.Do(func() {
-	// 	client, err = database.Default.NewMySqlClient()
+	var err error
+	var client *gorm.DB
+	once.Do(func() {
+		client, err = database.Default.NewMySqlClient()
 
-	// })
-	// if err != nil {
-	// 	log.Errorf("open mysql failed, err: %v", err)
-	// 	return nil, nil, err
-	// }
+	})
+	if err != nil {
+		log.Errorf("open mysql failed, err: %v", err)
+		return nil, nil, err
+	}
 
-	// if os.Getenv("init_db") == "true" {
-	// 	if err = initDB(database); err != nil {
-	// 		log.Errorf("init db failed, err: %v\n", err.Error())
-	// 		return nil, nil, err
-	// 	}
-	// 	os.Exit(0)
-	// }
-	// return &Data{
-	// 		DB: client,
-	// 	}, func() {
-	// 		log.Info("closing the data resources")
-	// 	}, nil
+	if os.Getenv("init_db") == "true" {
+		if err = initDB(database); err != nil {
+			log.Errorf("init db failed, err: %v\n", err.Error())
+			return nil, nil, err
+		}
+		os.Exit(0)
+	}
+	return &Data{
+		DB: client,
+	}, func() {
+		log.Info("closing the data resources")
+	}, nil
 }
 
 type Database struct {